@@ -2,10 +2,52 @@ package app
 
 import (
 	"fmt"
+	"forum/models"
+	"mime"
 	"net/http"
 	"runtime/debug"
+	"strings"
 )
 
+// jsonErrors marks the responses to requests that want their errors as
+// JSON, see NegotiateErrors.
+type jsonErrors struct {
+	http.ResponseWriter
+}
+
+func (w jsonErrors) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// NegotiateErrors makes the error responses of the requests WantsJSON
+// accepts JSON error bodies instead of the HTML error page.
+func (app *Application) NegotiateErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if WantsJSON(r) {
+			w = jsonErrors{w}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WantsJSON reports whether r is for the JSON API, or is from a client that
+// asks for JSON rather than HTML.
+func WantsJSON(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api/") || strings.HasSuffix(r.URL.Path, ".json") {
+		return true
+	}
+	var wantsJSON bool
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		switch mediaType, _, _ := mime.ParseMediaType(part); mediaType {
+		case "text/html":
+			return false
+		case "application/json":
+			wantsJSON = true
+		}
+	}
+	return wantsJSON
+}
+
 func (app *Application) ServerError(w http.ResponseWriter, err error) {
 	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
 	app.ErrorLog.Output(2, trace)
@@ -14,33 +56,48 @@ func (app *Application) ServerError(w http.ResponseWriter, err error) {
 }
 
 func (app *Application) ClientError(w http.ResponseWriter, status int) {
+	app.renderError(w, status, http.StatusText(status))
+}
+
+func (app *Application) renderError(w http.ResponseWriter, status int, text string) {
+	if _, ok := w.(jsonErrors); ok {
+		code, ok := apiCodes[status]
+		if !ok {
+			code = models.CodeInternal
+		}
+		app.JSON(w, status, models.APIErrorBody{Error: models.APIError{Code: code, Message: text}})
+		return
+	}
 	ts, ok := app.templateCache["error.html"]
 	if !ok {
 		err := fmt.Errorf("the template \"error\" does not exist")
 		trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
-		app.ErrorLog.Output(2, trace)
+		app.ErrorLog.Output(3, trace)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 	data := struct {
 		ErrorCode int
 		ErrorText string
+		Quote     string
 	}{
 		ErrorCode: status,
-		ErrorText: http.StatusText(status),
+		ErrorText: text,
 	}
 	w.WriteHeader(status)
 	err := ts.ExecuteTemplate(w, "errorBase", data)
 	if err != nil {
 		trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
-		app.ErrorLog.Output(2, trace)
+		app.ErrorLog.Output(3, trace)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
-
-	return
 }
 
 func (app *Application) NotFound(w http.ResponseWriter) {
 	app.ClientError(w, http.StatusNotFound)
 }
+
+func (app *Application) Maintenance(w http.ResponseWriter) {
+	app.renderError(w, http.StatusServiceUnavailable, "The forum is down for maintenance, come back later")
+}
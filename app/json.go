@@ -0,0 +1,139 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"forum/models"
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+func (app *Application) JSON(w http.ResponseWriter, status int, data any) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		app.ServerError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(js)
+}
+
+// apiCodes are the error codes of the statuses API handlers answer with
+// directly.
+var apiCodes = map[int]string{
+	http.StatusBadRequest:            models.CodeBadRequest,
+	http.StatusUnauthorized:          models.CodeUnauthorized,
+	http.StatusForbidden:             models.CodeForbidden,
+	http.StatusNotFound:              models.CodeNotFound,
+	http.StatusMethodNotAllowed:      models.CodeMethodNotAllowed,
+	http.StatusConflict:              models.CodeConflict,
+	http.StatusRequestEntityTooLarge: models.CodeTooLarge,
+	http.StatusUnprocessableEntity:   models.CodeValidation,
+	http.StatusTooManyRequests:       models.CodeRateLimited,
+	http.StatusInternalServerError:   models.CodeInternal,
+	http.StatusServiceUnavailable:    models.CodeUnavailable,
+}
+
+// invalidInput are the errors of requests that are well formed but ask for
+// something that isn't allowed.
+var invalidInput = []error{
+	models.ErrInvalidQuote,
+	models.ErrForeignComment,
+	models.ErrThreadTooDeep,
+	models.ErrInvalidPrivacy,
+	models.ErrInvalidAction,
+	models.ErrInvalidDateRange,
+	models.ErrInvalidStatus,
+	models.ErrInvalidSort,
+	models.ErrInvalidDigest,
+	models.ErrInvalidDisplayName,
+	models.ErrInvalidTimezone,
+	models.ErrInvalidBackup,
+	models.ErrSelfFollow,
+	models.ErrInvalidAnnouncement,
+	models.ErrUnknownLabel,
+	models.ErrUnknownReaction,
+	models.ErrInvalidLeaderboard,
+	models.ErrInvalidCommentMode,
+}
+
+// APIClientError answers a JSON API request with the error envelope for
+// status.
+func (app *Application) APIClientError(w http.ResponseWriter, status int) {
+	code, ok := apiCodes[status]
+	if !ok {
+		code = models.CodeBadRequest
+	}
+	app.JSON(w, status, models.APIErrorBody{Error: models.APIError{Code: code, Message: http.StatusText(status)}})
+}
+
+// APIError answers a JSON API request with the error envelope for err.
+// Errors it doesn't know are logged and sent as internal errors, without
+// their details.
+func (app *Application) APIError(w http.ResponseWriter, err error) {
+	var validation *models.ValidationError
+	var tooLarge *http.MaxBytesError
+	var cooldown *models.CooldownError
+	switch {
+	case errors.As(err, &validation):
+		app.JSON(w, http.StatusUnprocessableEntity, models.APIErrorBody{Error: models.APIError{
+			Code:    models.CodeValidation,
+			Message: "Some fields are invalid",
+			Fields:  validation.Fields,
+		}})
+		return
+	case errors.As(err, &cooldown):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(cooldown.RetryAfter.Seconds()))))
+		app.apiError(w, http.StatusTooManyRequests, err)
+		return
+	case errors.As(err, &tooLarge):
+		app.APIClientError(w, http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, models.ErrNoRecord), errors.Is(err, models.UnknownCategory):
+		app.apiError(w, http.StatusNotFound, err)
+		return
+	case errors.Is(err, models.ErrForbidden), errors.Is(err, models.ErrPostLocked), errors.Is(err, models.ErrPostArchived):
+		app.apiError(w, http.StatusForbidden, err)
+		return
+	case errors.Is(err, models.ErrInvalidCredentials):
+		app.apiError(w, http.StatusUnauthorized, err)
+		return
+	case errors.Is(err, models.ErrDuplicateEmail):
+		app.apiFieldError(w, http.StatusConflict, "email", "Email address is already in use")
+		return
+	case errors.Is(err, models.ErrDuplicateName):
+		app.apiFieldError(w, http.StatusConflict, "name", "Name is already in use")
+		return
+	case errors.Is(err, models.ErrPinLimit), errors.Is(err, models.ErrInviteQuota):
+		app.apiError(w, http.StatusConflict, err)
+		return
+	}
+	for _, target := range invalidInput {
+		if errors.Is(err, target) {
+			app.apiError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+	app.ErrorLog.Output(2, fmt.Sprintf("%s\n%s", err.Error(), debug.Stack()))
+	app.APIClientError(w, http.StatusInternalServerError)
+}
+
+// apiError sends err's message, without the "models: " prefix, under the
+// code of status.
+func (app *Application) apiError(w http.ResponseWriter, status int, err error) {
+	message, _ := strings.CutPrefix(err.Error(), "models: ")
+	app.JSON(w, status, models.APIErrorBody{Error: models.APIError{Code: apiCodes[status], Message: message}})
+}
+
+func (app *Application) apiFieldError(w http.ResponseWriter, status int, field, message string) {
+	app.JSON(w, status, models.APIErrorBody{Error: models.APIError{
+		Code:    apiCodes[status],
+		Message: message,
+		Fields:  map[string]string{field: message},
+	}})
+}
@@ -3,12 +3,20 @@ package app
 import (
 	"html/template"
 	"log"
+	"time"
 )
 
 type Application struct {
 	ErrorLog      *log.Logger
 	InfoLog       *log.Logger
 	templateCache map[string]*template.Template
+	// SnippetLength is how many characters of a post the lists show, 0
+	// for all of it.
+	SnippetLength int
+	// The reaction counts of posts are hidden for HideReactionsFor after
+	// posting, judged by Now, time.Now when nil.
+	HideReactionsFor time.Duration
+	Now              func() time.Time
 	// snippets       models.SnippetModelInterface
 	// users          models.UserModelInterface
 
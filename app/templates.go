@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"forum/models"
+	"forum/pkg/markdown"
 	"forum/ui"
 	"html/template"
 	"io/fs"
@@ -14,11 +15,31 @@ import (
 	"time"
 )
 
-func humanDate(t time.Time) string {
+// FormatTime formats t for a reader in loc, UTC when loc is nil. Times are
+// stored in UTC and only converted when they are shown.
+func FormatTime(t time.Time, loc *time.Location) string {
 	if t.IsZero() {
 		return ""
 	}
-	return t.Local().Format("02 Jan 2006 at 15:04")
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format("02 Jan 2006 at 15:04")
+}
+
+func humanDate(t time.Time) string {
+	return FormatTime(t, time.UTC)
+}
+
+// Markdown renders post content. Post pages and the preview endpoint both go
+// through it, so a preview always matches the published post.
+func Markdown(src string) template.HTML {
+	return template.HTML(markdown.Render(src))
+}
+
+// Snippet is the start of post content as plain text, for lists of posts.
+func Snippet(src string, n int) string {
+	return markdown.Snippet(src, n)
 }
 
 func sequence(start, end int) []int {
@@ -37,8 +58,11 @@ var functions = template.FuncMap{
 	"sub": func(a, b int) int {
 		return a - b
 	},
-	"sequence": sequence,
-	"toLower":  strings.ToLower,
+	"sequence":        sequence,
+	"toLower":         strings.ToLower,
+	"markdown":        Markdown,
+	"snippet":         func(src string) string { return Snippet(src, 0) },
+	"reactionsHidden": func(created time.Time) bool { return false },
 }
 
 func NewTemplateCache() (map[string]*template.Template, error) {
@@ -72,14 +96,13 @@ var Quotes = []string{"Strength is not in the grandmothers. After all, grandmoth
 func (app *Application) Render(w http.ResponseWriter, status int, page string, data *models.TemplateData) {
 	i := rand.Intn(10)
 	data.Quote = Quotes[i]
-	ts, ok := app.templateCache[page]
-	if !ok {
-		err := fmt.Errorf("the template %s does not exist", page)
+	ts, err := app.template(page, data.User.Location())
+	if err != nil {
 		app.ServerError(w, err)
 		return
 	}
 	buf := new(bytes.Buffer)
-	err := ts.ExecuteTemplate(buf, "base", data)
+	err = ts.ExecuteTemplate(buf, "base", data)
 	if err != nil {
 		app.ServerError(w, err)
 		return
@@ -87,3 +110,48 @@ func (app *Application) Render(w http.ResponseWriter, status int, page string, d
 	w.WriteHeader(status)
 	buf.WriteTo(w)
 }
+
+// template returns a copy of the cached page whose humanDate shows dates in
+// loc, whose snippet is SnippetLength long and whose reactionsHidden goes
+// by HideReactionsFor. The cached templates themselves are never executed,
+// which is what lets them be cloned.
+func (app *Application) template(page string, loc *time.Location) (*template.Template, error) {
+	ts, ok := app.templateCache[page]
+	if !ok {
+		return nil, fmt.Errorf("the template %s does not exist", page)
+	}
+	ts, err := ts.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return ts.Funcs(template.FuncMap{
+		"humanDate":       func(t time.Time) string { return FormatTime(t, loc) },
+		"snippet":         func(src string) string { return Snippet(src, app.SnippetLength) },
+		"reactionsHidden": app.ReactionsHidden,
+	}), nil
+}
+
+// ReactionsHidden reports whether the reaction counts of a post made at
+// created are still held back, see HideReactionsFor.
+func (app *Application) ReactionsHidden(created time.Time) bool {
+	now := time.Now
+	if app.Now != nil {
+		now = app.Now
+	}
+	return models.ReactionsHidden(created, app.HideReactionsFor, now())
+}
+
+// RenderPartial executes one of the partials, which every page includes, on
+// its own and returns the HTML. It is used to send pieces of a page as JSON.
+// Dates are shown in loc.
+func (app *Application) RenderPartial(name string, data any, loc *time.Location) (string, error) {
+	ts, err := app.template("home.html", loc)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	if err := ts.ExecuteTemplate(buf, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,82 @@
+// Command backup exports the forum database to an NDJSON backup on stdout, or
+// imports one into it:
+//
+//	backup -dsn ./data/storage.db export > forum.ndjson
+//	backup -dsn ./data/storage.db import forum.ndjson
+//
+// An import that stopped halfway can be run again with the same file; what
+// was already imported is skipped.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"forum/internal/repo"
+	"forum/internal/service"
+	"forum/models"
+	"io"
+	"log"
+	"os"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	dsn := flag.String("dsn", "./data/storage.db", "USAGE: STORAGE PATH, EX: ./data/storage.db")
+	batch := flag.Int("batch", 500, "USAGE: RECORDS IMPORTED PER TRANSACTION, EX: 500")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] export | import FILE\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	errLog := log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime)
+
+	r, err := repo.New(*dsn)
+	if err != nil {
+		errLog.Fatal(err)
+	}
+	s := service.New(r)
+
+	switch {
+	case flag.NArg() == 1 && flag.Arg(0) == "export":
+		w := bufio.NewWriter(os.Stdout)
+		if err := s.WriteBackup(w); err != nil {
+			errLog.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			errLog.Fatal(err)
+		}
+	case flag.NArg() == 2 && flag.Arg(0) == "import":
+		f, err := os.Open(flag.Arg(1))
+		if err != nil {
+			errLog.Fatal(err)
+		}
+		defer f.Close()
+		summary, err := s.ImportBackup(bufio.NewReader(f), *batch)
+		if summary != nil {
+			printSummary(os.Stdout, summary)
+		}
+		if err != nil {
+			errLog.Fatal(err)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func printSummary(w io.Writer, summary *models.ImportSummary) {
+	kinds := []string{
+		models.BackupUserType,
+		models.BackupCategoryType,
+		models.BackupPostType,
+		models.BackupCommentType,
+		models.BackupPostReactionType,
+		models.BackupCommentReactionType,
+	}
+	for _, kind := range kinds {
+		fmt.Fprintf(w, "%-17s %6d imported %6d skipped\n", kind, summary.Imported[kind], summary.Skipped[kind])
+	}
+}
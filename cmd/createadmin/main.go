@@ -0,0 +1,109 @@
+// Command createadmin makes the first admin of a forum, or any later one,
+// without editing the database by hand:
+//
+//	createadmin -dsn ./data/storage.db -username max -email max@example.com
+//
+// A new account takes its password from $ADMIN_PASSWORD, or else reads it
+// from the first line of stdin after a prompt, unechoed on a terminal, so
+// that it never shows in the process list, the shell history or the screen. An existing user by that name is
+// promoted as is and no password is asked for.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"forum/internal/repo"
+	"forum/internal/service"
+	"forum/models"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/term"
+)
+
+func main() {
+	errLog := log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime)
+
+	err := run(os.Args[1:], os.Getenv, os.Stdin, os.Stdout, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		errLog.Fatal(err)
+	}
+}
+
+// run is the command with its arguments, environment and standard streams
+// passed in.
+func run(args []string, getenv func(string) string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("createadmin", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dsn := flags.String("dsn", "./data/storage.db", "USAGE: STORAGE PATH, EX: ./data/storage.db")
+	username := flags.String("username", "", "USAGE: NAME OF THE ADMIN, EX: max")
+	email := flags.String("email", "", "USAGE: EMAIL OF A NEW ADMIN, EX: max@example.com")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: createadmin [flags]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || flags.NArg() > 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	r, err := repo.New(*dsn)
+	if err != nil {
+		return err
+	}
+	s := service.New(r)
+
+	form := models.UserSignupForm{Name: *username, Email: strings.ToLower(*email)}
+	if _, err := s.GetUserByName(form.Name); errors.Is(err, models.ErrNoRecord) {
+		if form.Password, err = readPassword(getenv, stdin, stderr); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	promoted, err := s.CreateAdmin(form, models.DefaultUsernamePolicy, models.DefaultEmailPolicy)
+	switch {
+	case errors.Is(err, models.ErrInvalidName):
+		return fmt.Errorf("%w: %s", err, models.DefaultUsernamePolicy.Rule())
+	case errors.Is(err, models.ErrWeakPassword):
+		return fmt.Errorf("%w: it must be at least %d characters long", err, models.PasswordMinChars)
+	case err != nil:
+		return err
+	case promoted:
+		fmt.Fprintf(stdout, "%s is an admin now\n", form.Name)
+	default:
+		fmt.Fprintf(stdout, "created admin %s\n", form.Name)
+	}
+	return nil
+}
+
+// readPassword takes the password from $ADMIN_PASSWORD, or else from the
+// first line of stdin. A terminal doesn't echo what is typed.
+func readPassword(getenv func(string) string, stdin io.Reader, stderr io.Writer) (string, error) {
+	if password := getenv("ADMIN_PASSWORD"); password != "" {
+		return password, nil
+	}
+	fmt.Fprint(stderr, "Password: ")
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		password, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(stderr)
+		return string(password), err
+	}
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared"
+	db, err := sqlite.NewDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateUser(models.User{Name: "alice", Email: "alice@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	createadmin := func(env map[string]string, stdin string, args ...string) (string, error) {
+		t.Helper()
+		var stdout strings.Builder
+		err := run(append([]string{"-dsn", dsn}, args...), func(key string) string { return env[key] }, strings.NewReader(stdin), &stdout, io.Discard)
+		return stdout.String(), err
+	}
+	status := func(name string) int {
+		t.Helper()
+		user, err := db.GetUserByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user.Status
+	}
+
+	t.Run("Weak password", func(t *testing.T) {
+		_, err := createadmin(nil, "short\n", "-username", "root", "-email", "root@example.com")
+		if !errors.Is(err, models.ErrWeakPassword) {
+			t.Errorf("got %v; expected %v", err, models.ErrWeakPassword)
+		}
+		if _, err := db.GetUserByName("root"); !errors.Is(err, models.ErrNoRecord) {
+			t.Errorf("got %v looking the admin up; expected %v", err, models.ErrNoRecord)
+		}
+	})
+
+	t.Run("New from stdin", func(t *testing.T) {
+		out, err := createadmin(nil, "correct horse\n", "--username", "admin", "--email", "Admin@Example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "created admin admin\n" {
+			t.Errorf("got %q", out)
+		}
+		if got := status("admin"); got != models.StatusAdmin {
+			t.Errorf("got status %d; expected %d", got, models.StatusAdmin)
+		}
+		if _, err := db.Authenticate("admin@example.com", "correct horse"); err != nil {
+			t.Errorf("got %v logging in as the new admin", err)
+		}
+	})
+
+	t.Run("New from the environment", func(t *testing.T) {
+		if _, err := createadmin(map[string]string{"ADMIN_PASSWORD": "correct horse"}, "", "-username", "root", "-email", "root@example.com"); err != nil {
+			t.Fatal(err)
+		}
+		if got := status("root"); got != models.StatusAdmin {
+			t.Errorf("got status %d; expected %d", got, models.StatusAdmin)
+		}
+	})
+
+	t.Run("Existing user", func(t *testing.T) {
+		out, err := createadmin(nil, "", "-username", "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "alice is an admin now\n" {
+			t.Errorf("got %q", out)
+		}
+		if got := status("alice"); got != models.StatusAdmin {
+			t.Errorf("got status %d; expected %d", got, models.StatusAdmin)
+		}
+		entries, err := db.GetAuditLogPaginated(models.AuditFilter{Actor: "alice"}, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*entries) != 1 || (*entries)[0].Action != models.AuditRoleChange {
+			t.Errorf("got audit entries %+v; expected the promotion", *entries)
+		}
+	})
+
+	t.Run("No username", func(t *testing.T) {
+		if _, err := createadmin(nil, ""); !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("got %v; expected %v", err, flag.ErrHelp)
+		}
+	})
+}
@@ -7,10 +7,20 @@ import (
 	"forum/internal/handlers"
 	"forum/internal/repo"
 	"forum/internal/service"
+	"forum/models"
+	"forum/pkg/blocklist"
+	"forum/pkg/captcha"
+	"forum/pkg/geo"
+	"forum/pkg/mailer"
+	"forum/pkg/urls"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -29,14 +39,68 @@ func main() {
 	}
 
 	app := app.New(infoLog, errLog, tc)
+	app.SnippetLength = cfg.SnippetLength
+	app.HideReactionsFor = cfg.HideReactionsFor
 
-	r, err := repo.New(cfg.StoragePath)
+	r, err := repo.NewWithSessions(cfg.StoragePath, cfg.Sessions, cfg.RedisAddr, cfg.SessionLimits)
 	if err != nil {
 		log.Fatal(err)
 	}
 	s := service.New(r)
 
-	h := handlers.New(s, app)
+	if !models.ValidSort(cfg.DefaultSort) {
+		errLog.Fatalf("unknown sort order %q", cfg.DefaultSort)
+	}
+	if cfg.CommentDepthPolicy != models.DepthFlatten && cfg.CommentDepthPolicy != models.DepthReject {
+		errLog.Fatalf("unknown comment depth policy %q", cfg.CommentDepthPolicy)
+	}
+	if cfg.SimilarNames != models.SimilarNamesOff && cfg.SimilarNames != models.SimilarNamesWarn && cfg.SimilarNames != models.SimilarNamesReject {
+		errLog.Fatalf("unknown similar names policy %q", cfg.SimilarNames)
+	}
+	if cfg.BlocklistMode != blocklist.ModeReject && cfg.BlocklistMode != blocklist.ModeMask {
+		errLog.Fatalf("unknown blocklist mode %q", cfg.BlocklistMode)
+	}
+	bl, err := blocklist.Load(cfg.BlocklistPath)
+	if err != nil {
+		errLog.Fatal(err)
+	}
+	go reloadOnSighup(bl, infoLog, errLog)
+
+	var m mailer.Mailer = mailer.NewLogMailer(infoLog)
+	if cfg.SMTPAddr != "" {
+		m = mailer.NewSMTPMailer(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUser, cfg.SMTPPassword)
+	}
+	var emailDir fs.FS
+	if cfg.EmailTemplates != "" {
+		emailDir = os.DirFS(cfg.EmailTemplates)
+	}
+	emails, err := mailer.LoadTemplates(emailDir, cfg.EmailLocale)
+	if err != nil {
+		errLog.Fatal(err)
+	}
+	ub, err := urls.New(cfg.BaseURL)
+	if err != nil {
+		errLog.Fatal(err)
+	}
+	go sendDigests(s, m, emails, ub, cfg.DigestEvery, infoLog, errLog)
+	if cfg.ArchiveAfter > 0 {
+		go archivePosts(s, cfg.ArchiveAfter, infoLog, errLog)
+	}
+
+	cv, err := captcha.New(cfg.CaptchaProvider, cfg.CaptchaSiteKey, cfg.CaptchaSecret)
+	if err != nil {
+		errLog.Fatal(err)
+	}
+
+	if len(cfg.GeoBlock) > 0 && cfg.GeoRanges == "" {
+		errLog.Fatal("-geo-block needs -geo-ranges to tell client countries by")
+	}
+	gr, err := geo.LoadRanges(cfg.GeoRanges)
+	if err != nil {
+		errLog.Fatal(err)
+	}
+
+	h := handlers.New(s, app, cfg, bl, cv, gr, ub, m, emails)
 
 	srv := &http.Server{
 		Addr:         cfg.Address,
@@ -51,3 +115,51 @@ func main() {
 	fmt.Println(srv.ListenAndServe())
 
 }
+
+// reloadOnSighup rereads the blocklist file every time the process gets
+// SIGHUP, so the list can change without a restart.
+func reloadOnSighup(bl *blocklist.Blocklist, infoLog, errLog *log.Logger) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	for range sighup {
+		if err := bl.Reload(); err != nil {
+			errLog.Printf("blocklist reload: %v", err)
+			continue
+		}
+		infoLog.Print("blocklist reloaded")
+	}
+}
+
+// archiveEvery is how often inactive posts are looked for.
+const archiveEvery = time.Hour
+
+// archivePosts archives the posts that went inactive every tick.
+func archivePosts(s service.ServiceI, inactivity time.Duration, infoLog, errLog *log.Logger) {
+	ticker := time.NewTicker(archiveEvery)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		archived, err := s.ArchiveInactivePosts(now, inactivity)
+		if err != nil {
+			errLog.Printf("archive: %v", err)
+		}
+		if archived > 0 {
+			infoLog.Printf("archived %d inactive posts", archived)
+		}
+	}
+}
+
+// sendDigests mails the due email digests every tick. Windows are tracked in
+// the database, so restarting the server doesn't resend anything.
+func sendDigests(s service.ServiceI, m mailer.Mailer, emails *mailer.Templates, ub *urls.Builder, every time.Duration, infoLog, errLog *log.Logger) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		sent, err := s.SendDigests(m, emails, ub, now)
+		if err != nil {
+			errLog.Printf("digests: %v", err)
+		}
+		if sent > 0 {
+			infoLog.Printf("sent %d digests", sent)
+		}
+	}
+}
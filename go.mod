@@ -6,7 +6,14 @@ toolchain go1.23.5
 
 require golang.org/x/crypto v0.28.0
 
-require github.com/mattn/go-sqlite3 v1.14.22
+require (
+	github.com/google/uuid v1.6.0
+	github.com/mattn/go-sqlite3 v1.14.22
+	github.com/sirupsen/logrus v1.9.3
+	github.com/tebeka/selenium v0.9.9
+	github.com/xuri/excelize/v2 v2.9.0
+	golang.org/x/term v0.28.0
+)
 
 require (
 	github.com/blang/semver v3.5.1+incompatible // indirect
@@ -16,17 +23,13 @@ require (
 	github.com/gobwas/httphead v0.1.0 // indirect
 	github.com/gobwas/pool v0.2.1 // indirect
 	github.com/gobwas/ws v1.4.0 // indirect
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/gorilla/mux v1.8.1 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.9.0 // indirect
 	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
 	github.com/richardlehane/mscfb v1.0.4 // indirect
 	github.com/richardlehane/msoleps v1.0.4 // indirect
-	github.com/sirupsen/logrus v1.9.3 // indirect
-	github.com/tebeka/selenium v0.9.9 // indirect
 	github.com/xuri/efp v0.0.0-20240408161823-9ad904a10d6d // indirect
-	github.com/xuri/excelize/v2 v2.9.0 // indirect
 	github.com/xuri/nfp v0.0.0-20240318013403-ab9948c2c4a7 // indirect
 	golang.org/x/net v0.30.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
@@ -2,27 +2,411 @@ package config
 
 import (
 	"flag"
+	"forum/models"
+	"forum/pkg/embed"
+	"forum/pkg/geo"
+	"forum/pkg/realip"
+	"net"
+	"os"
+	"time"
 )
 
 type Config struct {
 	Env         string
 	StoragePath string
 	Address     string
+	// BaseURL is the canonical address of the site, links in feeds, the
+	// API, notifications and emails are built under it.
+	BaseURL     string
+	Maintenance bool
+	InviteOnly  bool
+	InviteQuota int
+	// SignupDisabled turns new registrations away with SignupMessage.
+	// Existing users still log in.
+	SignupDisabled bool
+	SignupMessage  string
+	// DisplayNames lets users pick a display name besides their username.
+	DisplayNames bool
+	// HistorySize is how many recently viewed posts are kept per user.
+	HistorySize int
+	// ProfilePins is how many posts a user can pin to their profile.
+	ProfilePins int
+	ResetTTL    time.Duration
+	// EmailPolicy decides which signup emails are aliases of one mailbox
+	// and so taken once one of them is.
+	EmailPolicy models.EmailPolicy
+	// SimilarNames is "off", "warn" or "reject": what to do with signups
+	// named like an existing user.
+	SimilarNames string
+	// BlocklistPath is a file of blocked words, one per line. BlocklistMode
+	// is "reject" or "mask".
+	BlocklistPath string
+	BlocklistMode string
+	// HomeLimit is how many posts the home page renders before "load more".
+	HomeLimit int
+	// PageLimit is the page size of the other lists when none is asked
+	// for, and no list pages more than MaxLimit items at a time.
+	PageLimit int
+	MaxLimit  int
+	// Lists of posts show the first SnippetLength characters of each as
+	// plain text, 0 for the whole post.
+	SnippetLength int
+	// Posts hide their likes, dislikes, score and emoji reaction counts
+	// for HideReactionsFor after they are made, 0 to always show them.
+	HideReactionsFor time.Duration
+	// DefaultSort orders the home page for users without a preference.
+	DefaultSort string
+	// WordsPerMinute is the reading speed read times in the API assume.
+	WordsPerMinute int
+	// Comments scoring below CollapseThreshold render collapsed.
+	CollapseThreshold int
+	// The best scored comment of a post is shown above the others when it
+	// scores at least HotCommentScore, 0 to never show one.
+	HotCommentScore int
+	// VoteWeights is how much the reactions of users, moderators and admins
+	// count toward the score of a post.
+	VoteWeights models.VoteWeights
+	// RequireCategory turns down new posts without a category. Otherwise
+	// they go in the models.Uncategorized category.
+	RequireCategory bool
+	// TagLimits cap how many categories a new post is filed under and how
+	// long their names are.
+	TagLimits models.TagLimits
+	// PostLabels are the labels moderators may put on posts.
+	PostLabels []string
+	// ReactionTypes are the emoji users may react to posts with, one per
+	// user and post, besides liking or disliking them.
+	ReactionTypes []string
+	// GuestPosting lets visitors without an account post and comment under
+	// a name of their choosing. Everything they send waits for approval.
+	GuestPosting bool
+	// Replies nest at most MaxCommentDepth deep, 0 for no limit. Deeper
+	// replies are handled by CommentDepthPolicy, "flatten" or "reject".
+	MaxCommentDepth    int
+	CommentDepthPolicy string
+	// CommentSorts is the order comments are shown in on the page of a post
+	// in each category, unless the viewer picks one with ?sort=.
+	CommentSorts models.CommentSorts
+	// Threaded comments are shown at most ThreadDisplayDepth replies deep,
+	// 0 for no limit. Deeper ones are left for the page of their thread.
+	ThreadDisplayDepth int
+	// The page of a post shows CommentsPerPage comments at a time, or as
+	// many threads when they are threaded, 0 for all of them.
+	CommentsPerPage int
+	// Edits authors make within EditGrace of posting don't mark the post
+	// edited, 0 to mark every edit.
+	EditGrace time.Duration
+	// Authors may remove a post or comment nobody replied to for good for
+	// DeleteGrace after making it, 0 for never. Later it is only blanked.
+	DeleteGrace time.Duration
+	// CommentCooldown is the least time between two comments of a user, 0
+	// for none. Moderators are exempt.
+	CommentCooldown time.Duration
+	// A user may make WriteLimit posts, edits, comments and reactions
+	// together per WriteWindow, 0 for no limit.
+	WriteLimit  int
+	WriteWindow time.Duration
+	// A like or dislike repeated within ReactionDedup of the first is taken
+	// for a double click and ignored instead of taking the vote back.
+	ReactionDedup time.Duration
+	// ReactionCooldown is the least time between two reactions of a user to
+	// the same post or comment, 0 for none. Sooner ones get a 429.
+	ReactionCooldown time.Duration
+	// Accounts younger than ReactMinAge get a 403 when they react, to keep
+	// fresh accounts from swaying votes. 0 lets anyone react.
+	ReactMinAge time.Duration
+	// Accounts younger than NewUserAge or with fewer than NewUserPosts posts
+	// can't post links and make at most NewUserPostsPerDay posts a day, 0
+	// for no limit.
+	NewUserAge         time.Duration
+	NewUserPosts       int
+	NewUserPostsPerDay int
+	// DailyPostLimits caps the posts of users, moderators and admins per
+	// rolling 24 hours, 0 for no limit.
+	DailyPostLimits models.DailyPostLimits
+	// ApproveNewUsers holds comments of new accounts for a moderator's
+	// approval.
+	ApproveNewUsers bool
+	// AutosaveInterval is the least time between two stored autosaves of a
+	// draft, saves sent sooner are ignored.
+	AutosaveInterval time.Duration
+	// Signups and comments with the hidden Honeypot field filled in, or sent
+	// less than MinSubmitTime after the form was rendered, are dropped.
+	Honeypot      bool
+	MinSubmitTime time.Duration
+	// CaptchaProvider is "none", "hcaptcha" or "recaptcha". The secret is
+	// read from $CAPTCHA_SECRET, like the SMTP password.
+	CaptchaProvider string
+	CaptchaSiteKey  string
+	CaptchaSecret   string
+	// CSP replaces the default Content-Security-Policy when set, e.g. to
+	// allow inline scripts. ReferrerPolicy and FrameOptions are sent as
+	// given; empty values keep the defaults.
+	CSP            string
+	ReferrerPolicy string
+	FrameOptions   string
+	// UsernamePolicy is what the names of new accounts may look like.
+	UsernamePolicy models.UsernamePolicy
+	// Links to EmbedDomains are shown as players under posts, see
+	// embed.Renderers for the sites that can be embedded. None are by
+	// default.
+	EmbedDomains []string
+	// Sessions is where logins are kept: "db", "memory" or "redis" at
+	// RedisAddr.
+	Sessions  string
+	RedisAddr string
+	// SessionLimits are how long logins last, see models.SessionLimits.
+	SessionLimits models.SessionLimits
+	// Mail goes through SMTPAddr when it is set and to the log otherwise.
+	// The password is read from $SMTP_PASSWORD to keep it out of ps.
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPUser     string
+	SMTPPassword string
+	// Emails are rendered from the templates in EmailTemplates, one
+	// directory per locale, over the built-in ones. Mail for a locale without
+	// templates is in EmailLocale.
+	EmailTemplates string
+	EmailLocale    string
+	// X-Forwarded-For is only believed when the request comes from one of
+	// the TrustedProxies.
+	TrustedProxies []*net.IPNet
+	// Clients from the GeoBlock countries, as told by the networks in the
+	// GeoRanges file, get a 403. Those whose country can't be told get
+	// through unless GeoFailClosed.
+	GeoBlock      []string
+	GeoRanges     string
+	GeoFailClosed bool
+	// LoginRedirect sends users back to the page they asked for when they
+	// had to log in first.
+	LoginRedirect bool
+	// MaxBodyBytes caps the size of request bodies, larger ones get a 413.
+	MaxBodyBytes int64
+	// Posts without a new comment for ArchiveAfter are archived, 0 to
+	// never archive.
+	ArchiveAfter time.Duration
+	// DigestEvery is how often the digest job looks for due email digests.
+	DigestEvery time.Duration
 }
 
-
-
 func MustLoad() *Config {
 	addr := flag.String("addr", ":8080", "USAGE: :PORT, EX: \":8080\"")
+	baseURL := flag.String("base-url", "http://localhost:8080", "USAGE: CANONICAL ADDRESS OF THE SITE FOR ABSOLUTE LINKS, EX: https://forum.example.com")
 	env := flag.String("env", "dev", "USAGE: DEV, EX: DEV|STAGE|PROD")
 	dsn := flag.String("dsn", "./data/storage.db", "USAGE: STORAGE PATH, EX: ./data/storage.db")
+	maintenance := flag.Bool("maintenance", false, "USAGE: MAINTENANCE MODE, EX: -maintenance=true")
+	inviteOnly := flag.Bool("invite-only", false, "USAGE: SIGNUP REQUIRES AN INVITE CODE, EX: -invite-only=true")
+	displayNames := flag.Bool("display-names", true, "USAGE: ASK FOR AN OPTIONAL DISPLAY NAME AT SIGNUP, EX: -display-names=false")
+	inviteQuota := flag.Int("invite-quota", 5, "USAGE: INVITES A USER CAN GENERATE, EX: 5")
+	signupDisabled := flag.Bool("signup-disabled", false, "USAGE: TURN NEW REGISTRATIONS AWAY, EVEN WITH AN INVITE, EX: -signup-disabled=true")
+	signupMessage := flag.String("signup-message", "New registrations are closed for now", "USAGE: WHAT -signup-disabled TELLS VISITORS, EX: 'Back on Monday'")
+	historySize := flag.Int("history-size", 20, "USAGE: RECENTLY VIEWED POSTS KEPT PER USER, EX: 20")
+	profilePins := flag.Int("profile-pins", 3, "USAGE: POSTS A USER CAN PIN TO THEIR PROFILE, EX: 3")
+	blocklistPath := flag.String("blocklist", "", "USAGE: BLOCKED WORDS FILE, RELOADED ON SIGHUP, EX: ./data/blocklist.txt")
+	blocklistMode := flag.String("blocklist-mode", "reject", "USAGE: WHAT TO DO WITH BLOCKED WORDS, EX: reject|mask")
+	homeLimit := flag.Int("home-limit", 20, "USAGE: POSTS ON THE HOME PAGE BEFORE LOAD MORE, EX: 20")
+	pageLimit := flag.Int("page-limit", 5, "USAGE: ITEMS PER PAGE OF A LIST WITHOUT A REQUESTED LIMIT, EX: 5")
+	maxLimit := flag.Int("max-limit", 100, "USAGE: LARGEST LIMIT A LIST ACCEPTS, LARGER ONES ARE CLAMPED, EX: 100")
+	snippetLength := flag.Int("snippet-length", 200, "USAGE: CHARACTERS OF EACH POST SHOWN IN LISTS, 0 FOR ALL, EX: 200")
+	hideReactionsFor := flag.Duration("hide-reactions-for", 0, "USAGE: HIDE THE REACTION COUNTS OF NEW POSTS FOR THIS LONG, 0 TO ALWAYS SHOW THEM, EX: 1h")
+	defaultSort := flag.String("default-sort", "newest", "USAGE: HOME PAGE ORDER WITHOUT A USER PREFERENCE, EX: newest|top|hot|active")
+	wordsPerMinute := flag.Int("words-per-minute", 200, "USAGE: READING SPEED FOR READ TIMES IN THE API, EX: 200")
+	collapseThreshold := flag.Int("collapse-threshold", -5, "USAGE: SCORE BELOW WHICH COMMENTS ARE COLLAPSED, EX: -5")
+	hotCommentScore := flag.Int("hot-comment-score", 5, "USAGE: SCORE A COMMENT NEEDS TO BE SHOWN ABOVE THE THREAD, 0 FOR NEVER, EX: 5")
+	sessions := flag.String("sessions", "db", "USAGE: WHERE LOGINS ARE KEPT, REDIS SHARES THEM BETWEEN INSTANCES, EX: db|memory|redis")
+	redisAddr := flag.String("redis-addr", "localhost:6379", "USAGE: REDIS SERVER FOR -sessions=redis, EX: redis.example.com:6379")
+	sessionLifetime := flag.Duration("session-lifetime", models.DefaultSessionLimits.Lifetime, "USAGE: HOW LONG A LOGIN LASTS, HOWEVER ACTIVE, EX: 100m")
+	sessionIdle := flag.Duration("session-idle", models.DefaultSessionLimits.Idle, "USAGE: LOGINS UNUSED FOR THIS LONG EXPIRE, 0 FOR NEVER, EX: 30m")
+	smtpAddr := flag.String("smtp-addr", "", "USAGE: SMTP SERVER, EMPTY LOGS MAIL INSTEAD, EX: smtp.example.com:587")
+	smtpFrom := flag.String("smtp-from", "forum@localhost", "USAGE: SENDER ADDRESS, EX: forum@example.com")
+	smtpUser := flag.String("smtp-user", "", "USAGE: SMTP USERNAME, PASSWORD IN $SMTP_PASSWORD, EX: forum")
+	emailTemplates := flag.String("email-templates", "", "USAGE: DIRECTORY OF EMAIL TEMPLATES, ONE SUBDIRECTORY PER LOCALE, EX: ./data/email")
+	emailLocale := flag.String("email-locale", "en", "USAGE: LOCALE OF EMAILS TO USERS WITHOUT ONE, EX: en")
+	loginRedirect := flag.Bool("login-redirect", true, "USAGE: GO BACK TO THE REQUESTED PAGE AFTER LOGGING IN, EX: -login-redirect=false")
+	archiveAfter := flag.Duration("archive-after", 0, "USAGE: ARCHIVE POSTS WITHOUT A NEW COMMENT FOR THIS LONG, 0 FOR NEVER, EX: 4320h")
+	digestEvery := flag.Duration("digest-every", 15*time.Minute, "USAGE: HOW OFTEN DUE EMAIL DIGESTS ARE SENT, EX: 15m")
+	var trustedProxies []*net.IPNet
+	flag.Func("trusted-proxies", "USAGE: COMMA SEPARATED PROXY CIDRS WHOSE X-FORWARDED-FOR IS TRUSTED, EX: 10.0.0.0/8,127.0.0.1", func(s string) error {
+		var err error
+		trustedProxies, err = realip.ParseCIDRs(s)
+		return err
+	})
+	var geoBlock []string
+	flag.Func("geo-block", "USAGE: COMMA SEPARATED COUNTRY CODES WHOSE CLIENTS GET A 403, NEEDS -geo-ranges, EX: KP,IR", func(s string) error {
+		var err error
+		geoBlock, err = geo.ParseCountries(s)
+		return err
+	})
+	geoRanges := flag.String("geo-ranges", "", "USAGE: FILE OF CIDR,COUNTRY LINES TO TELL CLIENT COUNTRIES BY, EX: ./data/ranges.csv")
+	geoFailClosed := flag.Bool("geo-fail-closed", false, "USAGE: BLOCK CLIENTS WHOSE COUNTRY CAN'T BE TOLD, EX: -geo-fail-closed=true")
+	voteWeights := models.DefaultVoteWeights
+	flag.Func("vote-weights", "USAGE: WEIGHTS OF REACTIONS OF USERS, MODERATORS AND ADMINS IN POST SCORES, EX: 1,2,3", func(s string) error {
+		var err error
+		voteWeights, err = models.ParseVoteWeights(s)
+		return err
+	})
+	dailyPostLimits := models.DailyPostLimits{20, 100, 0}
+	flag.Func("daily-post-limits", "USAGE: POSTS USERS, MODERATORS AND ADMINS MAY MAKE PER 24 HOURS, 0 FOR NO LIMIT, EX: 20,100,0", func(s string) error {
+		var err error
+		dailyPostLimits, err = models.ParseDailyPostLimits(s)
+		return err
+	})
+	emailPolicy := models.DefaultEmailPolicy
+	flag.Func("email-policy", "USAGE: COMMA SEPARATED EMAIL DOMAINS AND THE PARTS OF ADDRESSES THEY IGNORE, dots AND tags, EX: gmail.com=dots+tags,outlook.com=tags", func(s string) error {
+		var err error
+		emailPolicy, err = models.ParseEmailPolicy(s)
+		return err
+	})
+	guestPosting := flag.Bool("guest-posting", false, "USAGE: LET VISITORS WITHOUT AN ACCOUNT POST AND COMMENT, HELD FOR APPROVAL, EX: -guest-posting=true")
+	requireCategory := flag.Bool("require-category", true, "USAGE: TURN DOWN NEW POSTS WITHOUT A CATEGORY RATHER THAN FILE THEM UNCATEGORIZED, EX: -require-category=false")
+	maxTags := flag.Int("max-tags", models.DefaultTagLimits.MaxTags, "USAGE: MOST CATEGORIES A POST CAN BE FILED UNDER, 0 FOR NO LIMIT, EX: 5")
+	maxTagChars := flag.Int("max-tag-chars", models.DefaultTagLimits.MaxChars, "USAGE: LONGEST CATEGORY NAME A POST CAN BE FILED UNDER, 0 FOR NO LIMIT, EX: 30")
+	maxTotalTagChars := flag.Int("max-total-tag-chars", models.DefaultTagLimits.MaxTotalChars, "USAGE: MOST CHARACTERS THE CATEGORY NAMES OF A POST CAN HAVE TOGETHER, 0 FOR NO LIMIT, EX: 100")
+	postLabels := models.DefaultPostLabels
+	flag.Func("post-labels", "USAGE: COMMA SEPARATED LABELS MODERATORS MAY PUT ON POSTS, EX: Announcement,Resolved,Pinned", func(s string) error {
+		var err error
+		postLabels, err = models.ParsePostLabels(s)
+		return err
+	})
+	reactionTypes := models.DefaultReactionTypes
+	flag.Func("reaction-types", "USAGE: COMMA SEPARATED EMOJI USERS MAY REACT TO POSTS WITH, EX: 👍,❤️,😂", func(s string) error {
+		var err error
+		reactionTypes, err = models.ParseReactionTypes(s)
+		return err
+	})
+	commentSorts := models.DefaultCommentSorts
+	flag.Func("comment-sort", "USAGE: COMMA SEPARATED COMMENT ORDERS BY CATEGORY AND ONE FOR THE REST, oldest, newest OR top, EX: oldest,Q&A=top", func(s string) error {
+		var err error
+		commentSorts, err = models.ParseCommentSorts(s)
+		return err
+	})
+	maxCommentDepth := flag.Int("max-comment-depth", 8, "USAGE: HOW DEEP REPLIES NEST, 0 FOR NO LIMIT, EX: 8")
+	commentsPerPage := flag.Int("comments-per-page", 50, "USAGE: COMMENTS OR THREADS SHOWN PER PAGE OF A POST, 0 FOR ALL, EX: 50")
+	threadDisplayDepth := flag.Int("thread-display-depth", 4, "USAGE: HOW DEEP THREADED COMMENTS ARE SHOWN BEFORE A CONTINUE THIS THREAD LINK, 0 FOR NO LIMIT, EX: 4")
+	commentDepthPolicy := flag.String("comment-depth-policy", "flatten", "USAGE: WHAT TO DO WITH TOO DEEP REPLIES, EX: flatten|reject")
+	editGrace := flag.Duration("edit-grace", 2*time.Minute, "USAGE: HOW LONG AFTER POSTING AUTHORS MAY EDIT WITHOUT THE EDITED MARK, 0 FOR NONE, EX: 2m")
+	deleteGrace := flag.Duration("delete-grace", 5*time.Minute, "USAGE: HOW LONG AUTHORS MAY REMOVE A POST OR COMMENT WITHOUT REPLIES FOR GOOD, 0 FOR NEVER, EX: 5m")
+	commentCooldown := flag.Duration("comment-cooldown", 15*time.Second, "USAGE: LEAST TIME BETWEEN TWO COMMENTS OF A USER, 0 FOR NONE, EX: 15s")
+	writeLimit := flag.Int("write-limit", 30, "USAGE: POSTS, COMMENTS AND REACTIONS A USER MAY MAKE PER WRITE WINDOW, 0 FOR NO LIMIT, EX: 30")
+	writeWindow := flag.Duration("write-window", time.Minute, "USAGE: WINDOW OF THE WRITE LIMIT, EX: 1m")
+	reactionCooldown := flag.Duration("reaction-cooldown", time.Second, "USAGE: LEAST TIME BETWEEN TWO REACTIONS OF A USER TO ONE POST OR COMMENT, 0 FOR NONE, EX: 1s")
+	reactionDedup := flag.Duration("reaction-dedup-window", 2*time.Second, "USAGE: REPEATS OF A LIKE OR DISLIKE WITHIN THIS ARE IGNORED, 0 FOR NONE, EX: 2s")
+	reactMinAge := flag.Duration("react-min-age", 0, "USAGE: ACCOUNTS YOUNGER THAN THIS CAN'T REACT, 0 FOR NONE, EX: 24h")
+	newUserAge := flag.Duration("new-user-age", 72*time.Hour, "USAGE: ACCOUNTS YOUNGER THAN THIS ARE NEW AND CAN'T POST LINKS, 0 FOR NONE, EX: 72h")
+	newUserPosts := flag.Int("new-user-posts", 3, "USAGE: POSTS AN ACCOUNT NEEDS TO STOP BEING NEW, EX: 3")
+	newUserPostsPerDay := flag.Int("new-user-posts-per-day", 3, "USAGE: POSTS A NEW ACCOUNT MAY MAKE A DAY, 0 FOR NO LIMIT, EX: 3")
+	newUserCommentApproval := flag.Bool("new-user-comment-approval", false, "USAGE: HOLD COMMENTS OF NEW ACCOUNTS UNTIL A MODERATOR APPROVES THEM, EX: -new-user-comment-approval=true")
+	autosaveInterval := flag.Duration("autosave-interval", 5*time.Second, "USAGE: LEAST TIME BETWEEN TWO STORED AUTOSAVES OF A DRAFT, EX: 5s")
+	honeypot := flag.Bool("honeypot", true, "USAGE: DROP FORMS WITH THE HIDDEN HONEYPOT FIELD FILLED IN, EX: -honeypot=false")
+	minSubmitTime := flag.Duration("min-submit-time", 2*time.Second, "USAGE: FORMS SENT FASTER THAN THIS ARE DROPPED, 0 FOR NO CHECK, EX: 2s")
+	captchaProvider := flag.String("captcha", "none", "USAGE: CAPTCHA SHOWN AT SIGNUP, SECRET IN $CAPTCHA_SECRET, EX: none|hcaptcha|recaptcha")
+	captchaSiteKey := flag.String("captcha-site-key", "", "USAGE: SITE KEY OF THE CAPTCHA PROVIDER, EX: 10000000-ffff-ffff-ffff-000000000001")
+	csp := flag.String("csp", "", "USAGE: CONTENT-SECURITY-POLICY REPLACING THE DEFAULT ONE, CAPTCHA ORIGINS INCLUDED, EX: \"default-src 'self'; script-src 'self' 'unsafe-inline'\"")
+	referrerPolicy := flag.String("referrer-policy", "origin-when-cross-origin", "USAGE: REFERRER-POLICY HEADER, EX: no-referrer")
+	frameOptions := flag.String("frame-options", "deny", "USAGE: X-FRAME-OPTIONS HEADER, EX: sameorigin")
+	usernamePolicy := models.DefaultUsernamePolicy
+	flag.Func("username-chars", "USAGE: CHARACTERS ALLOWED IN USERNAMES, unicode, ascii, lower OR A REGEXP CHARACTER CLASS, EX: a-z0-9_", func(s string) error {
+		var err error
+		usernamePolicy.Charset, err = models.ParseUsernameCharset(s)
+		return err
+	})
+	flag.IntVar(&usernamePolicy.MinChars, "username-min-chars", usernamePolicy.MinChars, "USAGE: SHORTEST USERNAME ALLOWED, EX: 3")
+	flag.IntVar(&usernamePolicy.MaxChars, "username-max-chars", usernamePolicy.MaxChars, "USAGE: LONGEST USERNAME ALLOWED, 0 FOR NO LIMIT, EX: 12")
+	flag.Func("reserved-names", "USAGE: COMMA SEPARATED USERNAMES NOBODY MAY SIGN UP WITH, EX: admin,root,api", func(s string) error {
+		usernamePolicy.Reserved = models.ParseReservedNames(s)
+		return nil
+	})
+	var embedDomains []string
+	flag.Func("embed-domains", "USAGE: COMMA SEPARATED SITES WHOSE LINKS ARE EMBEDDED IN POSTS, EX: youtube.com,youtu.be,vimeo.com", func(s string) error {
+		var err error
+		embedDomains, err = embed.ParseDomains(s)
+		return err
+	})
+	similarNames := flag.String("similar-names", "reject", "USAGE: WHAT TO DO WITH SIGNUPS NAMED LIKE AN EXISTING USER, EX: off|warn|reject")
+	maxBodyBytes := flag.Int64("max-body-bytes", 1<<20, "USAGE: LARGEST REQUEST BODY ACCEPTED, IN BYTES, EX: 1048576")
+	resetTTL := flag.Duration("reset-ttl", time.Hour, "USAGE: PASSWORD RESET LINK LIFETIME, EX: 30m")
 
 	flag.Parse()
 
 	cfg := Config{
-		Env:         *env,
-		Address:     *addr,
-		StoragePath: *dsn,
+		Env:                *env,
+		Address:            *addr,
+		BaseURL:            *baseURL,
+		StoragePath:        *dsn,
+		Maintenance:        *maintenance,
+		InviteOnly:         *inviteOnly,
+		InviteQuota:        *inviteQuota,
+		SignupDisabled:     *signupDisabled,
+		SignupMessage:      *signupMessage,
+		DisplayNames:       *displayNames,
+		ProfilePins:        *profilePins,
+		HistorySize:        *historySize,
+		ResetTTL:           *resetTTL,
+		EmailPolicy:        emailPolicy,
+		SimilarNames:       *similarNames,
+		BlocklistPath:      *blocklistPath,
+		BlocklistMode:      *blocklistMode,
+		HomeLimit:          *homeLimit,
+		PageLimit:          *pageLimit,
+		MaxLimit:           *maxLimit,
+		SnippetLength:      *snippetLength,
+		HideReactionsFor:   *hideReactionsFor,
+		DefaultSort:        *defaultSort,
+		WordsPerMinute:     *wordsPerMinute,
+		CollapseThreshold:  *collapseThreshold,
+		HotCommentScore:    *hotCommentScore,
+		MaxCommentDepth:    *maxCommentDepth,
+		CommentDepthPolicy: *commentDepthPolicy,
+		ThreadDisplayDepth: *threadDisplayDepth,
+		CommentsPerPage:    *commentsPerPage,
+		CommentSorts:       commentSorts,
+		EditGrace:          *editGrace,
+		DeleteGrace:        *deleteGrace,
+		CommentCooldown:    *commentCooldown,
+		WriteLimit:         *writeLimit,
+		WriteWindow:        *writeWindow,
+		ReactionDedup:      *reactionDedup,
+		ReactionCooldown:   *reactionCooldown,
+		ReactMinAge:        *reactMinAge,
+		NewUserAge:         *newUserAge,
+		NewUserPosts:       *newUserPosts,
+		NewUserPostsPerDay: *newUserPostsPerDay,
+		DailyPostLimits:    dailyPostLimits,
+		ApproveNewUsers:    *newUserCommentApproval,
+		AutosaveInterval:   *autosaveInterval,
+		Honeypot:           *honeypot,
+		MinSubmitTime:      *minSubmitTime,
+		CaptchaProvider:    *captchaProvider,
+		CaptchaSiteKey:     *captchaSiteKey,
+		CaptchaSecret:      os.Getenv("CAPTCHA_SECRET"),
+		CSP:                *csp,
+		ReferrerPolicy:     *referrerPolicy,
+		FrameOptions:       *frameOptions,
+		UsernamePolicy:     usernamePolicy,
+		EmbedDomains:       embedDomains,
+		Sessions:           *sessions,
+		RedisAddr:          *redisAddr,
+		SessionLimits:      models.SessionLimits{Lifetime: *sessionLifetime, Idle: *sessionIdle},
+		SMTPAddr:           *smtpAddr,
+		SMTPFrom:           *smtpFrom,
+		SMTPUser:           *smtpUser,
+		SMTPPassword:       os.Getenv("SMTP_PASSWORD"),
+		EmailTemplates:     *emailTemplates,
+		EmailLocale:        *emailLocale,
+		MaxBodyBytes:       *maxBodyBytes,
+		LoginRedirect:      *loginRedirect,
+		ArchiveAfter:       *archiveAfter,
+		DigestEvery:        *digestEvery,
+		TrustedProxies:     trustedProxies,
+		GeoBlock:           geoBlock,
+		GeoRanges:          *geoRanges,
+		GeoFailClosed:      *geoFailClosed,
+		VoteWeights:        voteWeights,
+		RequireCategory:    *requireCategory,
+		TagLimits:          models.TagLimits{MaxTags: *maxTags, MaxChars: *maxTagChars, MaxTotalChars: *maxTotalTagChars},
+		PostLabels:         postLabels,
+		ReactionTypes:      reactionTypes,
+		GuestPosting:       *guestPosting,
 	}
 
 	return &cfg
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// adminAnnouncements lists every announcement as JSON, or creates one from
+// the "message", "category", "post", "start" and "end" form values. Times are
+// RFC 3339, a missing start means now and a missing scope means site-wide.
+func (h *handler) adminAnnouncements(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/admin/announcements" {
+		h.app.NotFound(w)
+		return
+	}
+	token := cookie.GetSessionCookie(r)
+	switch r.Method {
+	case http.MethodGet:
+		announcements, err := h.service.GetAnnouncements(token.Value)
+		if err != nil {
+			h.announcementError(w, err)
+			return
+		}
+		if *announcements == nil {
+			*announcements = []models.Announcement{}
+		}
+		h.app.JSON(w, http.StatusOK, announcements)
+	case http.MethodPost:
+		a, err := announcementForm(r)
+		if err != nil {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		created, err := h.service.CreateAnnouncement(token.Value, a)
+		if err != nil {
+			h.announcementError(w, err)
+			return
+		}
+		h.app.JSON(w, http.StatusCreated, created)
+	default:
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+	}
+}
+
+// adminAnnouncementDelete removes the announcement "id".
+func (h *handler) adminAnnouncementDelete(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/admin/announcements/delete" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := GetIntForm(r, "id")
+	if err != nil || id < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	if err := h.service.DeleteAnnouncement(token.Value, id); err != nil {
+		h.announcementError(w, err)
+		return
+	}
+	http.Redirect(w, r, "/admin/announcements", http.StatusSeeOther)
+}
+
+func announcementForm(r *http.Request) (models.Announcement, error) {
+	a := models.Announcement{Message: r.FormValue("message"), Start: time.Now()}
+	var err error
+	if value := r.FormValue("category"); value != "" {
+		if a.CategoryID, err = strconv.Atoi(value); err != nil {
+			return a, err
+		}
+	}
+	if value := r.FormValue("post"); value != "" {
+		if a.PostID, err = strconv.Atoi(value); err != nil {
+			return a, err
+		}
+	}
+	if value := r.FormValue("start"); value != "" {
+		if a.Start, err = time.Parse(time.RFC3339, value); err != nil {
+			return a, err
+		}
+	}
+	a.End, err = time.Parse(time.RFC3339, r.FormValue("end"))
+	return a, err
+}
+
+func (h *handler) announcementError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrForbidden):
+		h.app.ClientError(w, http.StatusForbidden)
+	case errors.Is(err, models.ErrNoRecord):
+		h.app.NotFound(w)
+	case errors.Is(err, models.ErrInvalidAnnouncement), errors.Is(err, models.ErrInvalidDateRange),
+		errors.Is(err, models.UnknownCategory):
+		h.app.ClientError(w, http.StatusBadRequest)
+	default:
+		h.app.ServerError(w, err)
+	}
+}
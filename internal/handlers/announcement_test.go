@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnnouncementBanners(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "Home",
+			url:     "/",
+			want:    []string{mock.SiteAnnouncement},
+			notWant: []string{mock.CategoryAnnouncement, mock.PostAnnouncement, mock.ExpiredAnnouncement, mock.FutureAnnouncement},
+		},
+		{
+			name:    "Category page",
+			url:     "/?category=category1",
+			want:    []string{mock.SiteAnnouncement, mock.CategoryAnnouncement},
+			notWant: []string{mock.PostAnnouncement, mock.ExpiredAnnouncement, mock.FutureAnnouncement},
+		},
+		{
+			name:    "Post page",
+			url:     "/post/" + strconv.Itoa(mock.MarkdownPostID),
+			want:    []string{mock.SiteAnnouncement, mock.CategoryAnnouncement, mock.PostAnnouncement},
+			notWant: []string{mock.ExpiredAnnouncement, mock.FutureAnnouncement},
+		},
+		{
+			name:    "Other post",
+			url:     "/post/" + strconv.Itoa(mock.DisplayNamePostID),
+			want:    []string{mock.SiteAnnouncement},
+			notWant: []string{mock.PostAnnouncement, mock.ExpiredAnnouncement, mock.FutureAnnouncement},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.url)
+			mock.Equal(t, code, http.StatusOK)
+			for _, message := range tt.want {
+				mock.StringContains(t, body, `<div class="announcement">`+message+`</div>`)
+			}
+			for _, message := range tt.notWant {
+				if strings.Contains(body, message) {
+					t.Errorf("body shows %q", message)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminAnnouncements(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	start := time.Now().UTC().Format(time.RFC3339)
+	end := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name     string
+		token    string
+		form     url.Values
+		wantCode int
+	}{
+		{
+			name:     "Site-wide",
+			token:    mock.AdminToken,
+			form:     url.Values{"message": {"Hello"}, "start": {start}, "end": {end}},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Category",
+			token:    mock.AdminToken,
+			form:     url.Values{"message": {"Hello"}, "category": {"2"}, "end": {end}},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Regular user",
+			token:    sessionCookieValue,
+			form:     url.Values{"message": {"Hello"}, "end": {end}},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Unknown category",
+			token:    mock.AdminToken,
+			form:     url.Values{"message": {"Hello"}, "category": {"3"}, "end": {end}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Ends before start",
+			token:    mock.AdminToken,
+			form:     url.Values{"message": {"Hello"}, "start": {end}, "end": {start}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Malformed end",
+			token:    mock.AdminToken,
+			form:     url.Values{"message": {"Hello"}, "end": {"tomorrow"}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Empty message",
+			token:    mock.AdminToken,
+			form:     url.Values{"end": {end}},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.postFormWithSession(t, "/admin/announcements", tt.form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+
+	code, _, body := ts.getWithSession(t, "/admin/announcements", mock.AdminToken)
+	mock.Equal(t, code, http.StatusOK)
+	var announcements []models.Announcement
+	if err := json.Unmarshal([]byte(body), &announcements); err != nil {
+		t.Fatal(err)
+	}
+	// The five seeded ones and the two created above.
+	mock.Equal(t, len(announcements), 7)
+
+	code, _, _ = ts.getWithSession(t, "/admin/announcements", sessionCookieValue)
+	mock.Equal(t, code, http.StatusForbidden)
+
+	code, _, _ = ts.postFormWithSession(t, "/admin/announcements/delete", url.Values{"id": {"1"}}, mock.AdminToken)
+	mock.Equal(t, code, http.StatusSeeOther)
+	code, _, _ = ts.postFormWithSession(t, "/admin/announcements/delete", url.Values{"id": {"99"}}, mock.AdminToken)
+	mock.Equal(t, code, http.StatusNotFound)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"forum/pkg/antispam"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAntispam(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{Honeypot: true, MinSubmitTime: time.Minute})
+	defer ts.Close()
+
+	human := antispam.Stamp(time.Now().Add(-2 * time.Minute))
+	tooFast := antispam.Stamp(time.Now())
+	tests := []struct {
+		name    string
+		form    url.Values
+		dropped bool
+	}{
+		{"person", url.Values{antispam.StampField: {human}}, false},
+		{"filled honeypot", url.Values{antispam.StampField: {human}, antispam.HoneypotField: {"http://spam.example"}}, true},
+		{"too fast", url.Values{antispam.StampField: {tooFast}}, true},
+		{"no stamp", url.Values{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" signup", func(t *testing.T) {
+			form := url.Values{"name": {"max"}, "email": {"max@gmail.com"}, "password": {"password123"}}
+			for k, v := range tt.form {
+				form[k] = v
+			}
+			code, _, body := ts.postForm(t, "/signup", form)
+			if tt.dropped {
+				mock.Equal(t, code, http.StatusOK)
+				mock.StringContains(t, body, "your submission was received")
+			} else {
+				mock.Equal(t, code, http.StatusSeeOther)
+			}
+		})
+		t.Run(tt.name+" comment", func(t *testing.T) {
+			content := "comment by " + tt.name
+			form := url.Values{"postID": {"1"}, "comment": {content}}
+			for k, v := range tt.form {
+				form[k] = v
+			}
+			code, _, body := ts.postFormWithSession(t, "/comment/post", form, mock.TopSortToken)
+			last, _ := ts.repo.LastComment()
+			if tt.dropped {
+				mock.Equal(t, code, http.StatusOK)
+				mock.StringContains(t, body, "your submission was received")
+				mock.Equal(t, last.Content == content, false)
+			} else {
+				mock.Equal(t, code, http.StatusSeeOther)
+				mock.Equal(t, last.Content, content)
+			}
+		})
+	}
+
+	// The forms carry the fields the checks look for.
+	_, _, body := ts.get(t, "/signup")
+	for _, field := range []string{antispam.HoneypotField, antispam.StampField} {
+		mock.Equal(t, strings.Contains(body, `name="`+field+`"`), true)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"forum/app"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeAPIError(t *testing.T, body string) models.APIError {
+	t.Helper()
+
+	var envelope models.APIErrorBody
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+		t.Fatalf("decoding %q: %v", body, err)
+	}
+	return envelope.Error
+}
+
+func TestAPIError(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	a := app.New(logger, logger, nil)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantFields map[string]string
+	}{
+		{"Validation", &models.ValidationError{Fields: map[string]string{"title": "This field cannot be blank"}},
+			http.StatusUnprocessableEntity, models.CodeValidation, map[string]string{"title": "This field cannot be blank"}},
+		{"Cooldown", &models.CooldownError{RetryAfter: 1500 * time.Millisecond},
+			http.StatusTooManyRequests, models.CodeRateLimited, nil},
+		{"Too large", &http.MaxBytesError{Limit: 1}, http.StatusRequestEntityTooLarge, models.CodeTooLarge, nil},
+		{"No record", fmt.Errorf("service: %w", models.ErrNoRecord), http.StatusNotFound, models.CodeNotFound, nil},
+		{"Forbidden", models.ErrForbidden, http.StatusForbidden, models.CodeForbidden, nil},
+		{"Locked", models.ErrPostLocked, http.StatusForbidden, models.CodeForbidden, nil},
+		{"Credentials", models.ErrInvalidCredentials, http.StatusUnauthorized, models.CodeUnauthorized, nil},
+		{"Duplicate email", models.ErrDuplicateEmail, http.StatusConflict, models.CodeConflict,
+			map[string]string{"email": "Email address is already in use"}},
+		{"Pin limit", models.ErrPinLimit, http.StatusConflict, models.CodeConflict, nil},
+		{"Invalid input", models.ErrInvalidSort, http.StatusUnprocessableEntity, models.CodeValidation, nil},
+		{"Unknown", fmt.Errorf("sqlite: disk I/O error"), http.StatusInternalServerError, models.CodeInternal, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			a.APIError(rr, tt.err)
+
+			mock.Equal(t, rr.Code, tt.wantStatus)
+			mock.Equal(t, rr.Header().Get("Content-Type"), "application/json")
+			got := decodeAPIError(t, rr.Body.String())
+			mock.Equal(t, got.Code, tt.wantCode)
+			mock.Equal(t, len(got.Fields), len(tt.wantFields))
+			for field, message := range tt.wantFields {
+				mock.Equal(t, got.Fields[field], message)
+			}
+			if tt.wantStatus == http.StatusInternalServerError && strings.Contains(got.Message, "disk") {
+				t.Errorf("internal error leaked %q", got.Message)
+			}
+		})
+	}
+}
+
+func TestAPIErrorRetryAfter(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	a := app.New(logger, logger, nil)
+
+	rr := httptest.NewRecorder()
+	a.APIError(rr, &models.CooldownError{RetryAfter: 1500 * time.Millisecond})
+	mock.Equal(t, rr.Header().Get("Retry-After"), "2")
+}
+
+func TestPreviewErrors(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/api/v1/preview")
+	mock.Equal(t, code, http.StatusMethodNotAllowed)
+	mock.Equal(t, decodeAPIError(t, body).Code, models.CodeMethodNotAllowed)
+
+	code, body = ts.preview(t, strings.Repeat("a", previewMaxBytes+1))
+	mock.Equal(t, code, http.StatusRequestEntityTooLarge)
+	mock.Equal(t, decodeAPIError(t, body).Code, models.CodeTooLarge)
+
+	for i := 0; i < previewRate; i++ {
+		ts.preview(t, "hi")
+	}
+	code, body = ts.preview(t, "hi")
+	mock.Equal(t, code, http.StatusTooManyRequests)
+	mock.Equal(t, decodeAPIError(t, body).Code, models.CodeRateLimited)
+}
+
+func TestErrorNegotiation(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	const browser = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		accept     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "Browser not found", url: "/nope", accept: browser, wantStatus: http.StatusNotFound},
+		{name: "JSON not found", url: "/nope", accept: "application/json", wantStatus: http.StatusNotFound, wantCode: models.CodeNotFound},
+		{name: "API not found", url: "/api/v1/nope", wantStatus: http.StatusNotFound, wantCode: models.CodeNotFound},
+		{name: "JSON feed bad request", url: "/feed.json?after=nah", accept: browser, wantStatus: http.StatusBadRequest, wantCode: models.CodeBadRequest},
+		{name: "JSON method not allowed", method: http.MethodDelete, url: "/", accept: "application/json", wantStatus: http.StatusMethodNotAllowed, wantCode: models.CodeMethodNotAllowed},
+		{name: "Browser method not allowed", method: http.MethodDelete, url: "/", accept: browser, wantStatus: http.StatusMethodNotAllowed},
+		{name: "JSON unauthorized", url: "/notifications", accept: "application/json", wantStatus: http.StatusUnauthorized, wantCode: models.CodeUnauthorized},
+		{name: "Browser unauthorized", url: "/notifications", accept: browser, wantStatus: http.StatusSeeOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := cmp.Or(tt.method, http.MethodGet)
+			code, header, body := ts.request(t, method, tt.url, http.Header{"Accept": {tt.accept}})
+			mock.Equal(t, code, tt.wantStatus)
+			if tt.wantStatus == http.StatusSeeOther {
+				mock.Equal(t, header.Get("Location"), "/login")
+				return
+			}
+			if tt.wantCode == "" {
+				mock.StringContains(t, header.Get("Content-Type"), "text/html")
+				mock.StringContains(t, body, "<!DOCTYPE html>")
+				return
+			}
+			mock.Equal(t, header.Get("Content-Type"), "application/json")
+			mock.Equal(t, decodeAPIError(t, body).Code, tt.wantCode)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"forum/internal/service"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+	"strconv"
+)
+
+const auditPageSize = 20
+
+// audit records a security-sensitive action. The action has already happened
+// when this is called, so a failed write doesn't fail the request; the entry
+// goes to the error log instead of being lost.
+func (h *handler) audit(r *http.Request, actorID int, action, target string) {
+	entry := models.AuditEntry{
+		ActorID: actorID,
+		Action:  action,
+		Target:  target,
+		IP:      h.clientIP(r),
+	}
+	if err := h.service.RecordAudit(entry); err != nil {
+		h.app.ErrorLog.Printf("audit: failed to record %+v: %v", entry, err)
+	}
+}
+
+// adminAudit serves the audit log as JSON, filtered by the "actor" and
+// "action" query parameters.
+func (h *handler) adminAudit(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/admin/audit" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	page := 1
+	if value := query.Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+	limit := service.ValidateLimit(query.Get("limit"), auditPageSize, h.cfg.MaxLimit)
+	filter := models.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+	}
+
+	token := cookie.GetSessionCookie(r)
+	log, err := h.service.GetAuditLogPaginated(token.Value, filter, page, limit)
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	h.app.JSON(w, http.StatusOK, log)
+}
+
+func (h *handler) adminRole(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/admin/role" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.FormValue("name")
+	status, err := strconv.Atoi(r.FormValue("status"))
+	if err != nil || name == "" {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	err = h.service.ChangeRole(token.Value, name, status, h.clientIP(r))
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.ErrInvalidStatus) {
+			h.app.ClientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, "/user/"+name, http.StatusSeeOther)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAdminRoleChangeAudited(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		user     string
+		status   string
+		wantCode int
+	}{
+		{
+			name:     "Admin promotes user",
+			token:    mock.AdminToken,
+			user:     "test",
+			status:   "1",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Regular user",
+			token:    sessionCookieValue,
+			user:     "shy",
+			status:   "2",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Unknown status",
+			token:    mock.AdminToken,
+			user:     "test",
+			status:   "9",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Unknown user",
+			token:    mock.AdminToken,
+			user:     "nobody",
+			status:   "1",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("name", tt.user)
+			form.Add("status", tt.status)
+			code, _, _ := ts.postFormWithSession(t, "/admin/role", form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+
+	code, _, body := ts.getWithSession(t, "/admin/audit?action="+models.AuditRoleChange, mock.AdminToken)
+	mock.Equal(t, code, http.StatusOK)
+
+	var log models.AuditPage
+	if err := json.Unmarshal([]byte(body), &log); err != nil {
+		t.Fatal(err)
+	}
+	if len(log.Entries) != 1 {
+		t.Fatalf("got %d audit entries; expected 1", len(log.Entries))
+	}
+	entry := log.Entries[0]
+	mock.Equal(t, entry.ActorName, "admin")
+	mock.Equal(t, entry.Target, "user:1 status:0->1")
+	mock.Equal(t, entry.IP, "127.0.0.1")
+}
+
+func TestAdminAudit(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		query    string
+		wantCode int
+	}{
+		{
+			name:     "Admin",
+			token:    mock.AdminToken,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Regular user",
+			token:    sessionCookieValue,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Bad page",
+			token:    mock.AdminToken,
+			query:    "?page=0",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Limit too large",
+			token:    mock.AdminToken,
+			query:    "?limit=1000",
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.getWithSession(t, "/admin/audit"+tt.query, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestAdminAuditLimit(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{MaxLimit: 2})
+	defer ts.Close()
+
+	for _, status := range []string{"1", "0", "1"} {
+		form := url.Values{"name": {"test"}, "status": {status}}
+		code, _, _ := ts.postFormWithSession(t, "/admin/role", form, mock.AdminToken)
+		mock.Equal(t, code, http.StatusSeeOther)
+	}
+
+	// Limits past -max-limit are clamped like those of every other list.
+	for query, want := range map[string]int{"": 2, "?limit=1": 1, "?limit=1000": 2, "?limit=nah": 2} {
+		code, _, body := ts.getWithSession(t, "/admin/audit"+query, mock.AdminToken)
+		mock.Equal(t, code, http.StatusOK)
+		var log models.AuditPage
+		if err := json.Unmarshal([]byte(body), &log); err != nil {
+			t.Fatal(err)
+		}
+		if len(log.Entries) != want {
+			t.Errorf("%q: got %d audit entries; expected %d", query, len(log.Entries), want)
+		}
+	}
+}
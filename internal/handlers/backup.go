@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+	"time"
+)
+
+// backupWriter sends the export headers with the first line of the backup,
+// so an error before anything was written can still become an error page.
+type backupWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (bw *backupWriter) Write(p []byte) (int, error) {
+	if !bw.started {
+		bw.started = true
+		bw.w.Header().Set("Content-Type", "application/x-ndjson")
+		bw.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="forum-%s.ndjson"`, time.Now().UTC().Format("20060102-150405")))
+	}
+	return bw.w.Write(p)
+}
+
+func (h *handler) adminExport(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/admin/export" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	// A backup of a big forum takes longer than the server's write timeout.
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	token := cookie.GetSessionCookie(r)
+	bw := &backupWriter{w: w}
+	err := h.service.ExportBackup(token.Value, h.clientIP(r), bw)
+	if err != nil {
+		if bw.started {
+			// Too late for an error page, the client gets a truncated backup.
+			h.app.ErrorLog.Printf("export: %v", err)
+			return
+		}
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+			return
+		}
+		h.app.ServerError(w, err)
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAdminExport(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	code, _, _ := ts.getWithSession(t, "/admin/export", sessionCookieValue)
+	mock.Equal(t, code, http.StatusForbidden)
+
+	code, header, body := ts.getWithSession(t, "/admin/export", mock.AdminToken)
+	mock.Equal(t, code, http.StatusOK)
+	mock.Equal(t, header.Get("Content-Type"), "application/x-ndjson")
+
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	var header0 struct {
+		Type string              `json:"type"`
+		Data models.BackupHeader `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &header0); err != nil {
+		t.Fatal(err)
+	}
+	mock.Equal(t, header0.Type, models.BackupHeaderType)
+	mock.Equal(t, header0.Data.Version, models.BackupVersion)
+	mock.Equal(t, len(lines), 9)
+
+	_, _, body = ts.getWithSession(t, "/admin/audit?action="+models.AuditExport, mock.AdminToken)
+	var log models.AuditPage
+	if err := json.Unmarshal([]byte(body), &log); err != nil {
+		t.Fatal(err)
+	}
+	mock.Equal(t, len(log.Entries), 1)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"forum/pkg/blocklist"
+	"forum/pkg/captcha"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// stubCaptcha answers every challenge with solved and err.
+type stubCaptcha struct {
+	solved bool
+	err    error
+}
+
+func (c stubCaptcha) Verify(r *http.Request, remoteIP string) (bool, error) {
+	return c.solved, c.err
+}
+
+func (c stubCaptcha) Widget() *captcha.Widget {
+	return &captcha.Widget{Script: "https://captcha.example/api.js", Class: "stub-captcha", SiteKey: "site-key", Origins: "https://captcha.example"}
+}
+
+func TestSignUpCaptcha(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier captcha.Verifier
+		wantCode int
+	}{
+		{"Solved", stubCaptcha{solved: true}, http.StatusSeeOther},
+		{"Failed", stubCaptcha{solved: false}, http.StatusUnprocessableEntity},
+		{"Provider down", stubCaptcha{err: errors.New("timeout")}, http.StatusInternalServerError},
+		{"No-op", captcha.Noop{}, http.StatusSeeOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithCaptcha(t, &config.Config{}, blocklist.New(nil), tt.verifier)
+			defer ts.Close()
+
+			form := url.Values{"name": {"max"}, "email": {"max@gmail.com"}, "password": {"password123"}}
+			code, _, body := ts.postForm(t, "/signup", form)
+			mock.Equal(t, code, tt.wantCode)
+			if tt.wantCode == http.StatusUnprocessableEntity {
+				mock.StringContains(t, body, "Please solve the CAPTCHA")
+			}
+		})
+	}
+
+	// The widget and its provider's origins only show up when there is one.
+	ts := NewTestServerWithCaptcha(t, &config.Config{}, blocklist.New(nil), stubCaptcha{})
+	defer ts.Close()
+	_, header, body := ts.get(t, "/signup")
+	mock.StringContains(t, body, `class="stub-captcha" data-sitekey="site-key"`)
+	mock.StringContains(t, header.Get("Content-Security-Policy"), "script-src 'self' https://captcha.example")
+
+	ts = NewTestServer(t)
+	defer ts.Close()
+	_, header, body = ts.get(t, "/signup")
+	mock.Equal(t, strings.Contains(body, "data-sitekey"), false)
+	mock.Equal(t, strings.Contains(header.Get("Content-Security-Policy"), "script-src"), false)
+}
@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"fmt"
+	"forum/internal/config"
 	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"forum/pkg/blocklist"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCommentCreate(t *testing.T) {
@@ -59,3 +64,395 @@ func TestCommentCreate(t *testing.T) {
 		})
 	}
 }
+
+func TestCommentQuote(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name            string
+		quotedCommentID string
+		excerpt         string
+		wantCode        int
+	}{
+		{
+			name:            "Quote with excerpt",
+			quotedCommentID: "1",
+			excerpt:         "test",
+			wantCode:        http.StatusSeeOther,
+		},
+		{
+			name:            "Quote whole comment",
+			quotedCommentID: "1",
+			wantCode:        http.StatusSeeOther,
+		},
+		{
+			name:            "Excerpt not in quoted comment",
+			quotedCommentID: "1",
+			excerpt:         "something else",
+			wantCode:        http.StatusBadRequest,
+		},
+		{
+			name:            "Comment from another post",
+			quotedCommentID: "3",
+			wantCode:        http.StatusBadRequest,
+		},
+		{
+			name:            "Missing comment",
+			quotedCommentID: "42",
+			wantCode:        http.StatusBadRequest,
+		},
+		{
+			name:            "Invalid comment id",
+			quotedCommentID: "nah",
+			wantCode:        http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("postID", "1")
+			form.Add("comment", "I agree with this")
+			form.Add("quoted_comment_id", tt.quotedCommentID)
+			form.Add("excerpt", tt.excerpt)
+
+			code, _, _ := ts.postFormWithSession(t, "/comment/post", form, sessionCookieValue)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestCommentQuoteRender(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/post/1")
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, `<blockquote class="comment-quote">`)
+	mock.StringContains(t, body, `<a href="#comment-1">test wrote:</a>`)
+	mock.StringContains(t, body, "quoted excerpt")
+}
+
+func TestCommentBlocklist(t *testing.T) {
+	bl := blocklist.New([]string{"ass"})
+
+	tests := []struct {
+		name     string
+		mode     string
+		comment  string
+		wantCode int
+	}{
+		{
+			name:     "Reject blocked word",
+			mode:     blocklist.ModeReject,
+			comment:  "you ASS",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Reject keeps longer words",
+			mode:     blocklist.ModeReject,
+			comment:  "a classic assessment",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Mask blocked word",
+			mode:     blocklist.ModeMask,
+			comment:  "you ass",
+			wantCode: http.StatusSeeOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithBlocklist(t, &config.Config{BlocklistMode: tt.mode}, bl)
+			defer ts.Close()
+
+			form := url.Values{}
+			form.Add("postID", "1")
+			form.Add("comment", tt.comment)
+
+			code, _, _ := ts.postFormWithSession(t, "/comment/post", form, sessionCookieValue)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestCommentCollapse(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{CollapseThreshold: -5})
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/post/1")
+	mock.Equal(t, code, http.StatusOK)
+
+	tests := []struct {
+		name      string
+		commentID string
+		collapsed bool
+	}{
+		{name: "Below threshold", commentID: "4", collapsed: true},
+		{name: "At threshold", commentID: "5", collapsed: false},
+		{name: "No reactions", commentID: "1", collapsed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := `class="comment"`
+			if tt.collapsed {
+				class = `class="comment collapsed"`
+			}
+			mock.StringContains(t, body, class+` id="comment-`+tt.commentID+`"`)
+		})
+	}
+	mock.Equal(t, strings.Count(body, "This comment is hidden"), 1)
+}
+
+func TestHotComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		minScore int
+		want     bool
+	}{
+		{name: "Best above threshold", minScore: 5, want: true},
+		{name: "Best at threshold", minScore: 8, want: true},
+		{name: "All below threshold", minScore: 9, want: false},
+		{name: "Disabled", minScore: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{HotCommentScore: tt.minScore})
+			defer ts.Close()
+
+			code, _, body := ts.get(t, "/post/1")
+			mock.Equal(t, code, http.StatusOK)
+			mock.Equal(t, strings.Contains(body, `<div class="hot-comment">`), tt.want)
+			if tt.want {
+				mock.StringContains(t, body, `<span class="hot-score">Score: 8</span>`)
+				mock.StringContains(t, body, `<a href="#comment-9"><code>`+mock.HotComment+`</code></a>`)
+			}
+		})
+	}
+}
+
+func TestCommentDepthLimit(t *testing.T) {
+	// Comment 1 starts the thread, 2 replies to it, 6 to 2 and 7 to 6.
+	tests := []struct {
+		name       string
+		policy     string
+		replyTo    string
+		wantCode   int
+		wantQuoted int
+	}{
+		{name: "Within the limit", policy: models.DepthReject, replyTo: "2", wantCode: http.StatusSeeOther, wantQuoted: 2},
+		{name: "Reject at the limit", policy: models.DepthReject, replyTo: "6", wantCode: http.StatusBadRequest},
+		{name: "Reject beyond the limit", policy: models.DepthReject, replyTo: "7", wantCode: http.StatusBadRequest},
+		{name: "Flatten at the limit", policy: models.DepthFlatten, replyTo: "6", wantCode: http.StatusSeeOther, wantQuoted: 2},
+		{name: "Flatten beyond the limit", policy: models.DepthFlatten, replyTo: "7", wantCode: http.StatusSeeOther, wantQuoted: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{MaxCommentDepth: 2, CommentDepthPolicy: tt.policy})
+			defer ts.Close()
+
+			form := url.Values{}
+			form.Add("postID", "1")
+			form.Add("comment", "I agree with this")
+			form.Add("quoted_comment_id", tt.replyTo)
+
+			code, _, _ := ts.postFormWithSession(t, "/comment/post", form, sessionCookieValue)
+			mock.Equal(t, code, tt.wantCode)
+
+			comment, stored := ts.repo.LastComment()
+			mock.Equal(t, stored, tt.wantQuoted != 0)
+			mock.Equal(t, comment.QuotedCommentID, tt.wantQuoted)
+			if stored {
+				mock.Equal(t, comment.QuoteExcerpt, "reply")
+			}
+		})
+	}
+}
+
+func TestCommentCooldown(t *testing.T) {
+	const cooldown = 200 * time.Millisecond
+	ts := NewTestServerWithConfig(t, &config.Config{CommentCooldown: cooldown})
+	defer ts.Close()
+
+	comment := func(token string) (int, http.Header) {
+		t.Helper()
+		form := url.Values{}
+		form.Add("postID", "1")
+		form.Add("comment", "first!")
+		code, header, _ := ts.postFormWithSession(t, "/comment/post", form, token)
+		return code, header
+	}
+
+	code, _ := comment(sessionCookieValue)
+	mock.Equal(t, code, http.StatusSeeOther)
+
+	code, header := comment(sessionCookieValue)
+	mock.Equal(t, code, http.StatusTooManyRequests)
+	mock.Equal(t, header.Get("Retry-After"), "1")
+
+	// Moderators are trusted not to spam.
+	for range 2 {
+		code, _ = comment(mock.AdminToken)
+		mock.Equal(t, code, http.StatusSeeOther)
+	}
+
+	time.Sleep(cooldown)
+	code, _ = comment(sessionCookieValue)
+	mock.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestCommentApproval(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{NewUserAge: 72 * time.Hour, ApproveNewUsers: true})
+	defer ts.Close()
+
+	comment := func(token, content string) {
+		t.Helper()
+		form := url.Values{"postID": {"1"}, "comment": {content}}
+		code, _, _ := ts.postFormWithSession(t, "/comment/post", form, token)
+		mock.Equal(t, code, http.StatusSeeOther)
+	}
+	review := func(action, commentID string) int {
+		t.Helper()
+		form := url.Values{"commentID": {commentID}}
+		code, _, _ := ts.postFormWithSession(t, "/moderation/comments/"+action, form, mock.AdminToken)
+		return code
+	}
+
+	comment(mock.NewbieToken, "held for review")
+	comment(sessionCookieValue, "straight through")
+
+	// Trusted users' comments show right away, new users' wait for a
+	// moderator and only their author sees them meanwhile.
+	_, _, body := ts.get(t, "/post/1")
+	mock.StringContains(t, body, "straight through")
+	mock.Equal(t, strings.Contains(body, "held for review"), false)
+	_, _, body = ts.getWithSession(t, "/post/1", mock.HermitToken)
+	mock.Equal(t, strings.Contains(body, "held for review"), false)
+	_, _, body = ts.getWithSession(t, "/post/1", mock.NewbieToken)
+	mock.StringContains(t, body, "held for review")
+	mock.StringContains(t, body, "Awaiting approval")
+
+	code, _, _ := ts.getWithSession(t, "/moderation/comments", sessionCookieValue)
+	mock.Equal(t, code, http.StatusForbidden)
+	code, _, body = ts.getWithSession(t, "/moderation/comments", mock.AdminToken)
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, "held for review")
+	mock.Equal(t, strings.Contains(body, "straight through"), false)
+
+	mock.Equal(t, review("approve", "100"), http.StatusSeeOther)
+	_, _, body = ts.get(t, "/post/1")
+	mock.StringContains(t, body, "held for review")
+	mock.Equal(t, strings.Contains(body, "Awaiting approval"), false)
+
+	// Only pending comments can be reviewed.
+	mock.Equal(t, review("approve", "100"), http.StatusNotFound)
+	mock.Equal(t, review("reject", "101"), http.StatusNotFound)
+
+	comment(mock.NewbieToken, "spam for sure")
+	mock.Equal(t, review("reject", "102"), http.StatusSeeOther)
+	_, _, body = ts.getWithSession(t, "/post/1", mock.NewbieToken)
+	mock.Equal(t, strings.Contains(body, "spam for sure"), false)
+	_, _, body = ts.getWithSession(t, "/moderation/comments", mock.AdminToken)
+	mock.StringContains(t, body, "No comments are waiting for approval")
+}
+
+func TestCommentSort(t *testing.T) {
+	// Post 1 is in Category1 and Category2, and of its comments 9 scores
+	// best and 4 worst.
+	tests := []struct {
+		name  string
+		sorts models.CommentSorts
+		query string
+		want  []string
+	}{
+		{
+			name:  "Q&A defaults to top",
+			sorts: models.CommentSorts{Default: models.SortOldest, Categories: map[string]string{"category2": models.SortTop}},
+			want:  []string{"comment-9", "comment-8", "comment-1", "comment-4"},
+		},
+		{
+			name:  "Discussion defaults to oldest",
+			sorts: models.CommentSorts{Default: models.SortTop, Categories: map[string]string{"category1": models.SortOldest}},
+			want:  []string{"comment-1", "comment-4", "comment-8", "comment-9"},
+		},
+		{
+			name:  "Viewer picks oldest",
+			sorts: models.CommentSorts{Categories: map[string]string{"category1": models.SortTop}},
+			query: "?sort=oldest",
+			want:  []string{"comment-1", "comment-4", "comment-8", "comment-9"},
+		},
+		{
+			name:  "Viewer picks newest",
+			sorts: models.CommentSorts{Categories: map[string]string{"category1": models.SortTop}},
+			query: "?sort=newest",
+			want:  []string{"comment-9", "comment-8", "comment-4", "comment-1"},
+		},
+		{
+			name:  "Unknown sort",
+			sorts: models.CommentSorts{Categories: map[string]string{"category1": models.SortTop}},
+			query: "?sort=random",
+			want:  []string{"comment-9", "comment-8", "comment-1", "comment-4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{CommentSorts: tt.sorts})
+			defer ts.Close()
+
+			code, _, body := ts.get(t, "/post/1"+tt.query)
+			mock.Equal(t, code, http.StatusOK)
+			last := -1
+			for _, id := range tt.want {
+				i := strings.Index(body, `id="`+id+`"`)
+				if i < 0 || i < last {
+					t.Fatalf("got %s missing or out of order; expected %v", id, tt.want)
+				}
+				last = i
+			}
+		})
+	}
+}
+
+func TestParticipated(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	list := func(token string) []string {
+		t.Helper()
+		code, _, body := ts.getWithSession(t, "/my/participated", token)
+		mock.Equal(t, code, http.StatusOK)
+		var posts []string
+		for _, part := range strings.Split(body, `class="titleHome"`)[1:] {
+			posts = append(posts, strings.TrimSpace(strings.TrimPrefix(part[:strings.Index(part, "</a>")], ">")))
+		}
+		return posts
+	}
+	comment := func(postID string) {
+		t.Helper()
+		form := url.Values{"postID": {postID}, "comment": {"Count me in"}}
+		code, _, _ := ts.postFormWithSession(t, "/comment/post", form, sessionCookieValue)
+		mock.Equal(t, code, http.StatusSeeOther)
+	}
+
+	mock.Equal(t, len(list(sessionCookieValue)), 0)
+
+	comment("1")
+	comment("2")
+	mock.Equal(t, fmt.Sprint(list(sessionCookieValue)), "[post 2 post 1]")
+
+	// Another comment on post 1 moves it up rather than listing it twice.
+	comment("1")
+	mock.Equal(t, fmt.Sprint(list(sessionCookieValue)), "[post 1 post 2]")
+
+	// Only the commenter's list has them.
+	mock.Equal(t, len(list(mock.AdminToken)), 0)
+
+	code, _, _ := ts.get(t, "/my/participated")
+	mock.Equal(t, code, http.StatusSeeOther)
+}
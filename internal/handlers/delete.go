@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+)
+
+// postDelete deletes a post of the user's own. A post removed for good
+// sends them home, a blanked one back to its page.
+func (h *handler) postDelete(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/post/delete" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	postID, err := GetIntForm(r, "postID")
+	if err != nil || postID < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	removed, err := h.service.DeletePost(token.Value, postID, h.cfg.DeleteGrace)
+	if err != nil {
+		h.deleteError(w, err)
+		return
+	}
+	user, err := h.service.GetUser(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	h.audit(r, int(user.ID), models.AuditDelete, deleteTarget("post", postID, removed))
+	if removed {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// commentDelete deletes a comment of the user's own and sends them back to
+// its post.
+func (h *handler) commentDelete(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/comment/delete" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	commentID, err := GetIntForm(r, "commentID")
+	if err != nil || commentID < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	comment, err := h.service.GetComment(commentID)
+	if err != nil {
+		h.deleteError(w, err)
+		return
+	}
+	removed, err := h.service.DeleteComment(token.Value, commentID, h.cfg.DeleteGrace)
+	if err != nil {
+		h.deleteError(w, err)
+		return
+	}
+	h.audit(r, comment.UserID, models.AuditDelete, deleteTarget("comment", commentID, removed))
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", comment.PostID), http.StatusSeeOther)
+}
+
+// deleteTarget is the audit target of an author's delete, telling a row
+// removed for good ("hard") from a blanked one ("soft").
+func deleteTarget(kind string, id int, removed bool) string {
+	mode := "soft"
+	if removed {
+		mode = "hard"
+	}
+	return fmt.Sprintf("%s:%d %s", kind, id, mode)
+}
+
+func (h *handler) deleteError(w http.ResponseWriter, err error) {
+	if errors.Is(err, models.ErrForbidden) {
+		h.app.ClientError(w, http.StatusForbidden)
+	} else if errors.Is(err, models.ErrNoRecord) {
+		h.app.NotFound(w)
+	} else {
+		h.app.ServerError(w, err)
+	}
+}
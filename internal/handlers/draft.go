@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+)
+
+// draftMaxBytes caps the JSON body of an autosave.
+const draftMaxBytes = 128 << 10
+
+// draftAutosave stores the JSON draft in the request body for the user and
+// editor session and returns its id and save time.
+func (h *handler) draftAutosave(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/drafts/autosave" {
+		h.app.APIClientError(w, http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.APIClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var form models.DraftForm
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, draftMaxBytes)).Decode(&form); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.app.APIError(w, err)
+			return
+		}
+		h.app.APIClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	c := cookie.GetSessionCookie(r)
+	draft, saved, err := h.service.AutosaveDraft(c.Value, form, h.cfg.AutosaveInterval)
+	if err != nil {
+		h.app.APIError(w, err)
+		return
+	}
+	h.app.JSON(w, http.StatusOK, models.DraftSaved{ID: draft.ID, UpdatedAt: draft.Updated, Saved: saved})
+}
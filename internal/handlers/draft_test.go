@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func (ts *TestServer) autosave(t *testing.T, body, token string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/drafts/autosave", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: sessionIDCookie, Value: token})
+	}
+	rs, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+	b, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rs.StatusCode, string(bytes.TrimSpace(b))
+}
+
+func TestDraftAutosave(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{})
+	defer ts.Close()
+
+	save := func(body, token string) models.DraftSaved {
+		t.Helper()
+		code, rs := ts.autosave(t, body, token)
+		if code != http.StatusOK {
+			t.Fatalf("got %d %s; expected %d", code, rs, http.StatusOK)
+		}
+		var saved models.DraftSaved
+		if err := json.Unmarshal([]byte(rs), &saved); err != nil {
+			t.Fatal(err)
+		}
+		return saved
+	}
+
+	first := save(`{"session":"tab-1","title":"Hi","content":"draft"}`, sessionCookieValue)
+	mock.Equal(t, first.Saved, true)
+	second := save(`{"session":"tab-1","title":"Hi","content":"draft, longer"}`, sessionCookieValue)
+	mock.Equal(t, second.ID, first.ID)
+	if second.UpdatedAt.Before(first.UpdatedAt) {
+		t.Errorf("got updated_at %v before %v", second.UpdatedAt, first.UpdatedAt)
+	}
+	mock.Equal(t, ts.repo.Drafts(), 1)
+
+	// Other editor sessions and users get their own drafts.
+	other := save(`{"session":"tab-2","content":"other"}`, sessionCookieValue)
+	if other.ID == first.ID {
+		t.Errorf("tab-2 reused draft %d", first.ID)
+	}
+	save(`{"session":"tab-1","content":"admin"}`, mock.AdminToken)
+	mock.Equal(t, ts.repo.Drafts(), 3)
+
+	code, rs := ts.autosave(t, `{"content":"no session"}`, sessionCookieValue)
+	mock.Equal(t, code, http.StatusUnprocessableEntity)
+	mock.Equal(t, decodeAPIError(t, rs).Code, models.CodeValidation)
+
+	code, rs = ts.autosave(t, `{"session":`, sessionCookieValue)
+	mock.Equal(t, code, http.StatusBadRequest)
+	mock.Equal(t, decodeAPIError(t, rs).Code, models.CodeBadRequest)
+
+	code, rs = ts.autosave(t, `{"session":"tab-1"}`, "")
+	mock.Equal(t, code, http.StatusUnauthorized)
+	mock.Equal(t, decodeAPIError(t, rs).Code, models.CodeUnauthorized)
+}
+
+func TestDraftAutosaveDebounce(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{AutosaveInterval: time.Hour})
+	defer ts.Close()
+
+	code, _ := ts.autosave(t, `{"session":"tab-1","content":"one"}`, sessionCookieValue)
+	mock.Equal(t, code, http.StatusOK)
+	code, rs := ts.autosave(t, `{"session":"tab-1","content":"two"}`, sessionCookieValue)
+	mock.Equal(t, code, http.StatusOK)
+
+	var saved models.DraftSaved
+	if err := json.Unmarshal([]byte(rs), &saved); err != nil {
+		t.Fatal(err)
+	}
+	mock.Equal(t, saved.Saved, false)
+	mock.Equal(t, saved.ID, 1)
+	mock.Equal(t, ts.repo.Drafts(), 1)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+)
+
+// userFollow serves both /user/follow and /user/unfollow for the user in the
+// "name" form value.
+func (h *handler) userFollow(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/user/follow" && r.URL.Path != "/user/unfollow" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.FormValue("name")
+	if name == "" {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	c := cookie.GetSessionCookie(r)
+	var err error
+	if r.URL.Path == "/user/follow" {
+		err = h.service.FollowUser(c.Value, name)
+	} else {
+		err = h.service.UnfollowUser(c.Value, name)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrSelfFollow) {
+			h.app.ClientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, "/user/"+name, http.StatusSeeOther)
+}
+
+func (h *handler) followingFeed(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/feed/following" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data, err = h.service.SetUpPage(data, r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+
+	data.Posts, err = h.service.GetFollowingPostsPaginated(int(data.User.ID), data.CurrentPage, data.Limit)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	c := cookie.GetSessionCookie(r)
+	reactions, err := h.service.GetReactionPosts(c.Value)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Posts = h.service.IsLikedPost(data.Posts, reactions)
+	if err := h.service.ScorePosts(data.Posts, h.cfg.VoteWeights); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if len(*data.Posts) == 0 {
+		data.Posts = nil
+	}
+
+	h.app.Render(w, http.StatusOK, "following.html", data)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import "net/http"
+
+func (h *handler) history(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/history" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Posts, err = h.service.GetHistory(int(data.User.ID))
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if err := h.service.ScorePosts(data.Posts, h.cfg.VoteWeights); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if len(*data.Posts) == 0 {
+		data.Posts = nil
+	}
+
+	h.app.Render(w, http.StatusOK, "history.html", data)
+}
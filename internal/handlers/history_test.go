@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var viewedRX = regexp.MustCompile(`viewed post (\d+)`)
+
+func TestHistory(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{HistorySize: 3})
+	defer ts.Close()
+
+	history := func() []string {
+		t.Helper()
+		code, _, body := ts.getWithSession(t, "/history", sessionCookieValue)
+		mock.Equal(t, code, http.StatusOK)
+		var ids []string
+		for _, m := range viewedRX.FindAllStringSubmatch(body, -1) {
+			ids = append(ids, m[1])
+		}
+		return ids
+	}
+	view := func(ids ...int) {
+		t.Helper()
+		for _, id := range ids {
+			code, _, _ := ts.getWithSession(t, fmt.Sprintf("/post/%d", id), sessionCookieValue)
+			mock.Equal(t, code, http.StatusOK)
+		}
+	}
+
+	mock.Equal(t, len(history()), 0)
+
+	view(1, 2, 3)
+	mock.Equal(t, strings.Join(history(), ","), "3,2,1")
+
+	view(1)
+	mock.Equal(t, strings.Join(history(), ","), "1,3,2")
+
+	view(4, 5)
+	mock.Equal(t, strings.Join(history(), ","), "5,4,1")
+
+	// Guests have no history.
+	code, _, _ := ts.get(t, "/history")
+	mock.Equal(t, code, http.StatusSeeOther)
+}
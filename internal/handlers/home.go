@@ -5,6 +5,8 @@ import (
 	"forum/models"
 	"forum/pkg/cookie"
 	"net/http"
+	"slices"
+	"strconv"
 )
 
 func (h *handler) home(w http.ResponseWriter, r *http.Request) {
@@ -23,6 +25,12 @@ func (h *handler) home(w http.ResponseWriter, r *http.Request) {
 		h.app.ServerError(w, err)
 		return
 	}
+	if label := r.URL.Query().Get("label"); label != "" && !slices.Contains(h.cfg.PostLabels, label) {
+		h.app.NotFound(w)
+		return
+	}
+	data.Limit = h.cfg.HomeLimit
+	data.Sort = h.cfg.DefaultSort
 	data, err = h.service.SetUpPage(data, r)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
@@ -33,8 +41,27 @@ func (h *handler) home(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	if data.Category_id == 0 {
-		posts, err := h.service.GetAllPostPaginated(data.CurrentPage, data.Limit)
+	if data.Category_id != 0 {
+		scope := models.AnnouncementScope{Categories: []int{data.Category_id}}
+		if err := h.setAnnouncements(data, scope); err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+		data.IsSubscribedCategory, err = h.service.IsSubscribedCategory(data.User, data.Category_id)
+		if err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+	}
+	if data.Label != "" {
+		posts, err := h.service.GetAllPostByLabelPaginated(data.CurrentPage, data.Limit, data.Label, data.Sort)
+		if err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+		data.Posts = posts
+	} else if data.Category_id == 0 {
+		posts, err := h.service.GetAllPostPaginated(data.CurrentPage, data.Limit, data.Sort)
 		if err != nil {
 			h.app.ServerError(w, err)
 			return
@@ -42,7 +69,7 @@ func (h *handler) home(w http.ResponseWriter, r *http.Request) {
 
 		data.Posts = posts
 	} else {
-		posts, err := h.service.GetAllPostByCategoryPaginated(data.CurrentPage, data.Limit, data.Category_id)
+		posts, err := h.service.GetAllPostByCategoryPaginated(data.CurrentPage, data.Limit, data.Category_id, data.Sort)
 		if err != nil {
 			h.app.ServerError(w, err)
 			return
@@ -59,12 +86,121 @@ func (h *handler) home(w http.ResponseWriter, r *http.Request) {
 		data.Posts = h.service.IsLikedPost(data.Posts, reactions)
 	}
 
+	// The feed pages by date, so "load more" only continues the newest order,
+	// and it doesn't filter by label.
+	if len(*data.Posts) == data.Limit && data.Sort == models.SortNewest && data.Label == "" {
+		data.FeedNext = (*data.Posts)[data.Limit-1].PostID
+	}
+	if err := h.service.ScorePosts(data.Posts, h.cfg.VoteWeights); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
 	if len(*data.Posts) == 0 {
 		data.Posts = nil
 	}
 
+	data.Trending, err = h.service.GetTrendingCategories()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+
 	h.app.Render(w, http.StatusOK, "home.html", data)
 	return
 }
 
+// feed serves the home page posts as JSON for "load more". It takes the same
+// category and limit as the home page, and the cursor of the previous page in
+// "after", so items are neither skipped nor repeated when new posts arrive.
+func (h *handler) feed(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/feed.json" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var after int
+	if value := r.URL.Query().Get("after"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		after = n
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Limit = h.cfg.HomeLimit
+	data, err = h.service.SetUpPage(data, r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+
+	posts, err := h.service.GetPostsAfter(after, data.Category_id, data.Limit)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if err := h.service.ScorePosts(posts, h.cfg.VoteWeights); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	token := cookie.GetSessionCookie(r)
+	if token != nil {
+		reactions, err := h.service.GetReactionPosts(token.Value)
+		if err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+		posts = h.service.IsLikedPost(posts, reactions)
+	}
+
+	page := models.FeedPage{Posts: []models.FeedItem{}}
+	for _, post := range *posts {
+		html, err := h.app.RenderPartial("postCard", post, data.User.Location())
+		if err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+		item := models.FeedItem{
+			ID:           post.PostID,
+			Title:        post.Title,
+			URL:          h.urls.Post(post.PostID),
+			Author:       post.UserName,
+			Created:      post.Created,
+			ScoresHidden: h.app.ReactionsHidden(post.Created),
+			HTML:         html,
+			Stats:        models.NewTextStats(post.Content, h.cfg.WordsPerMinute),
+		}
+		if !item.ScoresHidden {
+			item.Score = post.Score
+		}
+		page.Posts = append(page.Posts, item)
+	}
+	if len(*posts) == data.Limit {
+		page.Next = (*posts)[data.Limit-1].PostID
+	}
+	h.app.JSON(w, http.StatusOK, page)
+}
+
+func (h *handler) health(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
 // SELECT count(*) FROM comments INNER JOIN posts ON comments.post_id=posts.id  GROUP by comments.post_id;
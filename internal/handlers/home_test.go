@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHomeLoadMore(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{HomeLimit: 2})
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/")
+	mock.Equal(t, code, http.StatusOK)
+	mock.Equal(t, strings.Count(body, `class="post-card"`), 2)
+	mock.StringContains(t, body, `href="/post/5"`)
+	mock.StringContains(t, body, `href="/post/4"`)
+	mock.StringContains(t, body, `data-next="4"`)
+
+	seen := map[int]bool{5: true, 4: true}
+	next := "4"
+	var pages int
+	for next != "0" {
+		code, _, body = ts.get(t, "/feed.json?limit=2&after="+next)
+		mock.Equal(t, code, http.StatusOK)
+
+		var page models.FeedPage
+		if err := json.Unmarshal([]byte(body), &page); err != nil {
+			t.Fatal(err)
+		}
+		for _, post := range page.Posts {
+			if seen[post.ID] {
+				t.Errorf("post %d sent twice", post.ID)
+			}
+			seen[post.ID] = true
+			mock.StringContains(t, post.HTML, `class="post-card"`)
+			mock.Equal(t, post.URL, testBaseURL+"/post/"+strconv.Itoa(post.ID))
+		}
+		next = strconv.Itoa(page.Next)
+		pages++
+		if pages > 5 {
+			t.Fatal("feed never ends")
+		}
+	}
+	mock.Equal(t, len(seen), 5)
+}
+
+func TestFeed(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{HomeLimit: 20})
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+		wantIDs  int
+	}{
+		{
+			name:     "Default limit",
+			wantCode: http.StatusOK,
+			wantIDs:  5,
+		},
+		{
+			name:     "Explicit limit",
+			query:    "?limit=3",
+			wantCode: http.StatusOK,
+			wantIDs:  3,
+		},
+		{
+			name:     "Bad cursor",
+			query:    "?after=nah",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Unknown category",
+			query:    "?category=nope",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, "/feed.json"+tt.query)
+			mock.Equal(t, code, tt.wantCode)
+			if code != http.StatusOK {
+				return
+			}
+			var page models.FeedPage
+			if err := json.Unmarshal([]byte(body), &page); err != nil {
+				t.Fatal(err)
+			}
+			mock.Equal(t, len(page.Posts), tt.wantIDs)
+		})
+	}
+}
+
+func TestFeedLimitCap(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{HomeLimit: 2, MaxLimit: 3})
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		query   string
+		wantIDs int
+	}{
+		{name: "Default limit", wantIDs: 2},
+		{name: "Below the cap", query: "?limit=1", wantIDs: 1},
+		{name: "Above the cap", query: "?limit=1000", wantIDs: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, "/feed.json"+tt.query)
+			mock.Equal(t, code, http.StatusOK)
+			var page models.FeedPage
+			if err := json.Unmarshal([]byte(body), &page); err != nil {
+				t.Fatal(err)
+			}
+			mock.Equal(t, len(page.Posts), tt.wantIDs)
+		})
+	}
+}
+
+func TestHomeSortPreference(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{HomeLimit: 5, DefaultSort: models.SortNewest})
+	defer ts.Close()
+
+	tests := []struct {
+		name      string
+		token     string
+		query     string
+		wantFirst string
+	}{
+		{name: "Guest gets the site default", query: "/", wantFirst: `href="/post/5"`},
+		{name: "User without a preference", token: sessionCookieValue, query: "/", wantFirst: `href="/post/5"`},
+		{name: "Saved preference applies", token: mock.TopSortToken, query: "/", wantFirst: `href="/post/1"`},
+		{name: "Explicit param overrides it", token: mock.TopSortToken, query: "/?sort=newest", wantFirst: `href="/post/5"`},
+		{name: "Explicit param for a guest", query: "/?sort=top", wantFirst: `href="/post/1"`},
+		{name: "Unknown param is ignored", token: mock.TopSortToken, query: "/?sort=random", wantFirst: `href="/post/1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var code int
+			var body string
+			if tt.token == "" {
+				code, _, body = ts.get(t, tt.query)
+			} else {
+				code, _, body = ts.getWithSession(t, tt.query, tt.token)
+			}
+			mock.Equal(t, code, http.StatusOK)
+
+			first := strings.Index(body, `class="titleHome"`)
+			if first < 0 {
+				t.Fatal("no posts on the page")
+			}
+			start := strings.LastIndex(body[:first], "href=")
+			mock.Equal(t, body[start:first-1], tt.wantFirst)
+		})
+	}
+}
+
+func TestUserSortUpdate(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		sort     string
+		wantCode int
+	}{
+		{name: "Top", sort: models.SortTop, wantCode: http.StatusSeeOther},
+		{name: "Back to the site default", sort: "", wantCode: http.StatusSeeOther},
+		{name: "Unknown order", sort: "random", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("sort", tt.sort)
+			code, _, _ := ts.postFormWithSession(t, "/user/sort", form, sessionCookieValue)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestHomeCommentCounts(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{HomeLimit: 5})
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/")
+	mock.Equal(t, code, http.StatusOK)
+	mock.Equal(t, strings.Count(body, `class="post-card"`), 5)
+	mock.Equal(t, strings.Count(body, `class="replies-container"`), 1)
+	mock.StringContains(t, body, "<p>"+strconv.Itoa(mock.HomeCommentCount)+"</p>")
+
+	// Five posts, one query for their categories and none for comments.
+	mock.Equal(t, ts.repo.Calls("GetCategoriesByPostIDs"), 1)
+	mock.Equal(t, ts.repo.Calls("GetCategoriesByPostID"), 0)
+	mock.Equal(t, ts.repo.Calls("GetCommentsByPostID"), 0)
+}
+
+func TestHomeSnippets(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{HomeLimit: 5, SnippetLength: 20})
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/")
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, ">Title Some bold and…</pre>")
+	mock.StringContains(t, body, ">"+mock.StatsContent+"</pre>")
+	for _, markup := range []string{"**bold**", "&lt;script&gt;", "# Title"} {
+		if strings.Contains(body, markup) {
+			t.Errorf("got %q in the snippets", markup)
+		}
+	}
+}
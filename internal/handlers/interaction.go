@@ -3,10 +3,14 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"forum/app"
 	"forum/models"
 	"forum/pkg/cookie"
 	"forum/pkg/validator"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -35,26 +39,51 @@ func (h *handler) postReaction(w http.ResponseWriter, r *http.Request) {
 		ID:    postID,
 		Token: token.Value,
 	}
-	reaction := r.FormValue("reaction")
-
-	switch reaction {
-	case "true":
-		form.Reaction = true
-	case "false":
-		form.Reaction = false
-	default:
-		h.app.ClientError(w, http.StatusBadRequest)
+	if !h.reactionAge(w, r) || !h.reactionCooldown(w, r, "post:"+strconv.Itoa(postID)) {
 		return
 	}
-	err = h.service.PostReaction(form)
+	// An emoji reaction comes with its type, a like or dislike without.
+	var state *models.ReactionState
+	if form.Type = r.FormValue("type"); form.Type != "" {
+		err = h.service.ReactToPost(form, h.cfg.ReactionTypes)
+	} else {
+		switch r.FormValue("reaction") {
+		case "true":
+			form.Reaction = true
+		case "false":
+			form.Reaction = false
+		default:
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		state, err = h.service.PostReaction(form, h.cfg.ReactionDedup)
+	}
 	if err != nil {
-		if errors.Is(err, models.ErrNoRecord) {
+		if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrUnknownReaction) {
 			h.app.ClientError(w, http.StatusBadRequest)
 			return
 		}
+		if errors.Is(err, models.ErrPostArchived) {
+			h.app.ClientError(w, http.StatusForbidden)
+			return
+		}
 		h.app.ServerError(w, err)
 		return
 	}
+	if state != nil && app.WantsJSON(r) {
+		if h.app.HideReactionsFor > 0 {
+			post, err := h.service.GetPostByID(postID)
+			if err != nil {
+				h.app.ServerError(w, err)
+				return
+			}
+			if h.app.ReactionsHidden(post.Created) {
+				*state = models.ReactionState{Mine: state.Mine, Hidden: true}
+			}
+		}
+		h.app.JSON(w, http.StatusOK, state)
+		return
+	}
 	url := strings.TrimPrefix(r.Header.Get("Referer"), r.Header.Get("Origin"))
 
 	http.Redirect(w, r, url, http.StatusSeeOther)
@@ -71,6 +100,11 @@ func (h *handler) commentPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.isSpam(r) {
+		h.spamDropped(w, r)
+		return
+	}
+
 	token := cookie.GetSessionCookie(r)
 	postID, err := GetIntForm(r, "postID")
 	if err != nil {
@@ -79,14 +113,39 @@ func (h *handler) commentPost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	form := models.CommentForm{
-		Content: r.FormValue("comment"),
-		PostID:  postID,
-		Token:   token.Value,
+		Content:      r.FormValue("comment"),
+		PostID:       postID,
+		QuoteExcerpt: r.FormValue("excerpt"),
+	}
+	if token != nil {
+		form.Token = token.Value
+	} else {
+		form.GuestName = strings.TrimSpace(r.FormValue("guest_name"))
+		form.GuestIP = h.clientIP(r)
+		form.CheckField(validator.NotBlank(form.GuestName), "guest_name", "This field cannot be blank")
+		form.CheckField(validator.MaxChars(form.GuestName, models.GuestNameMaxLen), "guest_name", fmt.Sprintf("This field must be maximum %d characters", models.GuestNameMaxLen))
+	}
+	if r.FormValue("quoted_comment_id") != "" {
+		form.QuotedCommentID, err = GetIntForm(r, "quoted_comment_id")
+		if err != nil || form.QuotedCommentID < 1 {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+	trim(&form.Content, &form.QuoteExcerpt)
+	if !h.filterBlocked(&form.Content) {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
 	}
-	trim(&form.Content)
 	form.CheckField(validator.NotBlank(form.Content), "comment", "This field cannot be blank")
 	form.CheckField(validator.MinChars(form.Content, 2), "comment", "This field must be at least 2 characters long")
 	form.CheckField(validator.MaxChars(form.Content, 100), "comment", "This field must be maximum 100 characters")
+	if form.Valid() && token != nil {
+		if err = h.checkNewUser(&form.Validator, "comment", token.Value, false, form.Content); err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+	}
 
 	if !form.Valid() {
 		data, err := h.NewTemplateData(r)
@@ -120,14 +179,192 @@ func (h *handler) commentPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.CommentPost(form)
+	err = h.service.CommentPost(form, models.CommentRules{
+		MaxDepth:        h.cfg.MaxCommentDepth,
+		DepthPolicy:     h.cfg.CommentDepthPolicy,
+		Cooldown:        h.cfg.CommentCooldown,
+		NewUser:         h.newUserRules(),
+		ApproveNewUsers: h.cfg.ApproveNewUsers,
+	})
 	if err != nil {
+		var cooldown *models.CooldownError
+		if errors.As(err, &cooldown) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(cooldown.RetryAfter.Seconds()))))
+			h.app.ClientError(w, http.StatusTooManyRequests)
+			return
+		}
+		if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrInvalidQuote) || errors.Is(err, models.ErrThreadTooDeep) {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, models.ErrPostLocked) || errors.Is(err, models.ErrPostArchived) {
+			h.app.ClientError(w, http.StatusForbidden)
+			return
+		}
 		h.app.ServerError(w, err)
 		return
 	}
+	if form.GuestName != "" {
+		h.guestSubmitted(w, r)
+		return
+	}
 	http.Redirect(w, r, fmt.Sprintf("/post/%d", form.PostID), http.StatusSeeOther)
 }
 
+// posts serves the paths under /posts/{postID}/.
+func (h *handler) posts(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/reactions") {
+		h.checkCookie(h.reactors)(w, r)
+		return
+	}
+	h.checkCookie(h.commentPermalink)(w, r)
+}
+
+// reactors lists who reacted to /posts/{postID}/reactions, or to
+// /posts/{postID}/comments/{commentID}/reactions, a page at a time. The
+// "type" parameter narrows the list to one type of reaction.
+func (h *handler) reactors(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/posts/"), "/")
+	if len(parts) != 2 && (len(parts) != 4 || parts[1] != "comments") {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	postID, err := strconv.Atoi(parts[0])
+	if err != nil || postID < 1 {
+		h.app.NotFound(w)
+		return
+	}
+	var commentID int
+	if len(parts) == 4 {
+		if commentID, err = strconv.Atoi(parts[2]); err != nil || commentID < 1 {
+			h.app.NotFound(w)
+			return
+		}
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.CurrentPage, err = strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || data.CurrentPage < 1 {
+		data.CurrentPage = 1
+	}
+	data.ReactionType = r.URL.Query().Get("type")
+	data.Reactors, data.NumberOfPage, err = h.service.GetReactors(data.User, postID, commentID, data.ReactionType, h.cfg.ReactionTypes, data.CurrentPage, data.Limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			h.app.NotFound(w)
+		case errors.Is(err, models.ErrUnknownReaction):
+			h.app.ClientError(w, http.StatusBadRequest)
+		default:
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	data.ReactionTypes = []string{models.ReactionLike, models.ReactionDislike}
+	data.ReactionsOf = fmt.Sprintf("/post/%d", postID)
+	if commentID != 0 {
+		data.ReactionsOf = fmt.Sprintf("/posts/%d/comments/%d", postID, commentID)
+	} else {
+		data.ReactionTypes = append(data.ReactionTypes, h.cfg.ReactionTypes...)
+	}
+	data.URL = r.URL.Path
+	if len(*data.Reactors) == 0 {
+		data.Reactors = nil
+	}
+	h.app.Render(w, http.StatusOK, "reactions.html", data)
+}
+
+// commentPermalink redirects /posts/{postID}/comments/{commentID} to the
+// comment's anchor on the page of the post it is on.
+func (h *handler) commentPermalink(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/posts/"), "/")
+	if len(parts) != 3 || parts[1] != "comments" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	postID, err := strconv.Atoi(parts[0])
+	if err != nil || postID < 1 {
+		h.app.NotFound(w)
+		return
+	}
+	commentID, err := strconv.Atoi(parts[2])
+	if err != nil || commentID < 1 {
+		h.app.NotFound(w)
+		return
+	}
+
+	comment, err := h.service.GetPostComment(postID, commentID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	query, err := h.commentPageQuery(r, comment)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	// The page a comment is on changes as comments come and go, so the
+	// redirect mustn't be cached.
+	http.Redirect(w, r, fmt.Sprintf("/post/%d%s#comment-%d", comment.PostID, query, comment.CommentID), http.StatusFound)
+}
+
+// commentPageQuery returns the query of the page of the post that shows
+// comment to the viewer of r, in the order asked for with ?sort= or the
+// default one. It is "" for the first page in the default order. Replies
+// too deep to show on the page are found in their thread, which is on the
+// same page.
+func (h *handler) commentPageQuery(r *http.Request, comment *models.Comment) (string, error) {
+	query := url.Values{}
+	sort := r.URL.Query().Get("sort")
+	if models.ValidCommentSort(sort) {
+		query.Set("sort", sort)
+	}
+	if h.cfg.CommentsPerPage > 0 {
+		post, err := h.service.GetPostByID(comment.PostID)
+		if err != nil {
+			return "", err
+		}
+		var viewer *models.User
+		if h.isAuthenticated(r) {
+			if viewer, err = h.service.GetUser(r); err != nil {
+				return "", err
+			}
+		}
+		if !models.ValidCommentSort(sort) {
+			sort = h.cfg.CommentSorts.For(post.Categories)
+		}
+		pages := models.CommentPages(arrangeComments(post, viewer, sort, 0), h.cfg.CommentsPerPage)
+		if page := models.CommentPage(pages, comment.CommentID); page > 1 {
+			query.Set("page", strconv.Itoa(page))
+		}
+	}
+	if len(query) == 0 {
+		return "", nil
+	}
+	return "?" + query.Encode(), nil
+}
+
 func (h *handler) commentReaction(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/comment/reaction" {
 		h.app.NotFound(w)
@@ -171,14 +408,25 @@ func (h *handler) commentReaction(w http.ResponseWriter, r *http.Request) {
 		h.app.ClientError(w, http.StatusBadRequest)
 		return
 	}
-	err = h.service.CommentReaction(form)
+	if !h.reactionAge(w, r) || !h.reactionCooldown(w, r, "comment:"+strconv.Itoa(commentID)) {
+		return
+	}
+	state, err := h.service.CommentReaction(form, h.cfg.ReactionDedup)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
 			h.app.ClientError(w, http.StatusBadRequest)
 			return
 		}
+		if errors.Is(err, models.ErrPostArchived) {
+			h.app.ClientError(w, http.StatusForbidden)
+			return
+		}
 		h.app.ServerError(w, err)
 		return
 	}
+	if app.WantsJSON(r) {
+		h.app.JSON(w, http.StatusOK, state)
+		return
+	}
 	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
 }
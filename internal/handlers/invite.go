@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+)
+
+func (h *handler) invites(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/invites" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	c := cookie.GetSessionCookie(r)
+	data.Invites, err = h.service.GetInvites(c.Value)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if len(*data.Invites) == 0 {
+		data.Invites = nil
+	}
+	h.app.Render(w, http.StatusOK, "invites.html", data)
+}
+
+func (h *handler) inviteCreate(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/invites/create" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	c := cookie.GetSessionCookie(r)
+	_, err := h.service.CreateInvite(c.Value, h.cfg.InviteQuota)
+	if err != nil {
+		if errors.Is(err, models.ErrInviteQuota) {
+			h.app.ClientError(w, http.StatusForbidden)
+			return
+		}
+		h.app.ServerError(w, err)
+		return
+	}
+	http.Redirect(w, r, "/invites", http.StatusSeeOther)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"forum/models"
+	"net/http"
+)
+
+// leaderboard ranks the top contributors by the metric and over the period
+// picked in the query string.
+func (h *handler) leaderboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/leaderboard" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := models.ParseLeaderboardQuery(r.URL.Query())
+	if err != nil {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Leaderboard, err = h.service.GetLeaderboard(q)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidLeaderboard) {
+			h.app.ClientError(w, http.StatusBadRequest)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+
+	h.app.Render(w, http.StatusOK, "leaderboard.html", data)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	mock "forum/internal/repo/mocks"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLeaderboard(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	row := func(rank, name, shown, score string) string {
+		return "<td>" + rank + "</td>\n    <td><a href=\"/user/" + name + "\">" + shown + "</a></td>\n    <td>" + score + "</td>"
+	}
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+		want     []string
+	}{
+		{
+			name:     "Default",
+			url:      "/leaderboard",
+			wantCode: http.StatusOK,
+			want:     []string{row("1", "test", "test", "5"), row("2", "admin", mock.AdminDisplayName, "3")},
+		},
+		{
+			name:     "Comments",
+			url:      "/leaderboard?metric=comments",
+			wantCode: http.StatusOK,
+			want:     []string{row("1", "admin", mock.AdminDisplayName, "4"), row("2", "test", "test", "2")},
+		},
+		{
+			name:     "Reactions of the last 30 days",
+			url:      "/leaderboard?metric=reactions&period=30d",
+			wantCode: http.StatusOK,
+			want:     []string{row("1", "admin", mock.AdminDisplayName, "1")},
+		},
+		{name: "Unknown metric", url: "/leaderboard?metric=karma", wantCode: http.StatusBadRequest},
+		{name: "Unknown period", url: "/leaderboard?period=7d", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.url)
+			mock.Equal(t, code, tt.wantCode)
+			for _, want := range tt.want {
+				mock.StringContains(t, body, want)
+			}
+			if len(tt.want) == 1 && strings.Contains(body, `<a href="/user/test">`) {
+				t.Errorf("test must not be ranked")
+			}
+		})
+	}
+
+	// Each of the three leaderboards above was counted once.
+	ts.get(t, "/leaderboard?metric=posts&period=all")
+	mock.Equal(t, ts.repo.Calls("GetTopContributors"), 3)
+}
@@ -2,17 +2,65 @@ package handlers
 
 import (
 	"forum/app"
+	"forum/internal/config"
 	"forum/internal/service"
+	"forum/pkg/blocklist"
+	"forum/pkg/captcha"
+	"forum/pkg/embed"
+	"forum/pkg/geo"
+	"forum/pkg/mailer"
+	"forum/pkg/ratelimit"
+	"forum/pkg/urls"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
+// previewRate is how many previews a client may render per minute.
+const previewRate = 30
+
 type handler struct {
-	service service.ServiceI
-	app     *app.Application
+	service   service.ServiceI
+	app       *app.Application
+	cfg       *config.Config
+	blocklist *blocklist.Blocklist
+	previews  *ratelimit.Limiter
+	captcha   captcha.Verifier
+	geo       geo.Resolver
+	urls      *urls.Builder
+	mailer    mailer.Mailer
+	emails    *mailer.Templates
+	embeds    *embed.Embedder
+	// writes limits the posts, comments and reactions of each user
+	// together, nil when there is no limit.
+	writes *ratelimit.Limiter
+	// reactions holds back a user's next reaction to the same post or
+	// comment for the cooldown, nil when there is none.
+	reactions *ratelimit.Limiter
+	// log records the panics recoverPanic catches.
+	log *logrus.Logger
 }
 
-func New(s service.ServiceI, app *app.Application) *handler {
-	return &handler{
-		s,
-		app,
+func New(s service.ServiceI, app *app.Application, cfg *config.Config, bl *blocklist.Blocklist, cv captcha.Verifier, gr geo.Resolver, ub *urls.Builder, m mailer.Mailer, emails *mailer.Templates) *handler {
+	h := &handler{
+		service:   s,
+		app:       app,
+		cfg:       cfg,
+		blocklist: bl,
+		previews:  ratelimit.New(previewRate, time.Minute),
+		captcha:   cv,
+		geo:       gr,
+		urls:      ub,
+		mailer:    m,
+		emails:    emails,
+		embeds:    embed.New(cfg.EmbedDomains),
+		log:       logrus.New(),
+	}
+	if cfg.WriteLimit > 0 {
+		h.writes = ratelimit.New(cfg.WriteLimit, cfg.WriteWindow)
+	}
+	if cfg.ReactionCooldown > 0 {
+		h.reactions = ratelimit.New(1, cfg.ReactionCooldown)
 	}
+	return h
 }
@@ -1,17 +1,39 @@
 package handlers
 
 import (
+	"cmp"
+	"errors"
+	"fmt"
+	"forum/app"
 	"forum/models"
+	"forum/pkg/antispam"
+	"forum/pkg/blocklist"
 	"forum/pkg/cookie"
+	"forum/pkg/geo"
+	"forum/pkg/ratelimit"
+	"forum/pkg/realip"
+	"math"
+	"mime"
 	"net/http"
+	"net/url"
+	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 type contextKey string
 
 const isAuthenticatedContextKey = contextKey("isAuthenticated")
 
+// maintenanceRetryAfter is sent in the Retry-After header (in seconds) while
+// the forum is in maintenance mode.
+const maintenanceRetryAfter = "3600"
+
 // func decorator(){
 
 // }
@@ -33,7 +55,7 @@ func (h *handler) requireAuthentication(next http.HandlerFunc) http.HandlerFunc
 		// the chain are executed.
 		c := cookie.GetSessionCookie(r)
 		if c == nil {
-			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			h.redirectToLogin(w, r)
 			return
 		}
 		isValid, err := h.service.ValidToken(c.Value)
@@ -43,7 +65,7 @@ func (h *handler) requireAuthentication(next http.HandlerFunc) http.HandlerFunc
 		}
 		if !isValid {
 			cookie.ExpireSessionCookie(w)
-			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			h.redirectToLogin(w, r)
 			return
 		}
 
@@ -54,6 +76,95 @@ func (h *handler) requireAuthentication(next http.HandlerFunc) http.HandlerFunc
 	})
 }
 
+// allowGuests is requireAuthentication for the forms guests may send too.
+// With GuestPosting on, requests without a session go through to next, which
+// tells guests apart by the missing session cookie. With it off, forms sent
+// without a session are refused with 401 rather than redirected, as there is
+// nothing to come back to after logging in.
+func (h *handler) allowGuests(next http.HandlerFunc) http.HandlerFunc {
+	authenticated := h.requireAuthentication(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case h.isAuthenticated(r):
+			authenticated(w, r)
+		case h.cfg.GuestPosting:
+			next(w, r)
+		case r.Method == http.MethodPost:
+			h.app.ClientError(w, http.StatusUnauthorized)
+		default:
+			h.redirectToLogin(w, r)
+		}
+	})
+}
+
+// redirectToLogin sends the user to the login page. With LoginRedirect on,
+// the page they asked for is remembered so that logging in brings them back
+// to it. Only GETs are, a form sent while logged out can't be replayed.
+// Clients that want JSON get a 401 instead.
+func (h *handler) redirectToLogin(w http.ResponseWriter, r *http.Request) {
+	if app.WantsJSON(r) {
+		h.app.ClientError(w, http.StatusUnauthorized)
+		return
+	}
+	if h.cfg.LoginRedirect && r.Method == http.MethodGet {
+		cookie.SetRedirectCookie(w, r.URL.RequestURI())
+	}
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// afterLogin is where a user who just logged in goes: the page remembered by
+// redirectToLogin if it is on this site, the home page otherwise.
+func (h *handler) afterLogin(w http.ResponseWriter, r *http.Request) string {
+	if !h.cfg.LoginRedirect {
+		return "/"
+	}
+	target := cookie.GetRedirectCookie(r)
+	if target == "" {
+		return "/"
+	}
+	cookie.ExpireRedirectCookie(w)
+	if !isLocalURL(target) {
+		return "/"
+	}
+	return target
+}
+
+// isLocalURL reports whether target is a path on this site. Browsers read
+// "//host" and "/\host" as another host, so those aren't.
+func isLocalURL(target string) bool {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return false
+	}
+	u, err := url.Parse(target)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
+
+// requireAPIAuthentication is requireAuthentication for the JSON API, it
+// answers 401 instead of redirecting to the login page.
+func (h *handler) requireAPIAuthentication(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := cookie.GetSessionCookie(r)
+		if c == nil {
+			h.app.APIClientError(w, http.StatusUnauthorized)
+			return
+		}
+		isValid, err := h.service.ValidToken(c.Value)
+		if err != nil {
+			h.app.APIError(w, err)
+			return
+		}
+		if !isValid {
+			cookie.ExpireSessionCookie(w)
+			h.app.APIClientError(w, http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Add("Cache-Control", "no-store")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (h *handler) checkCookie(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		c := cookie.GetSessionCookie(r)
@@ -91,22 +202,257 @@ func (h *handler) notRegistered(next http.HandlerFunc) http.HandlerFunc {
 	})
 }
 
+// Headers sent when the config leaves them empty.
+const (
+	defaultCSP            = "default-src 'self'; style-src 'self' fonts.googleapis.com; font-src fonts.gstatic.com; frame-ancestors 'none'"
+	defaultReferrerPolicy = "origin-when-cross-origin"
+	defaultFrameOptions   = "deny"
+)
+
+// secureHeaders sets the security headers on every response. A configured
+// Content-Security-Policy replaces the default one whole, CAPTCHA origins
+// included.
 func (h *handler) secureHeaders(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Note: This is split across multiple lines for readability. You don't
-		// need to do this in your own code.
-		w.Header().Set("Content-Security-Policy",
-			"default-src 'self'; style-src 'self' fonts.googleapis.com; font-src fonts.gstatic.com")
+	csp := h.cfg.CSP
+	if csp == "" {
+		csp = defaultCSP
+		var frames []string
+		// A CAPTCHA widget loads its script and frame from its provider.
+		if widget := h.captcha.Widget(); widget != nil {
+			csp += fmt.Sprintf("; script-src 'self' %[1]s; connect-src 'self' %[1]s", widget.Origins)
+			frames = append(frames, widget.Origins)
+		}
+		// Embedded players are framed from their sites.
+		if origins := h.embeds.Origins(); origins != "" {
+			frames = append(frames, origins)
+		}
+		if len(frames) > 0 {
+			csp += "; frame-src " + strings.Join(frames, " ")
+		}
+	}
+	referrerPolicy := cmp.Or(h.cfg.ReferrerPolicy, defaultReferrerPolicy)
+	frameOptions := cmp.Or(h.cfg.FrameOptions, defaultFrameOptions)
 
-		w.Header().Set("Referrer-Policy", "origin-when-cross-origin")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", csp)
+		w.Header().Set("Referrer-Policy", referrerPolicy)
 		w.Header().Set("X-Content-Type-Options", "nosniff")
-		w.Header().Set("X-Frame-Options", "deny")
+		w.Header().Set("X-Frame-Options", frameOptions)
 		w.Header().Set("X-XSS-Protection", "0")
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+// clientIP is the address of whoever sent r, seen through the trusted
+// proxies.
+func (h *handler) clientIP(r *http.Request) string {
+	return realip.ClientIP(r, h.cfg.TrustedProxies)
+}
+
+// rateLimit answers a JSON 429 to clients that went over l, keyed by their
+// IP.
+func (h *handler) rateLimit(l *ratelimit.Limiter, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := l.Take(h.clientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			h.app.APIClientError(w, http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitWrites counts POST requests against the write limit of the user,
+// which all write endpoints share, so spreading writes over them doesn't get
+// around it. Over the limit it answers 429 with a Retry-After.
+func (h *handler) limitWrites(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.writes == nil || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Guests share the limit of their address.
+		key := "guest:" + h.clientIP(r)
+		if h.isAuthenticated(r) {
+			user, err := h.service.GetUser(r)
+			if err != nil {
+				h.app.ServerError(w, err)
+				return
+			}
+			key = strconv.FormatInt(user.ID, 10)
+		}
+		if ok, wait := h.writes.Take(key); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			h.app.ClientError(w, http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reactionAge answers 403 when the account of the user of r is younger
+// than the configured minimum to react, and reports whether the reaction
+// may go ahead.
+func (h *handler) reactionAge(w http.ResponseWriter, r *http.Request) bool {
+	if h.cfg.ReactMinAge <= 0 {
+		return true
+	}
+	user, err := h.service.GetUser(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return false
+	}
+	if !user.CanReact(h.cfg.ReactMinAge) {
+		h.app.ClientError(w, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// reactionCooldown answers 429 with a Retry-After when the user of r
+// reacted to item, like "post:1", less than the reaction cooldown ago, and
+// reports whether the reaction may go ahead.
+func (h *handler) reactionCooldown(w http.ResponseWriter, r *http.Request, item string) bool {
+	if h.reactions == nil {
+		return true
+	}
+	user, err := h.service.GetUser(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return false
+	}
+	if ok, wait := h.reactions.Take(strconv.FormatInt(user.ID, 10) + ":" + item); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		h.app.ClientError(w, http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// geoBlock answers 403 to clients from the countries the config blocks.
+// Clients whose country can't be told get through unless it fails closed.
+// Health checks always do, as they come from our own network.
+func (h *handler) geoBlock(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(h.cfg.GeoBlock) == 0 || r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ip := h.clientIP(r)
+		country, err := h.geo.Country(ip)
+		if err != nil && h.cfg.GeoFailClosed || slices.Contains(h.cfg.GeoBlock, country) {
+			h.app.ClientError(w, http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			// Let through, but leave a trace of what got past the block.
+			// Addresses outside every listed network are the usual case.
+			entry := h.log.WithFields(logrus.Fields{"ip": ip, "path": r.URL.Path}).WithError(err)
+			if errors.Is(err, geo.ErrUnknown) {
+				entry.Debug("geo: unknown country, letting through")
+			} else {
+				entry.Warn("geo: lookup failed, letting through")
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *handler) maintenanceMode(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Health checks, static files and the login page stay reachable so
+		// that admins can still sign in while the forum is closed.
+		if !h.cfg.Maintenance || r.URL.Path == "/health" || r.URL.Path == "/login" || strings.HasPrefix(r.URL.Path, "/static/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		isAdmin, err := h.isAdmin(r)
+		if err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+		if isAdmin {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", maintenanceRetryAfter)
+		h.app.Maintenance(w)
+	})
+}
+
+// defaultMaxBodyBytes caps request bodies when the config leaves it at 0.
+const defaultMaxBodyBytes = 1 << 20
+
+// recoverPanic answers 500 to a request whose handler panicked, as an
+// error page or JSON like any other error, instead of dropping the
+// connection. The panic is logged with its stack trace and the id of the
+// request, which the response carries in X-Request-ID so that users can
+// quote it. http.ErrAbortHandler is left to the server, which aborts the
+// response quietly on purpose.
+func (h *handler) recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := uuid.NewString()
+		w.Header().Set("X-Request-ID", id)
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			h.log.WithFields(logrus.Fields{
+				"request_id": id,
+				"method":     r.Method,
+				"path":       r.URL.Path,
+				"stack":      string(debug.Stack()),
+			}).Errorf("panic: %v", rec)
+			w.Header().Set("Connection", "close")
+			h.app.ClientError(w, http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitBody answers 413 to requests with a body over cfg.MaxBodyBytes.
+// Forms are parsed here, so that a form cut off at the limit is refused
+// rather than handled with fields missing.
+func (h *handler) limitBody(next http.Handler) http.Handler {
+	limit := cmp.Or(h.cfg.MaxBodyBytes, defaultMaxBodyBytes)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tooLarge := func() {
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				h.app.APIClientError(w, http.StatusRequestEntityTooLarge)
+			} else {
+				h.app.ClientError(w, http.StatusRequestEntityTooLarge)
+			}
+		}
+		if r.ContentLength > limit {
+			tooLarge()
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+		var err error
+		switch mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType {
+		case "application/x-www-form-urlencoded":
+			err = r.ParseForm()
+		case "multipart/form-data":
+			err = r.ParseMultipartForm(limit)
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			tooLarge()
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func GetIntForm(r *http.Request, form string) (int, error) {
 	valueString := r.FormValue(form)
 	value, err := strconv.Atoi(valueString)
@@ -119,7 +465,15 @@ func GetIntForm(r *http.Request, form string) (int, error) {
 func (h *handler) NewTemplateData(r *http.Request) (*models.TemplateData, error) {
 	var TemplateData models.TemplateData
 
+	TemplateData.FormStamp = antispam.Stamp(time.Now())
 	TemplateData.IsAuthenticated = h.isAuthenticated(r)
+	TemplateData.PostLabels = h.cfg.PostLabels
+	TemplateData.GuestPosting = h.cfg.GuestPosting
+	TemplateData.Limit = h.cfg.PageLimit
+	TemplateData.MaxLimit = h.cfg.MaxLimit
+	if h.cfg.SignupDisabled {
+		TemplateData.SignupClosed = h.cfg.SignupMessage
+	}
 
 	if TemplateData.IsAuthenticated {
 		user, err := h.service.GetUser(r)
@@ -127,15 +481,49 @@ func (h *handler) NewTemplateData(r *http.Request) (*models.TemplateData, error)
 			return nil, err
 		}
 		TemplateData.User = user
+		TemplateData.UnreadCount, err = h.service.CountUnread(int(user.ID))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := h.setAnnouncements(&TemplateData, models.AnnouncementScope{}); err != nil {
+		return nil, err
 	}
 	return &TemplateData, nil
 }
 
+// setAnnouncements shows the banners of scope that are active now. Pages
+// narrower than the whole site call it again with their category or post.
+func (h *handler) setAnnouncements(data *models.TemplateData, scope models.AnnouncementScope) error {
+	announcements, err := h.service.GetActiveAnnouncements(time.Now(), scope)
+	if err != nil {
+		return err
+	}
+	data.Announcements = announcements
+	return nil
+}
+
 func (h *handler) isAuthenticated(r *http.Request) bool {
 	cookie := cookie.GetSessionCookie(r)
 	return cookie != nil && cookie.Value != ""
 }
 
+func (h *handler) isAdmin(r *http.Request) (bool, error) {
+	c := cookie.GetSessionCookie(r)
+	if c == nil {
+		return false, nil
+	}
+	isValid, err := h.service.ValidToken(c.Value)
+	if err != nil || !isValid {
+		return false, err
+	}
+	user, err := h.service.GetUser(r)
+	if err != nil {
+		return false, err
+	}
+	return user.IsAdmin(), nil
+}
+
 func ConverCategories(CategoriesString []string) ([]int, error) {
 	categories := make([]int, len(CategoriesString))
 	for i, str := range CategoriesString {
@@ -154,3 +542,50 @@ func trim(s ...*string) {
 		*value = strings.TrimSpace(*value)
 	}
 }
+
+// filterBlocked applies the word blocklist to the values. In mask mode the
+// blocked terms are replaced in place; otherwise it reports false if any
+// value contains one, and the content must be rejected.
+func (h *handler) filterBlocked(s ...*string) bool {
+	for _, value := range s {
+		if h.cfg.BlocklistMode == blocklist.ModeMask {
+			*value = h.blocklist.Mask(*value)
+		} else if h.blocklist.Contains(*value) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSpam reports whether the signup or comment form in r looks like it was
+// sent by a bot.
+func (h *handler) isSpam(r *http.Request) bool {
+	rules := antispam.Rules{Honeypot: h.cfg.Honeypot, MinTime: h.cfg.MinSubmitTime}
+	if !rules.IsBot(r, time.Now()) {
+		return false
+	}
+	h.app.InfoLog.Printf("dropped a likely bot submission to %s from %s", r.URL.Path, realip.ClientIP(r, h.cfg.TrustedProxies))
+	return true
+}
+
+// spamDropped answers a dropped submission with a page that looks like it
+// went through, so the bot has nothing to adapt to.
+func (h *handler) spamDropped(w http.ResponseWriter, r *http.Request) {
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	h.app.Render(w, http.StatusOK, "submitted.html", data)
+}
+
+// guestSubmitted tells a guest that what they sent waits for approval.
+func (h *handler) guestSubmitted(w http.ResponseWriter, r *http.Request) {
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Flash = "It will show once a moderator approves it"
+	h.app.Render(w, http.StatusAccepted, "submitted.html", data)
+}
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"forum/pkg/blocklist"
+	"forum/pkg/captcha"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{Maintenance: true})
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		token    string
+		wantCode int
+	}{
+		{
+			name:     "Guest request",
+			url:      "/",
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "Regular user request",
+			url:      "/",
+			token:    sessionCookieValue,
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "Admin request",
+			url:      "/",
+			token:    mock.AdminToken,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Health check",
+			url:      "/health",
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				code   int
+				header http.Header
+			)
+			if tt.token == "" {
+				code, header, _ = ts.get(t, tt.url)
+			} else {
+				code, header, _ = ts.getWithSession(t, tt.url, tt.token)
+			}
+			mock.Equal(t, code, tt.wantCode)
+			if code == http.StatusServiceUnavailable {
+				mock.Equal(t, header.Get("Retry-After"), maintenanceRetryAfter)
+			}
+		})
+	}
+}
+
+func TestMaintenanceModeOff(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	code, _, _ := ts.get(t, "/")
+	mock.Equal(t, code, http.StatusOK)
+}
+
+func TestClientIPBehindProxy(t *testing.T) {
+	// Test requests come from 127.0.0.1, so trusting it makes the test
+	// server sit behind a proxy.
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+
+	tests := []struct {
+		name        string
+		proxies     []*net.IPNet
+		wantLimited bool
+	}{
+		{
+			name:        "Trusted proxy forwards distinct clients",
+			proxies:     []*net.IPNet{loopback},
+			wantLimited: false,
+		},
+		{
+			name:        "Untrusted peer spoofs the header",
+			wantLimited: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{TrustedProxies: tt.proxies})
+			defer ts.Close()
+
+			var limited bool
+			for i := 0; i <= previewRate; i++ {
+				req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/preview", strings.NewReader("hi"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+				rs, err := ts.Client().Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rs.Body.Close()
+				if rs.StatusCode == http.StatusTooManyRequests {
+					limited = true
+				}
+			}
+			mock.Equal(t, limited, tt.wantLimited)
+		})
+	}
+}
+
+func TestWriteLimit(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{WriteLimit: 4, WriteWindow: time.Minute})
+	defer ts.Close()
+
+	post := url.Values{"title": {"title"}, "content": {"some content"}, "categories": {"1"}}
+	comment := url.Values{"postID": {"1"}, "comment": {"a comment"}}
+	reaction := url.Values{"postID": {"1"}, "reaction": {"true"}}
+
+	// Posts, comments and reactions all count against the same limit.
+	writes := []struct {
+		url  string
+		form url.Values
+	}{
+		{"/post/create", post},
+		{"/comment/post", comment},
+		{"/post/reaction", reaction},
+		{"/comment/post", comment},
+	}
+	for _, write := range writes {
+		code, _, _ := ts.postFormWithSession(t, write.url, write.form, sessionCookieValue)
+		mock.Equal(t, code, http.StatusSeeOther)
+	}
+	// Reading doesn't count.
+	code, _, _ := ts.getWithSession(t, "/post/create", sessionCookieValue)
+	mock.Equal(t, code, http.StatusOK)
+
+	for _, write := range writes[:2] {
+		code, header, _ := ts.postFormWithSession(t, write.url, write.form, sessionCookieValue)
+		mock.Equal(t, code, http.StatusTooManyRequests)
+		retryAfter, err := strconv.Atoi(header.Get("Retry-After"))
+		if err != nil || retryAfter < 1 || retryAfter > 60 {
+			t.Errorf("got Retry-After %q; expected 1 to 60 seconds", header.Get("Retry-After"))
+		}
+	}
+
+	// Each user has a limit of their own.
+	code, _, _ = ts.postFormWithSession(t, "/comment/post", comment, mock.AdminToken)
+	mock.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestSecureHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.Config
+		want map[string]string
+	}{
+		{
+			name: "Defaults",
+			cfg:  &config.Config{},
+			want: map[string]string{
+				"Content-Security-Policy": defaultCSP,
+				"Referrer-Policy":         defaultReferrerPolicy,
+				"X-Content-Type-Options":  "nosniff",
+				"X-Frame-Options":         defaultFrameOptions,
+			},
+		},
+		{
+			name: "Embeds",
+			cfg:  &config.Config{EmbedDomains: []string{"youtube.com", "vimeo.com"}},
+			want: map[string]string{
+				"Content-Security-Policy": defaultCSP + "; frame-src https://player.vimeo.com https://www.youtube-nocookie.com",
+			},
+		},
+		{
+			name: "Configured",
+			cfg: &config.Config{
+				CSP:            "default-src 'self'; script-src 'self' 'unsafe-inline'",
+				ReferrerPolicy: "no-referrer",
+				FrameOptions:   "sameorigin",
+			},
+			want: map[string]string{
+				"Content-Security-Policy": "default-src 'self'; script-src 'self' 'unsafe-inline'",
+				"Referrer-Policy":         "no-referrer",
+				"X-Content-Type-Options":  "nosniff",
+				"X-Frame-Options":         "sameorigin",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, tt.cfg)
+			defer ts.Close()
+
+			// Every response gets the headers, errors and static files too.
+			for _, url := range []string{"/", "/does-not-exist", "/static/css/main.css"} {
+				_, header, _ := ts.get(t, url)
+				for name, value := range tt.want {
+					mock.Equal(t, header.Get(name), value)
+				}
+			}
+		})
+	}
+}
+
+// An overridden policy replaces the default whole, without the CAPTCHA
+// origins.
+func TestSecureHeadersCSPOverride(t *testing.T) {
+	cfg := &config.Config{CSP: "default-src 'none'"}
+	ts := NewTestServerWithCaptcha(t, cfg, blocklist.New(nil), stubCaptcha{})
+	defer ts.Close()
+
+	_, header, _ := ts.get(t, "/signup")
+	mock.Equal(t, header.Get("Content-Security-Policy"), "default-src 'none'")
+}
+
+func TestLimitBody(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{MaxBodyBytes: 1024})
+	defer ts.Close()
+
+	post := func(comment string, chunked bool) int {
+		t.Helper()
+		form := url.Values{"postID": {"1"}, "comment": {comment}}
+		var body io.Reader = strings.NewReader(form.Encode())
+		if chunked {
+			// Without a length the limit can only be found out by reading.
+			body = io.MultiReader(body)
+		}
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/comment/post", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: sessionIDCookie, Value: sessionCookieValue})
+		res, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	for _, chunked := range []bool{false, true} {
+		mock.Equal(t, post("I agree with this", chunked), http.StatusSeeOther)
+		mock.Equal(t, post(strings.Repeat("a", 2048), chunked), http.StatusRequestEntityTooLarge)
+	}
+
+	code, body := ts.preview(t, strings.Repeat("a", 2048))
+	mock.Equal(t, code, http.StatusRequestEntityTooLarge)
+	mock.Equal(t, decodeAPIError(t, body).Code, models.CodeTooLarge)
+}
+
+func TestRecoverPanic(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	var logs bytes.Buffer
+	ts.handler.log.SetOutput(&logs)
+	h := ts.handler.app.NegotiateErrors(ts.handler.recoverPanic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("secret database password")
+	})))
+
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"HTML", "text/html", "Internal Server Error"},
+		{"JSON", "application/json", `"code":"` + models.CodeInternal + `"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			r := httptest.NewRequest(http.MethodGet, "/boom", nil)
+			r.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			mock.Equal(t, w.Code, http.StatusInternalServerError)
+			mock.StringContains(t, w.Body.String(), tt.want)
+			if strings.Contains(w.Body.String(), "secret") {
+				t.Errorf("got the panic in the response %q", w.Body.String())
+			}
+
+			id := w.Header().Get("X-Request-ID")
+			if id == "" {
+				t.Fatal("got no X-Request-ID")
+			}
+			mock.StringContains(t, logs.String(), "panic: secret database password")
+			mock.StringContains(t, logs.String(), "request_id="+id)
+			mock.StringContains(t, logs.String(), "path=/boom")
+			mock.StringContains(t, logs.String(), "runtime/debug.Stack")
+		})
+	}
+
+	// Aborting on purpose is left to the server.
+	abort := ts.handler.recoverPanic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		mock.Equal(t, recover(), any(http.ErrAbortHandler))
+	}()
+	abort.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+// stubGeo puts the addresses it has in their country and fails for the
+// rest.
+type stubGeo map[string]string
+
+func (g stubGeo) Country(ip string) (string, error) {
+	if country, ok := g[ip]; ok {
+		return country, nil
+	}
+	return "", errors.New("lookup failed")
+}
+
+func TestGeoBlock(t *testing.T) {
+	// Requests are forwarded by a trusted 127.0.0.1 so that each case can
+	// come from its own address.
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	resolver := stubGeo{"198.51.100.1": "NZ", "198.51.100.2": "KP"}
+
+	tests := []struct {
+		name       string
+		blocked    []string
+		failClosed bool
+		ip         string
+		url        string
+		wantCode   int
+		wantLog    bool
+	}{
+		{name: "Allowed country", blocked: []string{"KP"}, ip: "198.51.100.1", url: "/", wantCode: http.StatusOK},
+		{name: "Blocked country", blocked: []string{"KP"}, ip: "198.51.100.2", url: "/", wantCode: http.StatusForbidden},
+		{name: "Blocked API request", blocked: []string{"KP"}, ip: "198.51.100.2", url: "/api/v1/posts", wantCode: http.StatusForbidden},
+		{name: "Unknown failing open", blocked: []string{"KP"}, ip: "203.0.113.1", url: "/", wantCode: http.StatusOK, wantLog: true},
+		{name: "Unknown failing closed", blocked: []string{"KP"}, failClosed: true, ip: "203.0.113.1", url: "/", wantCode: http.StatusForbidden},
+		{name: "Health check", blocked: []string{"KP"}, failClosed: true, ip: "198.51.100.2", url: "/health", wantCode: http.StatusOK},
+		{name: "Nothing blocked", failClosed: true, ip: "203.0.113.1", url: "/", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{TrustedProxies: []*net.IPNet{loopback}, GeoBlock: tt.blocked, GeoFailClosed: tt.failClosed}
+			ts := NewTestServerWithGeo(t, cfg, blocklist.New(nil), captcha.Noop{}, resolver)
+			defer ts.Close()
+			var logs bytes.Buffer
+			ts.handler.log.SetOutput(&logs)
+
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-Forwarded-For", tt.ip)
+			rs, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rs.Body.Close()
+			mock.Equal(t, rs.StatusCode, tt.wantCode)
+			mock.Equal(t, strings.Contains(logs.String(), "ip=203.0.113.1"), tt.wantLog)
+		})
+	}
+}
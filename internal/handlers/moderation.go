@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// moderationBulk applies one action to a batch of posts and comments, passed
+// as repeated "post" and "comment" form values, and responds with the result
+// for every item. Locks take an optional "reason" shown on the post.
+func (h *handler) moderationBulk(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/moderation/bulk" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	var targets []models.ModerationTarget
+	for _, kind := range []string{models.TargetPost, models.TargetComment} {
+		for _, value := range r.PostForm[kind] {
+			id, err := strconv.Atoi(value)
+			if err != nil || id < 1 {
+				h.app.ClientError(w, http.StatusBadRequest)
+				return
+			}
+			targets = append(targets, models.ModerationTarget{Kind: kind, ID: id})
+		}
+	}
+	if len(targets) == 0 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	reason := strings.TrimSpace(r.PostForm.Get("reason"))
+	if utf8.RuneCountInString(reason) > models.LockReasonMaxLen {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	results, err := h.service.BulkModerate(token.Value, r.PostForm.Get("action"), reason, targets)
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.ErrInvalidAction) {
+			h.app.ClientError(w, http.StatusBadRequest)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	if r.PostForm.Get("action") == models.ModerationDelete {
+		user, err := h.service.GetUser(r)
+		if err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+		for _, result := range results {
+			if result.OK {
+				h.audit(r, int(user.ID), models.AuditDelete, fmt.Sprintf("%s:%d", result.Kind, result.ID))
+			}
+		}
+	}
+	h.app.JSON(w, http.StatusOK, results)
+}
+
+// adminCategoryModerator grants ("add") or revokes ("remove") moderation of
+// a category for a user.
+func (h *handler) adminCategoryModerator(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/admin/moderators" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.FormValue("name")
+	categoryID, err := strconv.Atoi(r.FormValue("category"))
+	if err != nil || name == "" {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	var grant bool
+	switch r.FormValue("action") {
+	case "add":
+		grant = true
+	case "remove":
+	default:
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	err = h.service.SetCategoryModerator(token.Value, name, categoryID, grant, h.clientIP(r))
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.UnknownCategory) {
+			h.app.ClientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, "/user/"+name, http.StatusSeeOther)
+}
+
+// adminCommentMode shows the comments of a category's posts flat or
+// threaded, then goes back to the category.
+func (h *handler) adminCommentMode(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/admin/categories/comments" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	categoryID, err := strconv.Atoi(r.FormValue("category"))
+	if err != nil {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	err = h.service.SetCategoryCommentMode(token.Value, categoryID, r.FormValue("mode"), h.clientIP(r))
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.UnknownCategory) || errors.Is(err, models.ErrInvalidCommentMode) {
+			h.app.ClientError(w, http.StatusBadRequest)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// adminPostStatus lets users of a status and above only post in a category,
+// then goes back to the category.
+func (h *handler) adminPostStatus(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/admin/categories/posting" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	categoryID, err := strconv.Atoi(r.FormValue("category"))
+	if err != nil {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	status, err := strconv.Atoi(r.FormValue("status"))
+	if err != nil {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	err = h.service.SetCategoryPostStatus(token.Value, categoryID, status, h.clientIP(r))
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.UnknownCategory) || errors.Is(err, models.ErrInvalidStatus) {
+			h.app.ClientError(w, http.StatusBadRequest)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// moderationLabel puts a label on a post ("add") or takes it off ("remove"),
+// then goes back to the post.
+func (h *handler) moderationLabel(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/moderation/labels" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	postID, err := GetIntForm(r, "postID")
+	label := r.FormValue("label")
+	if err != nil || postID < 1 || label == "" {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	var add bool
+	switch r.FormValue("action") {
+	case "add":
+		add = true
+	case "remove":
+	default:
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	err = h.service.SetPostLabel(token.Value, models.PostLabel{PostID: postID, Label: label}, h.cfg.PostLabels, add)
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.ErrUnknownLabel) {
+			h.app.ClientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// moderationGuestPosts lists the guest posts waiting for approval.
+func (h *handler) moderationGuestPosts(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/moderation/guests" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	posts, err := h.service.GetGuestPosts(token.Value)
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	h.app.JSON(w, http.StatusOK, posts)
+}
+
+// moderationGuestPostReview publishes or rejects a guest post. An approved
+// post is shown right away, a rejected one leads back to the queue.
+func (h *handler) moderationGuestPostReview(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/moderation/guests/approve" && r.URL.Path != "/moderation/guests/reject" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := GetIntForm(r, "id")
+	if err != nil || id < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	postID, err := h.service.ReviewGuestPost(token.Value, id, r.URL.Path == "/moderation/guests/approve")
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	if postID == 0 {
+		http.Redirect(w, r, "/moderation/guests", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// moderationComments lists the comments waiting for approval.
+func (h *handler) moderationComments(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/moderation/comments" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	token := cookie.GetSessionCookie(r)
+	data.PendingComments, err = h.service.GetPendingComments(token.Value)
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	if len(*data.PendingComments) == 0 {
+		data.PendingComments = nil
+	}
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	h.app.Render(w, http.StatusOK, "comment_queue.html", data)
+}
+
+// moderationCommentReview approves or rejects the pending comment
+// "commentID" and goes back to the queue.
+func (h *handler) moderationCommentReview(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/moderation/comments/approve" && r.URL.Path != "/moderation/comments/reject" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	commentID, err := GetIntForm(r, "commentID")
+	if err != nil || commentID < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	err = h.service.ReviewComment(token.Value, commentID, r.URL.Path == "/moderation/comments/approve")
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, "/moderation/comments", http.StatusSeeOther)
+}
+
+// moderationMove moves the post "postID" to the category "category" and
+// goes back to the post. The author is notified when "notify" is set.
+func (h *handler) moderationMove(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/moderation/move" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	postID, err := GetIntForm(r, "postID")
+	if err != nil || postID < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	categoryID, err := GetIntForm(r, "category")
+	if err != nil {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	notify := r.FormValue("notify") != ""
+
+	token := cookie.GetSessionCookie(r)
+	err = h.service.MovePost(token.Value, postID, categoryID, notify, h.clientIP(r))
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.UnknownCategory) {
+			h.app.ClientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"encoding/json"
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestModerationBulk(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		action   string
+		posts    []string
+		comments []string
+		wantCode int
+	}{
+		{
+			name:     "Mixed batch with invalid ID",
+			token:    mock.AdminToken,
+			action:   models.ModerationDelete,
+			posts:    []string{"1", "42"},
+			comments: []string{"3"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Regular user",
+			token:    sessionCookieValue,
+			action:   models.ModerationDelete,
+			posts:    []string{"1"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Unknown action",
+			token:    mock.AdminToken,
+			action:   "burn",
+			posts:    []string{"1"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Malformed ID",
+			token:    mock.AdminToken,
+			action:   models.ModerationLock,
+			posts:    []string{"nah"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Empty batch",
+			token:    mock.AdminToken,
+			action:   models.ModerationLock,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("action", tt.action)
+			form["post"] = tt.posts
+			form["comment"] = tt.comments
+
+			code, _, body := ts.postFormWithSession(t, "/moderation/bulk", form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+			if code != http.StatusOK {
+				return
+			}
+
+			var results []models.ModerationResult
+			if err := json.Unmarshal([]byte(body), &results); err != nil {
+				t.Fatal(err)
+			}
+			mock.Equal(t, len(results), 3)
+			for _, result := range results {
+				mock.Equal(t, result.OK, result.ID != 42)
+			}
+		})
+	}
+}
+
+func TestLockReason(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	moderate := func(action, reason string) int {
+		t.Helper()
+		form := url.Values{"action": {action}, "post": {"1"}, "reason": {reason}}
+		code, _, _ := ts.postFormWithSession(t, "/moderation/bulk", form, mock.AdminToken)
+		return code
+	}
+
+	mock.Equal(t, moderate(models.ModerationLock, "  off-topic "), http.StatusOK)
+	_, _, body := ts.get(t, "/post/1")
+	mock.StringContains(t, body, "This post is locked")
+	mock.StringContains(t, body, `<p class="lock-reason">Reason: off-topic</p>`)
+
+	mock.Equal(t, moderate(models.ModerationUnlock, ""), http.StatusOK)
+	_, _, body = ts.get(t, "/post/1")
+	mock.Equal(t, strings.Contains(body, "This post is locked"), false)
+	mock.Equal(t, strings.Contains(body, "off-topic"), false)
+
+	// A lock without a reason shows just the notice.
+	mock.Equal(t, moderate(models.ModerationLock, ""), http.StatusOK)
+	_, _, body = ts.get(t, "/post/1")
+	mock.StringContains(t, body, "This post is locked")
+	mock.Equal(t, strings.Contains(body, "lock-reason"), false)
+
+	mock.Equal(t, moderate(models.ModerationLock, strings.Repeat("x", models.LockReasonMaxLen+1)), http.StatusBadRequest)
+}
+
+func TestModerationCategoryScope(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		posts    []string
+		comments []string
+		wantCode int
+	}{
+		{
+			name:     "Post in own category",
+			token:    mock.CategoryModToken,
+			posts:    []string{"1"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Post in another category",
+			token:    mock.CategoryModToken,
+			posts:    []string{"2"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Comment under post in another category",
+			token:    mock.CategoryModToken,
+			comments: []string{"3"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Batch reaching outside own category",
+			token:    mock.CategoryModToken,
+			posts:    []string{"1", "2"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Global moderator anywhere",
+			token:    mock.AdminToken,
+			posts:    []string{"2"},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("action", models.ModerationDelete)
+			form["post"] = tt.posts
+			form["comment"] = tt.comments
+
+			code, _, _ := ts.postFormWithSession(t, "/moderation/bulk", form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestAdminCategoryModerator(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		action   string
+		category string
+		wantCode int
+	}{
+		{
+			name:     "Admin grants",
+			token:    mock.AdminToken,
+			action:   "add",
+			category: "1",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Admin revokes",
+			token:    mock.AdminToken,
+			action:   "remove",
+			category: "1",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Unknown category",
+			token:    mock.AdminToken,
+			action:   "add",
+			category: "99",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Unknown action",
+			token:    mock.AdminToken,
+			action:   "promote",
+			category: "1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Category moderator",
+			token:    mock.CategoryModToken,
+			action:   "add",
+			category: "2",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("name", "catmod")
+			form.Add("action", tt.action)
+			form.Add("category", tt.category)
+			code, _, _ := ts.postFormWithSession(t, "/admin/moderators", form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestPostLabels(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{PostLabels: models.DefaultPostLabels})
+	defer ts.Close()
+
+	label := func(token, postID, label, action string) int {
+		t.Helper()
+		form := url.Values{"postID": {postID}, "label": {label}, "action": {action}}
+		code, _, _ := ts.postFormWithSession(t, "/moderation/labels", form, token)
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		postID   string
+		label    string
+		action   string
+		wantCode int
+	}{
+		{name: "Moderator", token: mock.AdminToken, postID: "4", label: "Resolved", action: "add", wantCode: http.StatusSeeOther},
+		{name: "Second label", token: mock.AdminToken, postID: "4", label: "Pinned", action: "add", wantCode: http.StatusSeeOther},
+		{name: "Category moderator", token: mock.CategoryModToken, postID: "1", label: "Resolved", action: "add", wantCode: http.StatusSeeOther},
+		{name: "Category moderator elsewhere", token: mock.CategoryModToken, postID: "2", label: "Resolved", action: "add", wantCode: http.StatusForbidden},
+		{name: "Regular user", token: sessionCookieValue, postID: "3", label: "Resolved", action: "add", wantCode: http.StatusForbidden},
+		{name: "Unknown label", token: mock.AdminToken, postID: "3", label: "Spicy", action: "add", wantCode: http.StatusBadRequest},
+		{name: "Unknown action", token: mock.AdminToken, postID: "3", label: "Resolved", action: "toggle", wantCode: http.StatusBadRequest},
+		{name: "Malformed post", token: mock.AdminToken, postID: "nah", label: "Resolved", action: "add", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.Equal(t, label(tt.token, tt.postID, tt.label, tt.action), tt.wantCode)
+		})
+	}
+
+	_, _, body := ts.getWithSession(t, "/post/4", mock.AdminToken)
+	mock.StringContains(t, body, `<a href="/?label=Pinned" class="post-label">Pinned</a>`)
+	mock.StringContains(t, body, `<a href="/?label=Resolved" class="post-label">Resolved</a>`)
+	mock.StringContains(t, body, `action="/moderation/labels"`)
+
+	code, _, body := ts.get(t, "/?label=Resolved")
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, "post 4")
+	mock.StringContains(t, body, "post 1")
+	mock.Equal(t, strings.Contains(body, "post 5"), false)
+
+	code, _, _ = ts.get(t, "/?label=Spicy")
+	mock.Equal(t, code, http.StatusNotFound)
+
+	// Labels come off one at a time.
+	mock.Equal(t, label(mock.AdminToken, "4", "Resolved", "remove"), http.StatusSeeOther)
+	_, _, body = ts.get(t, "/?label=Resolved")
+	mock.Equal(t, strings.Contains(body, "post 4"), false)
+	_, _, body = ts.get(t, "/?label=Pinned")
+	mock.StringContains(t, body, "post 4")
+}
+
+func TestModerationMove(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		postID   string
+		category string
+		wantCode int
+	}{
+		{name: "Regular user", token: sessionCookieValue, postID: "3", category: "2", wantCode: http.StatusForbidden},
+		{name: "Unknown category", token: mock.AdminToken, postID: "3", category: "9", wantCode: http.StatusBadRequest},
+		{name: "Malformed category", token: mock.AdminToken, postID: "3", category: "music", wantCode: http.StatusBadRequest},
+		{name: "Malformed post", token: mock.AdminToken, postID: "nah", category: "2", wantCode: http.StatusBadRequest},
+		{name: "Moderator", token: mock.AdminToken, postID: "3", category: "3", wantCode: http.StatusSeeOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"postID": {tt.postID}, "category": {tt.category}, "notify": {"on"}}
+			code, _, _ := ts.postFormWithSession(t, "/moderation/move", form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+
+	_, _, body := ts.getWithSession(t, "/post/3", mock.AdminToken)
+	mock.StringContains(t, body, `<p class="categoryFooter">Category3</p>`)
+	mock.StringContains(t, body, `action="/moderation/move"`)
+	_, _, body = ts.getWithSession(t, "/post/3", sessionCookieValue)
+	mock.Equal(t, strings.Contains(body, `action="/moderation/move"`), false)
+
+	code, _, body := ts.getWithSession(t, "/admin/audit?action="+models.AuditMovePost, mock.AdminToken)
+	mock.Equal(t, code, http.StatusOK)
+	var log models.AuditPage
+	if err := json.Unmarshal([]byte(body), &log); err != nil {
+		t.Fatal(err)
+	}
+	if len(log.Entries) != 1 {
+		t.Fatalf("got %d audit entries; expected 1", len(log.Entries))
+	}
+	mock.Equal(t, log.Entries[0].ActorName, "admin")
+	mock.Equal(t, log.Entries[0].Target, "post:3 category:3")
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+)
+
+// notificationsLimit is how many of the latest notifications are listed.
+const notificationsLimit = 50
+
+func (h *handler) notifications(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/notifications" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	c := cookie.GetSessionCookie(r)
+	data.Notifications, err = h.service.GetNotifications(c.Value, notificationsLimit)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if len(*data.Notifications) == 0 {
+		data.Notifications = nil
+	}
+	h.app.Render(w, http.StatusOK, "notifications.html", data)
+}
+
+// notificationRead marks a notification as read and takes the user to what
+// it is about.
+func (h *handler) notificationRead(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/notifications/read" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := GetIntForm(r, "notificationID")
+	if err != nil || id < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	c := cookie.GetSessionCookie(r)
+	n, err := h.service.ReadNotification(c.Value, id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+			return
+		}
+		h.app.ServerError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, n.URL(h.urls), http.StatusSeeOther)
+}
+
+// notificationsReadAll marks the notifications up to the newest one the
+// user was shown as read.
+func (h *handler) notificationsReadAll(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/notifications/read-all" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	upTo, err := GetIntForm(r, "upTo")
+	if err != nil || upTo < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	c := cookie.GetSessionCookie(r)
+	if err := h.service.ReadAllNotifications(c.Value, upTo); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/notifications", http.StatusSeeOther)
+}
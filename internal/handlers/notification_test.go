@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	mock "forum/internal/repo/mocks"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNotifications(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	_, _, body := ts.getWithSession(t, "/", sessionCookieValue)
+	mock.StringContains(t, body, "Notifications (1)")
+	_, _, body = ts.getWithSession(t, "/", mock.AdminToken)
+	mock.StringContains(t, body, `<a href="/notifications">Notifications</a>`)
+
+	code, _, body := ts.getWithSession(t, "/notifications", sessionCookieValue)
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, `admin replied to &#34;test&#34;`)
+
+	tests := []struct {
+		name         string
+		id           string
+		token        string
+		wantCode     int
+		wantLocation string
+	}{
+		{name: "Own notification", id: "1", token: sessionCookieValue, wantCode: http.StatusSeeOther, wantLocation: testBaseURL + "/posts/1/comments/2"},
+		{name: "Someone else's", id: "1", token: mock.AdminToken, wantCode: http.StatusNotFound},
+		{name: "Unknown", id: "9", token: sessionCookieValue, wantCode: http.StatusNotFound},
+		{name: "Not a number", id: "one", token: sessionCookieValue, wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("notificationID", tt.id)
+			code, header, _ := ts.postFormWithSession(t, "/notifications/read", form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+			mock.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestCommentNotifiesInOneBatch(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	for _, token := range []string{mock.AdminToken, mock.TopSortToken, mock.TokyoToken} {
+		code, _, _ := ts.postFormWithSession(t, "/post/subscribe", url.Values{"postID": {"1"}}, token)
+		mock.Equal(t, code, http.StatusSeeOther)
+	}
+	form := url.Values{"postID": {"1"}, "comment": {"Nice one @newbie"}}
+	code, _, _ := ts.postFormWithSession(t, "/comment/post", form, mock.CategoryModToken)
+	mock.Equal(t, code, http.StatusSeeOther)
+
+	// The author, three subscribers and the mention are notified together.
+	mock.Equal(t, ts.repo.Calls("CreateNotifications"), 1)
+}
+
+func TestNotificationsReadAll(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	_, _, body := ts.getWithSession(t, "/notifications", sessionCookieValue)
+	mock.StringContains(t, body, `<input type="hidden" name="upTo" value="1" />`)
+
+	code, _, _ := ts.postFormWithSession(t, "/notifications/read-all", url.Values{"upTo": {"x"}}, sessionCookieValue)
+	mock.Equal(t, code, http.StatusBadRequest)
+	code, header, _ := ts.postFormWithSession(t, "/notifications/read-all", url.Values{"upTo": {"1"}}, sessionCookieValue)
+	mock.Equal(t, code, http.StatusSeeOther)
+	mock.Equal(t, header.Get("Location"), "/notifications")
+
+	_, _, body = ts.getWithSession(t, "/notifications", sessionCookieValue)
+	mock.StringContains(t, body, `<a href="/notifications">Notifications</a>`)
+	mock.Equal(t, strings.Contains(body, "/notifications/read-all"), false)
+	mock.Equal(t, strings.Contains(body, "activity-item unread"), false)
+}
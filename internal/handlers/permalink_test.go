@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCommentPermalink(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name         string
+		url          string
+		wantCode     int
+		wantLocation string
+	}{
+		{"Comment", "/posts/1/comments/2", http.StatusFound, "/post/1#comment-2"},
+		{"Comment on another post", "/posts/1/comments/3", http.StatusNotFound, ""},
+		{"Wrong post", "/posts/2/comments/1", http.StatusNotFound, ""},
+		{"No such comment", "/posts/1/comments/99", http.StatusNotFound, ""},
+		{"Bad comment id", "/posts/1/comments/x", http.StatusNotFound, ""},
+		{"Bad post id", "/posts/0/comments/1", http.StatusNotFound, ""},
+		{"Missing comment id", "/posts/1/comments", http.StatusNotFound, ""},
+		{"Trailing slash", "/posts/1/comments/2/", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, _ := ts.get(t, tt.url)
+			mock.Equal(t, code, tt.wantCode)
+			mock.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+
+	code, _, _ := ts.postForm(t, "/posts/1/comments/2", url.Values{})
+	mock.Equal(t, code, http.StatusMethodNotAllowed)
+
+	// Every comment on the post page links to its permalink.
+	_, _, body := ts.get(t, "/post/1")
+	mock.StringContains(t, body, `id="comment-1"`)
+	mock.StringContains(t, body, `href="/posts/1/comments/1"`)
+}
+
+func TestCommentPermalinkPage(t *testing.T) {
+	// Post 1 has comments 1, 2, 4, 5, 8 and 9, three pages of two oldest
+	// first.
+	ts := NewTestServerWithConfig(t, &config.Config{CommentsPerPage: 2})
+	defer ts.Close()
+
+	tests := []struct {
+		name         string
+		url          string
+		wantLocation string
+	}{
+		{"First page", "/posts/1/comments/2", "/post/1#comment-2"},
+		{"Third page", "/posts/1/comments/9", "/post/1?page=3#comment-9"},
+		{"Another order", "/posts/1/comments/9?sort=newest", "/post/1?sort=newest#comment-9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, _ := ts.get(t, tt.url)
+			mock.Equal(t, code, http.StatusFound)
+			mock.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+
+	code, _, body := ts.get(t, "/post/1?page=3")
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, `id="comment-9"`)
+	mock.StringContains(t, body, `id="comment-8"`)
+	mock.StringContains(t, body, `page=2" class="previous"`)
+	if strings.Contains(body, `id="comment-1"`) || strings.Contains(body, `class="next"`) {
+		t.Error("got the first page's comments or a next page on the last page")
+	}
+
+	code, _, body = ts.get(t, "/post/1")
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, `id="comment-1"`)
+	if strings.Contains(body, `id="comment-9"`) {
+		t.Error("got comment 9 on the first page")
+	}
+
+	for _, page := range []string{"4", "0", "x"} {
+		code, _, _ = ts.get(t, "/post/1?page="+page)
+		mock.Equal(t, code, http.StatusNotFound)
+	}
+}
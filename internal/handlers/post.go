@@ -3,10 +3,14 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"forum/app"
 	"forum/models"
 	"forum/pkg/cookie"
 	"forum/pkg/validator"
+	"maps"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -37,6 +41,19 @@ func (h *handler) postCreateGet(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) postCreatePost(w http.ResponseWriter, r *http.Request) {
+	if c := cookie.GetSessionCookie(r); c != nil {
+		err := h.service.CheckPostLimit(c.Value, h.cfg.DailyPostLimits)
+		var limit *models.PostLimitError
+		if errors.As(err, &limit) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limit.RetryAfter.Seconds()))))
+			h.app.ClientError(w, http.StatusTooManyRequests)
+			return
+		}
+		if err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+	}
 	form := models.PostForm{
 		Title:            r.FormValue("title"),
 		Content:          r.FormValue("content"),
@@ -49,10 +66,31 @@ func (h *handler) postCreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	trim(&form.Title, &form.Content)
+	if !h.filterBlocked(&form.Title, &form.Content) {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
 	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
 	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
-	form.CheckField(validator.NotSelected(form.CategoriesString), "categories", "At least one must be selected")
-	form.CheckField(validator.IsError(form.ConverCategories(categories)), "categories", "This field is not correct")
+	if h.cfg.RequireCategory {
+		form.CheckField(validator.NotSelected(form.CategoriesString), "categories", "At least one must be selected")
+	}
+	if err := form.ConverCategories(categories); err != nil {
+		form.AddFieldError("categories", "This field is not correct")
+	} else {
+		form.CheckTags(categories, h.cfg.TagLimits)
+	}
+	cookies := cookie.GetSessionCookie(r)
+	if cookies == nil {
+		form.GuestName = strings.TrimSpace(r.FormValue("guest_name"))
+		form.CheckField(validator.NotBlank(form.GuestName), "guest_name", "This field cannot be blank")
+		form.CheckField(validator.MaxChars(form.GuestName, models.GuestNameMaxLen), "guest_name", fmt.Sprintf("This field must be maximum %d characters", models.GuestNameMaxLen))
+	} else if form.Valid() {
+		if err = h.checkNewUser(&form.Validator, "content", cookies.Value, true, form.Title, form.Content); err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+	}
 
 	if !form.Valid() {
 		data, err := h.NewTemplateData(r)
@@ -66,8 +104,15 @@ func (h *handler) postCreatePost(w http.ResponseWriter, r *http.Request) {
 		h.app.Render(w, http.StatusUnprocessableEntity, "create.html", data)
 		return
 	}
-	cookies := cookie.GetSessionCookie(r)
+	if cookies == nil {
+		h.guestPostCreate(w, r, form)
+		return
+	}
 	postID, err := h.service.CreatePost(form.Title, form.Content, cookies.Value, form.Categories)
+	if errors.Is(err, models.ErrForbidden) {
+		h.restrictedCategories(w, r, form)
+		return
+	}
 	if err != nil {
 		h.app.ServerError(w, err)
 		return
@@ -75,6 +120,43 @@ func (h *handler) postCreatePost(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
 }
 
+// guestPostCreate queues a valid post of a guest for approval.
+func (h *handler) guestPostCreate(w http.ResponseWriter, r *http.Request, form models.PostForm) {
+	_, err := h.service.CreateGuestPost(models.GuestPost{
+		Name:       form.GuestName,
+		IP:         h.clientIP(r),
+		Title:      form.Title,
+		Content:    form.Content,
+		Categories: form.Categories,
+	})
+	if errors.Is(err, models.ErrForbidden) {
+		h.restrictedCategories(w, r, form)
+		return
+	}
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	h.guestSubmitted(w, r)
+}
+
+// restrictedCategories shows the form again when a category picked allows
+// posting to some roles only.
+func (h *handler) restrictedCategories(w http.ResponseWriter, r *http.Request, form models.PostForm) {
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if data.Categories, err = h.service.GetAllCategory(); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	form.AddFieldError("categories", "You may not post in these categories")
+	data.Form = form
+	h.app.Render(w, http.StatusForbidden, "create.html", data)
+}
+
 func (h *handler) postView(w http.ResponseWriter, r *http.Request) {
 	id, _ := strings.CutPrefix(r.URL.Path, "/post/")
 	if strings.Contains(id, "/") {
@@ -86,7 +168,60 @@ func (h *handler) postView(w http.ResponseWriter, r *http.Request) {
 		h.app.ClientError(w, 400)
 		return
 	}
+	h.renderPost(w, r, ID, 0)
+}
+
+// arrangeComments returns the comments of post viewer may see, sorted by
+// sort and, for threaded posts, nested at most maxDepth deep, as the page of
+// the post shows them.
+func arrangeComments(post *models.Post, viewer *models.User, sort string, maxDepth int) []models.Comment {
+	if post.Comment == nil {
+		return nil
+	}
+	visible := models.VisibleComments(*post.Comment, viewer)
+	models.SortComments(visible, sort)
+	if post.CommentMode == models.CommentsThreaded {
+		visible = models.ThreadComments(visible, maxDepth)
+	}
+	return visible
+}
+
+// commentThread shows a post with only the thread of one of its comments,
+// where "continue this thread" links lead.
+func (h *handler) commentThread(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/comments/"), "/")
+	if len(parts) != 2 || parts[1] != "thread" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	commentID, err := strconv.Atoi(parts[0])
+	if err != nil || commentID < 1 {
+		h.app.NotFound(w)
+		return
+	}
 
+	comment, err := h.service.GetComment(commentID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	h.renderPost(w, r, comment.PostID, comment.CommentID)
+}
+
+// renderPost renders the page of the post ID with a page of its comments,
+// the one asked for with ?page=, or with the thread of the comment
+// threadRoot only when it isn't 0.
+func (h *handler) renderPost(w http.ResponseWriter, r *http.Request, ID, threadRoot int) {
+	// The same URL serves HTML or JSON, caches must keep both.
+	w.Header().Add("Vary", "Accept")
 	post, err := h.service.GetPostByID(ID)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
@@ -102,7 +237,37 @@ func (h *handler) postView(w http.ResponseWriter, r *http.Request) {
 		h.app.ServerError(w, err)
 		return
 	}
+	if post.Pending && !data.User.CanManagePost(post) {
+		h.app.ClientError(w, http.StatusNotFound)
+		return
+	}
 	data.Post = post
+	if err := h.service.ScorePost(data.Post, h.cfg.VoteWeights); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Embeds = h.embeds.Embeds(post.Content)
+	scope := models.AnnouncementScope{Categories: slices.Collect(maps.Keys(post.Categories)), PostID: ID}
+	if err := h.setAnnouncements(data, scope); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if data.User != nil {
+		if err := h.service.RecordView(int(data.User.ID), ID, h.cfg.HistorySize); err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+	}
+	data.Post.Reactions, err = h.service.GetPostReactions(data.User, ID, h.cfg.ReactionTypes)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.IsSubscribed, err = h.service.IsSubscribed(data.User, ID)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
 	token := cookie.GetSessionCookie(r)
 	if token != nil {
 		exists, reaction, err := h.service.GetReactionPost(token.Value, ID)
@@ -125,6 +290,62 @@ func (h *handler) postView(w http.ResponseWriter, r *http.Request) {
 		data.Post = h.service.IsLikedComment(data.Post, reactions)
 	}
 
+	data.CommentSort = r.URL.Query().Get("sort")
+	if !models.ValidCommentSort(data.CommentSort) {
+		data.CommentSort = h.cfg.CommentSorts.For(post.Categories)
+	}
+	data.CurrentPage, data.NumberOfPage = 1, 1
+	if data.Post.Comment != nil {
+		var visible []models.Comment
+		if threadRoot != 0 {
+			visible = models.VisibleComments(*data.Post.Comment, data.User)
+			models.SortComments(visible, data.CommentSort)
+			visible = models.CommentThread(visible, threadRoot, h.cfg.ThreadDisplayDepth)
+		} else {
+			visible = arrangeComments(data.Post, data.User, data.CommentSort, h.cfg.ThreadDisplayDepth)
+		}
+		models.CollapseComments(visible, h.cfg.CollapseThreshold)
+		if threadRoot == 0 {
+			data.Post.HotComment = models.HotComment(visible, h.cfg.HotCommentScore, data.Post.AcceptedAnswerID)
+			pages := models.CommentPages(visible, h.cfg.CommentsPerPage)
+			if page := r.URL.Query().Get("page"); page != "" {
+				data.CurrentPage, err = strconv.Atoi(page)
+				if err != nil || data.CurrentPage < 1 || data.CurrentPage > max(len(pages), 1) {
+					h.app.NotFound(w)
+					return
+				}
+			}
+			if len(pages) > 0 {
+				data.NumberOfPage = len(pages)
+				visible = pages[data.CurrentPage-1]
+			}
+		}
+		data.Post.Comment = &visible
+	}
+	// The thread of a comment the viewer can't see isn't there either.
+	if threadRoot != 0 && (data.Post.Comment == nil || len(*data.Post.Comment) == 0) {
+		h.app.NotFound(w)
+		return
+	}
+	data.ThreadRoot = threadRoot
+
+	// The comments come with their counts and the viewer's reactions to
+	// all of them with one query each, so JSON clients get them as is.
+	if app.WantsJSON(r) {
+		hidden := h.app.ReactionsHidden(post.Created)
+		h.app.JSON(w, http.StatusOK, models.NewPostDetail(data.Post, data.CurrentPage, data.NumberOfPage, hidden))
+		return
+	}
+
+	data.Related, err = h.service.GetRelatedPosts(ID)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if len(*data.Related) == 0 {
+		data.Related = nil
+	}
+
 	data.Form = models.CommentForm{}
 	data.Categories, err = h.service.GetAllCategory()
 	if err != nil {
@@ -178,6 +399,10 @@ func (h *handler) PostByUser(w http.ResponseWriter, r *http.Request) {
 		data.Posts = h.service.IsLikedPost(data.Posts, reactions)
 	}
 
+	if err := h.service.ScorePosts(data.Posts, h.cfg.VoteWeights); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
 	if len(*data.Posts) == 0 {
 		data.Posts = nil
 	}
@@ -229,9 +454,239 @@ func (h *handler) LikedPosts(w http.ResponseWriter, r *http.Request) {
 		data.Posts = h.service.IsLikedPost(data.Posts, reactions)
 	}
 
+	if err := h.service.ScorePosts(data.Posts, h.cfg.VoteWeights); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
 	if len(*data.Posts) == 0 {
 		data.Posts = nil
 	}
 
 	h.app.Render(w, http.StatusOK, "home.html", data)
 }
+
+// participated lists the posts the user commented on, the one with the
+// latest comment first, to follow the conversations they joined.
+func (h *handler) participated(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/my/participated" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data, err = h.service.SetUpPage(data, r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+
+	data.Posts, err = h.service.GetParticipatedPostsPaginated(int(data.User.ID), data.CurrentPage, data.Limit)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	c := cookie.GetSessionCookie(r)
+	reactions, err := h.service.GetReactionPosts(c.Value)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Posts = h.service.IsLikedPost(data.Posts, reactions)
+	if err := h.service.ScorePosts(data.Posts, h.cfg.VoteWeights); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if len(*data.Posts) == 0 {
+		data.Posts = nil
+	}
+
+	h.app.Render(w, http.StatusOK, "participated.html", data)
+}
+
+func (h *handler) postAnswer(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/post/answer" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	postID, err := GetIntForm(r, "postID")
+	if err != nil || postID < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	// An empty commentID unsets the accepted answer.
+	var commentID int
+	if r.FormValue("commentID") != "" {
+		commentID, err = GetIntForm(r, "commentID")
+		if err != nil || commentID < 1 {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	token := cookie.GetSessionCookie(r)
+	err = h.service.SetAcceptedAnswer(token.Value, postID, commentID)
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrForeignComment) {
+			h.app.ClientError(w, http.StatusBadRequest)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// postPin toggles whether a post is pinned to its author's profile.
+func (h *handler) postPin(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/post/pin" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	postID, err := GetIntForm(r, "postID")
+	if err != nil || postID < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	err = h.service.ToggleProfilePin(token.Value, postID, h.cfg.ProfilePins)
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) || errors.Is(err, models.ErrPinLimit) {
+			h.app.ClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	user, err := h.service.GetUser(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	http.Redirect(w, r, "/user/"+user.Name, http.StatusSeeOther)
+}
+
+func (h *handler) postEdit(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/post/edit" {
+		h.app.NotFound(w)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodPost:
+	default:
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	postID, err := GetIntForm(r, "postID")
+	if err != nil || postID < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	token := cookie.GetSessionCookie(r)
+	post, err := h.service.GetPostForEdit(token.Value, postID)
+	if err != nil {
+		h.postEditError(w, err)
+		return
+	}
+
+	form := models.PostForm{Title: post.Title, Content: post.Content}
+	if r.Method == http.MethodPost {
+		form.Title, form.Content = r.FormValue("title"), r.FormValue("content")
+		trim(&form.Title, &form.Content)
+		if !h.filterBlocked(&form.Title, &form.Content) {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
+		form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+		if form.Valid() {
+			if err = h.checkNewUser(&form.Validator, "content", token.Value, false, form.Title, form.Content); err != nil {
+				h.app.ServerError(w, err)
+				return
+			}
+		}
+		if form.Valid() {
+			if err = h.service.EditPost(token.Value, postID, form.Title, form.Content, h.cfg.EditGrace); err != nil {
+				h.postEditError(w, err)
+				return
+			}
+			http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+			return
+		}
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Post = post
+	data.Form = form
+	status := http.StatusOK
+	if !form.Valid() {
+		status = http.StatusUnprocessableEntity
+	}
+	h.app.Render(w, status, "edit.html", data)
+}
+
+func (h *handler) postEditError(w http.ResponseWriter, err error) {
+	if errors.Is(err, models.ErrForbidden) {
+		h.app.ClientError(w, http.StatusForbidden)
+	} else if errors.Is(err, models.ErrNoRecord) {
+		h.app.NotFound(w)
+	} else {
+		h.app.ServerError(w, err)
+	}
+}
+
+// checkNewUser adds a field error explaining what a new account can't do yet
+// when the form does it. posting tells whether the form makes a new post.
+func (h *handler) checkNewUser(v *validator.Validator, field, token string, posting bool, texts ...string) error {
+	rules := h.newUserRules()
+	err := h.service.CheckNewUser(token, rules, posting, texts...)
+	switch {
+	case errors.Is(err, models.ErrNewUserLinks):
+		v.AddFieldError(field, "New accounts can't post links yet")
+	case errors.Is(err, models.ErrNewUserPostLimit):
+		v.AddFieldError(field, fmt.Sprintf("New accounts can make %d posts a day, try again tomorrow", rules.PostsPerDay))
+	case err != nil:
+		return err
+	}
+	return nil
+}
+
+func (h *handler) newUserRules() models.NewUserRules {
+	return models.NewUserRules{
+		MinAge:      h.cfg.NewUserAge,
+		MinPosts:    h.cfg.NewUserPosts,
+		PostsPerDay: h.cfg.NewUserPostsPerDay,
+	}
+}
@@ -0,0 +1,1010 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"forum/pkg/blocklist"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostAcceptedAnswer(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name      string
+		token     string
+		commentID string
+		wantCode  int
+	}{
+		{
+			name:      "Author sets answer",
+			token:     sessionCookieValue,
+			commentID: "1",
+			wantCode:  http.StatusSeeOther,
+		},
+		{
+			name:      "Author changes answer",
+			token:     sessionCookieValue,
+			commentID: "2",
+			wantCode:  http.StatusSeeOther,
+		},
+		{
+			name:     "Author unsets answer",
+			token:    sessionCookieValue,
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:      "Moderator sets answer",
+			token:     mock.AdminToken,
+			commentID: "1",
+			wantCode:  http.StatusSeeOther,
+		},
+		{
+			name:      "Comment from another post",
+			token:     sessionCookieValue,
+			commentID: "3",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Missing comment",
+			token:     sessionCookieValue,
+			commentID: "42",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Not the author",
+			token:     mock.HermitToken,
+			commentID: "1",
+			wantCode:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("postID", "1")
+			form.Add("commentID", tt.commentID)
+
+			code, _, _ := ts.postFormWithSession(t, "/post/answer", form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestPostAcceptedAnswerRender(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	code, _, body := ts.getWithSession(t, "/post/1", sessionCookieValue)
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, `<div class="accepted-answer">`)
+	mock.StringContains(t, body, "Unmark answer")
+
+	code, _, body = ts.get(t, "/post/1")
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, `<div class="accepted-answer">`)
+	if strings.Contains(body, "Mark as answer") {
+		t.Errorf("guests must not see the mark as answer button")
+	}
+}
+
+func TestPostRelatedRender(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/post/1")
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, "Related posts")
+	mock.StringContains(t, body, `<a href="/post/2">related post</a>`)
+}
+
+func TestPostCreateBlocklist(t *testing.T) {
+	bl := blocklist.New([]string{"bad word"})
+
+	tests := []struct {
+		name     string
+		mode     string
+		title    string
+		wantCode int
+	}{
+		{
+			name:     "Reject blocked phrase in title",
+			mode:     blocklist.ModeReject,
+			title:    "A Bad Word here",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Clean title",
+			mode:     blocklist.ModeReject,
+			title:    "Bad wordplay",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Mask blocked phrase",
+			mode:     blocklist.ModeMask,
+			title:    "A bad word here",
+			wantCode: http.StatusSeeOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithBlocklist(t, &config.Config{BlocklistMode: tt.mode}, bl)
+			defer ts.Close()
+
+			form := url.Values{}
+			form.Add("title", tt.title)
+			form.Add("content", "some content")
+			form.Add("categories", "1")
+
+			code, _, _ := ts.postFormWithSession(t, "/post/create", form, sessionCookieValue)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestPostEdit(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		postID   string
+		wantCode int
+		wantNote string
+	}{
+		{
+			name:     "Author edit",
+			token:    sessionCookieValue,
+			postID:   "1",
+			wantCode: http.StatusSeeOther,
+			wantNote: "Edited by the author",
+		},
+		{
+			name:     "Moderator edit",
+			token:    mock.AdminToken,
+			postID:   "1",
+			wantCode: http.StatusSeeOther,
+			wantNote: "Edited by moderator admin",
+		},
+		{
+			name:     "Category moderator edit",
+			token:    mock.CategoryModToken,
+			postID:   "1",
+			wantCode: http.StatusSeeOther,
+			wantNote: "Edited by moderator catmod",
+		},
+		{
+			name:     "Category moderator outside own category",
+			token:    mock.CategoryModToken,
+			postID:   "2",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Another user",
+			token:    mock.HermitToken,
+			postID:   "1",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServer(t)
+			defer ts.Close()
+
+			form := url.Values{}
+			form.Add("postID", tt.postID)
+			form.Add("title", "edited title")
+			form.Add("content", "edited content")
+			code, _, _ := ts.postFormWithSession(t, "/post/edit", form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+			if tt.wantNote == "" {
+				return
+			}
+
+			_, _, body := ts.get(t, "/post/"+tt.postID)
+			mock.StringContains(t, body, tt.wantNote)
+		})
+	}
+}
+
+func TestPostEditForm(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	code, _, body := ts.getWithSession(t, "/post/edit?postID=1", sessionCookieValue)
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, `action="/post/edit"`)
+
+	form := url.Values{}
+	form.Add("postID", "1")
+	form.Add("title", "")
+	form.Add("content", "still here")
+	code, _, _ = ts.postFormWithSession(t, "/post/edit", form, sessionCookieValue)
+	mock.Equal(t, code, http.StatusUnprocessableEntity)
+
+	code, _, _ = ts.getWithSession(t, "/post/edit?postID=nah", sessionCookieValue)
+	mock.Equal(t, code, http.StatusBadRequest)
+}
+
+func TestPostProfilePin(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{ProfilePins: 2})
+	defer ts.Close()
+
+	pin := func(postID, token string) int {
+		form := url.Values{}
+		form.Add("postID", postID)
+		code, _, _ := ts.postFormWithSession(t, "/post/pin", form, token)
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		postID   string
+		token    string
+		wantCode int
+	}{
+		{name: "Someone else's post", postID: "1", token: mock.AdminToken, wantCode: http.StatusForbidden},
+		{name: "Owner pins", postID: "1", token: sessionCookieValue, wantCode: http.StatusSeeOther},
+		{name: "Owner pins up to the cap", postID: "2", token: sessionCookieValue, wantCode: http.StatusSeeOther},
+		{name: "Owner goes over the cap", postID: "3", token: sessionCookieValue, wantCode: http.StatusForbidden},
+		{name: "Owner unpins", postID: "2", token: sessionCookieValue, wantCode: http.StatusSeeOther},
+		{name: "Room for another one", postID: "3", token: sessionCookieValue, wantCode: http.StatusSeeOther},
+		{name: "Not a post ID", postID: "x", token: sessionCookieValue, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.Equal(t, pin(tt.postID, tt.token), tt.wantCode)
+		})
+	}
+}
+
+func TestNewUserRestrictions(t *testing.T) {
+	cfg := &config.Config{NewUserAge: 72 * time.Hour, NewUserPosts: 3, NewUserPostsPerDay: 2}
+
+	tests := []struct {
+		name     string
+		cfg      *config.Config
+		token    string
+		url      string
+		form     url.Values
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "New account posting a link",
+			cfg:      cfg,
+			token:    mock.NewbieToken,
+			url:      "/post/create",
+			form:     url.Values{"title": {"Deals"}, "content": {"see https://spam.example"}, "categories": {"1"}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "New accounts can&#39;t post links yet",
+		},
+		{
+			name:     "New account posting a Markdown link in the title",
+			cfg:      cfg,
+			token:    mock.NewbieToken,
+			url:      "/post/create",
+			form:     url.Values{"title": {"[Deals](https://spam.example)"}, "content": {"hi"}, "categories": {"1"}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "New accounts can&#39;t post links yet",
+		},
+		{
+			name:     "New account posting without links",
+			cfg:      cfg,
+			token:    mock.NewbieToken,
+			url:      "/post/create",
+			form:     url.Values{"title": {"Hello"}, "content": {"first post"}, "categories": {"1"}},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "New account over its daily posts",
+			cfg:      &config.Config{NewUserAge: 72 * time.Hour, NewUserPostsPerDay: mock.NewbiePostsToday},
+			token:    mock.NewbieToken,
+			url:      "/post/create",
+			form:     url.Values{"title": {"Hello"}, "content": {"second post"}, "categories": {"1"}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "New accounts can make 1 posts a day",
+		},
+		{
+			name:     "New account commenting a link",
+			cfg:      cfg,
+			token:    mock.NewbieToken,
+			url:      "/comment/post",
+			form:     url.Values{"postID": {"1"}, "comment": {"www.spam.example"}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "New accounts can&#39;t post links yet",
+		},
+		{
+			name:     "Established account posting a link",
+			cfg:      cfg,
+			token:    sessionCookieValue,
+			url:      "/post/create",
+			form:     url.Values{"title": {"Docs"}, "content": {"see https://go.dev"}, "categories": {"1"}},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Moderator",
+			cfg:      cfg,
+			token:    mock.AdminToken,
+			url:      "/post/create",
+			form:     url.Values{"title": {"Docs"}, "content": {"see https://go.dev"}, "categories": {"1"}},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Restrictions off",
+			cfg:      &config.Config{},
+			token:    mock.NewbieToken,
+			url:      "/post/create",
+			form:     url.Values{"title": {"Deals"}, "content": {"see https://spam.example"}, "categories": {"1"}},
+			wantCode: http.StatusSeeOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, tt.cfg)
+			defer ts.Close()
+
+			code, _, body := ts.postFormWithSession(t, tt.url, tt.form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+			if tt.wantBody != "" {
+				mock.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestArchivedPost(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	archived := strconv.Itoa(mock.ArchivedPostID)
+	_, _, body := ts.get(t, "/post/"+archived)
+	mock.StringContains(t, body, "This post is archived")
+	mock.Equal(t, strings.Contains(body, `action="/comment/post"`), false)
+
+	form := url.Values{"postID": {archived}, "comment": {"anyone here?"}}
+	code, _, _ := ts.postFormWithSession(t, "/comment/post", form, sessionCookieValue)
+	mock.Equal(t, code, http.StatusForbidden)
+
+	form = url.Values{"postID": {archived}, "reaction": {"true"}}
+	code, _, _ = ts.postFormWithSession(t, "/post/reaction", form, sessionCookieValue)
+	mock.Equal(t, code, http.StatusForbidden)
+
+	// Active posts still take them.
+	form = url.Values{"postID": {"1"}, "reaction": {"true"}}
+	code, _, _ = ts.postFormWithSession(t, "/post/reaction", form, sessionCookieValue)
+	mock.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestPostScoreWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights models.VoteWeights
+		want    string
+	}{
+		{name: "Unset weights", want: `<p class="score">Score: 1</p>`},
+		{name: "Admins count more", weights: models.VoteWeights{1, 2, 3}, want: `<p class="score">Score: 3</p>`},
+		{name: "Only staff counts", weights: models.VoteWeights{0, 1, 2}, want: `<p class="score">Score: 2</p>`},
+		{name: "Admins ignored", weights: models.VoteWeights{2, 1, 0}, want: `<p class="score">Score: 0</p>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{VoteWeights: tt.weights})
+			defer ts.Close()
+
+			code, _, body := ts.get(t, "/post/"+strconv.Itoa(mock.ScoredPostID))
+			mock.Equal(t, code, http.StatusOK)
+			mock.StringContains(t, body, tt.want)
+		})
+	}
+}
+
+func TestGuestPosting(t *testing.T) {
+	post := url.Values{"guest_name": {"Visitor"}, "title": {"Hello"}, "content": {"from a guest"}, "categories": {"0"}}
+	comment := url.Values{"guest_name": {"Visitor"}, "postID": {"1"}, "comment": {"guest reply"}}
+
+	t.Run("Disabled", func(t *testing.T) {
+		ts := NewTestServer(t)
+		defer ts.Close()
+
+		code, _, _ := ts.postForm(t, "/post/create", post)
+		mock.Equal(t, code, http.StatusUnauthorized)
+		code, _, _ = ts.postForm(t, "/comment/post", comment)
+		mock.Equal(t, code, http.StatusUnauthorized)
+		code, _, body := ts.get(t, "/post/1")
+		mock.Equal(t, code, http.StatusOK)
+		mock.Equal(t, strings.Contains(body, `name="guest_name"`), false)
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		ts := NewTestServerWithConfig(t, &config.Config{GuestPosting: true})
+		defer ts.Close()
+
+		code, _, body := ts.get(t, "/post/create")
+		mock.Equal(t, code, http.StatusOK)
+		mock.StringContains(t, body, `name="guest_name"`)
+
+		code, _, _ = ts.postForm(t, "/post/create", url.Values{"title": {"Hello"}, "content": {"nameless"}, "categories": {"0"}})
+		mock.Equal(t, code, http.StatusUnprocessableEntity)
+
+		code, _, body = ts.postForm(t, "/post/create", post)
+		mock.Equal(t, code, http.StatusAccepted)
+		mock.StringContains(t, body, "once a moderator approves it")
+
+		// The post waits in the queue, which only moderators see.
+		code, _, _ = ts.getWithSession(t, "/moderation/guests", sessionCookieValue)
+		mock.Equal(t, code, http.StatusForbidden)
+		code, _, body = ts.getWithSession(t, "/moderation/guests", mock.AdminToken)
+		mock.Equal(t, code, http.StatusOK)
+		var queue []models.GuestPost
+		if err := json.Unmarshal([]byte(body), &queue); err != nil {
+			t.Fatal(err)
+		}
+		mock.Equal(t, len(queue), 1)
+		mock.Equal(t, queue[0].Name, "Visitor")
+		mock.Equal(t, queue[0].IP, "127.0.0.1")
+		mock.Equal(t, queue[0].Title, "Hello")
+
+		// It is a pending post, which only moderators may open.
+		id := strconv.Itoa(queue[0].ID)
+		code, _, _ = ts.getWithSession(t, "/post/"+id, sessionCookieValue)
+		mock.Equal(t, code, http.StatusNotFound)
+		code, _, _ = ts.getWithSession(t, "/post/"+id, mock.AdminToken)
+		mock.Equal(t, code, http.StatusOK)
+
+		code, header, _ := ts.postFormWithSession(t, "/moderation/guests/approve", url.Values{"id": {id}}, mock.AdminToken)
+		mock.Equal(t, code, http.StatusSeeOther)
+		mock.Equal(t, header.Get("Location"), "/post/"+id)
+		code, _, _ = ts.getWithSession(t, "/post/"+id, sessionCookieValue)
+		mock.Equal(t, code, http.StatusOK)
+		code, _, _ = ts.postFormWithSession(t, "/moderation/guests/reject", url.Values{"id": {id}}, mock.AdminToken)
+		mock.Equal(t, code, http.StatusNotFound)
+
+		// Comments of guests join the comment approval queue.
+		code, _, _ = ts.postForm(t, "/comment/post", comment)
+		mock.Equal(t, code, http.StatusAccepted)
+		_, _, body = ts.getWithSession(t, "/moderation/comments", mock.AdminToken)
+		mock.StringContains(t, body, "Visitor on post 1")
+		mock.StringContains(t, body, "guest reply")
+	})
+}
+
+func TestDailyPostLimit(t *testing.T) {
+	form := url.Values{"title": {"Hello"}, "content": {"again"}, "categories": {"1"}}
+
+	tests := []struct {
+		name           string
+		limits         models.DailyPostLimits
+		token          string
+		wantCode       int
+		wantRetryAfter string
+	}{
+		{
+			name:     "No limit",
+			token:    sessionCookieValue,
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Under the limit",
+			limits:   models.DailyPostLimits{mock.PostsToday + 1, 0, 0},
+			token:    sessionCookieValue,
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			// The oldest of the day's posts is 3 hours old, so the next post
+			// is allowed in 21 hours.
+			name:           "At the limit",
+			limits:         models.DailyPostLimits{mock.PostsToday, 0, 0},
+			token:          sessionCookieValue,
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: strconv.Itoa(21 * 60 * 60),
+		},
+		{
+			// All of the day's posts, down to the newest one made an hour
+			// ago, have to age out first.
+			name:           "Far over the limit",
+			limits:         models.DailyPostLimits{1, 0, 0},
+			token:          sessionCookieValue,
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: strconv.Itoa(23 * 60 * 60),
+		},
+		{
+			name:     "Higher trust, higher limit",
+			limits:   models.DailyPostLimits{mock.PostsToday, mock.PostsToday, mock.PostsToday + 1},
+			token:    mock.AdminToken,
+			wantCode: http.StatusSeeOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{DailyPostLimits: tt.limits})
+			defer ts.Close()
+
+			code, header, _ := ts.postFormWithSession(t, "/post/create", form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+			if tt.wantRetryAfter != "" {
+				mock.Equal(t, header.Get("Retry-After"), tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestCommentMode(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	setMode := func(token, category, mode string) int {
+		t.Helper()
+		form := url.Values{"category": {category}, "mode": {mode}}
+		code, _, _ := ts.postFormWithSession(t, "/admin/categories/comments", form, token)
+		return code
+	}
+
+	_, _, body := ts.get(t, "/post/1")
+	if strings.Contains(body, "comment-nested") {
+		t.Errorf("comments must be flat by default")
+	}
+
+	mock.Equal(t, setMode(sessionCookieValue, "1", models.CommentsThreaded), http.StatusForbidden)
+	mock.Equal(t, setMode(mock.AdminToken, "1", "nested"), http.StatusBadRequest)
+	mock.Equal(t, setMode(mock.AdminToken, "99", models.CommentsThreaded), http.StatusBadRequest)
+
+	// Post 1 is in categories 1 and 2, it stays flat while one of them is.
+	mock.Equal(t, setMode(mock.AdminToken, "1", models.CommentsThreaded), http.StatusSeeOther)
+	_, _, body = ts.get(t, "/post/1")
+	if strings.Contains(body, "comment-nested") {
+		t.Errorf("a post in a flat category must be shown flat")
+	}
+
+	mock.Equal(t, setMode(mock.AdminToken, "2", models.CommentsThreaded), http.StatusSeeOther)
+	code, _, body := ts.get(t, "/post/1")
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, `<div class="comment comment-nested depth-1" id="comment-2">`)
+	if strings.Contains(body, `comment-nested depth-1" id="comment-4"`) {
+		t.Errorf("comments that reply to nothing must not be indented")
+	}
+}
+
+func TestCategoryPostStatus(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	setStatus := func(token, category, status string) int {
+		t.Helper()
+		form := url.Values{"category": {category}, "status": {status}}
+		code, _, _ := ts.postFormWithSession(t, "/admin/categories/posting", form, token)
+		return code
+	}
+	admin := strconv.Itoa(models.StatusAdmin)
+
+	mock.Equal(t, setStatus(sessionCookieValue, "1", admin), http.StatusForbidden)
+	mock.Equal(t, setStatus(mock.AdminToken, "1", "9"), http.StatusBadRequest)
+	mock.Equal(t, setStatus(mock.AdminToken, "99", admin), http.StatusBadRequest)
+	// Category 1 is the first of the form's, picked as "0".
+	mock.Equal(t, setStatus(mock.AdminToken, "1", admin), http.StatusSeeOther)
+
+	post := func(token, category string) (int, string) {
+		t.Helper()
+		form := url.Values{"title": {"Announcement"}, "content": {"Read me"}, "categories": {category}}
+		code, _, body := ts.postFormWithSession(t, "/post/create", form, token)
+		return code, body
+	}
+
+	code, body := post(sessionCookieValue, "0")
+	mock.Equal(t, code, http.StatusForbidden)
+	mock.StringContains(t, body, "You may not post in these categories")
+	code, _ = post(sessionCookieValue, "1")
+	mock.Equal(t, code, http.StatusSeeOther)
+	code, _ = post(mock.AdminToken, "0")
+	mock.Equal(t, code, http.StatusSeeOther)
+
+	// Anyone may still comment on the posts there.
+	form := url.Values{"postID": {"1"}, "comment": {"Noted"}}
+	code, _, _ = ts.postFormWithSession(t, "/comment/post", form, sessionCookieValue)
+	mock.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestPostDetailJSON(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	header := http.Header{"Accept": {"application/json"}, "Cookie": {sessionIDCookie + "=" + sessionCookieValue}}
+	code, rsHeader, body := ts.request(t, http.MethodGet, "/post/1", header)
+	mock.Equal(t, code, http.StatusOK)
+	mock.Equal(t, rsHeader.Get("Content-Type"), "application/json")
+	mock.Equal(t, rsHeader.Get("Vary"), "Accept")
+
+	var detail models.PostDetail
+	if err := json.Unmarshal([]byte(body), &detail); err != nil {
+		t.Fatal(err)
+	}
+	mock.Equal(t, detail.ID, 1)
+	mock.Equal(t, detail.Mine, 1)
+	mock.Equal(t, detail.ScoresHidden, false)
+
+	type summary struct{ score, mine int }
+	got := map[int]summary{}
+	for _, c := range detail.Comments {
+		got[c.ID] = summary{c.Score, c.Mine}
+	}
+	want := map[int]summary{1: {0, 1}, 2: {0, 0}, 4: {-8, -1}, 5: {-5, 0}, 8: {5, 0}, 9: {8, 0}}
+	if !maps.Equal(got, want) {
+		t.Errorf("got comments %v; expected %v", got, want)
+	}
+
+	// One lookup for all the comments and one for the viewer's reactions
+	// to them, however many comments there are.
+	mock.Equal(t, ts.repo.Calls("GetCommentsByPostID"), 1)
+	mock.Equal(t, ts.repo.Calls("GetReactionComments"), 1)
+
+	// Guests have no reactions to look up.
+	code, _, body = ts.request(t, http.MethodGet, "/post/1", http.Header{"Accept": {"application/json"}})
+	mock.Equal(t, code, http.StatusOK)
+	if err := json.Unmarshal([]byte(body), &detail); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range detail.Comments {
+		if c.Mine != 0 {
+			t.Errorf("got comment %d with reaction %d for a guest", c.ID, c.Mine)
+		}
+	}
+	mock.Equal(t, ts.repo.Calls("GetReactionComments"), 1)
+
+	// The page is HTML by the same URL.
+	code, rsHeader, _ = ts.get(t, "/post/1")
+	mock.Equal(t, code, http.StatusOK)
+	mock.Equal(t, rsHeader.Get("Vary"), "Accept")
+
+	// Only the score of the post is held back, as on the page.
+	hiding := NewTestServerWithConfig(t, &config.Config{HideReactionsFor: time.Hour})
+	defer hiding.Close()
+	hiding.handler.app.Now = func() time.Time { return time.Time{} }
+	_, _, body = hiding.request(t, http.MethodGet, "/post/1", header)
+	detail = models.PostDetail{}
+	if err := json.Unmarshal([]byte(body), &detail); err != nil {
+		t.Fatal(err)
+	}
+	mock.Equal(t, detail.ScoresHidden, true)
+	mock.Equal(t, detail.Score, 0)
+	got = map[int]summary{}
+	for _, c := range detail.Comments {
+		got[c.ID] = summary{c.Score, c.Mine}
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got comments %v while hidden; expected %v", got, want)
+	}
+}
+
+func TestPostEmojiReactions(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{ReactionTypes: models.DefaultReactionTypes})
+	defer ts.Close()
+
+	button := regexp.MustCompile(`class="emojiButton( emojiOn)?" name="type" value="([^"]+)">\s*\S+ <span class="emojiCount">(\d+)</span>`)
+	// reactions returns the counts shown on post 1, and the type marked
+	// as the viewer's.
+	reactions := func() (map[string]string, string) {
+		t.Helper()
+		code, _, body := ts.getWithSession(t, "/post/1", sessionCookieValue)
+		mock.Equal(t, code, http.StatusOK)
+		counts := map[string]string{}
+		var mine string
+		for _, m := range button.FindAllStringSubmatch(body, -1) {
+			counts[m[2]] = m[3]
+			if m[1] != "" {
+				mine = m[2]
+			}
+		}
+		return counts, mine
+	}
+	react := func(reaction string) int {
+		t.Helper()
+		form := url.Values{"postID": {"1"}, "type": {reaction}}
+		code, _, _ := ts.postFormWithSession(t, "/post/reaction", form, sessionCookieValue)
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		reaction string
+		wantCode int
+		want     map[string]string
+		wantMine string
+	}{
+		{name: "First reaction", reaction: "👍", wantCode: http.StatusSeeOther, want: map[string]string{"👍": "1", "❤️": "1", "😂": "0"}, wantMine: "👍"},
+		{name: "Switching replaces it", reaction: "😂", wantCode: http.StatusSeeOther, want: map[string]string{"👍": "0", "❤️": "1", "😂": "1"}, wantMine: "😂"},
+		{name: "Same type takes it back", reaction: "😂", wantCode: http.StatusSeeOther, want: map[string]string{"👍": "0", "❤️": "1", "😂": "0"}},
+		{name: "Counts add up across users", reaction: "❤️", wantCode: http.StatusSeeOther, want: map[string]string{"👍": "0", "❤️": "2", "😂": "0"}, wantMine: "❤️"},
+		{name: "Unknown type", reaction: "🍕", wantCode: http.StatusBadRequest, want: map[string]string{"👍": "0", "❤️": "2", "😂": "0"}, wantMine: "❤️"},
+	}
+
+	counts, mine := reactions()
+	mock.Equal(t, len(counts), 3)
+	mock.Equal(t, counts["❤️"], "1")
+	mock.Equal(t, mine, "")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.Equal(t, react(tt.reaction), tt.wantCode)
+			counts, mine := reactions()
+			for reaction, want := range tt.want {
+				if counts[reaction] != want {
+					t.Errorf("%s: got %q; expected %q", reaction, counts[reaction], want)
+				}
+			}
+			mock.Equal(t, mine, tt.wantMine)
+		})
+	}
+
+	// Likes are votes apart from the emoji reactions.
+	code, _, _ := ts.postFormWithSession(t, "/post/reaction", url.Values{"postID": {"1"}, "reaction": {"true"}}, sessionCookieValue)
+	mock.Equal(t, code, http.StatusSeeOther)
+	_, mine = reactions()
+	mock.Equal(t, mine, "❤️")
+}
+
+func TestReactionState(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{ReactionDedup: 2 * time.Second})
+	defer ts.Close()
+
+	react := func(path string, form url.Values) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.AddCookie(&http.Cookie{Name: sessionIDCookie, Value: sessionCookieValue})
+		rs, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rs.Body.Close()
+		var state models.ReactionState
+		if err := json.NewDecoder(rs.Body).Decode(&state); err != nil {
+			t.Fatal(err)
+		}
+		return rs.StatusCode, fmt.Sprintf("%+v", state)
+	}
+
+	code, state := react("/post/reaction", url.Values{"postID": {"1"}, "reaction": {"true"}})
+	mock.Equal(t, code, http.StatusOK)
+	mock.Equal(t, state, "{Like:1 Dislike:0 Mine:1 Hidden:false}")
+	code, state = react("/comment/reaction", url.Values{"postID": {"1"}, "commentID": {"1"}, "reaction": {"false"}})
+	mock.Equal(t, code, http.StatusOK)
+	mock.Equal(t, state, "{Like:0 Dislike:1 Mine:-1 Hidden:false}")
+
+	// Forms still get redirected back.
+	code, _, _ = ts.postFormWithSession(t, "/post/reaction", url.Values{"postID": {"1"}, "reaction": {"true"}}, sessionCookieValue)
+	mock.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestPostEmbeds(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains []string
+		want    bool
+	}{
+		{name: "Trusted", domains: []string{"youtube.com"}, want: true},
+		{name: "Not trusted", domains: []string{"vimeo.com"}},
+		{name: "None trusted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{EmbedDomains: tt.domains})
+			defer ts.Close()
+
+			code, _, body := ts.get(t, "/post/"+strconv.Itoa(mock.EmbedPostID))
+			mock.Equal(t, code, http.StatusOK)
+			mock.Equal(t, strings.Contains(body, `src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ"`), tt.want)
+			// The arbitrary page is never embedded.
+			mock.Equal(t, strings.Count(body, "<iframe"), map[bool]int{true: 1}[tt.want])
+		})
+	}
+}
+
+func TestContinueThread(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{ThreadDisplayDepth: 2})
+	defer ts.Close()
+
+	for _, category := range []string{"1", "2"} {
+		form := url.Values{"category": {category}, "mode": {models.CommentsThreaded}}
+		code, _, _ := ts.postFormWithSession(t, "/admin/categories/comments", form, mock.AdminToken)
+		mock.Equal(t, code, http.StatusSeeOther)
+	}
+	// 1 <- 2 <- 100 <- 101, new comments are numbered from 100.
+	for _, replyTo := range []string{"2", "100"} {
+		form := url.Values{"postID": {"1"}, "comment": {"deeper"}, "quoted_comment_id": {replyTo}}
+		code, _, _ := ts.postFormWithSession(t, "/comment/post", form, sessionCookieValue)
+		mock.Equal(t, code, http.StatusSeeOther)
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+		want     []string
+		notWant  []string
+	}{
+		{
+			name:     "Post",
+			url:      "/post/1",
+			wantCode: http.StatusOK,
+			want: []string{
+				`<div class="comment comment-nested depth-2" id="comment-100">`,
+				`<a href="/comments/100/thread" class="continue-thread">Continue this thread</a>`,
+				`<div class="comment" id="comment-8">`,
+			},
+			notWant: []string{`id="comment-101"`, "Back to all comments"},
+		},
+		{
+			name:     "Thread beyond the limit",
+			url:      "/comments/100/thread",
+			wantCode: http.StatusOK,
+			want: []string{
+				`<div class="comment" id="comment-100">`,
+				`<div class="comment comment-nested depth-1" id="comment-101">`,
+				`<a href="/posts/1/comments/100" class="continue-thread">Back to all comments</a>`,
+			},
+			notWant: []string{`id="comment-1"`, `id="comment-2"`, `id="comment-4"`, "Continue this thread"},
+		},
+		{
+			name:     "Thread within the limit",
+			url:      "/comments/2/thread",
+			wantCode: http.StatusOK,
+			want: []string{
+				`<div class="comment" id="comment-2">`,
+				`<div class="comment comment-nested depth-1" id="comment-100">`,
+				`<div class="comment comment-nested depth-2" id="comment-101">`,
+			},
+			notWant: []string{`id="comment-1"`, "Continue this thread"},
+		},
+		{name: "Unknown comment", url: "/comments/999/thread", wantCode: http.StatusNotFound},
+		{name: "Malformed id", url: "/comments/abc/thread", wantCode: http.StatusNotFound},
+		{name: "Unknown page", url: "/comments/2/replies", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.url)
+			mock.Equal(t, code, tt.wantCode)
+			for _, want := range tt.want {
+				mock.StringContains(t, body, want)
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(body, notWant) {
+					t.Errorf("found %q", notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteOwn(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		form       url.Values
+		token      string
+		grace      time.Duration
+		wantCode   int
+		wantURL    string
+		wantTarget string
+	}{
+		// The posts of the mock are long past any grace and only blanked.
+		{name: "Post", url: "/post/delete", form: url.Values{"postID": {"1"}}, token: sessionCookieValue, grace: time.Hour, wantCode: http.StatusSeeOther, wantURL: "/post/1", wantTarget: "post:1 soft"},
+		{name: "Someone else's post", url: "/post/delete", form: url.Values{"postID": {"1"}}, token: mock.AdminToken, wantCode: http.StatusForbidden},
+		{name: "Missing post", url: "/post/delete", form: url.Values{}, token: sessionCookieValue, wantCode: http.StatusBadRequest},
+		{name: "Comment", url: "/comment/delete", form: url.Values{"commentID": {"2"}}, token: sessionCookieValue, wantCode: http.StatusSeeOther, wantURL: "/post/1", wantTarget: "comment:2 soft"},
+		{name: "Someone else's comment", url: "/comment/delete", form: url.Values{"commentID": {"2"}}, token: mock.AdminToken, wantCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{DeleteGrace: tt.grace})
+			defer ts.Close()
+
+			code, header, _ := ts.postFormWithSession(t, tt.url, tt.form, tt.token)
+			mock.Equal(t, code, tt.wantCode)
+			mock.Equal(t, header.Get("Location"), tt.wantURL)
+
+			_, _, body := ts.getWithSession(t, "/admin/audit?action="+models.AuditDelete, mock.AdminToken)
+			var log models.AuditPage
+			if err := json.Unmarshal([]byte(body), &log); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantTarget == "" {
+				mock.Equal(t, len(log.Entries), 0)
+				return
+			}
+			if len(log.Entries) != 1 {
+				t.Fatalf("got %d audit entries; expected 1", len(log.Entries))
+			}
+			mock.Equal(t, log.Entries[0].ActorName, "test")
+			mock.Equal(t, log.Entries[0].Target, tt.wantTarget)
+		})
+	}
+
+	// Only the author is offered to delete.
+	ts := NewTestServer(t)
+	defer ts.Close()
+	_, _, body := ts.getWithSession(t, "/post/1", sessionCookieValue)
+	mock.StringContains(t, body, `action="/post/delete"`)
+	_, _, body = ts.getWithSession(t, "/post/1", mock.AdminToken)
+	mock.Equal(t, strings.Contains(body, `action="/post/delete"`), false)
+}
+
+func TestPostRequireCategory(t *testing.T) {
+	tests := []struct {
+		name       string
+		require    bool
+		categories []string
+		wantCode   int
+	}{
+		{name: "Required and picked", require: true, categories: []string{"1"}, wantCode: http.StatusSeeOther},
+		{name: "Required and missing", require: true, wantCode: http.StatusUnprocessableEntity},
+		{name: "Required and unknown", require: true, categories: []string{"2"}, wantCode: http.StatusUnprocessableEntity},
+		{name: "Optional and missing", wantCode: http.StatusSeeOther},
+		{name: "Optional and unknown", categories: []string{"9"}, wantCode: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{RequireCategory: tt.require})
+			defer ts.Close()
+
+			form := url.Values{"title": {"Hello"}, "content": {"some content"}, "categories": tt.categories}
+			code, _, body := ts.postFormWithSession(t, "/post/create", form, sessionCookieValue)
+			mock.Equal(t, code, tt.wantCode)
+			if tt.require && tt.categories == nil {
+				mock.StringContains(t, body, "At least one must be selected")
+			}
+		})
+	}
+}
+
+func TestPostTagLimits(t *testing.T) {
+	// The mock categories are "Category1" and "Category2", 9 characters each.
+	tests := []struct {
+		name       string
+		limits     models.TagLimits
+		categories []string
+		wantCode   int
+		wantError  string
+	}{
+		{name: "Exactly max tags", limits: models.TagLimits{MaxTags: 2}, categories: []string{"0", "1"}, wantCode: http.StatusSeeOther},
+		{name: "One tag over", limits: models.TagLimits{MaxTags: 1}, categories: []string{"0", "1"}, wantCode: http.StatusUnprocessableEntity, wantError: "Pick at most 1 categories"},
+		{name: "Exactly max length", limits: models.TagLimits{MaxChars: 9}, categories: []string{"0"}, wantCode: http.StatusSeeOther},
+		{name: "Over-length tag", limits: models.TagLimits{MaxChars: 8}, categories: []string{"0"}, wantCode: http.StatusUnprocessableEntity, wantError: "Category &#34;Category1&#34; is longer than 8 characters"},
+		{name: "Exactly max total length", limits: models.TagLimits{MaxTotalChars: 18}, categories: []string{"0", "1"}, wantCode: http.StatusSeeOther},
+		{name: "One character over in total", limits: models.TagLimits{MaxTotalChars: 17}, categories: []string{"0", "1"}, wantCode: http.StatusUnprocessableEntity, wantError: "The categories must be at most 17 characters together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{TagLimits: tt.limits})
+			defer ts.Close()
+
+			form := url.Values{"title": {"Hello"}, "content": {"some content"}, "categories": tt.categories}
+			code, _, body := ts.postFormWithSession(t, "/post/create", form, sessionCookieValue)
+			mock.Equal(t, code, tt.wantCode)
+			if tt.wantError != "" {
+				mock.StringContains(t, body, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"forum/app"
+	"forum/models"
+	"io"
+	"net/http"
+)
+
+// previewMaxBytes caps the Markdown a preview request may send.
+const previewMaxBytes = 64 << 10
+
+// preview renders the raw Markdown in the request body the way a post page
+// would and returns the HTML as JSON. Nothing is stored.
+func (h *handler) preview(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/preview" {
+		h.app.APIClientError(w, http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.APIClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	src, err := io.ReadAll(http.MaxBytesReader(w, r.Body, previewMaxBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.app.APIError(w, err)
+			return
+		}
+		h.app.APIClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	h.app.JSON(w, http.StatusOK, models.Preview{
+		HTML:  string(app.Markdown(string(src))),
+		Stats: models.NewTextStats(string(src), h.cfg.WordsPerMinute),
+	})
+}
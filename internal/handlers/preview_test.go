@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func (ts *TestServer) preview(t *testing.T, src string) (int, string) {
+	t.Helper()
+
+	rs, err := ts.Client().Post(ts.URL+"/api/v1/preview", "text/markdown", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+	body, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rs.StatusCode, string(bytes.TrimSpace(body))
+}
+
+func TestPreviewMatchesPost(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	code, body := ts.preview(t, mock.MarkdownContent)
+	mock.Equal(t, code, http.StatusOK)
+
+	var preview models.Preview
+	if err := json.Unmarshal([]byte(body), &preview); err != nil {
+		t.Fatal(err)
+	}
+	mock.StringContains(t, preview.HTML, "<strong>bold</strong>")
+	mock.StringContains(t, preview.HTML, "&lt;script&gt;")
+	mock.StringContains(t, preview.HTML, `<span class="spoiler" tabindex="0">and a spoiler</span>`)
+
+	code, _, page := ts.get(t, "/post/"+strconv.Itoa(mock.MarkdownPostID))
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, page, preview.HTML)
+}
+
+func TestPreviewRateLimit(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	for i := 0; i < previewRate; i++ {
+		if code, _ := ts.preview(t, "*hi*"); code != http.StatusOK {
+			t.Fatalf("preview %d: got %d; expected %d", i, code, http.StatusOK)
+		}
+	}
+	code, _ := ts.preview(t, "*hi*")
+	mock.Equal(t, code, http.StatusTooManyRequests)
+}
+
+func TestTextStats(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{WordsPerMinute: 2})
+	defer ts.Close()
+
+	// Read times round up to whole minutes.
+	tests := []struct {
+		name string
+		src  string
+		want models.TextStats
+	}{
+		{"Empty", "", models.TextStats{}},
+		{"One word", "word", models.TextStats{Characters: 4, Words: 1, ReadMinutes: 1}},
+		{"Exactly a minute", "héllo wörld", models.TextStats{Characters: 11, Words: 2, ReadMinutes: 1}},
+		{"Just over", "one two  three", models.TextStats{Characters: 14, Words: 3, ReadMinutes: 2}},
+		{"Markdown", "# Title\n\n- one", models.TextStats{Characters: 14, Words: 4, ReadMinutes: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := ts.preview(t, tt.src)
+			mock.Equal(t, code, http.StatusOK)
+			var preview models.Preview
+			if err := json.Unmarshal([]byte(body), &preview); err != nil {
+				t.Fatal(err)
+			}
+			mock.Equal(t, preview.Stats, tt.want)
+		})
+	}
+
+	// The feed computes the same stats for stored posts.
+	_, _, body := ts.get(t, "/feed.json?limit=1")
+	var page models.FeedPage
+	if err := json.Unmarshal([]byte(body), &page); err != nil {
+		t.Fatal(err)
+	}
+	mock.Equal(t, page.Posts[0].Stats, models.NewTextStats(mock.StatsContent, 2))
+	mock.Equal(t, page.Posts[0].Stats, models.TextStats{Characters: 11, Words: 2, ReadMinutes: 1})
+
+	// Without a configured speed the default is used.
+	mock.Equal(t, models.NewTextStats(strings.Repeat("word ", 450), 0).ReadMinutes, 3)
+}
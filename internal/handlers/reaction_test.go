@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"forum/internal/config"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReactors(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{PageLimit: 2, ReactionTypes: []string{"❤️"}})
+	defer ts.Close()
+
+	scored := fmt.Sprintf("/posts/%d/reactions", mock.ScoredPostID)
+	tests := []struct {
+		name     string
+		url      string
+		token    string
+		wantCode int
+		want     []string
+		wantNot  []string
+	}{
+		{
+			name:     "First page",
+			url:      scored,
+			wantCode: http.StatusOK,
+			want:     []string{`<a href="/user/test">test</a>`, `<a href="/user/admin">Site Admin</a>`, "?type=&page=2"},
+			wantNot:  []string{"shy", "Previous"},
+		},
+		{
+			name:     "Private and deleted reactors",
+			url:      scored + "?page=2",
+			wantCode: http.StatusOK,
+			want:     []string{"A private user", "[deleted]"},
+			wantNot:  []string{"shy", `href="/user/"`, "Next"},
+		},
+		{
+			name:     "Private to guests only",
+			url:      scored + "?page=2",
+			token:    mock.AdminToken,
+			wantCode: http.StatusOK,
+			want:     []string{`<a href="/user/shy">shy</a>`, "[deleted]"},
+			wantNot:  []string{"A private user"},
+		},
+		{
+			name:     "By type",
+			url:      scored + "?type=dislike",
+			token:    mock.AdminToken,
+			wantCode: http.StatusOK,
+			want:     []string{`<a href="/user/shy">shy</a>`, "<span>dislike</span>"},
+			wantNot:  []string{"/user/test", "Next"},
+		},
+		{
+			name:     "Emoji",
+			url:      "/posts/1/reactions?type=❤️",
+			token:    mock.AdminToken,
+			wantCode: http.StatusOK,
+			want:     []string{`<a href="/user/shy">shy</a>`},
+		},
+		{
+			name:     "Comment",
+			url:      "/posts/1/comments/2/reactions",
+			wantCode: http.StatusOK,
+			want:     []string{"No reactions yet", `href="/posts/1/comments/2"`},
+			wantNot:  []string{"❤️"},
+		},
+		{name: "Unknown type", url: scored + "?type=🙃", wantCode: http.StatusBadRequest},
+		{name: "Emoji on a comment", url: "/posts/1/comments/2/reactions?type=❤️", wantCode: http.StatusBadRequest},
+		{name: "Comment on another post", url: "/posts/1/comments/3/reactions", wantCode: http.StatusNotFound},
+		{name: "Bad post id", url: "/posts/x/reactions", wantCode: http.StatusNotFound},
+		{name: "Too deep", url: "/posts/1/likes/2/reactions", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var code int
+			var body string
+			if tt.token == "" {
+				code, _, body = ts.get(t, tt.url)
+			} else {
+				code, _, body = ts.getWithSession(t, tt.url, tt.token)
+			}
+			mock.Equal(t, code, tt.wantCode)
+			for _, want := range tt.want {
+				mock.StringContains(t, body, want)
+			}
+			for _, notWant := range tt.wantNot {
+				if strings.Contains(body, notWant) {
+					t.Errorf("got %q in the body", notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestReactionCooldown(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{ReactionCooldown: time.Minute})
+	defer ts.Close()
+
+	like := url.Values{"postID": {"1"}, "reaction": {"true"}}
+	code, _, _ := ts.postFormWithSession(t, "/post/reaction", like, mock.AdminToken)
+	mock.Equal(t, code, http.StatusSeeOther)
+	mock.Equal(t, ts.repo.Calls("CreateNotificationOnce"), 1)
+
+	// Taking it back right away is turned down.
+	code, header, _ := ts.postFormWithSession(t, "/post/reaction", like, mock.AdminToken)
+	mock.Equal(t, code, http.StatusTooManyRequests)
+	if retryAfter, err := strconv.Atoi(header.Get("Retry-After")); err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Errorf("got Retry-After %q; expected 1 to 60 seconds", header.Get("Retry-After"))
+	}
+	mock.Equal(t, ts.repo.Calls("CreateNotificationOnce"), 1)
+
+	// Other items and other users have their own cooldown.
+	code, _, _ = ts.postFormWithSession(t, "/comment/reaction", url.Values{"postID": {"1"}, "commentID": {"1"}, "reaction": {"true"}}, mock.AdminToken)
+	mock.Equal(t, code, http.StatusSeeOther)
+	code, _, _ = ts.postFormWithSession(t, "/post/reaction", like, sessionCookieValue)
+	mock.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestReactMinAge(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{ReactMinAge: time.Hour})
+	defer ts.Close()
+
+	like := url.Values{"postID": {"1"}, "reaction": {"true"}}
+	commentLike := url.Values{"postID": {"1"}, "commentID": {"1"}, "reaction": {"true"}}
+	emoji := url.Values{"postID": {"1"}, "type": {"👍"}}
+
+	// newbie signed up just now.
+	for _, tt := range []struct {
+		path string
+		form url.Values
+	}{{"/post/reaction", like}, {"/comment/reaction", commentLike}, {"/post/reaction", emoji}} {
+		code, _, _ := ts.postFormWithSession(t, tt.path, tt.form, mock.NewbieToken)
+		mock.Equal(t, code, http.StatusForbidden)
+	}
+	mock.Equal(t, ts.repo.Calls("CreateNotificationOnce"), 0)
+
+	// tokyo has been around for long.
+	code, _, _ := ts.postFormWithSession(t, "/post/reaction", like, mock.TokyoToken)
+	mock.Equal(t, code, http.StatusSeeOther)
+	code, _, _ = ts.postFormWithSession(t, "/comment/reaction", commentLike, mock.TokyoToken)
+	mock.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestHideReactionsFor(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{HideReactionsFor: time.Hour})
+	defer ts.Close()
+
+	// The mock posts were all made at the zero time.
+	var now time.Time
+	ts.handler.app.Now = func() time.Time { return now }
+
+	for _, tt := range []struct {
+		name   string
+		at     time.Duration
+		hidden bool
+	}{
+		{"Just posted", 0, true},
+		{"Within the window", 59 * time.Minute, true},
+		{"Once the window is over", time.Hour, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			now = time.Time{}.Add(tt.at)
+			for _, url := range []string{"/", "/post/1"} {
+				code, _, body := ts.get(t, url)
+				mock.Equal(t, code, http.StatusOK)
+				mock.Equal(t, strings.Contains(body, "Reactions show up later"), tt.hidden)
+				mock.Equal(t, strings.Contains(body, `class="score">Score:`), !tt.hidden)
+			}
+			_, _, body := ts.get(t, "/post/1")
+			mock.Equal(t, strings.Contains(body, `href="/posts/1/reactions"`), !tt.hidden)
+
+			_, _, body = ts.get(t, "/feed.json")
+			var feed models.FeedPage
+			if err := json.Unmarshal([]byte(body), &feed); err != nil {
+				t.Fatal(err)
+			}
+			for _, item := range feed.Posts {
+				mock.Equal(t, item.ScoresHidden, tt.hidden)
+				if tt.hidden {
+					mock.Equal(t, item.Score, 0)
+				}
+			}
+
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/post/reaction", strings.NewReader("postID=1&reaction=true"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("Accept", "application/json")
+			req.AddCookie(&http.Cookie{Name: sessionIDCookie, Value: sessionCookieValue})
+			rs, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rs.Body.Close()
+			var state models.ReactionState
+			if err := json.NewDecoder(rs.Body).Decode(&state); err != nil {
+				t.Fatal(err)
+			}
+			want := models.ReactionState{Like: 1, Mine: 1}
+			if tt.hidden {
+				want = models.ReactionState{Mine: 1, Hidden: true}
+			}
+			mock.Equal(t, state, want)
+		})
+	}
+
+	// Without a window counts show right away.
+	plain := NewTestServer(t)
+	defer plain.Close()
+	_, _, body := plain.get(t, "/post/1")
+	mock.StringContains(t, body, `class="score">Score:`)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"forum/models"
+	"forum/pkg/validator"
+	"net/http"
+	"strings"
+)
+
+func (h *handler) passwordForgot(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/password/forgot" {
+		h.app.NotFound(w)
+		return
+	}
+	methodResolver(w, r, h.passwordForgotGet, h.passwordForgotPost)
+}
+
+func (h *handler) passwordForgotGet(w http.ResponseWriter, r *http.Request) {
+	h.renderPasswordForgot(w, r, http.StatusOK, models.PasswordForgotForm{}, "")
+}
+
+// passwordForgotPost answers the same way whether or not the email is
+// registered, so the form can't be used to probe for accounts.
+func (h *handler) passwordForgotPost(w http.ResponseWriter, r *http.Request) {
+	form := models.PasswordForgotForm{
+		Email: strings.ToLower(r.FormValue("email")),
+	}
+	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
+	form.CheckField(validator.IsEmail(form.Email), "email", "This field must be an email")
+	if !form.Valid() {
+		h.renderPasswordForgot(w, r, http.StatusUnprocessableEntity, form, "")
+		return
+	}
+
+	err := h.service.SendPasswordReset(h.mailer, h.emails, h.urls, form.Email, h.cfg.ResetTTL)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		// A mail that can't go out is logged rather than shown, or the
+		// answer would tell registered emails apart.
+		h.app.ErrorLog.Print(err)
+	}
+	h.renderPasswordForgot(w, r, http.StatusOK, models.PasswordForgotForm{}, "If the email is registered, a reset link has been sent")
+}
+
+func (h *handler) renderPasswordForgot(w http.ResponseWriter, r *http.Request, status int, form models.PasswordForgotForm, flash string) {
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Form = form
+	data.Flash = flash
+	h.app.Render(w, status, "forgot.html", data)
+}
+
+func (h *handler) passwordReset(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/password/reset" {
+		h.app.NotFound(w)
+		return
+	}
+	methodResolver(w, r, h.passwordResetGet, h.passwordResetPost)
+}
+
+func (h *handler) passwordResetGet(w http.ResponseWriter, r *http.Request) {
+	form := models.PasswordResetForm{Token: r.URL.Query().Get("token")}
+	if form.Token == "" {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	h.renderPasswordReset(w, r, http.StatusOK, form)
+}
+
+func (h *handler) passwordResetPost(w http.ResponseWriter, r *http.Request) {
+	form := models.PasswordResetForm{
+		Token:    r.FormValue("token"),
+		Password: r.FormValue("password"),
+	}
+	if form.Token == "" {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
+	form.CheckField(validator.MinChars(form.Password, models.PasswordMinChars), "password", fmt.Sprintf("This field must be at least %d characters long", models.PasswordMinChars))
+	if !form.Valid() {
+		h.renderPasswordReset(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	userID, err := h.service.ResetPassword(form.Token, form.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidResetToken) {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		h.app.ServerError(w, err)
+		return
+	}
+	h.audit(r, userID, models.AuditPasswordReset, fmt.Sprintf("user:%d", userID))
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (h *handler) renderPasswordReset(w http.ResponseWriter, r *http.Request, status int, form models.PasswordResetForm) {
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Form = form
+	h.app.Render(w, status, "reset.html", data)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	mock "forum/internal/repo/mocks"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestPasswordForgot(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/password/forgot")
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, `action="/password/forgot"`)
+
+	form := url.Values{}
+	form.Add("email", "test@gmail.com")
+	code, _, body = ts.postForm(t, "/password/forgot", form)
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, "If the email is registered, a reset link has been sent")
+
+	sent := ts.mail.Sent()
+	mock.Equal(t, len(sent), 1)
+	mock.Equal(t, sent[0].to, "test@gmail.com")
+	mock.Equal(t, sent[0].subject, "Reset your password")
+	mock.StringContains(t, sent[0].body, testBaseURL+"/password/reset?token=")
+
+	form = url.Values{}
+	form.Add("email", "not an email")
+	code, _, _ = ts.postForm(t, "/password/forgot", form)
+	mock.Equal(t, code, http.StatusUnprocessableEntity)
+}
+
+func TestPasswordReset(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/password/reset?token="+mock.ValidResetToken)
+	mock.Equal(t, code, http.StatusOK)
+	mock.StringContains(t, body, `value="`+mock.ValidResetToken+`"`)
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		wantCode int
+	}{
+		{
+			name:     "Valid token",
+			token:    mock.ValidResetToken,
+			password: "newpassword",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Invalid token",
+			token:    "usedOrExpired",
+			password: "newpassword",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Missing token",
+			password: "newpassword",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Short password",
+			token:    mock.ValidResetToken,
+			password: "short",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("token", tt.token)
+			form.Add("password", tt.password)
+			code, _, _ := ts.postForm(t, "/password/reset", form)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+}
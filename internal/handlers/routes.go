@@ -13,19 +13,72 @@ func (h *handler) Routes() http.Handler {
 	mux.Handle("/static", http.NotFoundHandler())
 	mux.Handle("/static/", fileServer)
 
+	mux.HandleFunc("/health", h.health)
 	mux.HandleFunc("/", h.checkCookie(h.home))
 	mux.HandleFunc("/post/", h.checkCookie(h.postView))
-	mux.HandleFunc("/post/create", h.requireAuthentication(h.postCreate))
+	mux.HandleFunc("/posts/", h.posts)
+	mux.HandleFunc("/comments/", h.checkCookie(h.commentThread))
+	mux.HandleFunc("/search", h.checkCookie(h.search))
+	mux.HandleFunc("/leaderboard", h.checkCookie(h.leaderboard))
+	mux.HandleFunc("/feed.json", h.checkCookie(h.feed))
+	mux.HandleFunc("/api/v1/preview", h.rateLimit(h.previews, h.preview))
+	mux.HandleFunc("/api/v1/search/suggest", h.searchSuggest)
+	mux.HandleFunc("/api/v1/users/suggest", h.requireAPIAuthentication(h.mentionSuggest))
+	mux.HandleFunc("/api/v1/drafts/autosave", h.requireAPIAuthentication(h.draftAutosave))
+	mux.HandleFunc("/post/create", h.allowGuests(h.limitWrites(h.postCreate)))
 	mux.HandleFunc("/login", h.notRegistered(h.login))
 	mux.HandleFunc("/signup", h.notRegistered(h.signup))
+	mux.HandleFunc("/password/forgot", h.notRegistered(h.passwordForgot))
+	mux.HandleFunc("/password/reset", h.notRegistered(h.passwordReset))
+	mux.HandleFunc("/invites", h.requireAuthentication(h.invites))
+	mux.HandleFunc("/invites/create", h.requireAuthentication(h.inviteCreate))
+	mux.HandleFunc("/notifications", h.requireAuthentication(h.notifications))
+	mux.HandleFunc("/notifications/read", h.requireAuthentication(h.notificationRead))
+	mux.HandleFunc("/notifications/read-all", h.requireAuthentication(h.notificationsReadAll))
+	mux.HandleFunc("/moderation/bulk", h.requireAuthentication(h.moderationBulk))
+	mux.HandleFunc("/moderation/labels", h.requireAuthentication(h.moderationLabel))
+	mux.HandleFunc("/moderation/move", h.requireAuthentication(h.moderationMove))
+	mux.HandleFunc("/moderation/guests", h.requireAuthentication(h.moderationGuestPosts))
+	mux.HandleFunc("/moderation/guests/approve", h.requireAuthentication(h.moderationGuestPostReview))
+	mux.HandleFunc("/moderation/guests/reject", h.requireAuthentication(h.moderationGuestPostReview))
+	mux.HandleFunc("/moderation/comments", h.requireAuthentication(h.moderationComments))
+	mux.HandleFunc("/moderation/comments/approve", h.requireAuthentication(h.moderationCommentReview))
+	mux.HandleFunc("/moderation/comments/reject", h.requireAuthentication(h.moderationCommentReview))
+	mux.HandleFunc("/admin/audit", h.requireAuthentication(h.adminAudit))
+	mux.HandleFunc("/admin/role", h.requireAuthentication(h.adminRole))
+	mux.HandleFunc("/admin/moderators", h.requireAuthentication(h.adminCategoryModerator))
+	mux.HandleFunc("/admin/categories/comments", h.requireAuthentication(h.adminCommentMode))
+	mux.HandleFunc("/admin/categories/posting", h.requireAuthentication(h.adminPostStatus))
+	mux.HandleFunc("/admin/export", h.requireAuthentication(h.adminExport))
+	mux.HandleFunc("/admin/announcements", h.requireAuthentication(h.adminAnnouncements))
+	mux.HandleFunc("/admin/announcements/delete", h.requireAuthentication(h.adminAnnouncementDelete))
 	mux.HandleFunc("/logout", h.requireAuthentication(h.logoutPost))
 	mux.HandleFunc("/user/posts", h.requireAuthentication(h.PostByUser))
 	mux.HandleFunc("/user/liked", h.requireAuthentication(h.LikedPosts))
-	mux.HandleFunc("/post/reaction", h.requireAuthentication(h.postReaction))
-	mux.HandleFunc("/comment/post", h.requireAuthentication(h.commentPost))
-	mux.HandleFunc("/comment/reaction", h.requireAuthentication(h.commentReaction))
+	mux.HandleFunc("/my/participated", h.requireAuthentication(h.participated))
+	mux.HandleFunc("/user/privacy", h.requireAuthentication(h.userPrivacy))
+	mux.HandleFunc("/user/sort", h.requireAuthentication(h.userSort))
+	mux.HandleFunc("/user/display-name", h.requireAuthentication(h.userDisplayName))
+	mux.HandleFunc("/user/timezone", h.requireAuthentication(h.userTimezone))
+	mux.HandleFunc("/user/digest", h.requireAuthentication(h.userDigest))
+	mux.HandleFunc("/user/follow", h.requireAuthentication(h.userFollow))
+	mux.HandleFunc("/user/unfollow", h.requireAuthentication(h.userFollow))
+	mux.HandleFunc("/feed/following", h.requireAuthentication(h.followingFeed))
+	mux.HandleFunc("/feed/subscribed-categories", h.requireAuthentication(h.subscribedCategoriesFeed))
+	mux.HandleFunc("/category/subscribe", h.requireAuthentication(h.categorySubscribe))
+	mux.HandleFunc("/history", h.requireAuthentication(h.history))
+	mux.HandleFunc("/user/", h.checkCookie(h.userPage))
+	mux.HandleFunc("/post/answer", h.requireAuthentication(h.postAnswer))
+	mux.HandleFunc("/post/pin", h.requireAuthentication(h.postPin))
+	mux.HandleFunc("/post/subscribe", h.requireAuthentication(h.postSubscribe))
+	mux.HandleFunc("/post/edit", h.requireAuthentication(h.limitWrites(h.postEdit)))
+	mux.HandleFunc("/post/delete", h.requireAuthentication(h.postDelete))
+	mux.HandleFunc("/post/reaction", h.requireAuthentication(h.limitWrites(h.postReaction)))
+	mux.HandleFunc("/comment/post", h.allowGuests(h.limitWrites(h.commentPost)))
+	mux.HandleFunc("/comment/reaction", h.requireAuthentication(h.limitWrites(h.commentReaction)))
+	mux.HandleFunc("/comment/delete", h.requireAuthentication(h.commentDelete))
 
-	return h.secureHeaders(mux)
+	return h.secureHeaders(h.app.NegotiateErrors(h.recoverPanic(h.geoBlock(h.maintenanceMode(h.limitBody(mux))))))
 }
 
 type neuteredFileSystem struct {
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+	"strconv"
+)
+
+func (h *handler) search(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/search" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := models.ParseSearchFilter(r.URL.Query())
+	if err != nil {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Search = &filter
+	data, err = h.service.SetUpPage(data, r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+
+	data.Posts, err = h.service.SearchPostsPaginated(*data.Search, data.CurrentPage, data.Limit)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	if token != nil {
+		reactions, err := h.service.GetReactionPosts(token.Value)
+		if err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+		data.Posts = h.service.IsLikedPost(data.Posts, reactions)
+	}
+
+	if err := h.service.ScorePosts(data.Posts, h.cfg.VoteWeights); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if len(*data.Posts) == 0 {
+		data.Posts = nil
+	}
+
+	h.app.Render(w, http.StatusOK, "search.html", data)
+}
+
+// suggestMaxAge is how long clients and proxies may reuse suggestions. They
+// don't depend on who asks, and a stale title for a minute is harmless.
+const suggestMaxAge = 60
+
+// searchSuggest answers a search query being typed with matching
+// categories, users and post titles. Queries too short to narrow anything
+// down get an empty list.
+func (h *handler) searchSuggest(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/search/suggest" {
+		h.app.APIClientError(w, http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.APIClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	suggestions, err := h.service.GetSuggestions(query)
+	if err != nil {
+		h.app.APIError(w, err)
+		return
+	}
+	for i, suggestion := range suggestions {
+		suggestions[i].URL = suggestion.Link(h.urls)
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", suggestMaxAge))
+	h.app.JSON(w, http.StatusOK, models.Suggestions{Query: query, Suggestions: suggestions})
+}
+
+// mentionSuggest lists the users whose names start with "q", for @-mention
+// autocomplete. "post", the post being commented on, puts the people on it
+// first.
+func (h *handler) mentionSuggest(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/users/suggest" {
+		h.app.APIClientError(w, http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.APIClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var postID int
+	if post := r.URL.Query().Get("post"); post != "" {
+		var err error
+		if postID, err = strconv.Atoi(post); err != nil || postID < 1 {
+			h.app.APIClientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+	query := r.URL.Query().Get("q")
+	c := cookie.GetSessionCookie(r)
+	users, err := h.service.SuggestMentions(c.Value, query, postID)
+	if err != nil {
+		h.app.APIError(w, err)
+		return
+	}
+	h.app.JSON(w, http.StatusOK, models.MentionSuggestions{Query: query, Users: users})
+}
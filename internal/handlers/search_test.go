@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	mock "forum/internal/repo/mocks"
+	"forum/models"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+	}{
+		{
+			name:     "Text query",
+			url:      "/search?q=golang",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "All filters",
+			url:      "/search?q=golang&author=test&from=2024-01-01&to=2024-02-01",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Empty filters",
+			url:      "/search?q=&author=&from=&to=&category=",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "From after to",
+			url:      "/search?from=2024-02-01&to=2024-01-01",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Malformed date",
+			url:      "/search?from=yesterday",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Unknown category",
+			url:      "/search?category=nothing",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.get(t, tt.url)
+			mock.Equal(t, code, tt.wantCode)
+		})
+	}
+
+	_, _, body := ts.get(t, "/search?q=golang")
+	mock.StringContains(t, body, `<p class="search-total">0 results</p>`)
+}
+
+func TestSearchSuggest(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	suggest := func(q string) []models.Suggestion {
+		t.Helper()
+		code, header, body := ts.get(t, "/api/v1/search/suggest?q="+url.QueryEscape(q))
+		mock.Equal(t, code, http.StatusOK)
+		mock.StringContains(t, header.Get("Cache-Control"), "max-age=")
+		var got models.Suggestions
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Suggestions == nil {
+			t.Fatalf("%q: got null suggestions; expected a list", q)
+		}
+		return got.Suggestions
+	}
+
+	tests := []struct {
+		name string
+		q    string
+		want []models.Suggestion
+	}{
+		{name: "Empty", q: "", want: nil},
+		{name: "Too short", q: "t", want: nil},
+		{name: "Blank", q: "   ", want: nil},
+		{
+			name: "User and post",
+			q:    "te",
+			want: []models.Suggestion{
+				{Kind: models.SuggestUser, Text: "test", URL: testBaseURL + "/user/test"},
+				{Kind: models.SuggestPost, Text: "Testing Go", URL: testBaseURL + "/post/1"},
+			},
+		},
+		{
+			name: "Categories and post",
+			q:    "CAT",
+			want: []models.Suggestion{
+				{Kind: models.SuggestCategory, Text: "Category1", URL: testBaseURL + "/?category=category1"},
+				{Kind: models.SuggestCategory, Text: "Category2", URL: testBaseURL + "/?category=category2"},
+				{Kind: models.SuggestPost, Text: "Category news", URL: testBaseURL + "/post/2"},
+			},
+		},
+		{name: "No match", q: "zebra", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggest(tt.q)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d suggestions; expected %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				mock.Equal(t, got[i], tt.want[i])
+			}
+		})
+	}
+
+	code, _, _ := ts.postForm(t, "/api/v1/search/suggest", url.Values{"q": {"te"}})
+	mock.Equal(t, code, http.StatusMethodNotAllowed)
+}
+
+func TestMentionSuggest(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		query    string
+		wantCode int
+		want     []string
+	}{
+		{name: "Prefix", token: sessionCookieValue, query: "?q=T", wantCode: http.StatusOK, want: []string{"test", "tokyo", "topfan"}},
+		{name: "Typed with the @", token: sessionCookieValue, query: "?q=%40top", wantCode: http.StatusOK, want: []string{"topfan"}},
+		{name: "Empty", token: sessionCookieValue, query: "?q=", wantCode: http.StatusOK, want: []string{}},
+		{name: "Private profile is left out", token: sessionCookieValue, query: "?q=her", wantCode: http.StatusOK, want: []string{}},
+		{name: "Private profile sees itself", token: mock.HermitToken, query: "?q=her", wantCode: http.StatusOK, want: []string{"hermit"}},
+		{name: "People on the post first", token: sessionCookieValue, query: "?q=t&post=1", wantCode: http.StatusOK, want: []string{"test", "tokyo", "topfan"}},
+		{name: "Malformed post", token: sessionCookieValue, query: "?q=t&post=first", wantCode: http.StatusBadRequest},
+		{name: "Guest", query: "?q=t", wantCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, "/api/v1/users/suggest"+tt.query)
+			if tt.token != "" {
+				code, _, body = ts.getWithSession(t, "/api/v1/users/suggest"+tt.query, tt.token)
+			}
+			mock.Equal(t, code, tt.wantCode)
+			if tt.want == nil {
+				return
+			}
+			var got models.MentionSuggestions
+			if err := json.Unmarshal([]byte(body), &got); err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, u := range got.Users {
+				names = append(names, u.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("got %q; expected %q", names, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"forum/models"
+	"forum/pkg/cookie"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// postSubscribe toggles whether the user is notified of every new comment on
+// the post.
+func (h *handler) postSubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/post/subscribe" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	postID, err := GetIntForm(r, "postID")
+	if err != nil || postID < 1 {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	if _, err = h.service.ToggleSubscription(token.Value, postID); err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, "/post/"+strconv.Itoa(postID), http.StatusSeeOther)
+}
+
+// categorySubscribe toggles whether the user is notified of every new post in
+// the category in the "category" form value.
+func (h *handler) categorySubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/category/subscribe" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	category := strings.ToLower(r.FormValue("category"))
+	if category == "" {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	token := cookie.GetSessionCookie(r)
+	if _, err := h.service.ToggleCategorySubscription(token.Value, category); err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	http.Redirect(w, r, "/?category="+url.QueryEscape(category), http.StatusSeeOther)
+}
+
+func (h *handler) subscribedCategoriesFeed(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/feed/subscribed-categories" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data, err = h.service.SetUpPage(data, r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+
+	data.Posts, err = h.service.GetSubscribedCategoriesPostsPaginated(int(data.User.ID), data.CurrentPage, data.Limit)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	c := cookie.GetSessionCookie(r)
+	reactions, err := h.service.GetReactionPosts(c.Value)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Posts = h.service.IsLikedPost(data.Posts, reactions)
+	if err := h.service.ScorePosts(data.Posts, h.cfg.VoteWeights); err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if len(*data.Posts) == 0 {
+		data.Posts = nil
+	}
+
+	h.app.Render(w, http.StatusOK, "subscribed_categories.html", data)
+}
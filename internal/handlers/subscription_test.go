@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	mock "forum/internal/repo/mocks"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPostSubscribe(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	button := func(token string) string {
+		t.Helper()
+		code, _, body := ts.getWithSession(t, "/post/1", token)
+		mock.Equal(t, code, http.StatusOK)
+		if strings.Contains(body, `value="Unsubscribe"`) {
+			return "Unsubscribe"
+		}
+		if strings.Contains(body, `value="Subscribe"`) {
+			return "Subscribe"
+		}
+		return ""
+	}
+	toggle := func(token string) {
+		t.Helper()
+		code, header, _ := ts.postFormWithSession(t, "/post/subscribe", url.Values{"postID": {"1"}}, token)
+		mock.Equal(t, code, http.StatusSeeOther)
+		mock.Equal(t, header.Get("Location"), "/post/1")
+	}
+
+	// The author of the post starts out subscribed, other users don't.
+	mock.Equal(t, button(sessionCookieValue), "Unsubscribe")
+	mock.Equal(t, button(mock.AdminToken), "Subscribe")
+
+	toggle(sessionCookieValue)
+	mock.Equal(t, button(sessionCookieValue), "Subscribe")
+	toggle(mock.AdminToken)
+	mock.Equal(t, button(mock.AdminToken), "Unsubscribe")
+
+	// Guests see no button and can't subscribe.
+	code, _, body := ts.get(t, "/post/1")
+	mock.Equal(t, code, http.StatusOK)
+	mock.Equal(t, strings.Contains(body, "/post/subscribe"), false)
+	code, _, _ = ts.postForm(t, "/post/subscribe", url.Values{"postID": {"1"}})
+	mock.Equal(t, code, http.StatusSeeOther)
+
+	code, _, _ = ts.postFormWithSession(t, "/post/subscribe", url.Values{"postID": {"x"}}, sessionCookieValue)
+	mock.Equal(t, code, http.StatusBadRequest)
+}
+
+func TestCategorySubscribe(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	button := func(token string) string {
+		t.Helper()
+		code, _, body := ts.getWithSession(t, "/?category=category1", token)
+		mock.Equal(t, code, http.StatusOK)
+		if strings.Contains(body, `value="Unsubscribe"`) {
+			return "Unsubscribe"
+		}
+		if strings.Contains(body, `value="Subscribe"`) {
+			return "Subscribe"
+		}
+		return ""
+	}
+	toggle := func(token string) {
+		t.Helper()
+		code, header, _ := ts.postFormWithSession(t, "/category/subscribe", url.Values{"category": {"Category1"}}, token)
+		mock.Equal(t, code, http.StatusSeeOther)
+		mock.Equal(t, header.Get("Location"), "/?category=category1")
+	}
+	feed := func(token string) string {
+		t.Helper()
+		code, _, body := ts.getWithSession(t, "/feed/subscribed-categories", token)
+		mock.Equal(t, code, http.StatusOK)
+		return body
+	}
+
+	mock.Equal(t, button(sessionCookieValue), "Subscribe")
+	mock.Equal(t, strings.Contains(feed(sessionCookieValue), "Nothing here yet"), true)
+
+	toggle(sessionCookieValue)
+	mock.Equal(t, button(sessionCookieValue), "Unsubscribe")
+	mock.Equal(t, strings.Contains(feed(sessionCookieValue), "Nothing here yet"), false)
+	mock.Equal(t, button(mock.AdminToken), "Subscribe")
+
+	toggle(sessionCookieValue)
+	mock.Equal(t, button(sessionCookieValue), "Subscribe")
+
+	// Guests see no button and can't subscribe.
+	code, _, body := ts.get(t, "/?category=category1")
+	mock.Equal(t, code, http.StatusOK)
+	mock.Equal(t, strings.Contains(body, "/category/subscribe"), false)
+	code, _, _ = ts.postForm(t, "/category/subscribe", url.Values{"category": {"category1"}})
+	mock.Equal(t, code, http.StatusSeeOther)
+
+	code, _, _ = ts.postFormWithSession(t, "/category/subscribe", url.Values{"category": {"nope"}}, sessionCookieValue)
+	mock.Equal(t, code, http.StatusNotFound)
+	code, _, _ = ts.postFormWithSession(t, "/category/subscribe", url.Values{}, sessionCookieValue)
+	mock.Equal(t, code, http.StatusBadRequest)
+}
@@ -3,28 +3,85 @@ package handlers
 import (
 	"bytes"
 	"forum/app"
+	"forum/internal/config"
 	mock "forum/internal/repo/mocks"
 	"forum/internal/service"
+	"forum/pkg/blocklist"
+	"forum/pkg/captcha"
+	"forum/pkg/geo"
+	"forum/pkg/mailer"
+	"forum/pkg/urls"
 	"io"
 	"log"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 )
 
 const (
 	sessionNameInCookie = "session"
 	sessionCookieValue  = "anythingHereWouldWork"
+	sessionIDCookie     = "session_id"
+	// testBaseURL is the canonical address links are built under.
+	testBaseURL = "https://forum.test"
 )
 
 type TestServer struct {
 	*httptest.Server
+	repo    *mock.MockRepo
+	handler *handler
+	mail    *testMailer
+}
+
+type sentMail struct {
+	to, subject, body string
+}
+
+// testMailer keeps the mail the server sends, for tests to look at.
+type testMailer struct {
+	mu   sync.Mutex
+	sent []sentMail
+}
+
+func (m *testMailer) Send(to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, sentMail{to, subject, body})
+	return nil
+}
+
+func (m *testMailer) SendHTML(to, subject, body string) error {
+	return m.Send(to, subject, body)
+}
+
+func (m *testMailer) Sent() []sentMail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.sent)
 }
 
 func NewTestServer(t *testing.T) *TestServer {
+	return NewTestServerWithConfig(t, &config.Config{})
+}
+
+func NewTestServerWithConfig(t *testing.T, cfg *config.Config) *TestServer {
+	return NewTestServerWithBlocklist(t, cfg, blocklist.New(nil))
+}
+
+func NewTestServerWithBlocklist(t *testing.T, cfg *config.Config, bl *blocklist.Blocklist) *TestServer {
+	return NewTestServerWithCaptcha(t, cfg, bl, captcha.Noop{})
+}
+
+func NewTestServerWithCaptcha(t *testing.T, cfg *config.Config, bl *blocklist.Blocklist, cv captcha.Verifier) *TestServer {
+	return NewTestServerWithGeo(t, cfg, bl, cv, geo.Noop{})
+}
+
+func NewTestServerWithGeo(t *testing.T, cfg *config.Config, bl *blocklist.Blocklist, cv captcha.Verifier, gr geo.Resolver) *TestServer {
 	var buff bytes.Buffer
 
 	logger := log.New(&buff, "", 0)
@@ -35,10 +92,21 @@ func NewTestServer(t *testing.T) *TestServer {
 	}
 
 	app := app.New(logger, logger, templateCache)
+	app.SnippetLength = cfg.SnippetLength
+	app.HideReactionsFor = cfg.HideReactionsFor
 	repo := mock.NewMockRepo(t)
 	serv := service.New(repo)
 
-	hand := New(serv, app)
+	ub, err := urls.New(testBaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emails, err := mailer.LoadTemplates(nil, mailer.DefaultLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mail := &testMailer{}
+	hand := New(serv, app, cfg, bl, cv, gr, ub, mail, emails)
 
 	ts := httptest.NewServer(hand.Routes())
 
@@ -53,7 +121,7 @@ func NewTestServer(t *testing.T) *TestServer {
 		return http.ErrUseLastResponse
 	}
 
-	return &TestServer{ts}
+	return &TestServer{ts, repo, hand, mail}
 }
 
 func (ts *TestServer) get(t *testing.T, url string) (int, http.Header, string) {
@@ -98,3 +166,79 @@ func (ts *TestServer) postForm(t *testing.T, url string, form url.Values) (int,
 
 	return res.StatusCode, res.Header, string(body)
 }
+
+func (ts *TestServer) getWithSession(t *testing.T, url, token string) (int, http.Header, string) {
+	req, err := http.NewRequest("GET", ts.URL+url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  sessionIDCookie,
+		Value: token,
+	})
+
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = bytes.TrimSpace(body)
+
+	return res.StatusCode, res.Header, string(body)
+}
+
+func (ts *TestServer) postFormWithSession(t *testing.T, url string, form url.Values, token string) (int, http.Header, string) {
+	req, err := http.NewRequest("POST", ts.URL+url, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	req.AddCookie(&http.Cookie{
+		Name:  sessionIDCookie,
+		Value: token,
+	})
+
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = bytes.TrimSpace(body)
+
+	return res.StatusCode, res.Header, string(body)
+}
+
+func (ts *TestServer) request(t *testing.T, method, url string, header http.Header) (int, http.Header, string) {
+	req, err := http.NewRequest(method, ts.URL+url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header = header
+
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = bytes.TrimSpace(body)
+
+	return res.StatusCode, res.Header, string(body)
+}
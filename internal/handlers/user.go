@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"forum/models"
 	"forum/pkg/cookie"
+	"forum/pkg/realip"
 	"forum/pkg/validator"
 	"net/http"
 	"strings"
@@ -62,22 +63,10 @@ func (h *handler) loginPost(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Println(session, err)
 	if err != nil {
-		if errors.Is(err, models.ErrNoRecord) {
-			form.AddFieldError("email", "email doesn't exist")
-			data, err := h.NewTemplateData(r)
-			if err != nil {
-				h.app.ServerError(w, err)
-				return
-			}
-			data.Form = form
-			data.Categories, err = h.service.GetAllCategory()
-			if err != nil {
-				h.app.ServerError(w, err)
-				return
-			}
-			h.app.Render(w, http.StatusUnprocessableEntity, "login.html", data)
-		} else if errors.Is(err, models.ErrInvalidCredentials) {
-			form.AddFieldError("password", models.ErrInvalidCredentials.Error())
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			// The same answer for unknown emails and wrong passwords, so
+			// it can't tell which emails have accounts.
+			form.AddFieldError("password", "Email or password is incorrect")
 			data, err := h.NewTemplateData(r)
 			if err != nil {
 				h.app.ServerError(w, err)
@@ -95,8 +84,9 @@ func (h *handler) loginPost(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	h.audit(r, session.UserID, models.AuditLogin, "")
 	cookie.SetSessionCookie(w, session.Token, session.ExpTime)
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	http.Redirect(w, r, h.afterLogin(w, r), http.StatusSeeOther)
 }
 
 func (h *handler) signup(w http.ResponseWriter, r *http.Request) {
@@ -104,9 +94,29 @@ func (h *handler) signup(w http.ResponseWriter, r *http.Request) {
 		h.app.NotFound(w)
 		return
 	}
+	if h.cfg.SignupDisabled {
+		h.signupClosed(w, r)
+		return
+	}
 	methodResolver(w, r, h.signupGet, h.signupPost)
 }
 
+// signupClosed shows why registrations are closed instead of the form.
+func (h *handler) signupClosed(w http.ResponseWriter, r *http.Request) {
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Form = models.UserSignupForm{}
+	h.app.Render(w, http.StatusForbidden, "signup.html", data)
+}
+
 func (h *handler) signupGet(w http.ResponseWriter, r *http.Request) {
 	data, err := h.NewTemplateData(r)
 	if err != nil {
@@ -119,22 +129,54 @@ func (h *handler) signupGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	data.Form = models.UserSignupForm{}
+	data.InviteOnly = h.cfg.InviteOnly
+	data.Captcha = h.captcha.Widget()
+	data.DisplayNames = h.cfg.DisplayNames
 	h.app.Render(w, http.StatusOK, "signup.html", data)
 }
 
 func (h *handler) signupPost(w http.ResponseWriter, r *http.Request) {
+	if h.isSpam(r) {
+		h.spamDropped(w, r)
+		return
+	}
 	form := models.UserSignupForm{
-		Name:     r.FormValue("name"),
-		Email:    strings.ToLower(r.FormValue("email")),
-		Password: r.FormValue("password"),
+		Name:       r.FormValue("name"),
+		Email:      strings.ToLower(r.FormValue("email")),
+		Password:   r.FormValue("password"),
+		InviteCode: strings.TrimSpace(r.FormValue("invite")),
+	}
+	if h.cfg.DisplayNames {
+		form.DisplayName = strings.TrimSpace(r.FormValue("display_name"))
 	}
 	fmt.Println(form)
 	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.Name, 12), "name", "This field must be 12 characters long maximum")
+	if err := h.cfg.UsernamePolicy.Check(form.Name); errors.Is(err, models.ErrReservedName) {
+		form.AddFieldError("name", "This name is reserved")
+	} else if err != nil {
+		form.AddFieldError("name", h.cfg.UsernamePolicy.Rule())
+	}
 	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
 	form.CheckField(validator.IsEmail(form.Email), "email", "This field must be an email")
 	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
-	form.CheckField(validator.MinChars(form.Password, 8), "password", "This field must be at least 8 characters long")
+	form.CheckField(validator.MinChars(form.Password, models.PasswordMinChars), "password", fmt.Sprintf("This field must be at least %d characters long", models.PasswordMinChars))
+	form.CheckField(models.ValidDisplayName(form.DisplayName), "display_name", fmt.Sprintf("This field must be at most %d letters, digits, spaces, dots, dashes or underscores, starting with a letter or digit", models.DisplayNameMaxChars))
+	if form.Valid() {
+		if err := h.checkSimilarName(&form, r.FormValue("confirm_name")); err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+	}
+	// The challenge is checked last, so the provider is only asked about
+	// otherwise valid forms.
+	if form.Valid() {
+		solved, err := h.captcha.Verify(r, realip.ClientIP(r, h.cfg.TrustedProxies))
+		if err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+		form.CheckField(solved, "captcha", "Please solve the CAPTCHA")
+	}
 
 	if !form.Valid() {
 		data, err := h.NewTemplateData(r)
@@ -143,6 +185,9 @@ func (h *handler) signupPost(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		data.Form = form
+		data.InviteOnly = h.cfg.InviteOnly
+		data.Captcha = h.captcha.Widget()
+		data.DisplayNames = h.cfg.DisplayNames
 		data.Categories, err = h.service.GetAllCategory()
 		if err != nil {
 			h.app.ServerError(w, err)
@@ -151,11 +196,23 @@ func (h *handler) signupPost(w http.ResponseWriter, r *http.Request) {
 		h.app.Render(w, http.StatusUnprocessableEntity, "signup.html", data)
 		return
 	}
+	if h.cfg.InviteOnly && form.InviteCode == "" {
+		h.signupForbidden(w, r, form)
+		return
+	}
 	//
 	user := form.FormToUser()
-	err := h.service.CreateUser(user)
+	user.EmailKey = h.cfg.EmailPolicy.Normalize(user.Email)
+	var err error
+	if form.InviteCode != "" {
+		err = h.service.CreateUserWithInvite(user, form.InviteCode)
+	} else {
+		err = h.service.CreateUser(user)
+	}
 	if err != nil {
-		if errors.Is(err, models.ErrDuplicateEmail) {
+		if errors.Is(err, models.ErrInvalidInvite) {
+			h.signupForbidden(w, r, form)
+		} else if errors.Is(err, models.ErrDuplicateEmail) {
 			form.AddFieldError("email", "Email address is already in use")
 			data, err := h.NewTemplateData(r)
 			if err != nil {
@@ -163,6 +220,9 @@ func (h *handler) signupPost(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			data.Form = form
+			data.InviteOnly = h.cfg.InviteOnly
+			data.Captcha = h.captcha.Widget()
+			data.DisplayNames = h.cfg.DisplayNames
 			h.app.Render(w, http.StatusUnprocessableEntity, "signup.html", data)
 		} else if errors.Is(err, models.ErrDuplicateName) {
 			form.AddFieldError("name", "Name is already in use")
@@ -172,6 +232,9 @@ func (h *handler) signupPost(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			data.Form = form
+			data.InviteOnly = h.cfg.InviteOnly
+			data.Captcha = h.captcha.Widget()
+			data.DisplayNames = h.cfg.DisplayNames
 			h.app.Render(w, http.StatusUnprocessableEntity, "signup.html", data)
 		} else {
 			h.app.ServerError(w, err)
@@ -181,6 +244,45 @@ func (h *handler) signupPost(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
 
+// checkSimilarName adds a field error if the name looks like an existing
+// user's and cfg.SimilarNames says so. A warned name passes when it comes
+// back as confirmed.
+func (h *handler) checkSimilarName(form *models.UserSignupForm, confirmed string) error {
+	if h.cfg.SimilarNames != models.SimilarNamesWarn && h.cfg.SimilarNames != models.SimilarNamesReject {
+		return nil
+	}
+	similar, err := h.service.SimilarName(form.Name)
+	if err != nil || similar == "" {
+		return err
+	}
+	if h.cfg.SimilarNames == models.SimilarNamesReject {
+		form.AddFieldError("name", "Name is too much like an existing one")
+	} else if confirmed != form.Name {
+		form.SimilarName = similar
+		form.AddFieldError("name", fmt.Sprintf("Name looks a lot like %s, send the form again to keep it", similar))
+	}
+	return nil
+}
+
+func (h *handler) signupForbidden(w http.ResponseWriter, r *http.Request, form models.UserSignupForm) {
+	form.AddFieldError("invite", "A valid invite code is required")
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Form = form
+	data.InviteOnly = h.cfg.InviteOnly
+	data.Captcha = h.captcha.Widget()
+	data.DisplayNames = h.cfg.DisplayNames
+	data.Categories, err = h.service.GetAllCategory()
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	h.app.Render(w, http.StatusForbidden, "signup.html", data)
+}
+
 func (h *handler) logoutPost(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/logout" {
 		h.app.NotFound(w)
@@ -188,9 +290,280 @@ func (h *handler) logoutPost(w http.ResponseWriter, r *http.Request) {
 	}
 	c := cookie.GetSessionCookie(r)
 	if c != nil {
+		if user, err := h.service.GetUser(r); err == nil {
+			h.audit(r, int(user.ID), models.AuditLogout, "")
+		}
 		h.service.DeleteSession(c.Value)
 		cookie.ExpireSessionCookie(w)
 	}
 
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
+
+func (h *handler) userPage(w http.ResponseWriter, r *http.Request) {
+	path, _ := strings.CutPrefix(r.URL.Path, "/user/")
+	parts := strings.Split(path, "/")
+	if parts[0] == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != "activity") {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	if len(parts) == 1 {
+		h.userProfile(w, r, parts[0])
+		return
+	}
+	h.userActivity(w, r, parts[0])
+}
+
+func (h *handler) userProfile(w http.ResponseWriter, r *http.Request, username string) {
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Profile, err = h.service.GetUserByName(username)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	data, err = h.service.SetUpPage(data, r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+
+	data.DisplayNames = h.cfg.DisplayNames
+	data.IsFollowing, err = h.service.IsFollowing(data.User, data.Profile)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+
+	// Viewers without access get the limited view: just the name and the
+	// date the user joined.
+	if data.Profile.ProfileVisibleTo(data.User) {
+		data.Posts, err = h.service.GetPostsByUserIDPaginated(int(data.Profile.ID), data.CurrentPage, data.Limit)
+		if err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+		if err := h.service.ScorePosts(data.Posts, h.cfg.VoteWeights); err != nil {
+			h.app.ServerError(w, err)
+			return
+		}
+		if len(*data.Posts) == 0 {
+			data.Posts = nil
+		}
+	} else {
+		data.Profile.Email = ""
+		data.NumberOfPage = 0
+	}
+
+	h.app.Render(w, http.StatusOK, "profile.html", data)
+}
+
+func (h *handler) userPrivacy(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/user/privacy" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	privacy, err := GetIntForm(r, "privacy")
+	if err != nil {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	c := cookie.GetSessionCookie(r)
+	err = h.service.UpdatePrivacy(c.Value, privacy)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPrivacy) {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		h.app.ServerError(w, err)
+		return
+	}
+	user, err := h.service.GetUser(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	http.Redirect(w, r, "/user/"+user.Name, http.StatusSeeOther)
+}
+
+func (h *handler) userSort(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/user/sort" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	c := cookie.GetSessionCookie(r)
+	err := h.service.UpdateSort(c.Value, r.FormValue("sort"))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidSort) {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		h.app.ServerError(w, err)
+		return
+	}
+	user, err := h.service.GetUser(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	http.Redirect(w, r, "/user/"+user.Name, http.StatusSeeOther)
+}
+
+func (h *handler) userDisplayName(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/user/display-name" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.cfg.DisplayNames {
+		h.app.NotFound(w)
+		return
+	}
+
+	c := cookie.GetSessionCookie(r)
+	err := h.service.UpdateDisplayName(c.Value, strings.TrimSpace(r.FormValue("display_name")))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidDisplayName) {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		h.app.ServerError(w, err)
+		return
+	}
+	user, err := h.service.GetUser(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	http.Redirect(w, r, "/user/"+user.Name, http.StatusSeeOther)
+}
+
+func (h *handler) userTimezone(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/user/timezone" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	c := cookie.GetSessionCookie(r)
+	err := h.service.UpdateTimezone(c.Value, strings.TrimSpace(r.FormValue("timezone")))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidTimezone) {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		h.app.ServerError(w, err)
+		return
+	}
+	user, err := h.service.GetUser(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	http.Redirect(w, r, "/user/"+user.Name, http.StatusSeeOther)
+}
+
+func (h *handler) userDigest(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/user/digest" {
+		h.app.NotFound(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.app.ClientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	digest, err := GetIntForm(r, "digest")
+	if err != nil {
+		h.app.ClientError(w, http.StatusBadRequest)
+		return
+	}
+	c := cookie.GetSessionCookie(r)
+	err = h.service.UpdateDigest(c.Value, digest)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidDigest) {
+			h.app.ClientError(w, http.StatusBadRequest)
+			return
+		}
+		h.app.ServerError(w, err)
+		return
+	}
+	user, err := h.service.GetUser(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	http.Redirect(w, r, "/user/"+user.Name, http.StatusSeeOther)
+}
+
+func (h *handler) userActivity(w http.ResponseWriter, r *http.Request, username string) {
+	data, err := h.NewTemplateData(r)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	data.Profile, err = h.service.GetUserByName(username)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+	if !data.Profile.ProfileVisibleTo(data.User) {
+		h.app.ClientError(w, http.StatusForbidden)
+		return
+	}
+	data, err = h.service.SetUpPage(data, r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.app.NotFound(w)
+		} else {
+			h.app.ServerError(w, err)
+		}
+		return
+	}
+
+	data.Activities, err = h.service.GetUserActivityPaginated(data.User, data.Profile, data.CurrentPage, data.Limit)
+	if err != nil {
+		h.app.ServerError(w, err)
+		return
+	}
+	if len(*data.Activities) == 0 {
+		data.Activities = nil
+	}
+
+	h.app.Render(w, http.StatusOK, "activity.html", data)
+}
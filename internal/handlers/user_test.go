@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"cmp"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,7 +15,10 @@ import (
 	"github.com/tebeka/selenium"
 	"github.com/xuri/excelize/v2"
 
+	"forum/internal/config"
 	mocks "forum/internal/repo/mocks"
+	"forum/models"
+	"forum/pkg/urls"
 )
 
 var Log = logrus.New()
@@ -41,6 +46,7 @@ type SignupTestCase struct {
 	Password      string
 	PasswordAgain string
 	WantCode      int
+	DisplayName   string
 }
 
 func loadSignupTestData(fileName, sheetName string) ([]SignupTestCase, error) {
@@ -73,6 +79,10 @@ func loadSignupTestData(fileName, sheetName string) ([]SignupTestCase, error) {
 			PasswordAgain: row[4],
 			WantCode:      wantCode,
 		}
+		// The display name column is optional.
+		if len(row) > 6 {
+			testCase.DisplayName = row[6]
+		}
 		tests = append(tests, testCase)
 	}
 	return tests, nil
@@ -119,7 +129,7 @@ func loadLoginTestData(fileName, sheetName string) ([]LoginTestCase, error) {
 }
 
 func TestSignUp(t *testing.T) {
-	ts := NewTestServer(t)
+	ts := NewTestServerWithConfig(t, &config.Config{DisplayNames: true, UsernamePolicy: models.DefaultUsernamePolicy})
 	defer ts.Close()
 
 	logrus.Info("TestSignUp: Starting Excel-driven tests for /signup")
@@ -138,6 +148,7 @@ func TestSignUp(t *testing.T) {
 			form.Add("email", tt.Email)
 			form.Add("password", tt.Password)
 			form.Add("password", tt.PasswordAgain)
+			form.Add("display_name", tt.DisplayName)
 
 			code, _, _ := ts.postForm(t, "/signup", form)
 
@@ -239,7 +250,13 @@ func TestUserLoginBrowserStack(t *testing.T) {
 	}
 	defer wd.Quit()
 
-	forumURL := "http://188.227.35.5:8080/login"
+	// The forum under test is at $FORUM_BASE_URL, the staging server by
+	// default.
+	site, err := urls.New(cmp.Or(os.Getenv("FORUM_BASE_URL"), "http://188.227.35.5:8080"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forumURL := site.Path("/login")
 
 	for _, tc := range loginTests {
 		t.Run(tc.Name, func(t *testing.T) {
@@ -286,3 +303,574 @@ func TestUserLoginBrowserStack(t *testing.T) {
 
 	logrus.Info("TestUserLoginBrowserStack: Completed BrowserStack E2E tests for /login")
 }
+
+func TestUserActivity(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name          string
+		url           string
+		token         string
+		wantCode      int
+		wantReactions bool
+	}{
+		{
+			name:          "Owner sees reactions",
+			url:           "/user/test/activity",
+			token:         sessionCookieValue,
+			wantCode:      http.StatusOK,
+			wantReactions: true,
+		},
+		{
+			name:     "Other user doesn't see reactions",
+			url:      "/user/test/activity",
+			token:    mocks.AdminToken,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Guest doesn't see reactions",
+			url:      "/user/test/activity",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Unknown user",
+			url:      "/user/nobody/activity",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Unknown page",
+			url:      "/user/test/nothing",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				code int
+				body string
+			)
+			if tt.token == "" {
+				code, _, body = ts.get(t, tt.url)
+			} else {
+				code, _, body = ts.getWithSession(t, tt.url, tt.token)
+			}
+			mocks.Equal(t, code, tt.wantCode)
+			if code != http.StatusOK {
+				return
+			}
+			mocks.StringContains(t, body, "public post")
+			mocks.StringContains(t, body, "public comment")
+			mocks.Equal(t, strings.Contains(body, "private reaction"), tt.wantReactions)
+		})
+	}
+}
+
+func TestSignUpInviteOnly(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{InviteOnly: true})
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		invite   string
+		wantCode int
+	}{
+		{
+			name:     "Valid invite code",
+			invite:   mocks.ValidInviteCode,
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Already used invite code",
+			invite:   "usedInvite",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "No invite code",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("name", "newbie")
+			form.Add("email", "newbie@gmail.com")
+			form.Add("password", "newbie123")
+			form.Add("invite", tt.invite)
+
+			code, _, _ := ts.postForm(t, "/signup", form)
+			mocks.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestSignUpSimilarName(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		username    string
+		confirmName string
+		wantCode    int
+		wantError   string
+	}{
+		{"Rejected", models.SimilarNamesReject, "Adm1n", "", http.StatusUnprocessableEntity, "Name is too much like an existing one"},
+		{"Rejected despite confirming", models.SimilarNamesReject, "Adm1n", "Adm1n", http.StatusUnprocessableEntity, "Name is too much like an existing one"},
+		{"Warned", models.SimilarNamesWarn, "Adm1n", "", http.StatusUnprocessableEntity, `looks a lot like admin`},
+		{"Warned then confirmed", models.SimilarNamesWarn, "Adm1n", "Adm1n", http.StatusSeeOther, ""},
+		{"Punctuation", models.SimilarNamesReject, "ad.min", "", http.StatusUnprocessableEntity, "Name is too much like an existing one"},
+		{"Unlike any", models.SimilarNamesReject, "maxwell", "", http.StatusSeeOther, ""},
+		{"Off", models.SimilarNamesOff, "Adm1n", "", http.StatusSeeOther, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{SimilarNames: tt.policy})
+			defer ts.Close()
+
+			form := url.Values{}
+			form.Add("name", tt.username)
+			form.Add("email", "maxwell@gmail.com")
+			form.Add("password", "password123")
+			form.Add("confirm_name", tt.confirmName)
+
+			code, _, body := ts.postForm(t, "/signup", form)
+			mocks.Equal(t, code, tt.wantCode)
+			if tt.wantError != "" {
+				mocks.StringContains(t, body, tt.wantError)
+			}
+			if tt.policy == models.SimilarNamesWarn && tt.confirmName == "" {
+				mocks.StringContains(t, body, `name="confirm_name" value="Adm1n"`)
+			}
+		})
+	}
+}
+
+func TestSignUpUsernamePolicy(t *testing.T) {
+	lower, err := models.ParseUsernameCharset("lower")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := models.UsernamePolicy{Charset: lower, MinChars: 4, MaxChars: 8, Reserved: []string{"staff"}}
+	ts := NewTestServerWithConfig(t, &config.Config{UsernamePolicy: policy})
+	defer ts.Close()
+
+	tests := []struct {
+		username  string
+		wantCode  int
+		wantError string
+	}{
+		{"Staff", http.StatusUnprocessableEntity, "This name is reserved"},
+		{"Maxwell", http.StatusUnprocessableEntity, "Names must be 4 to 8 characters long and use only lowercase Latin letters, digits and underscores"},
+		{"max", http.StatusUnprocessableEntity, "Names must be 4 to 8 characters long"},
+		{"maxwell", http.StatusSeeOther, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			form := url.Values{"name": {tt.username}, "email": {"maxwell@gmail.com"}, "password": {"password123"}}
+			code, _, body := ts.postForm(t, "/signup", form)
+			mocks.Equal(t, code, tt.wantCode)
+			if tt.wantError != "" {
+				mocks.StringContains(t, body, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestInvites(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{InviteQuota: 1})
+	defer ts.Close()
+
+	code, _, body := ts.getWithSession(t, "/invites", sessionCookieValue)
+	mocks.Equal(t, code, http.StatusOK)
+	mocks.StringContains(t, body, mocks.ValidInviteCode)
+
+	code, header, _ := ts.postFormWithSession(t, "/invites/create", url.Values{}, sessionCookieValue)
+	mocks.Equal(t, code, http.StatusSeeOther)
+	mocks.Equal(t, header.Get("Location"), "/invites")
+}
+
+func TestInvitesQuota(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{InviteQuota: 0})
+	defer ts.Close()
+
+	code, _, _ := ts.postFormWithSession(t, "/invites/create", url.Values{}, sessionCookieValue)
+	mocks.Equal(t, code, http.StatusForbidden)
+
+	code, _, _ = ts.postFormWithSession(t, "/invites/create", url.Values{}, mocks.AdminToken)
+	mocks.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestUserProfilePrivacy(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		profile     string
+		email       string
+		token       string
+		wantVisible bool
+	}{
+		{
+			name:        "Public profile, guest",
+			profile:     "test",
+			email:       "test@gmail.com",
+			wantVisible: true,
+		},
+		{
+			name:        "Public profile, logged in user",
+			profile:     "test",
+			email:       "test@gmail.com",
+			token:       mocks.AdminToken,
+			wantVisible: true,
+		},
+		{
+			name:    "Logged in only profile, guest",
+			profile: "shy",
+			email:   "shy@gmail.com",
+		},
+		{
+			name:        "Logged in only profile, logged in user",
+			profile:     "shy",
+			email:       "shy@gmail.com",
+			token:       sessionCookieValue,
+			wantVisible: true,
+		},
+		{
+			name:    "Private profile, guest",
+			profile: "hermit",
+			email:   "hermit@gmail.com",
+		},
+		{
+			name:    "Private profile, logged in user",
+			profile: "hermit",
+			email:   "hermit@gmail.com",
+			token:   sessionCookieValue,
+		},
+		{
+			name:        "Private profile, owner",
+			profile:     "hermit",
+			email:       "hermit@gmail.com",
+			token:       mocks.HermitToken,
+			wantVisible: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				code int
+				body string
+			)
+			profileURL := "/user/" + tt.profile
+			if tt.token == "" {
+				code, _, body = ts.get(t, profileURL)
+			} else {
+				code, _, body = ts.getWithSession(t, profileURL, tt.token)
+			}
+			mocks.Equal(t, code, http.StatusOK)
+			mocks.Equal(t, strings.Contains(body, tt.email), tt.wantVisible)
+			mocks.Equal(t, strings.Contains(body, "This profile is private"), !tt.wantVisible)
+
+			wantCode := http.StatusOK
+			if !tt.wantVisible {
+				wantCode = http.StatusForbidden
+			}
+			if tt.token == "" {
+				code, _, _ = ts.get(t, profileURL+"/activity")
+			} else {
+				code, _, _ = ts.getWithSession(t, profileURL+"/activity", tt.token)
+			}
+			mocks.Equal(t, code, wantCode)
+		})
+	}
+}
+
+func TestUserPrivacyUpdate(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		privacy  string
+		wantCode int
+	}{
+		{name: "Public", privacy: "0", wantCode: http.StatusSeeOther},
+		{name: "Logged in only", privacy: "1", wantCode: http.StatusSeeOther},
+		{name: "Private", privacy: "2", wantCode: http.StatusSeeOther},
+		{name: "Unknown setting", privacy: "3", wantCode: http.StatusBadRequest},
+		{name: "Not a number", privacy: "nah", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("privacy", tt.privacy)
+
+			code, _, _ := ts.postFormWithSession(t, "/user/privacy", form, sessionCookieValue)
+			mocks.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestUserDigestUpdate(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		digest   string
+		wantCode int
+	}{
+		{name: "Off", digest: "0", wantCode: http.StatusSeeOther},
+		{name: "Daily", digest: "1", wantCode: http.StatusSeeOther},
+		{name: "Weekly", digest: "2", wantCode: http.StatusSeeOther},
+		{name: "Unknown frequency", digest: "3", wantCode: http.StatusBadRequest},
+		{name: "Not a number", digest: "often", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("digest", tt.digest)
+
+			code, _, _ := ts.postFormWithSession(t, "/user/digest", form, sessionCookieValue)
+			mocks.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestUserFollowToggle(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	follow := func(path, name string) int {
+		form := url.Values{}
+		form.Add("name", name)
+		code, _, _ := ts.postFormWithSession(t, path, form, sessionCookieValue)
+		return code
+	}
+
+	_, _, body := ts.getWithSession(t, "/user/admin", sessionCookieValue)
+	mocks.StringContains(t, body, `value="Follow"`)
+
+	mocks.Equal(t, follow("/user/follow", "admin"), http.StatusSeeOther)
+	_, _, body = ts.getWithSession(t, "/user/admin", sessionCookieValue)
+	mocks.StringContains(t, body, `value="Unfollow"`)
+
+	mocks.Equal(t, follow("/user/unfollow", "admin"), http.StatusSeeOther)
+	_, _, body = ts.getWithSession(t, "/user/admin", sessionCookieValue)
+	mocks.StringContains(t, body, `value="Follow"`)
+
+	mocks.Equal(t, follow("/user/follow", "test"), http.StatusBadRequest)
+	mocks.Equal(t, follow("/user/follow", "nobody"), http.StatusNotFound)
+	mocks.Equal(t, follow("/user/follow", ""), http.StatusBadRequest)
+}
+
+func TestFollowingFeed(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	form := url.Values{}
+	form.Add("name", "admin")
+	code, _, _ := ts.postFormWithSession(t, "/user/follow", form, sessionCookieValue)
+	mocks.Equal(t, code, http.StatusSeeOther)
+
+	code, _, body := ts.getWithSession(t, "/feed/following", sessionCookieValue)
+	mocks.Equal(t, code, http.StatusOK)
+	mocks.StringContains(t, body, "post by admin")
+	if strings.Contains(body, "post by hermit") {
+		t.Errorf("feed contains posts by a user that isn't followed")
+	}
+
+	code, _, _ = ts.get(t, "/feed/following")
+	mocks.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestDisplayName(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{DisplayNames: true})
+	defer ts.Close()
+
+	_, _, body := ts.get(t, fmt.Sprintf("/post/%d", mocks.DisplayNamePostID))
+	mocks.StringContains(t, body, "By "+mocks.AdminDisplayName+" on")
+	_, _, body = ts.get(t, "/post/1")
+	mocks.StringContains(t, body, "By test on")
+
+	_, _, body = ts.get(t, "/user/admin")
+	mocks.StringContains(t, body, `<h2 class="headerPosts">`+mocks.AdminDisplayName+`</h2>`)
+	mocks.StringContains(t, body, "@admin")
+	_, _, body = ts.getWithSession(t, "/", mocks.AdminToken)
+	mocks.StringContains(t, body, mocks.AdminDisplayName)
+
+	tests := []struct {
+		name        string
+		displayName string
+		wantCode    int
+	}{
+		{name: "Set", displayName: "Tess T.", wantCode: http.StatusSeeOther},
+		{name: "Cleared", displayName: "", wantCode: http.StatusSeeOther},
+		{name: "Too long", displayName: strings.Repeat("a", models.DisplayNameMaxChars+1), wantCode: http.StatusBadRequest},
+		{name: "Markup", displayName: "<i>test</i>", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("display_name", tt.displayName)
+			code, _, _ := ts.postFormWithSession(t, "/user/display-name", form, sessionCookieValue)
+			mocks.Equal(t, code, tt.wantCode)
+		})
+	}
+
+	ts = NewTestServer(t)
+	defer ts.Close()
+	_, _, body = ts.get(t, "/signup")
+	if strings.Contains(body, `name="display_name"`) {
+		t.Error("expected no display name field when display names are off")
+	}
+	form := url.Values{}
+	form.Add("display_name", "Tess")
+	code, _, _ := ts.postFormWithSession(t, "/user/display-name", form, sessionCookieValue)
+	mocks.Equal(t, code, http.StatusNotFound)
+}
+
+func TestTimezone(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	// The same stored time shows in each viewer's zone, in UTC for guests.
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{name: "Guest", want: "Joined 01 Jan 2024 at 20:00"},
+		{name: "No zone", token: sessionCookieValue, want: "Joined 01 Jan 2024 at 20:00"},
+		{name: "Asia/Tokyo", token: mocks.TokyoToken, want: "Joined 02 Jan 2024 at 05:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body string
+			if tt.token == "" {
+				_, _, body = ts.get(t, "/user/tokyo")
+			} else {
+				_, _, body = ts.getWithSession(t, "/user/tokyo", tt.token)
+			}
+			mocks.StringContains(t, body, tt.want)
+		})
+	}
+
+	for _, tt := range []struct {
+		zone     string
+		wantCode int
+	}{
+		{zone: "Europe/Berlin", wantCode: http.StatusSeeOther},
+		{zone: "", wantCode: http.StatusSeeOther},
+		{zone: "Local", wantCode: http.StatusBadRequest},
+		{zone: "Mars/Olympus_Mons", wantCode: http.StatusBadRequest},
+	} {
+		form := url.Values{}
+		form.Add("timezone", tt.zone)
+		code, _, _ := ts.postFormWithSession(t, "/user/timezone", form, sessionCookieValue)
+		mocks.Equal(t, code, tt.wantCode)
+	}
+}
+
+func TestLoginRedirect(t *testing.T) {
+	login := url.Values{"email": {"max@gmail.com"}, "password": {"maxmax01"}}
+
+	t.Run("Back to the requested page", func(t *testing.T) {
+		ts := NewTestServerWithConfig(t, &config.Config{LoginRedirect: true})
+		defer ts.Close()
+
+		code, header, _ := ts.get(t, "/notifications?limit=5")
+		mocks.Equal(t, code, http.StatusSeeOther)
+		mocks.Equal(t, header.Get("Location"), "/login")
+
+		code, header, _ = ts.postForm(t, "/login", login)
+		mocks.Equal(t, code, http.StatusSeeOther)
+		mocks.Equal(t, header.Get("Location"), "/notifications?limit=5")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		ts := NewTestServer(t)
+		defer ts.Close()
+
+		ts.get(t, "/notifications")
+		code, header, _ := ts.postForm(t, "/login", login)
+		mocks.Equal(t, code, http.StatusSeeOther)
+		mocks.Equal(t, header.Get("Location"), "/")
+	})
+
+	for _, target := range []string{"https://evil.example/login", "//evil.example", `/\evil.example`, "javascript:alert(1)"} {
+		t.Run("Rejects "+target, func(t *testing.T) {
+			ts := NewTestServerWithConfig(t, &config.Config{LoginRedirect: true})
+			defer ts.Close()
+
+			u, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ts.Client().Jar.SetCookies(u, []*http.Cookie{{Name: "redirect", Value: url.QueryEscape(target), Path: "/"}})
+
+			code, header, _ := ts.postForm(t, "/login", login)
+			mocks.Equal(t, code, http.StatusSeeOther)
+			mocks.Equal(t, header.Get("Location"), "/")
+		})
+	}
+}
+
+func TestLoginFailureHidesAccounts(t *testing.T) {
+	ts := NewTestServer(t)
+	defer ts.Close()
+
+	// max@gmail.com has an account, nobody@gmail.com doesn't.
+	var bodies []string
+	for _, email := range []string{"max@gmail.com", "nobody@gmail.com"} {
+		code, _, body := ts.postForm(t, "/login", url.Values{"email": {email}, "password": {"wrongpass"}})
+		mocks.Equal(t, code, http.StatusUnprocessableEntity)
+		mocks.StringContains(t, body, `<label class="error">Email or password is incorrect</label>`)
+		bodies = append(bodies, strings.ReplaceAll(body, email, ""))
+	}
+	if strings.Count(bodies[0], `class="error"`) != strings.Count(bodies[1], `class="error"`) {
+		t.Errorf("got different errors for an unknown email and a wrong password")
+	}
+}
+
+func TestSignUpDisabled(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{SignupDisabled: true, SignupMessage: "Back on Monday", InviteOnly: true})
+	defer ts.Close()
+
+	code, _, body := ts.get(t, "/signup")
+	mocks.Equal(t, code, http.StatusForbidden)
+	mocks.StringContains(t, body, "Back on Monday")
+	mocks.Equal(t, strings.Contains(body, `action="/signup"`), false)
+	mocks.Equal(t, strings.Contains(body, `href="/signup"`), false)
+
+	// Not even a valid invite gets in.
+	form := url.Values{"name": {"newbie"}, "email": {"newbie@gmail.com"}, "password": {"newbie123"}, "invite": {mocks.ValidInviteCode}}
+	code, _, body = ts.postForm(t, "/signup", form)
+	mocks.Equal(t, code, http.StatusForbidden)
+	mocks.StringContains(t, body, "Back on Monday")
+
+	code, header, _ := ts.postForm(t, "/login", url.Values{"email": {"max@gmail.com"}, "password": {"maxmax01"}})
+	mocks.Equal(t, code, http.StatusSeeOther)
+	mocks.StringContains(t, header.Get("Set-Cookie"), "session_id=")
+}
+
+func TestSignUpEmailAlias(t *testing.T) {
+	ts := NewTestServerWithConfig(t, &config.Config{EmailPolicy: models.DefaultEmailPolicy})
+	defer ts.Close()
+
+	for _, email := range []string{"max+forum@gmail.com", "m.a.x@gmail.com"} {
+		form := url.Values{}
+		form.Add("name", "newbie")
+		form.Add("email", email)
+		form.Add("password", "newbie123")
+
+		code, _, body := ts.postForm(t, "/signup", form)
+		mocks.Equal(t, code, http.StatusUnprocessableEntity)
+		mocks.StringContains(t, body, "Email address is already in use")
+	}
+}
@@ -3,16 +3,127 @@ package repo
 import (
 	"forum/internal/repo/sqlite"
 	"forum/models"
+	"time"
 )
 
 type UserRepo interface {
 	CreateUser(models.User) error
 	GetUserByID(int) (*models.User, error)
 	GetUserByEmail(string) (*models.User, error)
+	GetUserByName(string) (*models.User, error)
+	GetUserNames() ([]string, error)
+	UpdateUserPrivacy(userID, privacy int) error
+	UpdateUserDigest(userID, digest int) error
+	UpdateUserSort(userID int, sort string) error
+	UpdateUserDisplayName(userID int, displayName string) error
+	UpdateUserTimezone(userID int, zone string) error
 	UpdateUserByID(string) (*models.User, error)
 	Authenticate(email, password string) (int, error)
 }
 
+type ActivityRepo interface {
+	GetUserActivityPaginated(userID int, withReactions bool, page, pageSize int) (*[]models.Activity, error)
+	GetPageNumberActivity(pageSize int, userID int, withReactions bool) (int, error)
+	GetTopContributors(metric string, since time.Time, limit int) ([]models.Contributor, error)
+}
+
+type InviteRepo interface {
+	CreateInvite(*models.Invite) error
+	CountInvitesByUserID(userID int) (int, error)
+	GetInvitesByUserID(userID int) (*[]models.Invite, error)
+	CreateUserWithInvite(u models.User, code string) error
+}
+
+type PasswordResetRepo interface {
+	CreatePasswordReset(*models.PasswordReset) error
+	ResetPassword(tokenHash string, hashedPassword []byte) (int, error)
+}
+
+type AuditRepo interface {
+	CreateAuditEntry(*models.AuditEntry) error
+	UpdateUserStatus(userID, status int, entry *models.AuditEntry) error
+	GetAuditLogPaginated(filter models.AuditFilter, page, pageSize int) (*[]models.AuditEntry, error)
+	GetPageNumberAudit(pageSize int, filter models.AuditFilter) (int, error)
+}
+
+type BackupRepo interface {
+	ExportBackup(emit func(models.BackupRecord) error) error
+	ImportBackup(source string, records []models.BackupRecord) (*models.ImportSummary, error)
+	FinishImport(source string) error
+}
+
+type HistoryRepo interface {
+	RecordView(userID, postID, keep int) error
+	GetHistory(userID int) (*[]models.Post, error)
+}
+
+type FollowRepo interface {
+	Follow(models.Follow) error
+	Unfollow(models.Follow) error
+	IsFollowing(followerID, followeeID int) (bool, error)
+	GetFollowingPostsPaginated(userID, page, pageSize int) (*[]models.Post, error)
+	GetPageNumberFollowing(pageSize int, userID int) (int, error)
+}
+
+type SubscriptionRepo interface {
+	Subscribe(userID, postID int) error
+	SetSubscription(models.Subscription) error
+	IsSubscribed(userID, postID int) (bool, error)
+	GetSubscribers(postID int) ([]int, error)
+	SubscribeCategory(models.CategorySubscription) error
+	UnsubscribeCategory(models.CategorySubscription) error
+	IsSubscribedCategory(userID, categoryID int) (bool, error)
+	GetCategorySubscribers(categoryIDs []int) ([]int, error)
+	GetSubscribedCategoriesPostsPaginated(userID, page, pageSize int) (*[]models.Post, error)
+	GetPageNumberSubscribedCategories(pageSize int, userID int) (int, error)
+}
+
+type DraftRepo interface {
+	GetDraft(userID int, session string) (*models.Draft, error)
+	SaveDraft(*models.Draft) error
+}
+
+type NotificationRepo interface {
+	CreateNotifications([]models.Notification) error
+	CreateNotificationOnce(models.Notification) (bool, error)
+	GetDigestRecipients() (*[]models.DigestRecipient, error)
+	ClaimDigest(userID int, last *time.Time, now time.Time) ([]models.Notification, error)
+	CountUnread(userID int) (int, error)
+	GetNotifications(userID, limit int) (*[]models.Notification, error)
+	MarkNotificationRead(userID, notificationID int) (*models.Notification, error)
+	MarkAllNotificationsRead(userID, upTo int) error
+}
+
+type ModerationRepo interface {
+	BulkModerate(action, reason string, targets []models.ModerationTarget) ([]models.ModerationResult, error)
+}
+
+type ArchiveRepo interface {
+	ArchiveInactivePosts(cutoff time.Time) (int, error)
+	IsPostArchived(postID int) (bool, error)
+}
+
+type AnnouncementRepo interface {
+	CreateAnnouncement(*models.Announcement) error
+	DeleteAnnouncement(id int) error
+	GetAnnouncements() (*[]models.Announcement, error)
+	GetActiveAnnouncements(now time.Time, scope models.AnnouncementScope) (*[]models.Announcement, error)
+}
+
+type LabelRepo interface {
+	AddPostLabel(models.PostLabel) error
+	RemovePostLabel(models.PostLabel) error
+	GetLabelsByPostIDs(postIDs []int) (map[int][]string, error)
+	GetAllPostByLabelPaginated(page, pageSize int, label, sort string) (*[]models.Post, error)
+	GetPageNumberLabel(pageSize int, label string) (int, error)
+}
+
+type GuestRepo interface {
+	GuestAccountID() (int, error)
+	CreateGuestPost(userID int, p *models.GuestPost) error
+	GetGuestPosts() (*[]models.GuestPost, error)
+}
+
 type SessionRepo interface {
 	GetUserIDByToken(string) (int, error)
 	CreateSession(*models.Session) error
@@ -25,41 +136,76 @@ type PostRepo interface {
 	CreatePost(userID int, title, content, imageName string) (int, error)
 	GetPostByID(int) (*models.Post, error)
 	GetCategoriesByPostID(int) (map[int]string, error)
+	GetCategoriesByPostIDs(postIDs []int) (map[int]map[int]string, error)
 	// GetAllPost() (*models.Post, error)
 	// UpdatePost(string, *models.Post) error
 	GetLikedPostsPaginated(userID, page, pageSize int) (*[]models.Post, error)
 	GetAllPostByUserIDPaginated(userID, page, pageSize int) (*[]models.Post, error)
 	GetAllPostByCategory(category int) (*[]models.Post, error)
 	GetPageNumber(pageSize int, category int) (int, error)
-	GetAllPostPaginated(page int, pageSize int) (*[]models.Post, error)
-	GetAllPostByCategoryPaginated(page int, pageSize int, category int) (*[]models.Post, error)
+	GetAllPostPaginated(page int, pageSize int, sort string) (*[]models.Post, error)
+	GetAllPostByCategoryPaginated(page int, pageSize int, category int, sort string) (*[]models.Post, error)
 	GetPageNumberLikedPosts(pageSize int, userID int) (int, error)
+	GetParticipatedPostsPaginated(userID, page, pageSize int) (*[]models.Post, error)
+	GetPageNumberParticipated(pageSize int, userID int) (int, error)
 	GetPageNumberMyPosts(pageSize int, userID int) (int, error)
 	CheckPostExists(postID int) bool
+	SetAcceptedAnswer(postID, commentID int) error
+	SearchPostsPaginated(filter models.SearchFilter, page, pageSize int) (*[]models.Post, error)
+	SearchCount(filter models.SearchFilter) (int, error)
+	GetSuggestions(prefix string, limit int) ([]models.Suggestion, error)
+	SuggestMentions(prefix string, viewerID, postID, limit int) ([]models.MentionSuggestion, error)
+	GetRelatedPosts(postID, limit int) (*[]models.Post, error)
+	GetPostsAfter(after, category, limit int) (*[]models.Post, error)
+	UpdatePost(rev *models.PostRevision, title, content string) error
+	GetLastRevision(postID int) (*models.PostRevision, error)
+	SetProfilePinned(postID int, pinned bool) error
+	CountProfilePins(userID int) (int, error)
+	DeletePost(postID int, hard bool) (bool, error)
+	CountPostsSince(userID int, since time.Time) (int, error)
+	GetPostTimesSince(userID int, since time.Time) ([]time.Time, error)
 }
 
 type InteractionRepo interface {
-	AddReactionPost(form models.ReactionForm) error
-	DeleteReactionPost(form models.ReactionForm, isLike bool) error
+	TogglePostReaction(form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error)
 	GetReactionPost(userID, postID int) (bool, bool, error)
 	GetReactionPosts(userID int) (map[int]bool, error)
 	GetReactionComments(userID, postID int) (map[int]bool, error)
+	SetPostReactionType(form models.ReactionForm) error
+	DeletePostReactionType(userID, postID int) error
+	GetPostReactionType(userID, postID int) (string, error)
+	CountPostReactionTypes(postID int) (map[string]int, error)
+	GetReactors(target models.ModerationTarget, reactionType string, page, pageSize int) (*[]models.Reactor, error)
+	CountReactors(target models.ModerationTarget, reactionType string) (int, error)
+	GetPostScores(postIDs []int, weights models.VoteWeights) (map[int]int, error)
 }
 
 type CategoryRepo interface {
 	AddCategoryToPost(int, []int) error
 	GetALLCategory() ([]string, error)
+	GetTrendingCategories(since time.Time, limit int) ([]string, error)
+	AddCategoryModerator(userID, categoryID int) error
+	RemoveCategoryModerator(userID, categoryID int) error
+	IsCategoryModerator(userID, postID int) (bool, error)
+	SetCategoryCommentMode(categoryID int, mode string) error
+	MovePostToCategory(postID, categoryID int) error
+	UncategorizedID() (int, error)
+	GetCommentMode(postID int) (string, error)
+	SetCategoryPostStatus(categoryID, status int) error
+	GetPostStatus(categoryIDs []int) (int, error)
 	// CreateCategory(string) error
 }
 
 type CommentRepo interface {
-	CommentPost(models.CommentForm) error
+	CommentPost(models.CommentForm) (int, error)
+	GetCommentByID(commentID int) (*models.Comment, error)
+	GetLastCommentTime(userID int) (time.Time, error)
 	GetCommentsByPostID(postID int) (*[]models.Comment, error)
+	GetPendingComments() (*[]models.Comment, error)
 	// 	GetAllCommentByUserID(string) (*[]models.Post, error)
-	CheckReactionComment(form models.ReactionForm) (bool, bool, error)
-	AddReactionComment(form models.ReactionForm) error
-	DeleteReactionComment(form models.ReactionForm, isLike bool) error
+	ToggleCommentReaction(form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error)
 	CheckCommentExists(commentID int) bool
+	DeleteComment(commentID int, hard bool) (bool, error)
 }
 
 type RepoI interface {
@@ -69,8 +215,37 @@ type RepoI interface {
 	CategoryRepo
 	CommentRepo
 	InteractionRepo
+	ActivityRepo
+	InviteRepo
+	ModerationRepo
+	PasswordResetRepo
+	AuditRepo
+	FollowRepo
+	NotificationRepo
+	SubscriptionRepo
+	HistoryRepo
+	BackupRepo
+	DraftRepo
+	ArchiveRepo
+	AnnouncementRepo
+	LabelRepo
+	GuestRepo
 }
 
 func New(storagePath string) (RepoI, error) {
 	return sqlite.NewDB(storagePath)
 }
+
+// NewWithSessions is New with the sessions kept by the sessions backend, see
+// NewSessionStore, and expired by limits.
+func NewWithSessions(storagePath, sessions, redisAddr string, limits models.SessionLimits) (RepoI, error) {
+	db, err := sqlite.NewDB(storagePath)
+	if err != nil {
+		return nil, err
+	}
+	store, err := NewSessionStore(sessions, db, redisAddr)
+	if err != nil {
+		return nil, err
+	}
+	return WithSessions(db, store, limits), nil
+}
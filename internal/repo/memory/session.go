@@ -0,0 +1,84 @@
+// Package memory keeps sessions in the memory of the process. They are lost
+// on restart and not shared between instances, which suits development and
+// tests.
+package memory
+
+import (
+	"forum/models"
+	"sync"
+	"time"
+)
+
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]models.Session
+}
+
+func NewSessionStore() *SessionStore {
+	return &SessionStore{sessions: map[string]models.Session{}}
+}
+
+// Get returns the session of token, or ErrNoRecord if there is none or it
+// expired.
+func (s *SessionStore) Get(token string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[token]
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+	if !session.ExpTime.After(time.Now()) {
+		delete(s.sessions, token)
+		return nil, models.ErrNoRecord
+	}
+	return &session, nil
+}
+
+// Set stores session, dropping all expired sessions on the way so the map
+// doesn't grow without bound.
+func (s *SessionStore) Set(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for token, stored := range s.sessions {
+		if !stored.ExpTime.After(now) {
+			delete(s.sessions, token)
+		}
+	}
+	s.sessions[session.Token] = *session
+	return nil
+}
+
+// Touch sets the LastSeen of the session of token, if there is one.
+func (s *SessionStore) Touch(token string, lastSeen time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[token]; ok {
+		session.LastSeen = lastSeen
+		s.sessions[token] = session
+	}
+	return nil
+}
+
+func (s *SessionStore) Delete(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, token)
+	return nil
+}
+
+func (s *SessionStore) DeleteByUser(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, token)
+		}
+	}
+	return nil
+}
@@ -1,11 +1,89 @@
 package mock
 
 import (
+	"fmt"
 	"forum/models"
+	"maps"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
+// Session tokens that the mock resolves to particular users. Any other token
+// belongs to the user "test" with ID 1.
+const (
+	AdminToken       = "adminToken"
+	HermitToken      = "hermitToken"
+	CategoryModToken = "categoryModToken"
+	TopSortToken     = "topSortToken"
+	TokyoToken       = "tokyoToken"
+	NewbieToken      = "newbieToken"
+	adminID          = 2
+	shyID            = 3
+	hermitID         = 4
+	categoryModID    = 5
+	topSortID        = 6
+	tokyoID          = 7
+	newbieID         = 8
+	guestID          = 9
+	defaultUser      = 1
+	defaultEmail     = "test@gmail.com"
+)
+
+// GuestPostID is what the ids of guest posts start from.
+const GuestPostID = 1000
+
+// AdminDisplayName is the display name of admin, who wrote DisplayNamePostID.
+const (
+	AdminDisplayName  = "Site Admin"
+	DisplayNamePostID = 7
+)
+
+// JoinedAt is when the user "tokyo", who sees dates in Asia/Tokyo, signed up.
+var JoinedAt = time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+
+// NewbiePostsToday is how many posts newbie, who just signed up, made so
+// far. Everyone else has been posting for long.
+const NewbiePostsToday = 1
+
+// PostsToday is how many posts the other users made in the last day.
+const PostsToday = 3
+
+// MarkdownPostID is the post whose content is MarkdownContent.
+const (
+	MarkdownPostID  = 6
+	MarkdownContent = "# Title\n\nSome **bold** and `code` with a [link](https://example.com).\n\n- one\n- two ||and a spoiler||\n\n<script>alert(1)</script>"
+)
+
+// EmbedPostID is the post that links a YouTube video and an arbitrary page.
+const (
+	EmbedPostID  = 8
+	EmbedContent = "Watch https://www.youtube.com/watch?v=dQw4w9WgXcQ and read https://example.com/watch?v=dQw4w9WgXcQ"
+)
+
+var users = map[int]models.User{
+	defaultUser: {ID: defaultUser, Name: "test", Email: defaultEmail},
+	adminID:     {ID: adminID, Name: "admin", DisplayName: AdminDisplayName, Email: "admin@gmail.com", Status: models.StatusAdmin},
+	shyID:       {ID: shyID, Name: "shy", Email: "shy@gmail.com", Privacy: models.PrivacyLoggedIn},
+	hermitID:    {ID: hermitID, Name: "hermit", Email: "hermit@gmail.com", Privacy: models.PrivacyPrivate},
+	// catmod moderates category 1 only, which post 2 isn't in.
+	categoryModID: {ID: categoryModID, Name: "catmod", Email: "catmod@gmail.com"},
+	topSortID:     {ID: topSortID, Name: "topfan", Email: "topfan@gmail.com", Sort: models.SortTop},
+	tokyoID:       {ID: tokyoID, Name: "tokyo", Email: "tokyo@gmail.com", Created: JoinedAt, Timezone: "Asia/Tokyo"},
+	newbieID:      {ID: newbieID, Name: "newbie", Email: "newbie@gmail.com", Created: time.Now()},
+}
+
+var tokens = map[string]int{
+	AdminToken:       adminID,
+	HermitToken:      hermitID,
+	CategoryModToken: categoryModID,
+	TopSortToken:     topSortID,
+	TokyoToken:       tokyoID,
+	NewbieToken:      newbieID,
+}
+
 func NewMockRepo(t *testing.T) *MockRepo {
 	return &MockRepo{}
 }
@@ -26,7 +104,69 @@ func StringContains(t *testing.T, actual, expectedStr string) {
 	}
 }
 
-type MockRepo struct{}
+// MockRepo keeps the audit log, post revisions, follows, profile pins, new
+// comments and viewing history in memory, so tests can read back what a
+// request recorded.
+type MockRepo struct {
+	mu        sync.Mutex
+	audit     []models.AuditEntry
+	revisions []models.PostRevision
+	follows   map[models.Follow]bool
+	pins      map[int]bool
+	comments  []models.CommentForm
+	history   map[int][]int
+	commented map[int]time.Time
+	// subscriptions is keyed by user and post id.
+	subscriptions map[[2]int]bool
+	drafts        []models.Draft
+	// locks maps locked post ids to their lock reason.
+	locks map[int]string
+	// announcements holds the ones created on top of the seeded ones.
+	announcements []models.Announcement
+	labels        map[models.PostLabel]bool
+	// guestPosts is the queue of guest posts, nil where one was reviewed.
+	guestPosts []*models.GuestPost
+	// rejected holds the ids of new comments a moderator deleted.
+	rejected map[int]bool
+	// categorySubscribed holds the categories users subscribed to.
+	categorySubscribed map[models.CategorySubscription]bool
+	// reactionTypes holds the emoji reactions made, keyed by user and post
+	// id, on top of seededReactionTypes.
+	reactionTypes map[[2]int]string
+	// commentModes holds the comment modes set on categories; unset ones
+	// are flat.
+	commentModes map[int]string
+	// postStatuses holds the least statuses set to post in categories.
+	postStatuses map[int]int
+	// notificationsRead holds the notification each user marked all read
+	// up to.
+	notificationsRead map[int]int
+	// moved maps the posts moderators moved to the category they moved
+	// them to.
+	moved map[int]int
+	// once holds the notifications made with CreateNotificationOnce.
+	once map[models.Notification]bool
+	// calls counts the calls of the methods that list pages of posts
+	// shouldn't make once per post, that notifying many users shouldn't
+	// make once per user, and the notifications likes make.
+	calls map[string]int
+}
+
+func (r *MockRepo) count(method string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.calls == nil {
+		r.calls = map[string]int{}
+	}
+	r.calls[method]++
+}
+
+// Calls returns how many times method was called.
+func (r *MockRepo) Calls(method string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[method]
+}
 
 func (r *MockRepo) CreatePost(userID int, title, content, imageName string) (int, error) {
 	return userID, nil
@@ -56,7 +196,7 @@ func (r *MockRepo) CreateUser(u models.User) error {
 		return nil
 	}
 
-	if u.Email == "max@gmail.com" {
+	if u.Email == "max@gmail.com" || u.EmailKey == "max@gmail.com" {
 		return models.ErrDuplicateEmail
 	}
 	return nil
@@ -81,14 +221,6 @@ func (r *MockRepo) CreateReaction(userid, postid, reaction int) error {
 	return nil
 }
 
-func (r *MockRepo) DeleteReactionComment(form models.ReactionForm, isLike bool) error {
-	return nil
-}
-
-func (r *MockRepo) DeleteReactionPost(form models.ReactionForm, isLike bool) error {
-	return nil
-}
-
 func (r *MockRepo) GetLikes(postid int) (int, error) {
 	return 0, nil
 }
@@ -97,16 +229,21 @@ func (r *MockRepo) GetDislikes(postid int) (int, error) {
 	return 0, nil
 }
 
-func (r *MockRepo) AddReactionComment(form models.ReactionForm) error {
-	return nil
+// reactionState is the state a toggle leaves in the mock, the user's vote
+// alone.
+func reactionState(form models.ReactionForm) *models.ReactionState {
+	if form.Reaction {
+		return &models.ReactionState{Like: 1, Mine: 1}
+	}
+	return &models.ReactionState{Dislike: 1, Mine: -1}
 }
 
-func (r *MockRepo) AddReactionPost(form models.ReactionForm) error {
-	return nil
+func (r *MockRepo) TogglePostReaction(form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error) {
+	return reactionState(form), nil
 }
 
-func (r *MockRepo) CheckReactionComment(form models.ReactionForm) (bool, bool, error) {
-	return true, true, nil
+func (r *MockRepo) ToggleCommentReaction(form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error) {
+	return reactionState(form), nil
 }
 
 func (r *MockRepo) CreateComment(postid, userid int, text string) (int, error) {
@@ -129,8 +266,65 @@ func (r *MockRepo) CheckPostExists(postID int) bool {
 	return true
 }
 
-func (r *MockRepo) CommentPost(form models.CommentForm) error {
-	return nil
+func (r *MockRepo) CommentPost(form models.CommentForm) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.comments = append(r.comments, form)
+	if r.commented == nil {
+		r.commented = map[int]time.Time{}
+	}
+	r.commented[form.UserID] = time.Now()
+	return newCommentID(len(r.comments) - 1), nil
+}
+
+// New comments get ids from 100 on, in the order they were made.
+func newCommentID(i int) int {
+	return 100 + i
+}
+
+// newComments returns the comments stored since the mock was made that
+// match keep. The caller holds r.mu.
+func (r *MockRepo) newComments(keep func(models.Comment) bool) []models.Comment {
+	var comments []models.Comment
+	for i, form := range r.comments {
+		id := newCommentID(i)
+		if r.rejected[id] {
+			continue
+		}
+		user := users[form.UserID]
+		c := models.Comment{CommentID: id, PostID: form.PostID, UserID: form.UserID, UserName: user.Name, DisplayName: form.GuestName, Content: form.Content, Pending: form.Pending, QuotedCommentID: form.QuotedCommentID}
+		if keep(c) {
+			comments = append(comments, c)
+		}
+	}
+	return comments
+}
+
+func (r *MockRepo) GetPendingComments() (*[]models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comments := r.newComments(func(c models.Comment) bool { return c.Pending })
+	return &comments, nil
+}
+
+func (r *MockRepo) GetLastCommentTime(userID int) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last, ok := r.commented[userID]
+	if !ok {
+		return time.Time{}, models.ErrNoRecord
+	}
+	return last, nil
+}
+
+// LastComment returns the last comment stored, as the service passed it on.
+func (r *MockRepo) LastComment() (models.CommentForm, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.comments) == 0 {
+		return models.CommentForm{}, false
+	}
+	return r.comments[len(r.comments)-1], true
 }
 
 func (r *MockRepo) IsValidToken(token string) (bool, error) {
@@ -150,7 +344,10 @@ func (r *MockRepo) CreateSession(*models.Session) error {
 }
 
 func (r *MockRepo) GetUserIDByToken(token string) (int, error) {
-	return 1, nil
+	if id, ok := tokens[token]; ok {
+		return id, nil
+	}
+	return defaultUser, nil
 }
 
 func (r *MockRepo) DeleteSessionByUserID(userID int) error {
@@ -186,7 +383,22 @@ func (r *MockRepo) Exitsts(name string) (bool, error) {
 }
 
 func (r *MockRepo) GetCategoriesByPostID(id int) (map[int]string, error) {
-	return map[int]string{1: "category1", 2: "category2"}, nil
+	r.count("GetCategoriesByPostID")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if categoryID, ok := r.moved[id]; ok {
+		return map[int]string{categoryID: fmt.Sprintf("Category%d", categoryID)}, nil
+	}
+	return map[int]string{1: "Category1", 2: "Category2"}, nil
+}
+
+func (r *MockRepo) GetCategoriesByPostIDs(ids []int) (map[int]map[int]string, error) {
+	r.count("GetCategoriesByPostIDs")
+	categories := make(map[int]map[int]string)
+	for _, id := range ids {
+		categories[id] = map[int]string{1: "Category1", 2: "Category2"}
+	}
+	return categories, nil
 }
 
 func (r *MockRepo) GetReactionPost(userID, postID int) (bool, bool, error) {
@@ -200,24 +412,420 @@ func (r *MockRepo) GetReactionPosts(userID int) (map[int]bool, error) {
 	return map[int]bool{1: true}, nil
 }
 
+// GetReactionComments has the user like comment 1 and dislike comment 4.
 func (r *MockRepo) GetReactionComments(userID, postID int) (map[int]bool, error) {
-	return map[int]bool{1: true}, nil
+	r.count("GetReactionComments")
+	return map[int]bool{1: true, 4: false}, nil
+}
+
+// seededReactionTypes has shy react to post 1 with a heart.
+var seededReactionTypes = map[[2]int]string{{shyID, 1}: "❤️"}
+
+func (r *MockRepo) SetPostReactionType(form models.ReactionForm) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reactionTypes == nil {
+		r.reactionTypes = maps.Clone(seededReactionTypes)
+	}
+	r.reactionTypes[[2]int{form.UserID, form.ID}] = form.Type
+	return nil
+}
+
+func (r *MockRepo) DeletePostReactionType(userID, postID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reactionTypes == nil {
+		r.reactionTypes = maps.Clone(seededReactionTypes)
+	}
+	delete(r.reactionTypes, [2]int{userID, postID})
+	return nil
+}
+
+func (r *MockRepo) GetPostReactionType(userID, postID int) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reactionTypes == nil {
+		return seededReactionTypes[[2]int{userID, postID}], nil
+	}
+	return r.reactionTypes[[2]int{userID, postID}], nil
+}
+
+func (r *MockRepo) CountPostReactionTypes(postID int) (map[string]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reactions := r.reactionTypes
+	if reactions == nil {
+		reactions = seededReactionTypes
+	}
+	counts := make(map[string]int)
+	for key, t := range reactions {
+		if key[1] == postID {
+			counts[t]++
+		}
+	}
+	return counts, nil
+}
+
+// GoneReactorID is the account of a reactor to ScoredPostID that no longer
+// exists.
+const GoneReactorID = 99
+
+// GetReactors lists the likes and dislikes of ScoredPostID, and the one of
+// GoneReactorID, then the emoji reactions to posts, by user. Comments have
+// none.
+func (r *MockRepo) GetReactors(target models.ModerationTarget, reactionType string, page, pageSize int) (*[]models.Reactor, error) {
+	var reactors []models.Reactor
+	if target.Kind == models.TargetPost {
+		reactors = r.reactors(target.ID)
+	}
+	reactors = slices.DeleteFunc(reactors, func(reactor models.Reactor) bool {
+		return reactionType != "" && reactor.Type != reactionType
+	})
+	start := min((page-1)*pageSize, len(reactors))
+	reactors = reactors[start:min(start+pageSize, len(reactors))]
+	return &reactors, nil
+}
+
+func (r *MockRepo) CountReactors(target models.ModerationTarget, reactionType string) (int, error) {
+	var count int
+	if target.Kind == models.TargetPost {
+		for _, reactor := range r.reactors(target.ID) {
+			if reactionType == "" || reactor.Type == reactionType {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (r *MockRepo) reactors(postID int) []models.Reactor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reactors []models.Reactor
+	add := func(userID int, t string) {
+		u, ok := users[userID]
+		reactors = append(reactors, models.Reactor{UserID: userID, Name: u.Name, DisplayName: u.DisplayName, Privacy: u.Privacy, Type: t, Deleted: !ok})
+	}
+	if postID == ScoredPostID {
+		for _, userID := range slices.Sorted(maps.Keys(scoredPostReactions)) {
+			if scoredPostReactions[userID] {
+				add(userID, models.ReactionLike)
+			} else {
+				add(userID, models.ReactionDislike)
+			}
+		}
+		add(GoneReactorID, models.ReactionLike)
+	}
+	reactions := r.reactionTypes
+	if reactions == nil {
+		reactions = seededReactionTypes
+	}
+	for _, key := range slices.SortedFunc(maps.Keys(reactions), func(a, b [2]int) int { return a[0] - b[0] }) {
+		if key[1] == postID {
+			add(key[0], reactions[key])
+		}
+	}
+	return reactors
+}
+
+// ScoredPostID is liked by test and admin and disliked by shy. Other posts
+// have no reactions.
+const ScoredPostID = MarkdownPostID
+
+var scoredPostReactions = map[int]bool{defaultUser: true, adminID: true, shyID: false}
+
+func (r *MockRepo) GetPostScores(postIDs []int, weights models.VoteWeights) (map[int]int, error) {
+	scores := make(map[int]int)
+	if !slices.Contains(postIDs, ScoredPostID) {
+		return scores, nil
+	}
+	for userID, like := range scoredPostReactions {
+		weight := weights[users[userID].Status]
+		if !like {
+			weight = -weight
+		}
+		scores[ScoredPostID] += weight
+	}
+	return scores, nil
 }
 
 func (r *MockRepo) GetALLCategory() ([]string, error) {
-	return []string{"category1", "category2"}, nil
+	return []string{"Category1", "Category2"}, nil
+}
+
+// Messages of the seeded announcements. Only the active ones should render:
+// the site-wide one everywhere, the category one on CategoryAnnouncement's
+// category and the post one on MarkdownPostID.
+const (
+	SiteAnnouncement     = "Site-wide maintenance tonight"
+	CategoryAnnouncement = "Category one rules changed"
+	PostAnnouncement     = "This thread is featured"
+	ExpiredAnnouncement  = "Yesterday's news"
+	FutureAnnouncement   = "Coming soon"
+)
+
+var seededAnnouncements = func() []models.Announcement {
+	now := time.Now()
+	day := 24 * time.Hour
+	return []models.Announcement{
+		{ID: 1, Message: SiteAnnouncement, Start: now.Add(-day), End: now.Add(day)},
+		{ID: 2, Message: CategoryAnnouncement, CategoryID: 1, Start: now.Add(-day), End: now.Add(day)},
+		{ID: 3, Message: PostAnnouncement, PostID: MarkdownPostID, Start: now.Add(-day), End: now.Add(day)},
+		{ID: 4, Message: ExpiredAnnouncement, Start: now.Add(-2 * day), End: now.Add(-day)},
+		{ID: 5, Message: FutureAnnouncement, Start: now.Add(day), End: now.Add(2 * day)},
+	}
+}()
+
+func (r *MockRepo) CreateAnnouncement(a *models.Announcement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a.ID = len(seededAnnouncements) + len(r.announcements) + 1
+	r.announcements = append(r.announcements, *a)
+	return nil
+}
+
+func (r *MockRepo) DeleteAnnouncement(id int) error {
+	if id < 1 || id > len(seededAnnouncements) {
+		return models.ErrNoRecord
+	}
+	return nil
+}
+
+func (r *MockRepo) GetAnnouncements() (*[]models.Announcement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	announcements := slices.Concat(seededAnnouncements, r.announcements)
+	return &announcements, nil
+}
+
+func (r *MockRepo) GetActiveAnnouncements(now time.Time, scope models.AnnouncementScope) (*[]models.Announcement, error) {
+	all, _ := r.GetAnnouncements()
+	var active []models.Announcement
+	for _, a := range *all {
+		inScope := a.CategoryID == 0 && a.PostID == 0 ||
+			a.CategoryID != 0 && slices.Contains(scope.Categories, a.CategoryID) ||
+			a.PostID != 0 && a.PostID == scope.PostID
+		if inScope && a.Active(now) {
+			active = append(active, a)
+		}
+	}
+	return &active, nil
+}
+
+// ArchivedPostID is the post that went inactive and was archived.
+const ArchivedPostID = 9
+
+func (r *MockRepo) ArchiveInactivePosts(cutoff time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *MockRepo) IsPostArchived(postID int) (bool, error) {
+	return postID == ArchivedPostID, nil
 }
 
 func (r *MockRepo) GetPostByID(postID int) (*models.Post, error) {
-	return &models.Post{
-		PostID:  1,
-		Title:   "test",
-		Content: "test",
-	}, nil
+	if postID == MarkdownPostID {
+		return &models.Post{PostID: postID, UserID: defaultUser, Title: "markdown", Content: MarkdownContent}, nil
+	}
+	if postID == EmbedPostID {
+		return &models.Post{PostID: postID, UserID: defaultUser, Title: "video", Content: EmbedContent}, nil
+	}
+	if postID == DisplayNamePostID {
+		return &models.Post{PostID: postID, UserID: adminID, UserName: "admin", DisplayName: AdminDisplayName, Title: "announcement", Content: "test"}, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reason, locked := r.locks[postID]
+	post := &models.Post{
+		PostID:           1,
+		UserID:           defaultUser,
+		Title:            "test",
+		Content:          "test",
+		AcceptedAnswerID: 2,
+		Locked:           locked,
+		LockReason:       reason,
+		Archived:         postID == ArchivedPostID,
+		ProfilePinned:    r.pins[postID],
+	}
+	// Guest posts wait for approval under the guest account.
+	if r.guestPostIndex(postID) >= 0 {
+		post.UserID, post.Pending = guestID, true
+	}
+	return post, nil
+}
+
+// DeletePost removes the post for good whenever hard is set, the mock
+// doesn't keep track of replies.
+func (r *MockRepo) DeletePost(postID int, hard bool) (bool, error) {
+	return hard, nil
+}
+
+func (r *MockRepo) DeleteComment(commentID int, hard bool) (bool, error) {
+	return hard, nil
+}
+
+func (r *MockRepo) SetProfilePinned(postID int, pinned bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pins == nil {
+		r.pins = map[int]bool{}
+	}
+	r.pins[postID] = pinned
+	return nil
+}
+
+func (r *MockRepo) AddPostLabel(l models.PostLabel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.labels == nil {
+		r.labels = map[models.PostLabel]bool{}
+	}
+	r.labels[l] = true
+	return nil
+}
+
+func (r *MockRepo) RemovePostLabel(l models.PostLabel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.labels, l)
+	return nil
+}
+
+func (r *MockRepo) GetLabelsByPostIDs(ids []int) (map[int][]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	labels := make(map[int][]string)
+	for l := range r.labels {
+		if slices.Contains(ids, l.PostID) {
+			labels[l.PostID] = append(labels[l.PostID], l.Label)
+		}
+	}
+	for _, names := range labels {
+		slices.Sort(names)
+	}
+	return labels, nil
+}
+
+// GetAllPostByLabelPaginated lists the homePosts carrying label, newest
+// first.
+func (r *MockRepo) GetAllPostByLabelPaginated(page, pageSize int, label, sort string) (*[]models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var labeled []models.Post
+	for _, post := range homePosts {
+		if r.labels[models.PostLabel{PostID: post.PostID, Label: label}] {
+			labeled = append(labeled, post)
+		}
+	}
+	start := min((page-1)*pageSize, len(labeled))
+	end := min(start+pageSize, len(labeled))
+	posts := labeled[start:end]
+	return &posts, nil
+}
+
+func (r *MockRepo) GetPageNumberLabel(pageSize int, label string) (int, error) {
+	return 1, nil
 }
 
+func (r *MockRepo) GuestAccountID() (int, error) {
+	return guestID, nil
+}
+
+// CreateGuestPost queues p as a pending post, with GuestPostID plus its
+// place in the queue as its id.
+func (r *MockRepo) CreateGuestPost(userID int, p *models.GuestPost) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.ID = GuestPostID + len(r.guestPosts) + 1
+	p.Created = time.Now()
+	r.guestPosts = append(r.guestPosts, p)
+	return nil
+}
+
+func (r *MockRepo) GetGuestPosts() (*[]models.GuestPost, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	posts := []models.GuestPost{}
+	for _, p := range r.guestPosts {
+		if p != nil {
+			posts = append(posts, *p)
+		}
+	}
+	return &posts, nil
+}
+
+// guestPostIndex returns the place of the pending guest post id in the
+// queue, -1 if it isn't pending. The caller holds mu.
+func (r *MockRepo) guestPostIndex(id int) int {
+	i := id - GuestPostID - 1
+	if i < 0 || i >= len(r.guestPosts) || r.guestPosts[i] == nil {
+		return -1
+	}
+	return i
+}
+
+// CountProfilePins counts the pins of the default user, who wrote every post.
+func (r *MockRepo) CountProfilePins(userID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int
+	for _, pinned := range r.pins {
+		if pinned && userID == defaultUser {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MockRepo) SetAcceptedAnswer(postID, commentID int) error {
+	return nil
+}
+
+// GetCommentsByPostID returns four fixed comments followed by the ones made
+// on the post since.
 func (r *MockRepo) GetCommentsByPostID(postID int) (*[]models.Comment, error) {
-	return &[]models.Comment{{CommentID: 1, Content: "test", UserID: 1}}, nil
+	r.count("GetCommentsByPostID")
+	comments := []models.Comment{
+		{CommentID: 1, PostID: 1, Content: "test", UserID: 1, UserName: "test"},
+		{CommentID: 2, PostID: 1, Content: "reply", UserID: 1, UserName: "test", QuotedCommentID: 1, QuotedUserName: "test", QuoteExcerpt: "quoted excerpt"},
+		{CommentID: 4, PostID: 1, Content: "downvoted", UserID: 1, UserName: "test", Like: "1", Dislike: "9"},
+		{CommentID: 5, PostID: 1, Content: "at the threshold", UserID: 1, UserName: "test", Like: "0", Dislike: "5"},
+		{CommentID: 8, PostID: 1, Content: "well liked", UserID: 1, UserName: "test", Like: "6", Dislike: "1"},
+		{CommentID: 9, PostID: 1, Content: HotComment, UserID: 1, UserName: "test", Like: "9", Dislike: "1"},
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comments = append(comments, r.newComments(func(c models.Comment) bool { return c.PostID == postID })...)
+	return &comments, nil
+}
+
+// HotComment is the content of the best scored comment, at 8. The next best
+// scores 5.
+const HotComment = "best of the thread"
+
+// GetCommentByID knows comments 1, 2, 6, 7 and 9 on post 1 and comment 3
+// on post 2. 2, 6 and 7 are a reply chain below 1.
+func (r *MockRepo) GetCommentByID(commentID int) (*models.Comment, error) {
+	switch commentID {
+	case 1:
+		return &models.Comment{CommentID: 1, PostID: 1, Content: "test comment", UserID: 1}, nil
+	case 2:
+		return &models.Comment{CommentID: 2, PostID: 1, Content: "reply", UserID: 1, QuotedCommentID: 1}, nil
+	case 3:
+		return &models.Comment{CommentID: 3, PostID: 2, Content: "other post", UserID: 1}, nil
+	case 6:
+		return &models.Comment{CommentID: 6, PostID: 1, Content: "deeper reply", UserID: 1, QuotedCommentID: 2}, nil
+	case 7:
+		return &models.Comment{CommentID: 7, PostID: 1, Content: "deepest reply", UserID: 1, QuotedCommentID: 6}, nil
+	case 9:
+		return &models.Comment{CommentID: 9, PostID: 1, Content: HotComment, UserID: 1}, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if comments := r.newComments(func(c models.Comment) bool { return c.CommentID == commentID }); len(comments) == 1 {
+		return &comments[0], nil
+	}
+	return nil, models.ErrNoRecord
 }
 
 func (s *MockRepo) GetAllPost() ([]models.Post, error) {
@@ -242,12 +850,53 @@ func (s *MockRepo) GetAllPostByCategory(categoryID int) (*[]models.Post, error)
 	}, nil
 }
 
-func (s *MockRepo) GetAllPostByCategoryPaginated(page int, pageSize int, categoryID int) (*[]models.Post, error) {
+func (s *MockRepo) GetAllPostByCategoryPaginated(page int, pageSize int, categoryID int, sort string) (*[]models.Post, error) {
 	return &[]models.Post{}, nil
 }
 
-func (s *MockRepo) GetAllPostPaginated(page, pageSize int) (*[]models.Post, error) {
-	return &[]models.Post{}, nil
+// StatsContent is the body of the newest home page post: 11 characters, 2
+// words.
+const StatsContent = "héllo wörld"
+
+// HomeCommentCount is the comment count of post 4 on the home page, the only
+// one there with comments.
+const HomeCommentCount = 17
+
+// homePosts are the posts on the home page, newest first.
+var homePosts = []models.Post{
+	{PostID: 5, UserID: defaultUser, UserName: "test", Title: "post 5", Content: StatsContent},
+	{PostID: 4, UserID: defaultUser, UserName: "test", Title: "post 4", CommentCount: HomeCommentCount},
+	{PostID: 3, UserID: defaultUser, UserName: "test", Title: "post 3", Content: MarkdownContent},
+	{PostID: 2, UserID: defaultUser, UserName: "test", Title: "post 2"},
+	{PostID: 1, UserID: defaultUser, UserName: "test", Title: "post 1"},
+}
+
+// GetAllPostPaginated lists homePosts oldest first for every sort but newest,
+// so tests can tell which order was asked for.
+func (s *MockRepo) GetAllPostPaginated(page, pageSize int, sort string) (*[]models.Post, error) {
+	ordered := append([]models.Post{}, homePosts...)
+	if sort != models.SortNewest {
+		slices.Reverse(ordered)
+	}
+	start := min((page-1)*pageSize, len(ordered))
+	end := min(start+pageSize, len(ordered))
+	posts := ordered[start:end]
+	return &posts, nil
+}
+
+func (s *MockRepo) GetPostsAfter(after, category, limit int) (*[]models.Post, error) {
+	start := 0
+	if after != 0 {
+		start = len(homePosts)
+		for i, post := range homePosts {
+			if post.PostID == after {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+limit, len(homePosts))
+	posts := append([]models.Post{}, homePosts[start:end]...)
+	return &posts, nil
 }
 
 func (s *MockRepo) GetLikedPostsPaginated(userID, page, pageSize int) (*[]models.Post, error) {
@@ -262,6 +911,63 @@ func (s *MockRepo) GetPageNumberLikedPosts(pageSize int, userID int) (int, error
 	return 1, nil
 }
 
+// GetParticipatedPostsPaginated lists the posts userID commented on since
+// the mock was made, the one commented on last first.
+func (s *MockRepo) GetParticipatedPostsPaginated(userID, page, pageSize int) (*[]models.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := []models.Post{}
+	for _, id := range s.participated(userID) {
+		posts = append(posts, models.Post{PostID: id, UserID: defaultUser, UserName: "test", Title: fmt.Sprintf("post %d", id)})
+	}
+	return &posts, nil
+}
+
+func (s *MockRepo) GetPageNumberParticipated(pageSize int, userID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return (len(s.participated(userID)) + pageSize - 1) / pageSize, nil
+}
+
+// participated returns the ids of the posts userID commented on, the one
+// commented on last first. The caller holds s.mu.
+func (s *MockRepo) participated(userID int) []int {
+	var ids []int
+	for i := len(s.comments) - 1; i >= 0; i-- {
+		if id := s.comments[i].PostID; !slices.Contains(ids, id) && slices.ContainsFunc(s.comments, func(form models.CommentForm) bool {
+			return form.PostID == id && form.UserID == userID
+		}) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (s *MockRepo) CountPostsSince(userID int, since time.Time) (int, error) {
+	if userID == newbieID {
+		return NewbiePostsToday, nil
+	}
+	if since.IsZero() {
+		return 100, nil
+	}
+	return 0, nil
+}
+
+// GetPostTimesSince has every user but newbie make PostsToday posts, an hour
+// apart, the last one an hour before the end of the day starting at since.
+// Counting from since keeps the times exact however long the test takes.
+func (s *MockRepo) GetPostTimesSince(userID int, since time.Time) ([]time.Time, error) {
+	n := PostsToday
+	if userID == newbieID {
+		n = NewbiePostsToday
+	}
+	var times []time.Time
+	for i := n; i > 0; i-- {
+		times = append(times, since.Add(24*time.Hour-time.Duration(i)*time.Hour))
+	}
+	return times, nil
+}
+
 func (s *MockRepo) GetPageNumberMyPosts(pageSize int, userID int) (int, error) {
 	return 1, nil
 }
@@ -287,9 +993,674 @@ func (s *MockRepo) UpdateUserByID(id string) (*models.User, error) {
 }
 
 func (s *MockRepo) GetUserByID(id int) (*models.User, error) {
-	return &models.User{
-		ID:    1,
-		Name:  "test",
-		Email: "test@gmail.com",
-	}, nil
+	u, ok := users[id]
+	if !ok {
+		u = users[defaultUser]
+	}
+	return &u, nil
+}
+
+func (s *MockRepo) GetUserByName(name string) (*models.User, error) {
+	for _, u := range users {
+		if u.Name == name {
+			return &u, nil
+		}
+	}
+	return nil, models.ErrNoRecord
+}
+
+func (s *MockRepo) GetUserNames() ([]string, error) {
+	var names []string
+	for _, u := range users {
+		names = append(names, u.Name)
+	}
+	return names, nil
+}
+
+func (s *MockRepo) UpdateUserPrivacy(userID, privacy int) error {
+	return nil
+}
+
+func (s *MockRepo) UpdateUserSort(userID int, sort string) error {
+	return nil
+}
+
+func (s *MockRepo) UpdateUserDisplayName(userID int, displayName string) error {
+	return nil
+}
+
+func (s *MockRepo) UpdateUserTimezone(userID int, zone string) error {
+	return nil
+}
+
+func (s *MockRepo) UpdateUserDigest(userID, digest int) error {
+	return nil
+}
+
+func (s *MockRepo) GetUserActivityPaginated(userID int, withReactions bool, page, pageSize int) (*[]models.Activity, error) {
+	activities := []models.Activity{
+		{Kind: models.ActivityComment, PostID: 1, PostTitle: "test", CommentID: 1, Content: "public comment"},
+		{Kind: models.ActivityPost, PostID: 1, PostTitle: "test", Content: "public post"},
+	}
+	if withReactions {
+		activities = append([]models.Activity{
+			{Kind: models.ActivityReaction, PostID: 2, PostTitle: "private reaction", IsLike: true},
+		}, activities...)
+	}
+	return &activities, nil
+}
+
+func (s *MockRepo) GetPageNumberActivity(pageSize int, userID int, withReactions bool) (int, error) {
+	return 1, nil
+}
+
+// GetTopContributors ranks test above admin on posts and the other way round
+// on every other metric. Over the last days, only admin contributed.
+func (s *MockRepo) GetTopContributors(metric string, since time.Time, limit int) ([]models.Contributor, error) {
+	s.count("GetTopContributors")
+	test := models.Contributor{UserID: defaultUser, Name: "test"}
+	admin := models.Contributor{UserID: adminID, Name: "admin", DisplayName: AdminDisplayName}
+	ranked := func(contributors ...models.Contributor) []models.Contributor {
+		for i := range contributors {
+			contributors[i].Rank = i + 1
+		}
+		return contributors
+	}
+	switch {
+	case !since.IsZero():
+		admin.Score = 1
+		return ranked(admin), nil
+	case metric == models.LeaderboardPosts:
+		test.Score, admin.Score = 5, 3
+		return ranked(test, admin), nil
+	default:
+		test.Score, admin.Score = 2, 4
+		return ranked(admin, test), nil
+	}
+}
+
+// ValidInviteCode is the only invite code the mock accepts. Every other code
+// behaves as if it was already used.
+const ValidInviteCode = "validInvite"
+
+func (s *MockRepo) CreateInvite(invite *models.Invite) error {
+	return nil
+}
+
+func (s *MockRepo) CountInvitesByUserID(userID int) (int, error) {
+	return 0, nil
+}
+
+func (s *MockRepo) GetInvitesByUserID(userID int) (*[]models.Invite, error) {
+	return &[]models.Invite{{ID: 1, Code: ValidInviteCode, InviterID: userID}}, nil
+}
+
+func (s *MockRepo) CreateUserWithInvite(u models.User, code string) error {
+	if code != ValidInviteCode {
+		return models.ErrInvalidInvite
+	}
+	return s.CreateUser(u)
+}
+
+// BulkModerate reports every item with ID 42 as missing.
+// BulkModerate fails for id 42, keeps post locks, which GetPostByID reports,
+// takes approved or deleted guest posts off the queue and approves or
+// deletes new comments.
+func (s *MockRepo) BulkModerate(action, reason string, targets []models.ModerationTarget) ([]models.ModerationResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results := make([]models.ModerationResult, 0, len(targets))
+	for _, target := range targets {
+		result := models.ModerationResult{Kind: target.Kind, ID: target.ID, OK: true}
+		if target.ID == 42 {
+			result.OK = false
+			result.Error = "not found"
+		} else if target.Kind == models.TargetPost && action == models.ModerationLock {
+			if s.locks == nil {
+				s.locks = map[int]string{}
+			}
+			s.locks[target.ID] = reason
+		} else if target.Kind == models.TargetPost && action == models.ModerationUnlock {
+			delete(s.locks, target.ID)
+		} else if i := s.guestPostIndex(target.ID); target.Kind == models.TargetPost && i >= 0 {
+			if action == models.ModerationApprove || action == models.ModerationDelete {
+				s.guestPosts[i] = nil
+			}
+		} else if i := target.ID - newCommentID(0); target.Kind == models.TargetComment && i >= 0 && i < len(s.comments) {
+			switch action {
+			case models.ModerationApprove:
+				s.comments[i].Pending = false
+			case models.ModerationDelete:
+				if s.rejected == nil {
+					s.rejected = map[int]bool{}
+				}
+				s.rejected[target.ID] = true
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *MockRepo) SearchPostsPaginated(filter models.SearchFilter, page, pageSize int) (*[]models.Post, error) {
+	return &[]models.Post{}, nil
+}
+
+func (s *MockRepo) SearchCount(filter models.SearchFilter) (int, error) {
+	return 0, nil
+}
+
+// GetSuggestions matches prefix against the names of categories 1 and 2,
+// the user test and the titles of posts 1 and 2.
+func (s *MockRepo) GetSuggestions(prefix string, limit int) ([]models.Suggestion, error) {
+	all := []models.Suggestion{
+		{Kind: models.SuggestCategory, Text: "Category1", ID: 1},
+		{Kind: models.SuggestCategory, Text: "Category2", ID: 2},
+		{Kind: models.SuggestUser, Text: "test", ID: 1},
+		{Kind: models.SuggestPost, Text: "Testing Go", ID: 1},
+		{Kind: models.SuggestPost, Text: "Category news", ID: 2},
+	}
+	var suggestions []models.Suggestion
+	for _, suggestion := range all {
+		if strings.HasPrefix(strings.ToLower(suggestion.Text), strings.ToLower(prefix)) {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions, nil
+}
+
+// SuggestMentions knows the mock users, the default user being on every
+// post.
+func (s *MockRepo) SuggestMentions(prefix string, viewerID, postID, limit int) ([]models.MentionSuggestion, error) {
+	var matches []models.User
+	for _, u := range users {
+		onPost := postID != 0 && u.ID == defaultUser
+		if strings.HasPrefix(strings.ToLower(u.Name), strings.ToLower(prefix)) &&
+			(u.Privacy != models.PrivacyPrivate || onPost || int(u.ID) == viewerID) {
+			matches = append(matches, u)
+		}
+	}
+	slices.SortFunc(matches, func(a, b models.User) int {
+		aOn, bOn := postID != 0 && a.ID == defaultUser, postID != 0 && b.ID == defaultUser
+		if aOn != bOn {
+			if aOn {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	var suggestions []models.MentionSuggestion
+	for _, u := range matches[:min(limit, len(matches))] {
+		suggestions = append(suggestions, models.MentionSuggestion{Name: u.Name, DisplayName: u.DisplayName})
+	}
+	return suggestions, nil
+}
+
+func (s *MockRepo) GetRelatedPosts(postID, limit int) (*[]models.Post, error) {
+	return &[]models.Post{{PostID: 2, UserID: 1, Title: "related post"}}, nil
+}
+
+func (s *MockRepo) GetTrendingCategories(since time.Time, limit int) ([]string, error) {
+	return []string{"Category2"}, nil
+}
+
+// ValidResetToken is the only reset token the mock accepts.
+const ValidResetToken = "validResetToken"
+
+func (s *MockRepo) CreatePasswordReset(reset *models.PasswordReset) error {
+	return nil
+}
+
+func (s *MockRepo) ResetPassword(tokenHash string, hashedPassword []byte) (int, error) {
+	if tokenHash != models.HashResetToken(ValidResetToken) {
+		return 0, models.ErrInvalidResetToken
+	}
+	return defaultUser, nil
+}
+
+func (s *MockRepo) CreateAuditEntry(entry *models.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.ID = len(s.audit) + 1
+	entry.ActorName = users[entry.ActorID].Name
+	s.audit = append(s.audit, *entry)
+	return nil
+}
+
+func (s *MockRepo) UpdateUserStatus(userID, status int, entry *models.AuditEntry) error {
+	if _, ok := users[userID]; !ok {
+		return models.ErrNoRecord
+	}
+	return s.CreateAuditEntry(entry)
+}
+
+func (s *MockRepo) GetAuditLogPaginated(filter models.AuditFilter, page, pageSize int) (*[]models.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []models.AuditEntry
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		entry := s.audit[i]
+		if (filter.Action == "" || entry.Action == filter.Action) && (filter.Actor == "" || entry.ActorName == filter.Actor) {
+			entries = append(entries, entry)
+		}
+	}
+	start := min((page-1)*pageSize, len(entries))
+	entries = entries[start:min(start+pageSize, len(entries))]
+	return &entries, nil
+}
+
+func (s *MockRepo) GetPageNumberAudit(pageSize int, filter models.AuditFilter) (int, error) {
+	return 1, nil
+}
+
+func (s *MockRepo) AddCategoryModerator(userID, categoryID int) error {
+	if categoryID > 4 {
+		return models.UnknownCategory
+	}
+	return nil
+}
+
+// UncategorizedID is one past the categories the mock knows.
+func (s *MockRepo) UncategorizedID() (int, error) {
+	return 5, nil
+}
+
+func (s *MockRepo) MovePostToCategory(postID, categoryID int) error {
+	if categoryID < 1 || categoryID > 4 {
+		return models.UnknownCategory
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.moved == nil {
+		s.moved = map[int]int{}
+	}
+	s.moved[postID] = categoryID
+	return nil
+}
+
+func (s *MockRepo) SetCategoryCommentMode(categoryID int, mode string) error {
+	if categoryID > 4 {
+		return models.UnknownCategory
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.commentModes == nil {
+		s.commentModes = map[int]string{}
+	}
+	s.commentModes[categoryID] = mode
+	return nil
+}
+
+// GetCommentMode treats every post as being in categories 1 and 2, like
+// GetCategoriesByPostID.
+func (s *MockRepo) GetCommentMode(postID int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range []int{1, 2} {
+		if s.commentModes[id] != models.CommentsThreaded {
+			return models.CommentsFlat, nil
+		}
+	}
+	return models.CommentsThreaded, nil
+}
+
+func (s *MockRepo) SetCategoryPostStatus(categoryID, status int) error {
+	if categoryID > 4 {
+		return models.UnknownCategory
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.postStatuses == nil {
+		s.postStatuses = map[int]int{}
+	}
+	s.postStatuses[categoryID] = status
+	return nil
+}
+
+func (s *MockRepo) GetPostStatus(categoryIDs []int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := models.StatusUser
+	for _, id := range categoryIDs {
+		status = max(status, s.postStatuses[id])
+	}
+	return status, nil
+}
+
+func (s *MockRepo) RemoveCategoryModerator(userID, categoryID int) error {
+	return nil
+}
+
+func (s *MockRepo) IsCategoryModerator(userID, postID int) (bool, error) {
+	return userID == categoryModID && postID != 2, nil
+}
+
+func (s *MockRepo) UpdatePost(rev *models.PostRevision, title, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rev.ID = len(s.revisions) + 1
+	rev.EditorName = users[rev.EditorID].Name
+	rev.Created = time.Now()
+	s.revisions = append(s.revisions, *rev)
+	return nil
+}
+
+func (s *MockRepo) GetLastRevision(postID int) (*models.PostRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.revisions) - 1; i >= 0; i-- {
+		if s.revisions[i].PostID == postID && !s.revisions[i].Silent {
+			rev := s.revisions[i]
+			return &rev, nil
+		}
+	}
+	return nil, models.ErrNoRecord
+}
+
+func (s *MockRepo) Follow(f models.Follow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.follows == nil {
+		s.follows = map[models.Follow]bool{}
+	}
+	s.follows[models.Follow{FollowerID: f.FollowerID, FolloweeID: f.FolloweeID}] = true
+	return nil
+}
+
+func (s *MockRepo) Unfollow(f models.Follow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.follows, models.Follow{FollowerID: f.FollowerID, FolloweeID: f.FolloweeID})
+	return nil
+}
+
+func (s *MockRepo) IsFollowing(followerID, followeeID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.follows[models.Follow{FollowerID: followerID, FolloweeID: followeeID}], nil
+}
+
+// GetFollowingPostsPaginated returns one post by every followed user.
+func (s *MockRepo) GetFollowingPostsPaginated(userID, page, pageSize int) (*[]models.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := []models.Post{}
+	for f := range s.follows {
+		if f.FollowerID == userID {
+			author := users[f.FolloweeID]
+			posts = append(posts, models.Post{PostID: 100 + f.FolloweeID, UserID: f.FolloweeID, UserName: author.Name, Title: "post by " + author.Name})
+		}
+	}
+	return &posts, nil
+}
+
+func (s *MockRepo) GetPageNumberFollowing(pageSize int, userID int) (int, error) {
+	return 1, nil
+}
+
+func (s *MockRepo) Subscribe(userID, postID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscriptions[[2]int{userID, postID}]; ok {
+		return nil
+	}
+	if s.subscriptions == nil {
+		s.subscriptions = map[[2]int]bool{}
+	}
+	s.subscriptions[[2]int{userID, postID}] = true
+	return nil
+}
+
+func (s *MockRepo) SetSubscription(sub models.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscriptions == nil {
+		s.subscriptions = map[[2]int]bool{}
+	}
+	s.subscriptions[[2]int{sub.UserID, sub.PostID}] = sub.Subscribed
+	return nil
+}
+
+// IsSubscribed falls back to the author of the post as returned by
+// GetPostByID.
+func (s *MockRepo) IsSubscribed(userID, postID int) (bool, error) {
+	post, err := s.GetPostByID(postID)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if subscribed, ok := s.subscriptions[[2]int{userID, postID}]; ok {
+		return subscribed, nil
+	}
+	return userID == post.UserID, nil
+}
+
+func (s *MockRepo) GetSubscribers(postID int) ([]int, error) {
+	post, err := s.GetPostByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int
+	if _, ok := s.subscriptions[[2]int{post.UserID, postID}]; !ok {
+		ids = append(ids, post.UserID)
+	}
+	for key, subscribed := range s.subscriptions {
+		if key[1] == postID && subscribed {
+			ids = append(ids, key[0])
+		}
+	}
+	return ids, nil
+}
+
+func (s *MockRepo) SubscribeCategory(sub models.CategorySubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.categorySubscribed == nil {
+		s.categorySubscribed = map[models.CategorySubscription]bool{}
+	}
+	s.categorySubscribed[sub] = true
+	return nil
+}
+
+func (s *MockRepo) UnsubscribeCategory(sub models.CategorySubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.categorySubscribed, sub)
+	return nil
+}
+
+func (s *MockRepo) IsSubscribedCategory(userID, categoryID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.categorySubscribed[models.CategorySubscription{UserID: userID, CategoryID: categoryID}], nil
+}
+
+func (s *MockRepo) GetCategorySubscribers(categoryIDs []int) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int
+	for sub := range s.categorySubscribed {
+		if slices.Contains(categoryIDs, sub.CategoryID) && !slices.Contains(ids, sub.UserID) {
+			ids = append(ids, sub.UserID)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// GetSubscribedCategoriesPostsPaginated lists every homePost once the user
+// subscribed to a category, as they are all in both categories.
+func (s *MockRepo) GetSubscribedCategoriesPostsPaginated(userID, page, pageSize int) (*[]models.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := []models.Post{}
+	for sub := range s.categorySubscribed {
+		if sub.UserID == userID {
+			posts = append(posts, homePosts...)
+			break
+		}
+	}
+	return &posts, nil
+}
+
+func (s *MockRepo) GetPageNumberSubscribedCategories(pageSize int, userID int) (int, error) {
+	return 1, nil
+}
+
+func (s *MockRepo) CreateNotifications(notifications []models.Notification) error {
+	s.count("CreateNotifications")
+	return nil
+}
+
+func (s *MockRepo) CreateNotificationOnce(n models.Notification) (bool, error) {
+	s.count("CreateNotificationOnce")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.once == nil {
+		s.once = map[models.Notification]bool{}
+	}
+	if s.once[n] {
+		return false, nil
+	}
+	s.once[n] = true
+	return true, nil
+}
+
+func (s *MockRepo) GetDigestRecipients() (*[]models.DigestRecipient, error) {
+	return &[]models.DigestRecipient{}, nil
+}
+
+func (s *MockRepo) ClaimDigest(userID int, last *time.Time, now time.Time) ([]models.Notification, error) {
+	return nil, models.ErrNoRecord
+}
+
+// The default user has a single unread notification, a reply by admin to
+// comment 2 of post 1.
+var notification = models.Notification{ID: 1, UserID: defaultUser, ActorID: adminID, ActorName: "admin", Kind: models.NotificationReply, PostID: 1, PostTitle: "test", CommentID: 2}
+
+func (s *MockRepo) CountUnread(userID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if userID == defaultUser && s.notificationsRead[userID] < notification.ID {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (s *MockRepo) GetNotifications(userID, limit int) (*[]models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notifications := []models.Notification{}
+	if userID == defaultUser {
+		n := notification
+		n.Read = s.notificationsRead[userID] >= n.ID
+		notifications = append(notifications, n)
+	}
+	return &notifications, nil
+}
+
+func (s *MockRepo) MarkAllNotificationsRead(userID, upTo int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if userID != notification.UserID || upTo < notification.ID {
+		return nil
+	}
+	if s.notificationsRead == nil {
+		s.notificationsRead = map[int]int{}
+	}
+	s.notificationsRead[userID] = notification.ID
+	return nil
+}
+
+func (s *MockRepo) MarkNotificationRead(userID, notificationID int) (*models.Notification, error) {
+	if userID != notification.UserID || notificationID != notification.ID {
+		return nil, models.ErrNoRecord
+	}
+	n := notification
+	n.Read = true
+	return &n, nil
+}
+
+func (s *MockRepo) RecordView(userID, postID, keep int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.history == nil {
+		s.history = map[int][]int{}
+	}
+	views := slices.DeleteFunc(s.history[userID], func(id int) bool { return id == postID })
+	views = append([]int{postID}, views...)
+	s.history[userID] = views[:min(len(views), keep)]
+	return nil
+}
+
+func (s *MockRepo) GetHistory(userID int) (*[]models.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := []models.Post{}
+	for _, id := range s.history[userID] {
+		posts = append(posts, models.Post{PostID: id, UserID: defaultUser, UserName: "test", Title: fmt.Sprintf("viewed post %d", id)})
+	}
+	return &posts, nil
+}
+
+// ExportBackup emits the mock users, leaving out their password hashes like
+// the real repository does.
+func (s *MockRepo) ExportBackup(emit func(models.BackupRecord) error) error {
+	for _, id := range slices.Sorted(maps.Keys(users)) {
+		u := users[id]
+		record := models.BackupRecord{Type: models.BackupUserType, Data: models.BackupUser{ID: int(u.ID), Name: u.Name, Email: u.Email}}
+		if err := emit(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MockRepo) ImportBackup(source string, records []models.BackupRecord) (*models.ImportSummary, error) {
+	summary := models.NewImportSummary()
+	for _, record := range records {
+		summary.Imported[record.Type]++
+	}
+	return summary, nil
+}
+
+func (s *MockRepo) FinishImport(source string) error {
+	return nil
+}
+
+func (s *MockRepo) GetDraft(userID int, session string) (*models.Draft, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.drafts {
+		if d.UserID == userID && d.Session == session {
+			return &d, nil
+		}
+	}
+	return nil, models.ErrNoRecord
+}
+
+func (s *MockRepo) SaveDraft(d *models.Draft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.drafts {
+		if s.drafts[i].UserID == d.UserID && s.drafts[i].Session == d.Session {
+			d.ID = s.drafts[i].ID
+			s.drafts[i] = *d
+			return nil
+		}
+	}
+	d.ID = len(s.drafts) + 1
+	s.drafts = append(s.drafts, *d)
+	return nil
+}
+
+// Drafts returns the number of stored drafts.
+func (s *MockRepo) Drafts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.drafts)
 }
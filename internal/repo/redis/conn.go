@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// conn is a single connection to Redis, used by one command at a time.
+type conn struct {
+	addr    string
+	timeout time.Duration
+
+	mu sync.Mutex
+	nc net.Conn
+	rw *bufio.ReadWriter
+}
+
+// do sends the command and returns its reply: a string, an int64, nil for a
+// missing value or a []any of these. Error replies come back as errors.
+func (c *conn) do(args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.nc == nil {
+		nc, err := net.DialTimeout("tcp", c.addr, c.timeout)
+		if err != nil {
+			return nil, err
+		}
+		c.nc = nc
+		c.rw = bufio.NewReadWriter(bufio.NewReader(nc), bufio.NewWriter(nc))
+	}
+	c.nc.SetDeadline(time.Now().Add(c.timeout))
+
+	reply, err := c.roundTrip(args)
+	var replyErr replyError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection is in an unknown state, start over next time.
+		c.nc.Close()
+		c.nc, c.rw = nil, nil
+	}
+	return reply, err
+}
+
+func (c *conn) roundTrip(args []string) (any, error) {
+	fmt.Fprintf(c.rw, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.rw, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := c.rw.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(c.rw.Reader)
+}
+
+// replyError is an error reply of the server. The connection stays usable.
+type replyError string
+
+func (e replyError) Error() string {
+	return "redis: " + string(e)
+}
+
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, line := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return line, nil
+	case '-':
+		return nil, replyError(line)
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    any
+		wantErr bool
+	}{
+		{"Simple string", "+OK\r\n", "OK", false},
+		{"Integer", ":3\r\n", int64(3), false},
+		{"Bulk string", "$11\r\n1 170000000\r\n", "1 170000000", false},
+		{"Missing value", "$-1\r\n", nil, false},
+		{"Array", "*2\r\n$1\r\na\r\n$1\r\nb\r\n", []any{"a", "b"}, false},
+		{"Error", "-WRONGTYPE wrong kind of value\r\n", nil, true},
+		{"Garbage", "?\r\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readReply(bufio.NewReader(strings.NewReader(tt.reply)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v; expected one: %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v; expected %#v", got, tt.want)
+			}
+		})
+	}
+}
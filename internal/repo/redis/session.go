@@ -0,0 +1,145 @@
+// Package redis keeps sessions in Redis, so that every instance of the forum
+// behind a load balancer sees the same logins. It speaks just enough of the
+// Redis protocol for that.
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"forum/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Every session is a key holding "<user id> <expiry in unix ms> <last seen
+// in unix ms>" that Redis expires by itself. Sessions stored before the
+// last seen time was kept lack it. The set under userKey lists the tokens of a user, for
+// DeleteByUser.
+const (
+	sessionKey = "forum:session:"
+	userKey    = "forum:user-sessions:"
+)
+
+type SessionStore struct {
+	conn *conn
+}
+
+// NewSessionStore returns a store on the Redis server at addr. The
+// connection is made on first use and remade after errors.
+func NewSessionStore(addr string) *SessionStore {
+	return &SessionStore{conn: &conn{addr: addr, timeout: 5 * time.Second}}
+}
+
+// Get returns the session of token, or ErrNoRecord if there is none or it
+// expired.
+func (s *SessionStore) Get(token string) (*models.Session, error) {
+	op := "redis.SessionStore.Get"
+	reply, err := s.conn.do("GET", sessionKey+token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+	fields := strings.Fields(value)
+	if len(fields) != 2 && len(fields) != 3 {
+		return nil, fmt.Errorf("%s: malformed session %q", op, value)
+	}
+	session := models.Session{Token: token}
+	if session.UserID, err = strconv.Atoi(fields[0]); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	ms, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	session.ExpTime = time.UnixMilli(ms)
+	if len(fields) == 3 {
+		if ms, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		session.LastSeen = time.UnixMilli(ms)
+	}
+	if !session.ExpTime.After(time.Now()) {
+		return nil, models.ErrNoRecord
+	}
+	return &session, nil
+}
+
+// Set stores session until its ExpTime. Sessions that expired already are
+// not stored at all.
+func (s *SessionStore) Set(session *models.Session) error {
+	op := "redis.SessionStore.Set"
+	ttl := strconv.FormatInt(time.Until(session.ExpTime).Milliseconds(), 10)
+	if ttl[0] == '-' || ttl == "0" {
+		return nil
+	}
+	value := sessionValue(session)
+	user := userKey + strconv.Itoa(session.UserID)
+	for _, cmd := range [][]string{
+		{"SET", sessionKey + session.Token, value, "PX", ttl},
+		{"SADD", user, session.Token},
+		{"PEXPIRE", user, ttl},
+	} {
+		if _, err := s.conn.do(cmd...); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+// Touch sets the LastSeen of the session of token, if there is one,
+// keeping its expiry.
+func (s *SessionStore) Touch(token string, lastSeen time.Time) error {
+	op := "redis.SessionStore.Touch"
+	session, err := s.Get(token)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	session.LastSeen = lastSeen
+	if _, err := s.conn.do("SET", sessionKey+token, sessionValue(session), "XX", "KEEPTTL"); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *SessionStore) Delete(token string) error {
+	op := "redis.SessionStore.Delete"
+	if _, err := s.conn.do("DEL", sessionKey+token); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *SessionStore) DeleteByUser(userID int) error {
+	op := "redis.SessionStore.DeleteByUser"
+	user := userKey + strconv.Itoa(userID)
+	reply, err := s.conn.do("SMEMBERS", user)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	keys := []string{"DEL", user}
+	tokens, _ := reply.([]any)
+	for _, token := range tokens {
+		if token, ok := token.(string); ok {
+			keys = append(keys, sessionKey+token)
+		}
+	}
+	if _, err := s.conn.do(keys...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func sessionValue(session *models.Session) string {
+	value := strconv.Itoa(session.UserID) + " " + strconv.FormatInt(session.ExpTime.UnixMilli(), 10)
+	if !session.LastSeen.IsZero() {
+		value += " " + strconv.FormatInt(session.LastSeen.UnixMilli(), 10)
+	}
+	return value
+}
@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"forum/internal/repo/memory"
+	"forum/internal/repo/redis"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"time"
+)
+
+// Where sessions are kept, see NewSessionStore.
+const (
+	SessionsDB     = "db"
+	SessionsMemory = "memory"
+	SessionsRedis  = "redis"
+)
+
+// SessionStore keeps login sessions. Get returns ErrNoRecord for sessions
+// that don't exist or expired, and Touch does nothing for them.
+type SessionStore interface {
+	Get(token string) (*models.Session, error)
+	Set(session *models.Session) error
+	Touch(token string, lastSeen time.Time) error
+	Delete(token string) error
+	DeleteByUser(userID int) error
+}
+
+// NewSessionStore returns the session store of backend, one of SessionsDB
+// (the database of db), SessionsMemory or SessionsRedis at redisAddr.
+func NewSessionStore(backend string, db *sqlite.Sqlite, redisAddr string) (SessionStore, error) {
+	switch backend {
+	case SessionsDB:
+		return db.Sessions(), nil
+	case SessionsMemory:
+		return memory.NewSessionStore(), nil
+	case SessionsRedis:
+		return redis.NewSessionStore(redisAddr), nil
+	}
+	return nil, fmt.Errorf("repo: unknown session store %q", backend)
+}
+
+// withSessions serves the sessions of RepoI from a SessionStore, expiring
+// them by limits.
+type withSessions struct {
+	RepoI
+	sessions SessionStore
+	limits   models.SessionLimits
+	now      func() time.Time
+}
+
+// WithSessions returns r keeping its sessions in store instead. Sessions
+// last limits.Lifetime from login and expire when unused for limits.Idle.
+func WithSessions(r RepoI, store SessionStore, limits models.SessionLimits) RepoI {
+	return &withSessions{RepoI: r, sessions: store, limits: limits, now: time.Now}
+}
+
+// session returns the session of token, deleting it and returning
+// ErrNoRecord when it expired.
+func (r *withSessions) session(token string) (*models.Session, error) {
+	session, err := r.sessions.Get(token)
+	if err != nil {
+		return nil, err
+	}
+	if r.limits.Expired(session, r.now()) {
+		if err := r.sessions.Delete(token); err != nil {
+			return nil, err
+		}
+		return nil, models.ErrNoRecord
+	}
+	return session, nil
+}
+
+func (r *withSessions) GetUserIDByToken(token string) (int, error) {
+	session, err := r.session(token)
+	if err != nil {
+		return -1, err
+	}
+	return session.UserID, nil
+}
+
+// CreateSession stores session to last the configured lifetime from now.
+func (r *withSessions) CreateSession(session *models.Session) error {
+	now := r.now()
+	if r.limits.Lifetime > 0 {
+		session.ExpTime = now.Add(r.limits.Lifetime)
+	}
+	session.LastSeen = now
+	return r.sessions.Set(session)
+}
+
+func (r *withSessions) DeleteSessionByUserID(userID int) error {
+	return r.sessions.DeleteByUser(userID)
+}
+
+func (r *withSessions) DeleteSessionByToken(token string) error {
+	return r.sessions.Delete(token)
+}
+
+// IsValidToken is called on every request, so it also resets the idle
+// timer of the session. Its lifetime stays the same.
+func (r *withSessions) IsValidToken(token string) (bool, error) {
+	_, err := r.session(token)
+	if errors.Is(err, models.ErrNoRecord) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := r.sessions.Touch(token, r.now()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
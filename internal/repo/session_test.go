@@ -0,0 +1,212 @@
+package repo
+
+import (
+	"errors"
+	"forum/internal/repo/memory"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TestSessionStores runs the same checks against every store that works
+// without a server.
+func TestSessionStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) SessionStore{
+		SessionsMemory: func(t *testing.T) SessionStore {
+			return memory.NewSessionStore()
+		},
+		SessionsDB: func(t *testing.T) SessionStore {
+			db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return db.Sessions()
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			testSessionStore(t, newStore(t))
+		})
+	}
+}
+
+func testSessionStore(t *testing.T, store SessionStore) {
+	set := func(userID int, token string, ttl time.Duration) {
+		t.Helper()
+		if err := store.Set(&models.Session{UserID: userID, Token: token, ExpTime: time.Now().Add(ttl)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(token string, wantUserID int) {
+		t.Helper()
+		session, err := store.Get(token)
+		if wantUserID == 0 {
+			if !errors.Is(err, models.ErrNoRecord) {
+				t.Errorf("%s: got %v, %v; expected ErrNoRecord", token, session, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", token, err)
+		}
+		if session.UserID != wantUserID || session.Token != token {
+			t.Errorf("%s: got user %d with token %s; expected user %d", token, session.UserID, session.Token, wantUserID)
+		}
+	}
+
+	set(1, "alice-laptop", time.Hour)
+	set(1, "alice-phone", time.Hour)
+	set(2, "bob", time.Hour)
+	set(3, "carol", -time.Second)
+	check("alice-laptop", 1)
+	check("alice-phone", 1)
+	check("bob", 2)
+	check("carol", 0)
+	check("nobody", 0)
+
+	if err := store.Delete("alice-phone"); err != nil {
+		t.Fatal(err)
+	}
+	check("alice-phone", 0)
+	check("alice-laptop", 1)
+	if err := store.Delete("nobody"); err != nil {
+		t.Fatal(err)
+	}
+
+	set(1, "alice-phone", time.Hour)
+	if err := store.DeleteByUser(1); err != nil {
+		t.Fatal(err)
+	}
+	check("alice-laptop", 0)
+	check("alice-phone", 0)
+	check("bob", 2)
+
+	// Touch keeps the last use of a session, and nothing else.
+	set(5, "erin", time.Hour)
+	lastSeen := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	if err := store.Touch("erin", lastSeen); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Touch("nobody", lastSeen); err != nil {
+		t.Fatal(err)
+	}
+	if session, err := store.Get("erin"); err != nil || session.UserID != 5 || !session.LastSeen.Equal(lastSeen) {
+		t.Errorf("got %+v, %v; expected user 5 last seen at %v", session, err, lastSeen)
+	}
+	check("nobody", 0)
+
+	// A session lasts until its expiry.
+	set(4, "dave", 50*time.Millisecond)
+	check("dave", 4)
+	time.Sleep(100 * time.Millisecond)
+	check("dave", 0)
+}
+
+func TestWithSessions(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := WithSessions(db, memory.NewSessionStore(), models.DefaultSessionLimits)
+
+	session := models.NewSession(1)
+	if err := r.CreateSession(session); err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := r.IsValidToken(session.Token); !valid || err != nil {
+		t.Errorf("got %t, %v; expected the session to be valid", valid, err)
+	}
+	if userID, err := r.GetUserIDByToken(session.Token); userID != 1 || err != nil {
+		t.Errorf("got user %d, %v; expected 1", userID, err)
+	}
+	// The database never saw the session.
+	if valid, _ := db.IsValidToken(session.Token); valid {
+		t.Error("session was stored in the database")
+	}
+
+	if err := r.DeleteSessionByToken(session.Token); err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := r.IsValidToken(session.Token); valid || err != nil {
+		t.Errorf("got %t, %v; expected the session to be gone", valid, err)
+	}
+}
+
+func TestSessionLimits(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits := models.SessionLimits{Lifetime: time.Hour, Idle: 10 * time.Minute}
+	newRepo := func() (*withSessions, *time.Time) {
+		now := time.Now()
+		r := WithSessions(db, db.Sessions(), limits).(*withSessions)
+		r.now = func() time.Time { return now }
+		return r, &now
+	}
+	login := func(r *withSessions) string {
+		t.Helper()
+		session := models.NewSession(1)
+		if err := r.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+		if want := r.now().Add(limits.Lifetime); !session.ExpTime.Equal(want) {
+			t.Errorf("got the session expiring at %v; expected %v", session.ExpTime, want)
+		}
+		return session.Token
+	}
+	valid := func(r *withSessions, token string, want bool) {
+		t.Helper()
+		if got, err := r.IsValidToken(token); got != want || err != nil {
+			t.Errorf("got %t, %v at %v; expected %t", got, err, r.now(), want)
+		}
+	}
+
+	t.Run("Idle", func(t *testing.T) {
+		r, now := newRepo()
+		token := login(r)
+
+		*now = now.Add(9 * time.Minute)
+		valid(r, token, true)
+		// The request above reset the idle timer.
+		*now = now.Add(9 * time.Minute)
+		valid(r, token, true)
+		*now = now.Add(10 * time.Minute)
+		valid(r, token, false)
+		if _, err := db.Sessions().Get(token); !errors.Is(err, models.ErrNoRecord) {
+			t.Errorf("got %v; expected the idle session to be deleted", err)
+		}
+		if _, err := r.GetUserIDByToken(token); !errors.Is(err, models.ErrNoRecord) {
+			t.Errorf("got %v; expected %v", err, models.ErrNoRecord)
+		}
+	})
+
+	t.Run("Lifetime", func(t *testing.T) {
+		r, now := newRepo()
+		token := login(r)
+
+		// Requests keep the session from idling, but not past its lifetime.
+		for range 6 {
+			*now = now.Add(9 * time.Minute)
+			valid(r, token, true)
+		}
+		*now = now.Add(9 * time.Minute)
+		if _, err := r.GetUserIDByToken(token); !errors.Is(err, models.ErrNoRecord) {
+			t.Errorf("got %v an hour after login; expected %v", err, models.ErrNoRecord)
+		}
+		valid(r, token, false)
+	})
+
+	t.Run("No idle timeout", func(t *testing.T) {
+		r, now := newRepo()
+		r.limits.Idle = 0
+		token := login(r)
+
+		*now = now.Add(59 * time.Minute)
+		valid(r, token, true)
+	})
+}
@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"forum/models"
+	"time"
+)
+
+const timestampLayout = "2006-01-02 15:04:05"
+
+// activityQuery merges everything a user did into a single result set so that
+// SQLite does the ordering and paging instead of us. Timestamps are
+// normalized with strftime because the branches store them in different
+// formats and the driver can't infer the column type of a UNION.
+func activityQuery(withReactions bool) string {
+	query := `SELECT 'post' AS kind, p.id, p.title, 0, p.content, FALSE, strftime('%Y-%m-%d %H:%M:%S', p.created) AS created
+	FROM posts p
+	WHERE p.user_id = :user AND p.approved
+	UNION ALL
+	SELECT 'comment', c.post_id, p.title, c.id, c.content, FALSE, strftime('%Y-%m-%d %H:%M:%S', c.created)
+	FROM comments c
+	JOIN posts p ON c.post_id = p.id
+	WHERE c.user_id = :user AND c.approved`
+
+	if withReactions {
+		query += `
+	UNION ALL
+	SELECT 'reaction', l.post_id, p.title, 0, '', l.is_like, strftime('%Y-%m-%d %H:%M:%S', COALESCE(l.created, p.created))
+	FROM post_user_Like l
+	JOIN posts p ON l.post_id = p.id
+	WHERE l.user_id = :user
+	UNION ALL
+	SELECT 'reaction', c.post_id, p.title, c.id, c.content, l.is_like, strftime('%Y-%m-%d %H:%M:%S', COALESCE(l.created, c.created))
+	FROM comment_user_Like l
+	JOIN comments c ON l.comment_id = c.id
+	JOIN posts p ON c.post_id = p.id
+	WHERE l.user_id = :user`
+	}
+	return query
+}
+
+func (s *Sqlite) GetUserActivityPaginated(userID int, withReactions bool, page, pageSize int) (*[]models.Activity, error) {
+	op := "sqlite.GetUserActivityPaginated"
+	offset := (page - 1) * pageSize
+	stmt := activityQuery(withReactions) + `
+	ORDER BY created DESC, kind ASC
+	LIMIT :limit OFFSET :offset`
+
+	rows, err := s.db.Query(stmt, sql.Named("user", userID), sql.Named("limit", pageSize), sql.Named("offset", offset))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var activities []models.Activity
+	for rows.Next() {
+		var a models.Activity
+		var created string
+		if err := rows.Scan(&a.Kind, &a.PostID, &a.PostTitle, &a.CommentID, &a.Content, &a.IsLike, &created); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		a.Created, err = time.Parse(timestampLayout, created)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		activities = append(activities, a)
+	}
+	return &activities, nil
+}
+
+func (s *Sqlite) GetPageNumberActivity(pageSize int, userID int, withReactions bool) (int, error) {
+	var total int
+	op := "sqlite.GetPageNumberActivity"
+
+	stmt := `SELECT COUNT(*) FROM (` + activityQuery(withReactions) + `)`
+	err := s.db.QueryRow(stmt, sql.Named("user", userID)).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	totalPages := (total + pageSize - 1) / pageSize
+	return totalPages, nil
+}
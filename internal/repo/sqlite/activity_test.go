@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"forum/models"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestDB(t *testing.T) *Sqlite {
+	t.Helper()
+
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.db.Close() })
+	return db
+}
+
+func exec(t *testing.T, s *Sqlite, query string, args ...any) {
+	t.Helper()
+
+	if _, err := s.db.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetUserActivityPaginated(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, created) VALUES
+		(1, 1, 'first', 'first post', '2024-01-01 10:00:00'),
+		(2, 2, 'second', 'second post', '2024-01-02 10:00:00'),
+		(3, 1, 'third', 'third post', '2024-01-05 10:00:00')`)
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content, created) VALUES
+		(1, 2, 1, 'nice', '2024-01-03 10:00:00'),
+		(2, 1, 2, 'thanks', '2024-01-03 11:00:00')`)
+	exec(t, s, `INSERT INTO post_user_Like (user_id, post_id, is_like, created) VALUES (1, 2, TRUE, '2024-01-04 10:00:00')`)
+	exec(t, s, `INSERT INTO comment_user_Like (user_id, comment_id, is_like, created) VALUES (1, 2, FALSE, '2024-01-06 10:00:00')`)
+
+	type entry struct {
+		kind   string
+		postID int
+	}
+
+	tests := []struct {
+		name          string
+		withReactions bool
+		page          int
+		pageSize      int
+		want          []entry
+		wantPages     int
+	}{
+		{
+			name:          "Owner sees everything in order",
+			withReactions: true,
+			page:          1,
+			pageSize:      10,
+			want: []entry{
+				{models.ActivityReaction, 1},
+				{models.ActivityPost, 3},
+				{models.ActivityReaction, 2},
+				{models.ActivityComment, 2},
+				{models.ActivityPost, 1},
+			},
+			wantPages: 1,
+		},
+		{
+			name:          "Second page",
+			withReactions: true,
+			page:          2,
+			pageSize:      2,
+			want: []entry{
+				{models.ActivityReaction, 2},
+				{models.ActivityComment, 2},
+			},
+			wantPages: 3,
+		},
+		{
+			name:     "Reactions hidden",
+			page:     1,
+			pageSize: 10,
+			want: []entry{
+				{models.ActivityPost, 3},
+				{models.ActivityComment, 2},
+				{models.ActivityPost, 1},
+			},
+			wantPages: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activities, err := s.GetUserActivityPaginated(1, tt.withReactions, tt.page, tt.pageSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(*activities) != len(tt.want) {
+				t.Fatalf("got %d activities; expected %d", len(*activities), len(tt.want))
+			}
+			for i, a := range *activities {
+				if a.Kind != tt.want[i].kind || a.PostID != tt.want[i].postID {
+					t.Errorf("activity %d: got %s on post %d; expected %s on post %d", i, a.Kind, a.PostID, tt.want[i].kind, tt.want[i].postID)
+				}
+			}
+
+			pages, err := s.GetPageNumberActivity(tt.pageSize, 1, tt.withReactions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("got %d pages; expected %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
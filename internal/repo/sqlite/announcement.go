@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"forum/models"
+	"strings"
+	"time"
+)
+
+const selectAnnouncements = `SELECT id, message, category_id, post_id, starts_at, ends_at, created FROM announcements`
+
+// CreateAnnouncement stores a and sets its ID.
+func (s *Sqlite) CreateAnnouncement(a *models.Announcement) error {
+	op := "sqlite.CreateAnnouncement"
+	stmt := `INSERT INTO announcements (message, category_id, post_id, starts_at, ends_at, created)
+	VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP) RETURNING id`
+	start := a.Start.UTC().Format(timestampLayout)
+	end := a.End.UTC().Format(timestampLayout)
+	if err := s.db.QueryRow(stmt, a.Message, a.CategoryID, a.PostID, start, end).Scan(&a.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) DeleteAnnouncement(id int) error {
+	op := "sqlite.DeleteAnnouncement"
+	result, err := s.db.Exec(`DELETE FROM announcements WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if deleted == 0 {
+		return models.ErrNoRecord
+	}
+	return nil
+}
+
+// GetAnnouncements returns every announcement, active or not, the latest
+// starting first.
+func (s *Sqlite) GetAnnouncements() (*[]models.Announcement, error) {
+	op := "sqlite.GetAnnouncements"
+	rows, err := s.db.Query(selectAnnouncements + ` ORDER BY datetime(starts_at) DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	announcements, err := scanAnnouncements(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return announcements, nil
+}
+
+// GetActiveAnnouncements returns the announcements of scope that show at now,
+// site-wide ones first.
+func (s *Sqlite) GetActiveAnnouncements(now time.Time, scope models.AnnouncementScope) (*[]models.Announcement, error) {
+	op := "sqlite.GetActiveAnnouncements"
+	at := now.UTC().Format(timestampLayout)
+	args := []any{at, at}
+
+	scopes := []string{`(category_id = 0 AND post_id = 0)`}
+	if len(scope.Categories) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(scope.Categories)), ", ")
+		scopes = append(scopes, `category_id IN (`+placeholders+`)`)
+		for _, id := range scope.Categories {
+			args = append(args, id)
+		}
+	}
+	if scope.PostID > 0 {
+		scopes = append(scopes, `post_id = ?`)
+		args = append(args, scope.PostID)
+	}
+
+	stmt := selectAnnouncements + ` WHERE datetime(starts_at) <= datetime(?) AND datetime(ends_at) > datetime(?)
+	AND (` + strings.Join(scopes, " OR ") + `)
+	ORDER BY category_id != 0 OR post_id != 0, datetime(starts_at), id`
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	announcements, err := scanAnnouncements(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return announcements, nil
+}
+
+func scanAnnouncements(rows *sql.Rows) (*[]models.Announcement, error) {
+	defer rows.Close()
+	var announcements []models.Announcement
+	for rows.Next() {
+		var a models.Announcement
+		if err := rows.Scan(&a.ID, &a.Message, &a.CategoryID, &a.PostID, &a.Start, &a.End, &a.Created); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &announcements, nil
+}
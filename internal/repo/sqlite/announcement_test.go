@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"forum/models"
+	"testing"
+	"time"
+)
+
+func TestGetActiveAnnouncements(t *testing.T) {
+	s := newTestDB(t)
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	for _, a := range []models.Announcement{
+		{Message: "site", Start: now.Add(-day), End: now.Add(day)},
+		{Message: "category", CategoryID: 2, Start: now.Add(-day), End: now.Add(day)},
+		{Message: "post", PostID: 5, Start: now.Add(-day), End: now.Add(day)},
+		{Message: "expired", Start: now.Add(-2 * day), End: now},
+		{Message: "future", Start: now.Add(time.Second), End: now.Add(2 * day)},
+	} {
+		if err := s.CreateAnnouncement(&a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		scope models.AnnouncementScope
+		want  []string
+	}{
+		{name: "Site-wide page", now: now, want: []string{"site"}},
+		{name: "Other category", now: now, scope: models.AnnouncementScope{Categories: []int{1}}, want: []string{"site"}},
+		{name: "Category page", now: now, scope: models.AnnouncementScope{Categories: []int{1, 2}}, want: []string{"site", "category"}},
+		{name: "Post page", now: now, scope: models.AnnouncementScope{Categories: []int{2}, PostID: 5}, want: []string{"site", "category", "post"}},
+		{name: "Before any", now: now.Add(-3 * day), want: nil},
+		{name: "Expired at end", now: now.Add(day), scope: models.AnnouncementScope{PostID: 5}, want: []string{"future"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			announcements, err := s.GetActiveAnnouncements(tt.now, tt.scope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, a := range *announcements {
+				got = append(got, a.Message)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v; want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v; want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if err := s.DeleteAnnouncement(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteAnnouncement(1); err != models.ErrNoRecord {
+		t.Errorf("deleting twice: got %v; want %v", err, models.ErrNoRecord)
+	}
+	all, err := s.GetAnnouncements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*all) != 4 {
+		t.Errorf("got %d announcements; want 4", len(*all))
+	}
+}
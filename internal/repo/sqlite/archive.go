@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+)
+
+// ArchiveInactivePosts archives the posts that were created and last
+// commented on before cutoff, and returns how many it archived.
+func (s *Sqlite) ArchiveInactivePosts(cutoff time.Time) (int, error) {
+	op := "sqlite.ArchiveInactivePosts"
+	at := cutoff.UTC().Format(timestampLayout)
+	stmt := `UPDATE posts SET archived = TRUE
+	WHERE NOT archived AND datetime(created) < ?
+	AND NOT EXISTS (SELECT 1 FROM comments c WHERE c.post_id = posts.id AND datetime(c.created) >= ?)`
+
+	result, err := s.db.Exec(stmt, at, at)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	archived, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(archived), nil
+}
+
+// IsPostArchived reports whether the post is archived. Missing posts aren't.
+func (s *Sqlite) IsPostArchived(postID int) (bool, error) {
+	op := "sqlite.IsPostArchived"
+	var archived bool
+	stmt := `SELECT EXISTS(SELECT 1 FROM posts WHERE id = ? AND archived)`
+	if err := s.db.QueryRow(stmt, postID).Scan(&archived); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return archived, nil
+}
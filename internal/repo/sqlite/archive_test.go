@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+)
+
+func TestArchiveInactivePosts(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	// 1 is old and quiet, 2 is old with a recent comment, 3 is new.
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+		(1, 1, 'p', 'c', 'Nan', '2024-01-01 10:00:00'),
+		(2, 1, 'p', 'c', 'Nan', '2024-01-01 10:00:00'),
+		(3, 1, 'p', 'c', 'Nan', '2024-03-01 10:00:00')`)
+	exec(t, s, `INSERT INTO comments (post_id, user_id, content, created) VALUES
+		(1, 1, 'old', '2024-01-02 10:00:00'),
+		(2, 1, 'recent', '2024-02-20 10:00:00')`)
+
+	archived, err := s.ArchiveInactivePosts(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived != 1 {
+		t.Errorf("got %d posts archived; expected 1", archived)
+	}
+	for id, want := range map[int]bool{1: true, 2: false, 3: false} {
+		got, err := s.IsPostArchived(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("post %d: got archived %t; expected %t", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"forum/models"
+	"strings"
+)
+
+const insertAuditEntry = `INSERT INTO audit_log (actor_id, action, target, ip, created) VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`
+
+func (s *Sqlite) CreateAuditEntry(entry *models.AuditEntry) error {
+	op := "sqlite.CreateAuditEntry"
+	if _, err := s.db.Exec(insertAuditEntry, entry.ActorID, entry.Action, entry.Target, entry.IP); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UpdateUserStatus changes the role of the user and records entry in the
+// same transaction, so a role change never happens without its audit trail.
+func (s *Sqlite) UpdateUserStatus(userID, status int, entry *models.AuditEntry) error {
+	op := "sqlite.UpdateUserStatus"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := tx.Exec(`UPDATE users SET status = ? WHERE id = ?`, status, userID)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if updated == 0 {
+		tx.Rollback()
+		return models.ErrNoRecord
+	}
+
+	if _, err = tx.Exec(insertAuditEntry, entry.ActorID, entry.Action, entry.Target, entry.IP); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tx.Commit()
+}
+
+func auditConditions(filter models.AuditFilter) (string, []any) {
+	conditions := []string{"1 = 1"}
+	var args []any
+
+	if filter.Actor != "" {
+		conditions = append(conditions, `u.name = ?`)
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, `a.action = ?`)
+		args = append(args, filter.Action)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func (s *Sqlite) GetAuditLogPaginated(filter models.AuditFilter, page, pageSize int) (*[]models.AuditEntry, error) {
+	op := "sqlite.GetAuditLogPaginated"
+	offset := (page - 1) * pageSize
+	where, args := auditConditions(filter)
+	stmt := `SELECT a.id, a.actor_id, COALESCE(u.name, ''), a.action, a.target, a.ip, a.created
+	FROM audit_log a
+	LEFT JOIN users u ON a.actor_id = u.id
+	WHERE ` + where + `
+	ORDER BY a.id DESC
+	LIMIT ? OFFSET ?`
+
+	rows, err := s.db.Query(stmt, append(args, pageSize, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var entry models.AuditEntry
+		var actorID sql.NullInt64
+		if err := rows.Scan(&entry.ID, &actorID, &entry.ActorName, &entry.Action, &entry.Target, &entry.IP, &entry.Created); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		entry.ActorID = int(actorID.Int64)
+		entries = append(entries, entry)
+	}
+	return &entries, nil
+}
+
+func (s *Sqlite) GetPageNumberAudit(pageSize int, filter models.AuditFilter) (int, error) {
+	var total int
+	op := "sqlite.GetPageNumberAudit"
+	where, args := auditConditions(filter)
+	stmt := `SELECT COUNT(*)
+	FROM audit_log a
+	LEFT JOIN users u ON a.actor_id = u.id
+	WHERE ` + where
+
+	if err := s.db.QueryRow(stmt, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return (total + pageSize - 1) / pageSize, nil
+}
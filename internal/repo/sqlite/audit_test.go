@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"errors"
+	"forum/models"
+	"testing"
+)
+
+func TestUpdateUserStatusAudited(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password, status) VALUES (1, 'root', 'root@gmail.com', '', 2), (2, 'bob', 'bob@gmail.com', '', 0)`)
+
+	entry := &models.AuditEntry{ActorID: 1, Action: models.AuditRoleChange, Target: "user:2 status:0->1", IP: "10.0.0.1"}
+	if err := s.UpdateUserStatus(2, models.StatusModerator, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := s.GetUserByID(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Status != models.StatusModerator {
+		t.Errorf("got status %d; expected %d", user.Status, models.StatusModerator)
+	}
+
+	err = s.UpdateUserStatus(42, models.StatusAdmin, entry)
+	if !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("got %v; expected %v", err, models.ErrNoRecord)
+	}
+
+	if err := s.CreateAuditEntry(&models.AuditEntry{ActorID: 2, Action: models.AuditLogin, IP: "10.0.0.2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.GetAuditLogPaginated(models.AuditFilter{Action: models.AuditRoleChange}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*entries) != 1 {
+		t.Fatalf("got %d entries; expected 1", len(*entries))
+	}
+	got := (*entries)[0]
+	if got.ActorName != "root" || got.Target != entry.Target || got.IP != entry.IP {
+		t.Errorf("got %+v; expected actor root, target %q, ip %q", got, entry.Target, entry.IP)
+	}
+
+	entries, err = s.GetAuditLogPaginated(models.AuditFilter{Actor: "bob"}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*entries) != 1 || (*entries)[0].Action != models.AuditLogin {
+		t.Errorf("actor filter: got %+v", *entries)
+	}
+
+	pages, err := s.GetPageNumberAudit(1, models.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 2 {
+		t.Errorf("got %d pages; expected 2", pages)
+	}
+}
@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"forum/models"
+	"strconv"
+	"strings"
+)
+
+// backupQueries select each record type of a backup, in the order they are
+// written. Everything is ordered by id so a comment comes after the comment
+// it quotes.
+var backupQueries = []struct {
+	kind  string
+	query string
+	scan  func(*sql.Rows) (any, error)
+}{
+	{models.BackupUserType, `SELECT id, name, display_name, email, created, COALESCE(status, 0), privacy, is_guest FROM users ORDER BY id`, scanBackupUser},
+	{models.BackupCategoryType, `SELECT id, name FROM category ORDER BY id`, scanBackupCategory},
+	{models.BackupPostType, `SELECT p.id, p.user_id, p.title, p.content, COALESCE(p.image_name, ''), p.created, p.locked, p.lock_reason, p.approved, p.profile_pinned,
+		COALESCE(p.accepted_answer_comment_id, 0), COALESCE((SELECT GROUP_CONCAT(pc.category_id) FROM post_category pc WHERE pc.post_id = p.id), ''), p.guest_name
+		FROM posts p ORDER BY p.id`, scanBackupPost},
+	{models.BackupCommentType, `SELECT id, post_id, user_id, content, created, COALESCE(quoted_comment_id, 0), quote_excerpt, approved, guest_name FROM comments ORDER BY id`, scanBackupComment},
+	{models.BackupPostReactionType, `SELECT user_id, post_id, is_like, created FROM post_user_Like ORDER BY post_id, user_id`, scanBackupReaction},
+	{models.BackupCommentReactionType, `SELECT user_id, comment_id, is_like, created FROM comment_user_Like ORDER BY comment_id, user_id`, scanBackupReaction},
+}
+
+// ExportBackup hands every record of a backup to emit, one at a time, so the
+// caller can stream them. It reads in a single transaction to get a
+// consistent snapshot.
+func (s *Sqlite) ExportBackup(emit func(models.BackupRecord) error) error {
+	op := "sqlite.ExportBackup"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, q := range backupQueries {
+		if err := exportRows(tx, q.kind, q.query, q.scan, emit); err != nil {
+			return fmt.Errorf("%s: %s: %w", op, q.kind, err)
+		}
+	}
+	return nil
+}
+
+func exportRows(tx *sql.Tx, kind, query string, scan func(*sql.Rows) (any, error), emit func(models.BackupRecord) error) error {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := emit(models.BackupRecord{Type: kind, Data: data}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func scanBackupUser(rows *sql.Rows) (any, error) {
+	var u models.BackupUser
+	err := rows.Scan(&u.ID, &u.Name, &u.DisplayName, &u.Email, &u.Created, &u.Status, &u.Privacy, &u.Guest)
+	return u, err
+}
+
+func scanBackupCategory(rows *sql.Rows) (any, error) {
+	var c models.BackupCategory
+	err := rows.Scan(&c.ID, &c.Name)
+	return c, err
+}
+
+func scanBackupPost(rows *sql.Rows) (any, error) {
+	var p models.BackupPost
+	var categories string
+	err := rows.Scan(&p.ID, &p.UserID, &p.Title, &p.Content, &p.ImageName, &p.Created, &p.Locked, &p.LockReason, &p.Approved, &p.ProfilePinned, &p.AcceptedAnswerID, &categories, &p.GuestName)
+	if err != nil {
+		return nil, err
+	}
+	p.Categories = []int{}
+	if categories != "" {
+		for _, id := range strings.Split(categories, ",") {
+			n, err := strconv.Atoi(id)
+			if err != nil {
+				return nil, err
+			}
+			p.Categories = append(p.Categories, n)
+		}
+	}
+	return p, nil
+}
+
+func scanBackupComment(rows *sql.Rows) (any, error) {
+	var c models.BackupComment
+	err := rows.Scan(&c.ID, &c.PostID, &c.UserID, &c.Content, &c.Created, &c.QuotedCommentID, &c.QuoteExcerpt, &c.Approved, &c.GuestName)
+	return c, err
+}
+
+func scanBackupReaction(rows *sql.Rows) (any, error) {
+	var r models.BackupReaction
+	var created sql.NullTime
+	if err := rows.Scan(&r.UserID, &r.TargetID, &r.Like, &created); err != nil {
+		return nil, err
+	}
+	if created.Valid {
+		r.Created = &created.Time
+	}
+	return r, nil
+}
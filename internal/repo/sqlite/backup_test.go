@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"forum/models"
+	"strings"
+	"testing"
+)
+
+func TestExportBackup(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, display_name, email, hashed_password) VALUES
+		(1, 'alice', 'Alice A.', 'alice@gmail.com', 'secret-hash-1'), (2, 'bob', '', 'bob@gmail.com', 'secret-hash-2')`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Sports')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'one', 'c', 'Nan'), (2, 2, 'two', 'c', NULL)`)
+	exec(t, s, `INSERT INTO post_category (category_id, post_id) VALUES (1, 1), (2, 1)`)
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content) VALUES (1, 1, 2, 'hi')`)
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content, quoted_comment_id, quote_excerpt) VALUES (2, 1, 1, 'hello', 1, 'hi')`)
+	exec(t, s, `UPDATE posts SET accepted_answer_comment_id = 2 WHERE id = 1`)
+	exec(t, s, `INSERT INTO post_user_Like (user_id, post_id, is_like) VALUES (1, 2, TRUE), (2, 1, FALSE)`)
+	exec(t, s, `INSERT INTO comment_user_Like (user_id, comment_id, is_like) VALUES (1, 1, TRUE)`)
+
+	var out strings.Builder
+	counts := map[string]int{}
+	var posts []models.BackupPost
+	err := s.ExportBackup(func(record models.BackupRecord) error {
+		counts[record.Type]++
+		if post, ok := record.Data.(models.BackupPost); ok {
+			posts = append(posts, post)
+		}
+		return json.NewEncoder(&out).Encode(record)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{
+		models.BackupUserType:            2,
+		models.BackupCategoryType:        2,
+		models.BackupPostType:            2,
+		models.BackupCommentType:         2,
+		models.BackupPostReactionType:    2,
+		models.BackupCommentReactionType: 1,
+	}
+	for kind, n := range want {
+		if counts[kind] != n {
+			t.Errorf("got %d %s records; expected %d", counts[kind], kind, n)
+		}
+	}
+	if strings.Contains(out.String(), "secret-hash") || strings.Contains(out.String(), "password") {
+		t.Errorf("expected no password hashes in the backup:\n%s", out.String())
+	}
+	if len(posts) == 2 {
+		if len(posts[0].Categories) != 2 || posts[0].AcceptedAnswerID != 2 {
+			t.Errorf("got %+v; expected post 1 in 2 categories with answer 2", posts[0])
+		}
+		if len(posts[1].Categories) != 0 {
+			t.Errorf("got %+v; expected post 2 without categories", posts[1])
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package sqlite
 
-import "fmt"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"forum/models"
+	"strings"
+)
 
 func (s *Sqlite) AddCategoryToPost(postID int, categories []int) error {
 	const op = "sqlite.AddCategoryToPost"
@@ -86,3 +92,222 @@ func (s *Sqlite) GetCategoriesByPostID(postID int) (map[int]string, error) {
 	}
 	return category, nil
 }
+
+// GetCategoriesByPostIDs returns the categories of all the posts at once,
+// keyed by post ID, so listing a page of posts takes one query rather than
+// one per post. Posts without categories are left out.
+func (s *Sqlite) GetCategoriesByPostIDs(postIDs []int) (map[int]map[int]string, error) {
+	op := "sqlite.GetCategoriesByPostIDs"
+	categories := make(map[int]map[int]string)
+	if len(postIDs) == 0 {
+		return categories, nil
+	}
+
+	args := make([]any, len(postIDs))
+	for i, id := range postIDs {
+		args[i] = id
+	}
+	stmt := `SELECT pc.post_id, pc.category_id, c.name
+	FROM post_category pc
+	INNER JOIN category c ON pc.category_id = c.id
+	WHERE pc.post_id IN (?` + strings.Repeat(", ?", len(postIDs)-1) + `)`
+
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID, categoryID int
+		var name string
+		if err := rows.Scan(&postID, &categoryID, &name); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if categories[postID] == nil {
+			categories[postID] = make(map[int]string)
+		}
+		categories[postID][categoryID] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return categories, nil
+}
+
+func (s *Sqlite) AddCategoryModerator(userID, categoryID int) error {
+	op := "sqlite.AddCategoryModerator"
+
+	var exists bool
+	if err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM category WHERE id = ?)`, categoryID).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return models.UnknownCategory
+	}
+
+	stmt := `INSERT OR IGNORE INTO category_moderators (user_id, category_id) VALUES (?, ?)`
+	if _, err := s.db.Exec(stmt, userID, categoryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) RemoveCategoryModerator(userID, categoryID int) error {
+	op := "sqlite.RemoveCategoryModerator"
+	stmt := `DELETE FROM category_moderators WHERE user_id = ? AND category_id = ?`
+	if _, err := s.db.Exec(stmt, userID, categoryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// IsCategoryModerator reports whether the user moderates any of the
+// categories of the post.
+func (s *Sqlite) IsCategoryModerator(userID, postID int) (bool, error) {
+	op := "sqlite.IsCategoryModerator"
+	stmt := `SELECT EXISTS(
+		SELECT 1 FROM category_moderators cm
+		JOIN post_category pc ON pc.category_id = cm.category_id
+		WHERE cm.user_id = ? AND pc.post_id = ?)`
+
+	var ok bool
+	if err := s.db.QueryRow(stmt, userID, postID).Scan(&ok); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
+
+// UncategorizedID returns the id of the models.Uncategorized category,
+// which is made the first time it is needed.
+func (s *Sqlite) UncategorizedID() (int, error) {
+	op := "sqlite.UncategorizedID"
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`SELECT id FROM category WHERE name = ? LIMIT 1`, models.Uncategorized).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		var result sql.Result
+		if result, err = tx.Exec(`INSERT INTO category (name) VALUES (?)`, models.Uncategorized); err == nil {
+			var inserted int64
+			inserted, err = result.LastInsertId()
+			id = int(inserted)
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+// MovePostToCategory replaces the categories of the post with the category,
+// or returns models.UnknownCategory if there is no such category.
+func (s *Sqlite) MovePostToCategory(postID, categoryID int) error {
+	op := "sqlite.MovePostToCategory"
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM category WHERE id = ?)`, categoryID).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return models.UnknownCategory
+	}
+	if _, err := tx.Exec(`DELETE FROM post_category WHERE post_id = ?`, postID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO post_category (post_id, category_id) VALUES (?, ?)`, postID, categoryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetCategoryCommentMode sets how the comments of the posts in the category
+// are shown.
+func (s *Sqlite) SetCategoryCommentMode(categoryID int, mode string) error {
+	op := "sqlite.SetCategoryCommentMode"
+	res, err := s.db.Exec(`UPDATE category SET comment_mode = ? WHERE id = ?`, mode, categoryID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return models.UnknownCategory
+	}
+	return nil
+}
+
+// SetCategoryPostStatus sets the least status, models.StatusUser and up, a
+// user needs to post in the category.
+func (s *Sqlite) SetCategoryPostStatus(categoryID, status int) error {
+	op := "sqlite.SetCategoryPostStatus"
+	res, err := s.db.Exec(`UPDATE category SET post_status = ? WHERE id = ?`, status, categoryID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return models.UnknownCategory
+	}
+	return nil
+}
+
+// GetPostStatus returns the least status a user needs to post in all of the
+// categories, the highest any of them asks for.
+func (s *Sqlite) GetPostStatus(categoryIDs []int) (int, error) {
+	op := "sqlite.GetPostStatus"
+	if len(categoryIDs) == 0 {
+		return models.StatusUser, nil
+	}
+
+	args := make([]any, len(categoryIDs))
+	for i, id := range categoryIDs {
+		args[i] = id
+	}
+	stmt := `SELECT COALESCE(MAX(post_status), 0) FROM category
+	WHERE id IN (?` + strings.Repeat(", ?", len(categoryIDs)-1) + `)`
+
+	var status int
+	if err := s.db.QueryRow(stmt, args...).Scan(&status); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return status, nil
+}
+
+// GetCommentMode returns how the comments of the post are shown: flat if any
+// of its categories is, threaded if it has threaded categories only.
+func (s *Sqlite) GetCommentMode(postID int) (string, error) {
+	op := "sqlite.GetCommentMode"
+	stmt := `SELECT
+		EXISTS(SELECT 1 FROM post_category pc JOIN category c ON c.id = pc.category_id WHERE pc.post_id = ? AND c.comment_mode = ?),
+		EXISTS(SELECT 1 FROM post_category pc JOIN category c ON c.id = pc.category_id WHERE pc.post_id = ? AND c.comment_mode = ?)`
+
+	var flat, threaded bool
+	if err := s.db.QueryRow(stmt, postID, models.CommentsFlat, postID, models.CommentsThreaded).Scan(&flat, &threaded); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if threaded && !flat {
+		return models.CommentsThreaded, nil
+	}
+	return models.CommentsFlat, nil
+}
@@ -1,8 +1,11 @@
 package sqlite
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"forum/models"
+	"time"
 )
 
 func (s *Sqlite) CheckCommentExists(commentID int) bool {
@@ -15,22 +18,82 @@ func (s *Sqlite) CheckCommentExists(commentID int) bool {
 	return isExists
 }
 
-func (s *Sqlite) CommentPost(form models.CommentForm) error {
+func (s *Sqlite) CommentPost(form models.CommentForm) (int, error) {
 	op := "sqlite.CommentPost"
-	stmt := `INSERT INTO Comments (post_id, user_id, content, created) VALUES(?, ?, ?, CURRENT_TIMESTAMP)`
-	_, err := s.db.Exec(stmt, form.PostID, form.UserID, form.Content)
+	stmt := `INSERT INTO Comments (post_id, user_id, content, quoted_comment_id, quote_excerpt, approved, guest_name, guest_ip, created) VALUES(?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+	var quotedCommentID sql.NullInt64
+	if form.QuotedCommentID != 0 {
+		quotedCommentID = sql.NullInt64{Int64: int64(form.QuotedCommentID), Valid: true}
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(stmt, form.PostID, form.UserID, form.Content, quotedCommentID, form.QuoteExcerpt, !form.Pending, form.GuestName, form.GuestIP)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	id, err := result.LastInsertId()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	// Pending comments bump the post once approved.
+	if !form.Pending {
+		if _, err := tx.Exec(`UPDATE posts SET last_activity = CURRENT_TIMESTAMP WHERE id = ?`, form.PostID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
 	}
-	return nil
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(id), nil
+}
+
+// GetLastCommentTime returns when the user last commented, ErrNoRecord if
+// they never did.
+func (s *Sqlite) GetLastCommentTime(userID int) (time.Time, error) {
+	op := "sqlite.GetLastCommentTime"
+	stmt := `SELECT created FROM comments WHERE user_id = ? ORDER BY created DESC LIMIT 1`
+
+	var created time.Time
+	if err := s.db.QueryRow(stmt, userID).Scan(&created); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, models.ErrNoRecord
+		}
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return created, nil
+}
+
+func (s *Sqlite) GetCommentByID(commentID int) (*models.Comment, error) {
+	op := "sqlite.GetCommentByID"
+	const query = `SELECT c.id, c.post_id, c.user_id, c.created, c.content, c.like, c.dislike, u.name, COALESCE(NULLIF(c.guest_name, ''), u.display_name), COALESCE(c.quoted_comment_id, 0), NOT c.approved, c.deleted
+	FROM comments c
+	JOIN users u ON c.user_id = u.id
+	WHERE c.id = ?`
+
+	var comment models.Comment
+	err := s.db.QueryRow(query, commentID).Scan(&comment.CommentID, &comment.PostID, &comment.UserID, &comment.Created, &comment.Content, &comment.Like, &comment.Dislike, &comment.UserName, &comment.DisplayName, &comment.QuotedCommentID, &comment.Pending, &comment.Deleted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &comment, nil
 }
 
 func (s *Sqlite) GetCommentsByPostID(postID int) (*[]models.Comment, error) {
-	const query = `SELECT c.id, c.post_id, c.user_id, c.created, c.content, c.like, c.dislike, u.name 
+	const query = `SELECT c.id, c.post_id, c.user_id, c.created, c.content, c.like, c.dislike, u.name, COALESCE(NULLIF(c.guest_name, ''), u.display_name),
+	COALESCE(c.quoted_comment_id, 0), c.quote_excerpt, IIF(q.deleted, ?, COALESCE(qu.name, '')), IIF(q.deleted, '', COALESCE(qu.display_name, '')), NOT c.approved, c.deleted
 	FROM comments c 
 	JOIN users u ON c.user_id = u.id 
+	LEFT JOIN comments q ON c.quoted_comment_id = q.id
+	LEFT JOIN users qu ON q.user_id = qu.id
 	WHERE c.post_id = ?`
-	rows, err := s.db.Query(query, postID)
+	rows, err := s.db.Query(query, models.DeletedContent, postID)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +102,8 @@ func (s *Sqlite) GetCommentsByPostID(postID int) (*[]models.Comment, error) {
 	var comments []models.Comment
 	for rows.Next() {
 		var comment models.Comment
-		err := rows.Scan(&comment.CommentID, &comment.PostID, &comment.UserID, &comment.Created, &comment.Content, &comment.Like, &comment.Dislike, &comment.UserName)
+		err := rows.Scan(&comment.CommentID, &comment.PostID, &comment.UserID, &comment.Created, &comment.Content, &comment.Like, &comment.Dislike, &comment.UserName, &comment.DisplayName,
+			&comment.QuotedCommentID, &comment.QuoteExcerpt, &comment.QuotedUserName, &comment.QuotedDisplayName, &comment.Pending, &comment.Deleted)
 		if err != nil {
 			return nil, err
 		}
@@ -48,63 +112,31 @@ func (s *Sqlite) GetCommentsByPostID(postID int) (*[]models.Comment, error) {
 	return &comments, nil
 }
 
-// like system
-
-func (s *Sqlite) AddReactionComment(form models.ReactionForm) error {
-	tx, err := s.db.Begin()
+// GetPendingComments returns the comments waiting for approval, oldest
+// first.
+func (s *Sqlite) GetPendingComments() (*[]models.Comment, error) {
+	op := "sqlite.GetPendingComments"
+	stmt := `SELECT c.id, c.post_id, c.user_id, c.created, c.content, u.name, COALESCE(NULLIF(c.guest_name, ''), u.display_name)
+	FROM comments c
+	JOIN users u ON c.user_id = u.id
+	WHERE NOT c.approved
+	ORDER BY c.id`
+	rows, err := s.db.Query(stmt)
 	if err != nil {
-		return err
-	}
-
-	// Insert like/dislike
-	insertQuery := `INSERT INTO Comment_User_Like (user_id, comment_id, is_like) VALUES (?, ?, ?)`
-	_, err = tx.Exec(insertQuery, form.UserID, form.ID, form.Reaction)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// Update Post like/dislike count
-	updateQuery := ""
-	if form.Reaction {
-		updateQuery = `UPDATE Comments SET like = like + 1 WHERE id = ?`
-	} else {
-		updateQuery = `UPDATE Comments SET dislike = dislike + 1 WHERE id = ?`
-	}
-	_, err = tx.Exec(updateQuery, form.ID)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-	return tx.Commit()
-}
-
-func (s *Sqlite) DeleteReactionComment(form models.ReactionForm, isLike bool) error {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
-	}
-
-	// delete the like/dislike
-	deleteQuery := `DELETE FROM Comment_User_Like WHERE user_id = ? AND comment_id = ?`
-	_, err = tx.Exec(deleteQuery, form.UserID, form.ID)
-	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer rows.Close()
 
-	// decrement the like or dislike
-	updateQuery := ""
-	if isLike {
-		updateQuery = `UPDATE Comments SET like = like - 1 WHERE id = ? AND like > 0`
-	} else {
-		updateQuery = `UPDATE Comments SET dislike = dislike - 1  WHERE id = ? AND dislike > 0`
+	comments := []models.Comment{}
+	for rows.Next() {
+		comment := models.Comment{Pending: true}
+		if err := rows.Scan(&comment.CommentID, &comment.PostID, &comment.UserID, &comment.Created, &comment.Content, &comment.UserName, &comment.DisplayName); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		comments = append(comments, comment)
 	}
-	_, err = tx.Exec(updateQuery, form.ID)
-	if err != nil {
-		tx.Rollback()
-		return err
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-
-	return tx.Commit()
+	return &comments, nil
 }
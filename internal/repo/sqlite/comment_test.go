@@ -0,0 +1,30 @@
+package sqlite
+
+import (
+	"errors"
+	"forum/models"
+	"testing"
+	"time"
+)
+
+func TestGetLastCommentTime(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'hello', 'c', 'Nan')`)
+	exec(t, s, `INSERT INTO comments (post_id, user_id, content, created) VALUES
+		(1, 1, 'old', '2024-01-01 10:00:00'),
+		(1, 1, 'new', '2024-01-02 10:00:00')`)
+
+	last, err := s.GetLastCommentTime(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC); !last.Equal(want) {
+		t.Errorf("got %v; expected %v", last, want)
+	}
+
+	if _, err := s.GetLastCommentTime(2); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("got %v; expected ErrNoRecord for a user without comments", err)
+	}
+}
@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"forum/models"
+)
+
+// DeletePost deletes a post for its author. With hard set and no comments
+// on the post it is removed for good, like a moderator deletes it.
+// Otherwise it is blanked and marked deleted, which keeps its comments and
+// their threads. removed tells which it was.
+func (s *Sqlite) DeletePost(postID int, hard bool) (bool, error) {
+	op := "sqlite.DeletePost"
+	return s.deleteOwn(op, models.ModerationTarget{Kind: models.TargetPost, ID: postID}, hard,
+		`SELECT EXISTS(SELECT 1 FROM comments WHERE post_id = ?)`,
+		[]string{
+			`DELETE FROM post_revisions WHERE post_id = ?2`,
+			`UPDATE posts SET deleted = TRUE, title = ?1, content = ?1 WHERE id = ?2`,
+		})
+}
+
+// DeleteComment is DeletePost for a comment, which is kept when anyone
+// replied to it.
+func (s *Sqlite) DeleteComment(commentID int, hard bool) (bool, error) {
+	op := "sqlite.DeleteComment"
+	return s.deleteOwn(op, models.ModerationTarget{Kind: models.TargetComment, ID: commentID}, hard,
+		`SELECT EXISTS(SELECT 1 FROM comments WHERE quoted_comment_id = ?)`,
+		[]string{
+			`UPDATE posts SET accepted_answer_comment_id = NULL WHERE accepted_answer_comment_id = ?2`,
+			`UPDATE comments SET quote_excerpt = '' WHERE quoted_comment_id = ?2`,
+			`UPDATE comments SET deleted = TRUE, content = ?1, quote_excerpt = '' WHERE id = ?2`,
+		})
+}
+
+// deleteOwn removes target when hard is set and the replies query finds
+// none, and otherwise runs the soft queries, whose ?1 is DeletedContent and
+// ?2 the id of target. The last of them updates target itself.
+func (s *Sqlite) deleteOwn(op string, target models.ModerationTarget, hard bool, replies string, soft []string) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if hard {
+		var replied bool
+		if err := tx.QueryRow(replies, target.ID).Scan(&replied); err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		hard = !replied
+	}
+	if hard {
+		err = moderate(tx, models.ModerationDelete, "", target)
+	} else {
+		var result sql.Result
+		for _, query := range soft {
+			if result, err = tx.Exec(query, models.DeletedContent, target.ID); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			var affected int64
+			if affected, err = result.RowsAffected(); err == nil && affected == 0 {
+				err = models.ErrNoRecord
+			}
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return hard, nil
+}
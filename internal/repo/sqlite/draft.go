@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"forum/models"
+)
+
+func (s *Sqlite) GetDraft(userID int, session string) (*models.Draft, error) {
+	op := "sqlite.GetDraft"
+	stmt := `SELECT id, user_id, session, title, content, updated FROM drafts WHERE user_id = ? AND session = ?`
+	var d models.Draft
+	err := s.db.QueryRow(stmt, userID, session).Scan(&d.ID, &d.UserID, &d.Session, &d.Title, &d.Content, &d.Updated)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &d, nil
+}
+
+// SaveDraft stores d over the user's draft of the same session, or as a new
+// one, and sets its ID.
+func (s *Sqlite) SaveDraft(d *models.Draft) error {
+	op := "sqlite.SaveDraft"
+	stmt := `INSERT INTO drafts (user_id, session, title, content, updated) VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (user_id, session) DO UPDATE SET title = excluded.title, content = excluded.content, updated = excluded.updated
+	RETURNING id`
+	if err := s.db.QueryRow(stmt, d.UserID, d.Session, d.Title, d.Content, d.Updated).Scan(&d.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"forum/models"
+	"testing"
+	"time"
+)
+
+func TestSaveDraft(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+
+	save := func(userID int, session, content string) *models.Draft {
+		t.Helper()
+		d := &models.Draft{UserID: userID, Session: session, Content: content, Updated: time.Now().UTC()}
+		if err := s.SaveDraft(d); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	first := save(1, "tab-1", "one")
+	again := save(1, "tab-1", "two")
+	if again.ID != first.ID {
+		t.Fatalf("got draft %d; expected %d", again.ID, first.ID)
+	}
+	got, err := s.GetDraft(1, "tab-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != "two" {
+		t.Errorf("got content %q; expected %q", got.Content, "two")
+	}
+	if !got.Updated.Equal(again.Updated) {
+		t.Errorf("got updated %v; expected %v", got.Updated, again.Updated)
+	}
+
+	// Drafts are per user and editor session.
+	if d := save(1, "tab-2", "x"); d.ID == first.ID {
+		t.Errorf("tab-2 reused draft %d", first.ID)
+	}
+	if d := save(2, "tab-1", "x"); d.ID == first.ID {
+		t.Errorf("bob reused draft %d", first.ID)
+	}
+
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM drafts`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("got %d drafts; expected 3", count)
+	}
+
+	if _, err := s.GetDraft(2, "tab-2"); err != models.ErrNoRecord {
+		t.Errorf("got error %v; expected %v", err, models.ErrNoRecord)
+	}
+}
@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"fmt"
+	"forum/models"
+)
+
+func (s *Sqlite) Follow(f models.Follow) error {
+	op := "sqlite.Follow"
+	stmt := `INSERT OR IGNORE INTO follows (follower_id, followee_id, created) VALUES (?, ?, CURRENT_TIMESTAMP)`
+	if _, err := s.db.Exec(stmt, f.FollowerID, f.FolloweeID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) Unfollow(f models.Follow) error {
+	op := "sqlite.Unfollow"
+	stmt := `DELETE FROM follows WHERE follower_id = ? AND followee_id = ?`
+	if _, err := s.db.Exec(stmt, f.FollowerID, f.FolloweeID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) IsFollowing(followerID, followeeID int) (bool, error) {
+	op := "sqlite.IsFollowing"
+	stmt := `SELECT EXISTS(SELECT 1 FROM follows WHERE follower_id = ? AND followee_id = ?)`
+	var ok bool
+	if err := s.db.QueryRow(stmt, followerID, followeeID).Scan(&ok); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
+
+// GetFollowingPostsPaginated returns the posts of the users that userID
+// follows, newest first.
+func (s *Sqlite) GetFollowingPostsPaginated(userID, page, pageSize int) (*[]models.Post, error) {
+	op := "sqlite.GetFollowingPostsPaginated"
+	offset := (page - 1) * pageSize
+	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved)
+	FROM posts p
+	JOIN users u ON p.user_id = u.id
+	JOIN follows f ON f.followee_id = p.user_id
+	WHERE f.follower_id = ? AND p.approved
+	ORDER BY p.created DESC, p.id DESC
+	LIMIT ? OFFSET ?`
+
+	rows, err := s.db.Query(stmt, userID, pageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		posts = append(posts, post)
+	}
+	return &posts, nil
+}
+
+func (s *Sqlite) GetPageNumberFollowing(pageSize int, userID int) (int, error) {
+	var total int
+	op := "sqlite.GetPageNumberFollowing"
+	stmt := `SELECT COUNT(*)
+	FROM posts p
+	JOIN follows f ON f.followee_id = p.user_id
+	WHERE f.follower_id = ? AND p.approved`
+
+	if err := s.db.QueryRow(stmt, userID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return (total + pageSize - 1) / pageSize, nil
+}
@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"forum/models"
+	"testing"
+)
+
+func TestGetFollowingPostsPaginated(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES
+		(1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', ''), (3, 'carol', 'carol@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+		(1, 2, 'bob old', 'c', 'Nan', '2024-01-01 10:00:00'),
+		(2, 3, 'carol', 'c', 'Nan', '2024-01-02 10:00:00'),
+		(3, 2, 'bob new', 'c', 'Nan', '2024-01-03 10:00:00'),
+		(4, 1, 'alice', 'c', 'Nan', '2024-01-04 10:00:00')`)
+
+	if err := s.Follow(models.Follow{FollowerID: 1, FolloweeID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Follow(models.Follow{FollowerID: 1, FolloweeID: 2}); err != nil {
+		t.Fatalf("following twice: %v", err)
+	}
+
+	ok, err := s.IsFollowing(1, 2)
+	if err != nil || !ok {
+		t.Fatalf("got %v, %v; expected alice to follow bob", ok, err)
+	}
+
+	posts, err := s.GetFollowingPostsPaginated(1, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{3, 1}
+	if len(*posts) != len(want) {
+		t.Fatalf("got %d posts; expected %d", len(*posts), len(want))
+	}
+	for i, post := range *posts {
+		if post.PostID != want[i] {
+			t.Errorf("post %d: got %d; expected %d", i, post.PostID, want[i])
+		}
+	}
+
+	pages, err := s.GetPageNumberFollowing(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 2 {
+		t.Errorf("got %d pages; expected 2", pages)
+	}
+
+	if err := s.Unfollow(models.Follow{FollowerID: 1, FolloweeID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	posts, err = s.GetFollowingPostsPaginated(1, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*posts) != 0 {
+		t.Errorf("got %d posts after unfollowing; expected 0", len(*posts))
+	}
+}
@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"forum/models"
+	"strconv"
+	"strings"
+)
+
+// GuestAccountID returns the id of the account guests post under, creating
+// it the first time. It has no password, so nobody can log in as it. It is
+// told apart by is_guest, not by its name: a user who signed up before the
+// name was reserved may hold it, and then the account gets a numbered one.
+func (s *Sqlite) GuestAccountID() (int, error) {
+	op := "sqlite.GuestAccountID"
+	stmt := `INSERT INTO users (name, email, hashed_password, is_guest)
+	SELECT CASE WHEN EXISTS (SELECT 1 FROM users WHERE name = ?1)
+		THEN ?1 || '#' || (SELECT MAX(id) + 1 FROM users) ELSE ?1 END, ?2, '', TRUE
+	WHERE NOT EXISTS (SELECT 1 FROM users WHERE is_guest)`
+	if _, err := s.db.Exec(stmt, models.GuestAccount, models.GuestAccount+"@localhost"); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	var id int
+	if err := s.db.QueryRow(`SELECT id FROM users WHERE is_guest`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+// CreateGuestPost stores p as a pending post of userID, the guest account,
+// which moderate approves. It sets the ID and Created of p.
+func (s *Sqlite) CreateGuestPost(userID int, p *models.GuestPost) error {
+	op := "sqlite.CreateGuestPost"
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := insertGuestPost(tx, userID, p); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// insertGuestPost inserts p with its categories, keeping its Created unless
+// it is zero.
+func insertGuestPost(tx *sql.Tx, userID int, p *models.GuestPost) error {
+	var created any
+	if !p.Created.IsZero() {
+		created = p.Created.UTC().Format(timestampLayout)
+	}
+	stmt := `INSERT INTO posts (user_id, title, content, image_name, guest_name, guest_ip, approved, created)
+	VALUES (?, ?, ?, 'Nan', ?, ?, FALSE, COALESCE(?, CURRENT_TIMESTAMP)) RETURNING id, created`
+	if err := tx.QueryRow(stmt, userID, p.Title, p.Content, p.Name, p.IP, created).Scan(&p.ID, &p.Created); err != nil {
+		return err
+	}
+	for _, categoryID := range p.Categories {
+		if _, err := tx.Exec(`INSERT INTO post_category (post_id, category_id) VALUES (?, ?)`, p.ID, categoryID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetGuestPosts returns the pending posts, oldest first. Only guests' posts
+// wait for approval.
+func (s *Sqlite) GetGuestPosts() (*[]models.GuestPost, error) {
+	op := "sqlite.GetGuestPosts"
+	stmt := `SELECT p.id, p.guest_name, p.guest_ip, p.title, p.content, COALESCE(GROUP_CONCAT(pc.category_id), ''), p.created
+	FROM posts p
+	LEFT JOIN post_category pc ON pc.post_id = p.id
+	WHERE NOT p.approved
+	GROUP BY p.id
+	ORDER BY p.id`
+	rows, err := s.db.Query(stmt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	posts := []models.GuestPost{}
+	for rows.Next() {
+		p, err := scanGuestPost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		posts = append(posts, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &posts, nil
+}
+
+// moveGuestPosts turns the posts still queued in guest_posts, where guest
+// posts waited before they were stored as pending posts, into pending posts
+// and drops the table.
+func (s *Sqlite) moveGuestPosts() error {
+	op := "sqlite.moveGuestPosts"
+	var found bool
+	stmt := `SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'guest_posts')`
+	if err := s.db.QueryRow(stmt).Scan(&found); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !found {
+		return nil
+	}
+
+	rows, err := s.db.Query(`SELECT id, guest_name, guest_ip, title, content, categories, created FROM guest_posts ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	var queued []*models.GuestPost
+	for rows.Next() {
+		p, err := scanGuestPost(rows)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		queued = append(queued, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var guestID int
+	if len(queued) > 0 {
+		if guestID, err = s.GuestAccountID(); err != nil {
+			return err
+		}
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	for _, p := range queued {
+		if err := insertGuestPost(tx, guestID, p); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if _, err := tx.Exec(`DROP TABLE guest_posts`); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func scanGuestPost(row interface{ Scan(...any) error }) (*models.GuestPost, error) {
+	var p models.GuestPost
+	var categories string
+	if err := row.Scan(&p.ID, &p.Name, &p.IP, &p.Title, &p.Content, &categories, &p.Created); err != nil {
+		return nil, err
+	}
+	for _, field := range strings.Split(categories, ",") {
+		if id, err := strconv.Atoi(field); err == nil {
+			p.Categories = append(p.Categories, id)
+		}
+	}
+	return &p, nil
+}
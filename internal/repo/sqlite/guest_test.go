@@ -0,0 +1,123 @@
+package sqlite
+
+import (
+	"forum/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGuestPosts(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Go'), (2, 'Rust')`)
+	// Someone took the name before it was reserved.
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'guest', 'guest@gmail.com', 'hash')`)
+	guestID, err := s.GuestAccountID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := s.GuestAccountID()
+	if err != nil || again != guestID {
+		t.Fatalf("got guest account %d, %v; want %d", again, err, guestID)
+	}
+	if guestID == 1 {
+		t.Fatal("got the user named guest as the guest account")
+	}
+
+	queued := &models.GuestPost{Name: "Visitor", IP: "10.0.0.1", Title: "hello", Content: "from a guest", Categories: []int{1, 2}}
+	if err := s.CreateGuestPost(guestID, queued); err != nil {
+		t.Fatal(err)
+	}
+	posts, err := s.GetGuestPosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*posts) != 1 || (*posts)[0].ID != queued.ID || (*posts)[0].Name != "Visitor" || len((*posts)[0].Categories) != 2 {
+		t.Fatalf("got queue %+v", *posts)
+	}
+	listed, err := s.GetAllPostPaginated(1, 10, models.SortNewest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*listed) != 0 {
+		t.Errorf("got %d posts listed; want the guest post held back", len(*listed))
+	}
+
+	results, err := s.BulkModerate(models.ModerationApprove, "", []models.ModerationTarget{{Kind: models.TargetPost, ID: queued.ID}})
+	if err != nil || !results[0].OK {
+		t.Fatalf("got %+v, %v approving", results, err)
+	}
+	postID := queued.ID
+	post, err := s.GetPostByID(postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Pending || post.UserID != guestID || post.AuthorName() != "Visitor" {
+		t.Errorf("got pending %v, author %d shown as %q", post.Pending, post.UserID, post.AuthorName())
+	}
+	categories, err := s.GetCategoriesByPostID(postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != 2 {
+		t.Errorf("got categories %v; want 2", categories)
+	}
+	if posts, err = s.GetGuestPosts(); err != nil || len(*posts) != 0 {
+		t.Errorf("got queue %+v, %v after approval; want it empty", posts, err)
+	}
+
+	// Guest comments are shown under the name given too.
+	if _, err := s.CommentPost(models.CommentForm{PostID: postID, UserID: guestID, Content: "me again", Pending: true, GuestName: "Visitor", GuestIP: "10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := s.GetPendingComments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*pending) != 1 || (*pending)[0].AuthorName() != "Visitor" {
+		t.Errorf("got pending comments %+v", *pending)
+	}
+}
+
+func TestMoveGuestPosts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	s, err := NewDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Guest posts used to wait in a table of their own.
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Go')`)
+	exec(t, s, `CREATE TABLE guest_posts (
+		id INTEGER PRIMARY KEY,
+		guest_name TEXT NOT NULL,
+		guest_ip TEXT NOT NULL,
+		title TEXT NOT NULL,
+		content TEXT NOT NULL,
+		categories TEXT NOT NULL DEFAULT '',
+		created TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	exec(t, s, `INSERT INTO guest_posts (guest_name, guest_ip, title, content, categories, created)
+		VALUES ('Visitor', '10.0.0.1', 'hello', 'from a guest', '1', '2024-01-02 10:00:00')`)
+
+	s.db.Close()
+	if s, err = NewDB(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.db.Close() })
+	posts, err := s.GetGuestPosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	if len(*posts) != 1 || (*posts)[0].Name != "Visitor" || len((*posts)[0].Categories) != 1 || !(*posts)[0].Created.Equal(sent) {
+		t.Fatalf("got queue %+v; want the post Visitor sent at %v", *posts, sent)
+	}
+	var found bool
+	if err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE name = 'guest_posts')`).Scan(&found); err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Error("got guest_posts kept; want it dropped")
+	}
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"fmt"
+	"forum/models"
+)
+
+// RecordView puts postID at the top of the user's history and drops all but
+// the keep most recent views. Replacing the row gives it a new id, so a post
+// viewed again moves up instead of showing twice.
+func (s *Sqlite) RecordView(userID, postID, keep int) error {
+	op := "sqlite.RecordView"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt := `INSERT OR REPLACE INTO post_views (user_id, post_id) VALUES (?, ?)`
+	if _, err = tx.Exec(stmt, userID, postID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt = `DELETE FROM post_views WHERE user_id = ? AND id NOT IN (
+		SELECT id FROM post_views WHERE user_id = ? ORDER BY id DESC LIMIT ?
+	)`
+	if _, err = tx.Exec(stmt, userID, userID, keep); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tx.Commit()
+}
+
+// GetHistory returns the posts the user viewed, most recent first.
+func (s *Sqlite) GetHistory(userID int) (*[]models.Post, error) {
+	op := "sqlite.GetHistory"
+	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved)
+	FROM post_views v
+	JOIN posts p ON v.post_id = p.id
+	JOIN users u ON p.user_id = u.id
+	WHERE v.user_id = ?
+	ORDER BY v.id DESC`
+
+	rows, err := s.db.Query(stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		posts = append(posts, post)
+	}
+	return &posts, nil
+}
@@ -0,0 +1,55 @@
+package sqlite
+
+import "testing"
+
+func TestRecordView(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES
+		(1, 1, 'one', 'c', 'Nan'), (2, 1, 'two', 'c', 'Nan'), (3, 1, 'three', 'c', 'Nan'), (4, 1, 'four', 'c', 'Nan')`)
+
+	view := func(userID int, postIDs ...int) {
+		t.Helper()
+		for _, id := range postIDs {
+			if err := s.RecordView(userID, id, 3); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	check := func(userID int, want ...int) {
+		t.Helper()
+		posts, err := s.GetHistory(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		for _, post := range *posts {
+			got = append(got, post.PostID)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got history %v; expected %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("got history %v; expected %v", got, want)
+			}
+		}
+	}
+
+	view(1, 1, 2, 3)
+	check(1, 3, 2, 1)
+
+	// Viewing a post again moves it to the top.
+	view(1, 1)
+	check(1, 1, 3, 2)
+
+	// Only the 3 most recent views are kept.
+	view(1, 4)
+	check(1, 4, 1, 3)
+
+	// Histories are per user.
+	view(2, 2)
+	check(2, 2)
+	check(1, 4, 1, 3)
+}
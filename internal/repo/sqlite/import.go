@@ -0,0 +1,316 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"forum/models"
+	"time"
+)
+
+// backupAnswerKind maps an imported post's old id to the old id of its
+// accepted answer, which is only imported after the post.
+const backupAnswerKind = "accepted_answer"
+
+// ImportBackup imports a batch of backup records in one transaction. The new
+// id of every record is kept in backup_imports under source, the backup it
+// came from, which maps later references and makes an interrupted import
+// resumable: records imported by an earlier run are skipped. So are users and
+// categories with a name that is already taken, and posts and comments that
+// already exist; they are mapped to what is there instead.
+func (s *Sqlite) ImportBackup(source string, records []models.BackupRecord) (*models.ImportSummary, error) {
+	op := "sqlite.ImportBackup"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	summary := models.NewImportSummary()
+	for _, record := range records {
+		imported, err := importRecord(tx, source, record)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %s: %w", op, record.Type, err)
+		}
+		if imported {
+			summary.Imported[record.Type]++
+		} else {
+			summary.Skipped[record.Type]++
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return summary, nil
+}
+
+// FinishImport sets the accepted answers of the posts imported from source,
+// recounts the likes and dislikes of all posts and comments, and fills in
+// the last activity of the posts imported with comments.
+func (s *Sqlite) FinishImport(source string) error {
+	op := "sqlite.FinishImport"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	queries := []struct {
+		stmt string
+		args []any
+	}{
+		{`UPDATE posts SET accepted_answer_comment_id = (
+			SELECT c.new_id FROM backup_imports a
+			JOIN backup_imports p ON p.source = a.source AND p.kind = ? AND p.old_id = a.old_id
+			JOIN backup_imports c ON c.source = a.source AND c.kind = ? AND c.old_id = a.new_id
+			WHERE a.source = ? AND a.kind = ? AND p.new_id = posts.id
+		) WHERE accepted_answer_comment_id IS NULL`, []any{models.BackupPostType, models.BackupCommentType, source, backupAnswerKind}},
+		{`UPDATE posts SET
+			like = (SELECT COUNT(*) FROM post_user_Like r WHERE r.post_id = posts.id AND r.is_like = TRUE),
+			dislike = (SELECT COUNT(*) FROM post_user_Like r WHERE r.post_id = posts.id AND r.is_like = FALSE)`, nil},
+		{`UPDATE comments SET
+			like = (SELECT COUNT(*) FROM comment_user_Like r WHERE r.comment_id = comments.id AND r.is_like = TRUE),
+			dislike = (SELECT COUNT(*) FROM comment_user_Like r WHERE r.comment_id = comments.id AND r.is_like = FALSE)`, nil},
+		{backfillLastActivity, nil},
+	}
+	for _, q := range queries {
+		if _, err = tx.Exec(q.stmt, q.args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// importRecord imports one record and reports whether it was created.
+func importRecord(tx *sql.Tx, source string, record models.BackupRecord) (bool, error) {
+	switch data := record.Data.(type) {
+	case models.BackupUser:
+		return importUser(tx, source, data)
+	case models.BackupCategory:
+		return importCategory(tx, source, data)
+	case models.BackupPost:
+		return importPost(tx, source, data)
+	case models.BackupComment:
+		return importComment(tx, source, data)
+	case models.BackupReaction:
+		return importReaction(tx, source, record.Type, data)
+	}
+	return false, models.ErrInvalidBackup
+}
+
+func importUser(tx *sql.Tx, source string, u models.BackupUser) (bool, error) {
+	if _, ok, err := importedID(tx, source, models.BackupUserType, u.ID); err != nil || ok {
+		return false, err
+	}
+	// Guests post under the guest account here, whatever it is called.
+	lookup, args := `SELECT id FROM users WHERE name = ? OR email = ? LIMIT 1`, []any{u.Name, u.Email}
+	if u.Guest {
+		lookup, args = `SELECT id FROM users WHERE is_guest`, nil
+	}
+	var id int
+	err := tx.QueryRow(lookup, args...).Scan(&id)
+	if err == nil {
+		return false, mapImported(tx, source, models.BackupUserType, u.ID, int64(id))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	// Without the password hash, imported users log in after a reset.
+	stmt := `INSERT INTO users (name, display_name, email, hashed_password, created, status, privacy, is_guest) VALUES (?, ?, ?, '', ?, ?, ?, ?)`
+	result, err := tx.Exec(stmt, u.Name, u.DisplayName, u.Email, backupTime(u.Created), u.Status, u.Privacy, u.Guest)
+	if err != nil {
+		return false, err
+	}
+	return insertedAndMapped(tx, source, models.BackupUserType, u.ID, result)
+}
+
+func importCategory(tx *sql.Tx, source string, c models.BackupCategory) (bool, error) {
+	if _, ok, err := importedID(tx, source, models.BackupCategoryType, c.ID); err != nil || ok {
+		return false, err
+	}
+	var id int
+	err := tx.QueryRow(`SELECT id FROM category WHERE name = ? LIMIT 1`, c.Name).Scan(&id)
+	if err == nil {
+		return false, mapImported(tx, source, models.BackupCategoryType, c.ID, int64(id))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	result, err := tx.Exec(`INSERT INTO category (name) VALUES (?)`, c.Name)
+	if err != nil {
+		return false, err
+	}
+	return insertedAndMapped(tx, source, models.BackupCategoryType, c.ID, result)
+}
+
+func importPost(tx *sql.Tx, source string, p models.BackupPost) (bool, error) {
+	if _, ok, err := importedID(tx, source, models.BackupPostType, p.ID); err != nil || ok {
+		return false, err
+	}
+	userID, err := requireImported(tx, source, models.BackupUserType, p.UserID)
+	if err != nil {
+		return false, err
+	}
+	if p.AcceptedAnswerID != 0 {
+		stmt := `INSERT OR IGNORE INTO backup_imports (source, kind, old_id, new_id) VALUES (?, ?, ?, ?)`
+		if _, err := tx.Exec(stmt, source, backupAnswerKind, p.ID, p.AcceptedAnswerID); err != nil {
+			return false, err
+		}
+	}
+
+	var id int
+	stmt := `SELECT id FROM posts WHERE user_id = ? AND title = ? AND created = ? LIMIT 1`
+	err = tx.QueryRow(stmt, userID, p.Title, backupTime(p.Created)).Scan(&id)
+	if err == nil {
+		return false, mapImported(tx, source, models.BackupPostType, p.ID, int64(id))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	stmt = `INSERT INTO posts (user_id, title, content, image_name, created, locked, lock_reason, approved, profile_pinned, guest_name) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.Exec(stmt, userID, p.Title, p.Content, p.ImageName, backupTime(p.Created), p.Locked, p.LockReason, p.Approved, p.ProfilePinned, p.GuestName)
+	if err != nil {
+		return false, err
+	}
+	postID, err := result.LastInsertId()
+	if err != nil {
+		return false, err
+	}
+	for _, oldID := range p.Categories {
+		categoryID, err := requireImported(tx, source, models.BackupCategoryType, oldID)
+		if err != nil {
+			return false, err
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO post_category (category_id, post_id) VALUES (?, ?)`, categoryID, postID); err != nil {
+			return false, err
+		}
+	}
+	return true, mapImported(tx, source, models.BackupPostType, p.ID, postID)
+}
+
+func importComment(tx *sql.Tx, source string, c models.BackupComment) (bool, error) {
+	if _, ok, err := importedID(tx, source, models.BackupCommentType, c.ID); err != nil || ok {
+		return false, err
+	}
+	postID, err := requireImported(tx, source, models.BackupPostType, c.PostID)
+	if err != nil {
+		return false, err
+	}
+	userID, err := requireImported(tx, source, models.BackupUserType, c.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	var id int
+	stmt := `SELECT id FROM comments WHERE post_id = ? AND user_id = ? AND created = ? AND content = ? LIMIT 1`
+	err = tx.QueryRow(stmt, postID, userID, backupTime(c.Created), c.Content).Scan(&id)
+	if err == nil {
+		return false, mapImported(tx, source, models.BackupCommentType, c.ID, int64(id))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	// A quote of a comment that wasn't backed up becomes a plain comment.
+	var quotedCommentID sql.NullInt64
+	if c.QuotedCommentID != 0 {
+		id, ok, err := importedID(tx, source, models.BackupCommentType, c.QuotedCommentID)
+		if err != nil {
+			return false, err
+		}
+		quotedCommentID = sql.NullInt64{Int64: int64(id), Valid: ok}
+	}
+
+	stmt = `INSERT INTO comments (post_id, user_id, content, created, quoted_comment_id, quote_excerpt, approved, guest_name) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.Exec(stmt, postID, userID, c.Content, backupTime(c.Created), quotedCommentID, c.QuoteExcerpt, c.Approved, c.GuestName)
+	if err != nil {
+		return false, err
+	}
+	return insertedAndMapped(tx, source, models.BackupCommentType, c.ID, result)
+}
+
+// importReaction needs no mapping of its own: a reaction is identified by its
+// user and target, so one that is already there is simply ignored.
+func importReaction(tx *sql.Tx, source, kind string, r models.BackupReaction) (bool, error) {
+	userID, err := requireImported(tx, source, models.BackupUserType, r.UserID)
+	if err != nil {
+		return false, err
+	}
+	var created any
+	if r.Created != nil {
+		created = backupTime(*r.Created)
+	}
+
+	var stmt string
+	var targetID int
+	switch kind {
+	case models.BackupPostReactionType:
+		targetID, err = requireImported(tx, source, models.BackupPostType, r.TargetID)
+		stmt = `INSERT OR IGNORE INTO post_user_Like (user_id, post_id, is_like, created) VALUES (?, ?, ?, ?)`
+	case models.BackupCommentReactionType:
+		targetID, err = requireImported(tx, source, models.BackupCommentType, r.TargetID)
+		stmt = `INSERT OR IGNORE INTO comment_user_Like (user_id, comment_id, is_like, created) VALUES (?, ?, ?, ?)`
+	default:
+		return false, models.ErrInvalidBackup
+	}
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.Exec(stmt, userID, targetID, r.Like, created)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+func importedID(tx *sql.Tx, source, kind string, oldID int) (int, bool, error) {
+	var id int
+	err := tx.QueryRow(`SELECT new_id FROM backup_imports WHERE source = ? AND kind = ? AND old_id = ?`, source, kind, oldID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return id, err == nil, err
+}
+
+// requireImported maps a reference that the backup must have brought along.
+func requireImported(tx *sql.Tx, source, kind string, oldID int) (int, error) {
+	id, ok, err := importedID(tx, source, kind, oldID)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown %s %d", models.ErrInvalidBackup, kind, oldID)
+	}
+	return id, nil
+}
+
+func mapImported(tx *sql.Tx, source, kind string, oldID int, newID int64) error {
+	_, err := tx.Exec(`INSERT INTO backup_imports (source, kind, old_id, new_id) VALUES (?, ?, ?, ?)`, source, kind, oldID, newID)
+	return err
+}
+
+func insertedAndMapped(tx *sql.Tx, source, kind string, oldID int, result sql.Result) (bool, error) {
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return false, err
+	}
+	return true, mapImported(tx, source, kind, oldID, newID)
+}
+
+// backupTime stores imported times the way CURRENT_TIMESTAMP does, so they
+// sort and compare with the rest.
+func backupTime(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
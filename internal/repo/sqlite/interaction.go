@@ -3,6 +3,7 @@ package sqlite
 import (
 	"fmt"
 	"forum/models"
+	"time"
 )
 
 func (s *Sqlite) GetReactionPost(userID, postID int) (bool, bool, error) {
@@ -25,63 +26,106 @@ func (s *Sqlite) GetReactionPost(userID, postID int) (bool, bool, error) {
 	return isExists, dbLike, nil
 }
 
-func (s *Sqlite) AddReactionPost(form models.ReactionForm) error {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
-	}
+// votes names the table of the likes and dislikes of posts or comments,
+// its column of the liked thing and the table counting them.
+type votes struct {
+	table, column, counts string
+}
 
-	// Insert like/dislike
-	insertQuery := `INSERT INTO Post_User_Like (user_id, post_id, is_like) VALUES (?, ?, ?)`
-	_, err = tx.Exec(insertQuery, form.UserID, form.ID, form.Reaction)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
+var (
+	postVotes    = votes{table: "Post_User_Like", column: "post_id", counts: "Posts"}
+	commentVotes = votes{table: "Comment_User_Like", column: "comment_id", counts: "Comments"}
+)
 
-	// Update Post like/dislike count
-	updateQuery := ""
-	if form.Reaction {
-		updateQuery = `UPDATE Posts SET like = like + 1 WHERE id = ?`
-	} else {
-		updateQuery = `UPDATE Posts SET dislike = dislike + 1 WHERE id = ?`
-	}
-	_, err = tx.Exec(updateQuery, form.ID)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-	return tx.Commit()
+// TogglePostReaction likes or dislikes a post for form.UserID, or takes the
+// vote back when it is the one they had. See toggleReaction.
+func (s *Sqlite) TogglePostReaction(form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error) {
+	return s.toggleReaction(postVotes, form, dedup)
 }
 
-func (s *Sqlite) DeleteReactionPost(form models.ReactionForm, isLike bool) error {
+// ToggleCommentReaction is TogglePostReaction for a comment.
+func (s *Sqlite) ToggleCommentReaction(form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error) {
+	return s.toggleReaction(commentVotes, form, dedup)
+}
+
+// toggleReaction applies a like or dislike in one transaction and returns
+// the state it left. A vote repeated less than dedup after it was made is
+// taken for a double click and changes nothing. The insert goes first so
+// the transaction holds the write lock before it reads anything, which
+// makes concurrent toggles of the same vote run one after the other.
+func (s *Sqlite) toggleReaction(v votes, form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error) {
+	op := "sqlite.toggleReaction"
 	tx, err := s.db.Begin()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer tx.Rollback()
 
-	// delete the like/dislike
-	deleteQuery := `DELETE FROM Post_User_Like WHERE user_id = ? AND post_id = ?`
-	_, err = tx.Exec(deleteQuery, form.UserID, form.ID)
+	res, err := tx.Exec(`INSERT INTO `+v.table+` (user_id, `+v.column+`, is_like, created) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+	ON CONFLICT (user_id, `+v.column+`) DO NOTHING`, form.UserID, form.ID, form.Reaction)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	inserted, err := res.RowsAffected()
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// decrement the like or dislike
-	updateQuery := ""
-	if isLike {
-		updateQuery = `UPDATE Posts SET like = like - 1 WHERE id = ? AND like > 0`
+	// like and dislike are how the counts change.
+	var like, dislike int
+	vote := func(isLike bool, n int) {
+		if isLike {
+			like += n
+		} else {
+			dislike += n
+		}
+	}
+	if inserted == 1 {
+		vote(form.Reaction, 1)
 	} else {
-		updateQuery = `UPDATE Posts SET dislike = dislike - 1  WHERE id = ? AND dislike > 0`
+		var isLike, recent bool
+		err = tx.QueryRow(`SELECT is_like, COALESCE(datetime(created) >= datetime(?), FALSE) FROM `+v.table+`
+		WHERE user_id = ? AND `+v.column+` = ?`, time.Now().Add(-dedup).UTC(), form.UserID, form.ID).Scan(&isLike, &recent)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		switch {
+		case isLike == form.Reaction && recent && dedup > 0:
+		case isLike == form.Reaction:
+			res, err = tx.Exec(`DELETE FROM `+v.table+` WHERE user_id = ? AND `+v.column+` = ?`, form.UserID, form.ID)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			if n, err := res.RowsAffected(); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			} else if n == 1 {
+				vote(isLike, -1)
+			}
+		default:
+			_, err = tx.Exec(`UPDATE `+v.table+` SET is_like = ?, created = CURRENT_TIMESTAMP WHERE user_id = ? AND `+v.column+` = ?`,
+				form.Reaction, form.UserID, form.ID)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			vote(isLike, -1)
+			vote(form.Reaction, 1)
+		}
 	}
-	_, err = tx.Exec(updateQuery, form.ID)
-	if err != nil {
-		tx.Rollback()
-		return err
+	if like != 0 || dislike != 0 {
+		_, err = tx.Exec(`UPDATE `+v.counts+` SET like = MAX(like + ?, 0), dislike = MAX(dislike + ?, 0) WHERE id = ?`, like, dislike, form.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
-	return tx.Commit()
+	state := &models.ReactionState{}
+	err = tx.QueryRow(`SELECT t.like, t.dislike, COALESCE((SELECT CASE WHEN v.is_like THEN 1 ELSE -1 END FROM `+v.table+` v
+		WHERE v.user_id = ? AND v.`+v.column+` = t.id), 0)
+	FROM `+v.counts+` t WHERE t.id = ?`, form.UserID, form.ID).Scan(&state.Like, &state.Dislike, &state.Mine)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return state, tx.Commit()
 }
 
 func (s *Sqlite) GetReactionComments(userID int, postID int) (map[int]bool, error) {
@@ -130,24 +174,3 @@ func (s *Sqlite) GetReactionPosts(userID int) (map[int]bool, error) {
 	}
 	return reactions, nil
 }
-
-
-func (s *Sqlite) CheckReactionComment(form models.ReactionForm) (bool, bool, error) {
-	// Check if the user has already liked/disliked the post
-	var isExists bool
-	checkQuery := `SELECT EXISTS(SELECT is_like FROM Comment_User_Like WHERE user_id = ? AND comment_id = ?)`
-	err := s.db.QueryRow(checkQuery, form.UserID, form.ID).Scan(&isExists)
-	if err != nil {
-		return false, false, err
-	}
-	var dbLike bool
-	if isExists {
-		checkQuery = `SELECT is_like FROM Comment_User_Like WHERE user_id = ? AND comment_id = ?`
-		err = s.db.QueryRow(checkQuery, form.UserID, form.ID).Scan(&dbLike)
-		if err != nil {
-			return false, false, err
-		}
-	}
-
-	return isExists, dbLike, nil
-}
\ No newline at end of file
@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"forum/models"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTogglePostReactionConcurrently(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'go', 'c', 'Nan')`)
+
+	// Bob double clicks like a few times over.
+	const clicks = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, clicks)
+	for range clicks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.TogglePostReaction(models.ReactionForm{UserID: 2, ID: 1, Reaction: true}, time.Minute)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var rows, like, dislike int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM post_user_Like WHERE post_id = 1`).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.QueryRow(`SELECT like, dislike FROM posts WHERE id = 1`).Scan(&like, &dislike); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 1 || like != 1 || dislike != 0 {
+		t.Errorf("got %d rows, %d likes and %d dislikes; expected 1, 1 and 0", rows, like, dislike)
+	}
+	scores, err := s.GetPostScores([]int{1}, models.DefaultVoteWeights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scores[1] != 1 {
+		t.Errorf("got score %d; expected 1", scores[1])
+	}
+}
+
+func TestToggleReaction(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'go', 'c', 'Nan')`)
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content) VALUES (1, 1, 1, 'first')`)
+
+	tests := []struct {
+		name     string
+		reaction bool
+		dedup    time.Duration
+		want     models.ReactionState
+	}{
+		{"like", true, 0, models.ReactionState{Like: 1, Mine: 1}},
+		{"double click", true, time.Minute, models.ReactionState{Like: 1, Mine: 1}},
+		{"like again", true, 0, models.ReactionState{}},
+		{"dislike", false, 0, models.ReactionState{Dislike: 1, Mine: -1}},
+		{"like instead", true, time.Minute, models.ReactionState{Like: 1, Mine: 1}},
+	}
+	for _, toggle := range []struct {
+		name string
+		fn   func(models.ReactionForm, time.Duration) (*models.ReactionState, error)
+	}{
+		{"post", s.TogglePostReaction},
+		{"comment", s.ToggleCommentReaction},
+	} {
+		for _, tt := range tests {
+			got, err := toggle.fn(models.ReactionForm{UserID: 2, ID: 1, Reaction: tt.reaction}, tt.dedup)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("%s %s: got %+v; expected %+v", toggle.name, tt.name, *got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"fmt"
+	"forum/models"
+)
+
+func (s *Sqlite) CreateInvite(invite *models.Invite) error {
+	op := "sqlite.CreateInvite"
+	stmt := `INSERT INTO invites (code, inviter_id, created) VALUES (?, ?, CURRENT_TIMESTAMP)`
+	_, err := s.db.Exec(stmt, invite.Code, invite.InviterID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) CountInvitesByUserID(userID int) (int, error) {
+	op := "sqlite.CountInvitesByUserID"
+	var count int
+	stmt := `SELECT COUNT(*) FROM invites WHERE inviter_id = ?`
+	if err := s.db.QueryRow(stmt, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+func (s *Sqlite) GetInvitesByUserID(userID int) (*[]models.Invite, error) {
+	op := "sqlite.GetInvitesByUserID"
+	stmt := `SELECT i.id, i.code, i.inviter_id, COALESCE(i.invitee_id, 0), COALESCE(u.name, ''), i.created
+	FROM invites i
+	LEFT JOIN users u ON i.invitee_id = u.id
+	WHERE i.inviter_id = ?
+	ORDER BY i.created DESC`
+
+	rows, err := s.db.Query(stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var invites []models.Invite
+	for rows.Next() {
+		var invite models.Invite
+		if err := rows.Scan(&invite.ID, &invite.Code, &invite.InviterID, &invite.InviteeID, &invite.InviteeName, &invite.Created); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		invites = append(invites, invite)
+	}
+	return &invites, nil
+}
+
+// CreateUserWithInvite creates the user and consumes the invite in one
+// transaction, so a code can never be used twice.
+func (s *Sqlite) CreateUserWithInvite(u models.User, code string) error {
+	op := "sqlite.CreateUserWithInvite"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt := `INSERT INTO users (name, display_name, email, email_key, hashed_password, created) VALUES(?, ?, ?, NULLIF(?, ''), ?, CURRENT_TIMESTAMP)`
+	result, err := tx.Exec(stmt, u.Name, u.DisplayName, u.Email, u.EmailKey, string(u.HashedPassword))
+	if err != nil {
+		tx.Rollback()
+		if err.Error() == "UNIQUE constraint failed: users.email" || err.Error() == "UNIQUE constraint failed: users.email_key" {
+			return models.ErrDuplicateEmail
+		}
+		if err.Error() == "UNIQUE constraint failed: users.name" {
+			return models.ErrDuplicateName
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	userID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt = `UPDATE invites SET invitee_id = ? WHERE code = ? AND invitee_id IS NULL`
+	result, err = tx.Exec(stmt, userID, code)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	consumed, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if consumed == 0 {
+		tx.Rollback()
+		return models.ErrInvalidInvite
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"fmt"
+	"forum/models"
+	"strings"
+)
+
+func (s *Sqlite) AddPostLabel(l models.PostLabel) error {
+	op := "sqlite.AddPostLabel"
+	stmt := `INSERT OR IGNORE INTO post_labels (post_id, label) VALUES (?, ?)`
+	if _, err := s.db.Exec(stmt, l.PostID, l.Label); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) RemovePostLabel(l models.PostLabel) error {
+	op := "sqlite.RemovePostLabel"
+	stmt := `DELETE FROM post_labels WHERE post_id = ? AND label = ?`
+	if _, err := s.db.Exec(stmt, l.PostID, l.Label); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// GetLabelsByPostIDs returns the labels of all the posts at once, keyed by
+// post ID and sorted by name. Posts without labels are left out.
+func (s *Sqlite) GetLabelsByPostIDs(postIDs []int) (map[int][]string, error) {
+	op := "sqlite.GetLabelsByPostIDs"
+	labels := make(map[int][]string)
+	if len(postIDs) == 0 {
+		return labels, nil
+	}
+
+	args := make([]any, len(postIDs))
+	for i, id := range postIDs {
+		args[i] = id
+	}
+	stmt := `SELECT post_id, label FROM post_labels
+	WHERE post_id IN (?` + strings.Repeat(", ?", len(postIDs)-1) + `)
+	ORDER BY label`
+
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int
+		var label string
+		if err := rows.Scan(&postID, &label); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		labels[postID] = append(labels[postID], label)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return labels, nil
+}
+
+func (s *Sqlite) GetAllPostByLabelPaginated(page, pageSize int, label, sort string) (*[]models.Post, error) {
+	op := "sqlite.GetAllPostByLabelPaginated"
+	offset := (page - 1) * pageSize
+	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved)
+	FROM posts p
+	JOIN users u ON p.user_id = u.id
+	JOIN post_labels pl ON pl.post_id = p.id
+	WHERE pl.label = ? AND p.approved
+	ORDER BY ` + orderBy(sort) + `
+	LIMIT ? OFFSET ?`
+
+	rows, err := s.db.Query(stmt, label, pageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &posts, nil
+}
+
+func (s *Sqlite) GetPageNumberLabel(pageSize int, label string) (int, error) {
+	op := "sqlite.GetPageNumberLabel"
+	var totalPosts int
+	stmt := `SELECT COUNT(*) FROM post_labels pl
+	JOIN posts p ON p.id = pl.post_id
+	WHERE pl.label = ? AND p.approved`
+	if err := s.db.QueryRow(stmt, label).Scan(&totalPosts); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return (totalPosts + pageSize - 1) / pageSize, nil
+}
@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"forum/models"
+	"slices"
+	"testing"
+)
+
+func TestPostLabels(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'user', 'user@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'a', 'a', 'Nan'), (2, 1, 'b', 'b', 'Nan'), (3, 1, 'c', 'c', 'Nan')`)
+
+	for _, l := range []models.PostLabel{
+		{PostID: 1, Label: "Resolved"},
+		{PostID: 1, Label: "Pinned"},
+		{PostID: 1, Label: "Pinned"},
+		{PostID: 2, Label: "Resolved"},
+	} {
+		if err := s.AddPostLabel(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	labels, err := s.GetLabelsByPostIDs([]int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(labels[1], []string{"Pinned", "Resolved"}) || !slices.Equal(labels[2], []string{"Resolved"}) || labels[3] != nil {
+		t.Fatalf("got labels %v", labels)
+	}
+
+	if err := s.RemovePostLabel(models.PostLabel{PostID: 1, Label: "Resolved"}); err != nil {
+		t.Fatal(err)
+	}
+	posts, err := s.GetAllPostByLabelPaginated(1, 10, "Resolved", models.SortNewest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*posts) != 1 || (*posts)[0].PostID != 2 {
+		t.Errorf("got posts %v; want only post 2", *posts)
+	}
+	pages, err := s.GetPageNumberLabel(10, "Resolved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 1 {
+		t.Errorf("got %d pages; want 1", pages)
+	}
+}
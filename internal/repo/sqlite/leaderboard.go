@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"fmt"
+	"forum/models"
+	"time"
+)
+
+// contributionQueries select the author and time of each contribution that
+// counts toward a leaderboard metric. Reactions from before reaction times
+// were recorded count as made when their target was, and nobody gets
+// points for reacting to themselves.
+var contributionQueries = map[string]string{
+	models.LeaderboardPosts: `SELECT p.user_id AS author, p.created AS created
+	FROM posts p
+	WHERE p.approved`,
+	models.LeaderboardComments: `SELECT c.user_id AS author, c.created AS created
+	FROM comments c
+	WHERE c.approved`,
+	models.LeaderboardReactions: `SELECT p.user_id AS author, COALESCE(l.created, p.created) AS created
+	FROM post_user_Like l
+	JOIN posts p ON p.id = l.post_id
+	WHERE l.is_like AND l.user_id != p.user_id
+	UNION ALL
+	SELECT c.user_id, COALESCE(l.created, c.created)
+	FROM comment_user_Like l
+	JOIN comments c ON c.id = l.comment_id
+	WHERE l.is_like AND l.user_id != c.user_id
+	UNION ALL
+	SELECT p.user_id, r.created
+	FROM post_reactions r
+	JOIN posts p ON p.id = r.post_id
+	WHERE r.user_id != p.user_id`,
+}
+
+// GetTopContributors ranks the users by their contributions of metric made
+// since, and returns the first limit of them. Ties go to the user who
+// signed up first.
+func (s *Sqlite) GetTopContributors(metric string, since time.Time, limit int) ([]models.Contributor, error) {
+	op := "sqlite.GetTopContributors"
+	contributions, ok := contributionQueries[metric]
+	if !ok {
+		return nil, models.ErrInvalidLeaderboard
+	}
+	stmt := `SELECT u.id, u.name, u.display_name, COUNT(*) AS score
+	FROM (` + contributions + `) x
+	JOIN users u ON u.id = x.author
+	WHERE datetime(x.created) >= ? AND NOT u.is_guest
+	GROUP BY u.id
+	ORDER BY score DESC, u.id ASC
+	LIMIT ?`
+
+	rows, err := s.db.Query(stmt, since.UTC().Format(timestampLayout), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var contributors []models.Contributor
+	for rows.Next() {
+		var c models.Contributor
+		if err := rows.Scan(&c.UserID, &c.Name, &c.DisplayName, &c.Score); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		c.Rank = len(contributors) + 1
+		contributors = append(contributors, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return contributors, nil
+}
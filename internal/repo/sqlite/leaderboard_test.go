@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"forum/models"
+	"testing"
+	"time"
+)
+
+func TestGetTopContributors(t *testing.T) {
+	s := newTestDB(t)
+
+	recent := time.Now().Add(-24 * time.Hour).UTC().Format(timestampLayout)
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password, display_name) VALUES
+		(1, 'alice', 'alice@gmail.com', '', 'Alice'), (2, 'bob', 'bob@gmail.com', '', ''),
+		(3, 'carol', 'carol@gmail.com', '', '')`)
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password, is_guest) VALUES (4, 'guest', 'guest@localhost', '', TRUE)`)
+	// Alice posted a lot long ago, bob a little lately. Guest posts and
+	// posts waiting for approval don't count.
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created, approved) VALUES
+		(1, 1, 'a', 'c', 'Nan', '2024-01-01 10:00:00', TRUE),
+		(2, 1, 'b', 'c', 'Nan', '2024-01-02 10:00:00', TRUE),
+		(3, 1, 'c', 'c', 'Nan', '2024-01-03 10:00:00', TRUE),
+		(4, 2, 'd', 'c', 'Nan', ?, TRUE),
+		(5, 2, 'e', 'c', 'Nan', ?, TRUE),
+		(6, 3, 'f', 'c', 'Nan', ?, FALSE),
+		(7, 4, 'g', 'c', 'Nan', ?, TRUE),
+		(8, 4, 'h', 'c', 'Nan', ?, TRUE),
+		(9, 4, 'i', 'c', 'Nan', ?, TRUE)`, recent, recent, recent, recent, recent, recent)
+	// Carol comments the most, bob lately.
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content, created, approved) VALUES
+		(1, 1, 3, 'x', '2024-01-05 10:00:00', TRUE),
+		(2, 1, 3, 'x', '2024-01-05 11:00:00', TRUE),
+		(3, 2, 3, 'x', '2024-01-05 12:00:00', TRUE),
+		(4, 4, 2, 'x', ?, TRUE),
+		(5, 4, 1, 'x', ?, FALSE)`, recent, recent)
+	// Bob's posts got likes and a heart lately, alice's a like long ago
+	// and one without a time. Self-likes and dislikes don't count.
+	exec(t, s, `INSERT INTO post_user_Like (user_id, post_id, is_like, created) VALUES
+		(1, 4, TRUE, ?), (3, 4, TRUE, ?), (2, 4, TRUE, ?),
+		(2, 1, TRUE, '2024-02-01 10:00:00'), (3, 2, TRUE, NULL), (3, 3, FALSE, '2024-02-01 10:00:00')`, recent, recent, recent)
+	exec(t, s, `INSERT INTO comment_user_Like (user_id, comment_id, is_like, created) VALUES (1, 1, TRUE, '2024-02-01 10:00:00')`)
+	exec(t, s, `INSERT INTO post_reactions (user_id, post_id, type, created) VALUES (1, 5, '❤️', ?)`, recent)
+
+	month := time.Now().Add(-30 * 24 * time.Hour)
+	tests := []struct {
+		name   string
+		metric string
+		since  time.Time
+		want   []models.Contributor
+	}{
+		{
+			name:   "Posts of all time",
+			metric: models.LeaderboardPosts,
+			want:   []models.Contributor{{Rank: 1, UserID: 1, Name: "alice", DisplayName: "Alice", Score: 3}, {Rank: 2, UserID: 2, Name: "bob", Score: 2}},
+		},
+		{
+			name:   "Posts of the last 30 days",
+			metric: models.LeaderboardPosts,
+			since:  month,
+			want:   []models.Contributor{{Rank: 1, UserID: 2, Name: "bob", Score: 2}},
+		},
+		{
+			name:   "Comments of all time",
+			metric: models.LeaderboardComments,
+			want:   []models.Contributor{{Rank: 1, UserID: 3, Name: "carol", Score: 3}, {Rank: 2, UserID: 2, Name: "bob", Score: 1}},
+		},
+		{
+			name:   "Comments of the last 30 days",
+			metric: models.LeaderboardComments,
+			since:  month,
+			want:   []models.Contributor{{Rank: 1, UserID: 2, Name: "bob", Score: 1}},
+		},
+		{
+			name:   "Reactions of all time",
+			metric: models.LeaderboardReactions,
+			want:   []models.Contributor{{Rank: 1, UserID: 2, Name: "bob", Score: 3}, {Rank: 2, UserID: 1, Name: "alice", DisplayName: "Alice", Score: 2}, {Rank: 3, UserID: 3, Name: "carol", Score: 1}},
+		},
+		{
+			name:   "Reactions of the last 30 days",
+			metric: models.LeaderboardReactions,
+			since:  month,
+			want:   []models.Contributor{{Rank: 1, UserID: 2, Name: "bob", Score: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetTopContributors(tt.metric, tt.since, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v; expected %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("rank %d: got %+v; expected %+v", i+1, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	got, err := s.GetTopContributors(models.LeaderboardPosts, time.Time{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "alice" {
+		t.Errorf("got %+v; expected alice alone", got)
+	}
+}
@@ -0,0 +1,121 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"forum/models"
+)
+
+var errNotSupported = errors.New("action not supported for this item")
+
+// BulkModerate applies action to every target inside a single transaction.
+// Each item runs in its own savepoint, so a failing item is rolled back and
+// reported without aborting the rest of the batch. reason is stored with
+// locks.
+func (s *Sqlite) BulkModerate(action, reason string, targets []models.ModerationTarget) ([]models.ModerationResult, error) {
+	op := "sqlite.BulkModerate"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	results := make([]models.ModerationResult, 0, len(targets))
+	for _, target := range targets {
+		result := models.ModerationResult{Kind: target.Kind, ID: target.ID, OK: true}
+
+		if _, err = tx.Exec(`SAVEPOINT moderation_item`); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		itemErr := moderate(tx, action, reason, target)
+		if itemErr != nil {
+			result.OK = false
+			if errors.Is(itemErr, models.ErrNoRecord) {
+				result.Error = "not found"
+			} else if errors.Is(itemErr, errNotSupported) {
+				result.Error = errNotSupported.Error()
+			} else {
+				result.Error = "internal error"
+			}
+			_, err = tx.Exec(`ROLLBACK TO moderation_item`)
+		} else {
+			_, err = tx.Exec(`RELEASE moderation_item`)
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		results = append(results, result)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+	return results, nil
+}
+
+func moderate(tx *sql.Tx, action, reason string, target models.ModerationTarget) error {
+	var queries []string
+	args := []any{target.ID}
+	switch {
+	case target.Kind == models.TargetPost && action == models.ModerationDelete:
+		queries = []string{
+			`UPDATE posts SET accepted_answer_comment_id = NULL WHERE id = ?`,
+			`DELETE FROM comment_user_Like WHERE comment_id IN (SELECT id FROM comments WHERE post_id = ?)`,
+			`DELETE FROM comments WHERE post_id = ?`,
+			`DELETE FROM post_user_Like WHERE post_id = ?`,
+			`DELETE FROM post_reactions WHERE post_id = ?`,
+			`DELETE FROM post_category WHERE post_id = ?`,
+			`DELETE FROM post_subscriptions WHERE post_id = ?`,
+			`DELETE FROM post_labels WHERE post_id = ?`,
+			`DELETE FROM post_views WHERE post_id = ?`,
+			`DELETE FROM announcements WHERE post_id = ?`,
+			`DELETE FROM notifications WHERE post_id = ?`,
+			`DELETE FROM post_revisions WHERE post_id = ?`,
+			`DELETE FROM posts WHERE id = ?`,
+		}
+	case target.Kind == models.TargetPost && action == models.ModerationLock:
+		queries = []string{`UPDATE posts SET locked = TRUE, lock_reason = ? WHERE id = ?`}
+		args = []any{reason, target.ID}
+	case target.Kind == models.TargetPost && action == models.ModerationUnlock:
+		queries = []string{`UPDATE posts SET locked = FALSE, lock_reason = '' WHERE id = ?`}
+	case target.Kind == models.TargetPost && action == models.ModerationApprove:
+		queries = []string{`UPDATE posts SET approved = TRUE WHERE id = ?`}
+	case target.Kind == models.TargetComment && action == models.ModerationDelete:
+		queries = []string{
+			`UPDATE posts SET accepted_answer_comment_id = NULL WHERE accepted_answer_comment_id = ?`,
+			`UPDATE comments SET quoted_comment_id = NULL WHERE quoted_comment_id = ?`,
+			`DELETE FROM comment_user_Like WHERE comment_id = ?`,
+			`DELETE FROM notifications WHERE comment_id = ?`,
+			`DELETE FROM comments WHERE id = ?`,
+		}
+	case target.Kind == models.TargetComment && action == models.ModerationApprove:
+		queries = []string{
+			`UPDATE posts SET last_activity = CURRENT_TIMESTAMP WHERE id = (SELECT post_id FROM comments WHERE id = ? AND NOT approved)`,
+			`UPDATE comments SET approved = TRUE WHERE id = ?`,
+		}
+	default:
+		return errNotSupported
+	}
+
+	// The last query always touches the target row itself, so it tells us
+	// whether the item exists.
+	var result sql.Result
+	var err error
+	for _, query := range queries {
+		result, err = tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrNoRecord
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+package sqlite
+
+import (
+	"errors"
+	"forum/models"
+	"testing"
+	"time"
+)
+
+func TestBulkModerate(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'spam', 'spam', 'Nan'), (2, 1, 'ham', 'ham', 'Nan')`)
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content) VALUES (1, 1, 1, 'spam'), (2, 2, 1, 'spam')`)
+	exec(t, s, `INSERT INTO post_user_Like (user_id, post_id, is_like) VALUES (1, 1, TRUE)`)
+
+	results, err := s.BulkModerate(models.ModerationDelete, "", []models.ModerationTarget{
+		{Kind: models.TargetPost, ID: 1},
+		{Kind: models.TargetPost, ID: 42},
+		{Kind: models.TargetComment, ID: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []bool{true, false, true}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results; expected %d", len(results), len(want))
+	}
+	for i, result := range results {
+		if result.OK != want[i] {
+			t.Errorf("result %d: got ok=%v; expected %v (%s)", i, result.OK, want[i], result.Error)
+		}
+	}
+
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM posts`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got %d posts; expected 1", count)
+	}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM comments`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("got %d comments; expected 0", count)
+	}
+
+	results, err = s.BulkModerate(models.ModerationLock, "", []models.ModerationTarget{
+		{Kind: models.TargetPost, ID: 2},
+		{Kind: models.TargetComment, ID: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].OK || results[1].OK {
+		t.Errorf("got %+v; expected the post to be locked and the comment to fail", results)
+	}
+	post, err := s.GetPostByID(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !post.Locked {
+		t.Errorf("expected post 2 to be locked")
+	}
+}
+
+func TestApprovePost(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'go')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, approved) VALUES (1, 1, 'held', 'held', 'Nan', FALSE)`)
+	exec(t, s, `INSERT INTO post_category (post_id, category_id) VALUES (1, 1)`)
+
+	shown := func() int {
+		t.Helper()
+		posts, err := s.GetAllPostPaginated(1, 10, models.SortNewest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inCategory, err := s.GetAllPostByCategoryPaginated(1, 10, 1, models.SortNewest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, err := s.SearchPostsPaginated(models.SearchFilter{Query: "held"}, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages, err := s.GetPageNumber(10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*inCategory) != len(*posts) || len(*found) != len(*posts) || pages != len(*posts) {
+			t.Errorf("got %d posts, %d in the category, %d found and %d pages; expected the same", len(*posts), len(*inCategory), len(*found), pages)
+		}
+		return len(*posts)
+	}
+
+	post, err := s.GetPostByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !post.Pending {
+		t.Errorf("expected post 1 to be pending")
+	}
+	if n := shown(); n != 0 {
+		t.Errorf("got %d posts listed before approval; expected 0", n)
+	}
+
+	results, err := s.BulkModerate(models.ModerationApprove, "", []models.ModerationTarget{{Kind: models.TargetPost, ID: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].OK {
+		t.Fatalf("got %+v; expected the post to be approved", results[0])
+	}
+	if post, err = s.GetPostByID(1); err != nil {
+		t.Fatal(err)
+	}
+	if post.Pending {
+		t.Errorf("expected post 1 to be approved")
+	}
+	if n := shown(); n != 1 {
+		t.Errorf("got %d posts listed after approval; expected 1", n)
+	}
+}
+
+func TestLockReason(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'go', 'c', 'Nan')`)
+
+	moderate := func(action, reason string) *models.Post {
+		t.Helper()
+		results, err := s.BulkModerate(action, reason, []models.ModerationTarget{{Kind: models.TargetPost, ID: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !results[0].OK {
+			t.Fatalf("%s: got %+v", action, results[0])
+		}
+		post, err := s.GetPostByID(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return post
+	}
+
+	post := moderate(models.ModerationLock, "duplicate")
+	if !post.Locked || post.LockReason != "duplicate" {
+		t.Errorf("got locked %v reason %q; expected locked with %q", post.Locked, post.LockReason, "duplicate")
+	}
+	post = moderate(models.ModerationUnlock, "")
+	if post.Locked || post.LockReason != "" {
+		t.Errorf("got locked %v reason %q; expected unlocked without a reason", post.Locked, post.LockReason)
+	}
+}
+
+func TestIsCategoryModerator(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Sports')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'go', 'c', 'Nan'), (2, 1, 'ball', 'c', 'Nan')`)
+	exec(t, s, `INSERT INTO post_category (category_id, post_id) VALUES (1, 1), (2, 2)`)
+
+	if err := s.AddCategoryModerator(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddCategoryModerator(1, 1); err != nil {
+		t.Fatalf("granting twice: %v", err)
+	}
+	if err := s.AddCategoryModerator(1, 99); !errors.Is(err, models.UnknownCategory) {
+		t.Errorf("got %v; expected %v", err, models.UnknownCategory)
+	}
+
+	for postID, want := range map[int]bool{1: true, 2: false} {
+		got, err := s.IsCategoryModerator(1, postID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("post %d: got %v; expected %v", postID, got, want)
+		}
+	}
+
+	if err := s.RemoveCategoryModerator(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.IsCategoryModerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got {
+		t.Errorf("still a moderator after removal")
+	}
+}
+
+func TestGetCommentMode(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Sports')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'go', 'c', 'Nan'), (2, 1, 'both', 'c', 'Nan'), (3, 1, 'none', 'c', 'Nan')`)
+	exec(t, s, `INSERT INTO post_category (category_id, post_id) VALUES (1, 1), (1, 2), (2, 2)`)
+
+	if err := s.SetCategoryCommentMode(1, models.CommentsThreaded); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetCategoryCommentMode(99, models.CommentsThreaded); !errors.Is(err, models.UnknownCategory) {
+		t.Errorf("got %v; expected %v", err, models.UnknownCategory)
+	}
+
+	for postID, want := range map[int]string{1: models.CommentsThreaded, 2: models.CommentsFlat, 3: models.CommentsFlat} {
+		got, err := s.GetCommentMode(postID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("post %d: got %q; expected %q", postID, got, want)
+		}
+	}
+}
+
+func TestGetPostStatus(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Announcements'), (2, 'Sports'), (3, 'Staff')`)
+
+	if err := s.SetCategoryPostStatus(1, models.StatusAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetCategoryPostStatus(3, models.StatusModerator); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetCategoryPostStatus(99, models.StatusAdmin); !errors.Is(err, models.UnknownCategory) {
+		t.Errorf("got %v; expected %v", err, models.UnknownCategory)
+	}
+
+	tests := []struct {
+		categories []int
+		want       int
+	}{
+		{categories: nil, want: models.StatusUser},
+		{categories: []int{2}, want: models.StatusUser},
+		{categories: []int{2, 3}, want: models.StatusModerator},
+		{categories: []int{1, 2, 3}, want: models.StatusAdmin},
+	}
+	for _, tt := range tests {
+		got, err := s.GetPostStatus(tt.categories)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("categories %v: got %d; expected %d", tt.categories, got, tt.want)
+		}
+	}
+}
+
+// Post ids are reused after the newest post is deleted, so nothing of a
+// deleted post may be left for the next one to inherit.
+func TestDeletePostCleanup(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'old', 'c', 'Nan')`)
+	if err := s.Subscribe(2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddPostLabel(models.PostLabel{PostID: 1, Label: "Resolved"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordView(2, 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := s.CreateAnnouncement(&models.Announcement{Message: "Read this", PostID: 1, Start: now.Add(-time.Hour), End: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.BulkModerate(models.ModerationDelete, "", []models.ModerationTarget{{Kind: models.TargetPost, ID: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	subscribers, err := s.GetSubscribers(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subscribers) != 0 {
+		t.Errorf("got subscribers %v of the deleted post; expected none", subscribers)
+	}
+	labels, err := s.GetLabelsByPostIDs([]int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels[1]) != 0 {
+		t.Errorf("got labels %v of the deleted post; expected none", labels[1])
+	}
+	// Views of it would keep taking up room in the capped history.
+	var views int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM post_views`).Scan(&views); err != nil {
+		t.Fatal(err)
+	}
+	if views != 0 {
+		t.Errorf("got %d views of the deleted post; expected none", views)
+	}
+	announcements, err := s.GetActiveAnnouncements(now, models.AnnouncementScope{PostID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*announcements) != 0 {
+		t.Errorf("got announcements %+v of the deleted post; expected none", *announcements)
+	}
+}
+
+// The list leaves out notifications of deleted posts, so the unread count
+// must not count them either.
+func TestDeleteNotifications(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'p', 'c', 'Nan'), (2, 1, 'p', 'c', 'Nan')`)
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content) VALUES (1, 1, 2, 'hi'), (2, 2, 2, 'hi'), (3, 2, 2, 'hi again')`)
+	err := s.CreateNotifications([]models.Notification{
+		{UserID: 1, ActorID: 2, Kind: models.NotificationComment, PostID: 1, CommentID: 1},
+		{UserID: 1, ActorID: 2, Kind: models.NotificationComment, PostID: 2, CommentID: 2},
+		{UserID: 1, ActorID: 2, Kind: models.NotificationComment, PostID: 2, CommentID: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := func() int {
+		t.Helper()
+		n, err := s.CountUnread(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if got := count(); got != 3 {
+		t.Fatalf("got %d unread; expected 3", got)
+	}
+
+	_, err = s.BulkModerate(models.ModerationDelete, "", []models.ModerationTarget{
+		{Kind: models.TargetPost, ID: 1},
+		{Kind: models.TargetComment, ID: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := count(); got != 1 {
+		t.Errorf("got %d unread after deleting; expected 1", got)
+	}
+}
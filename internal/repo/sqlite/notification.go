@@ -0,0 +1,252 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"forum/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+// notificationBatch is how many notifications one INSERT adds, which keeps
+// its parameters under the limit of older SQLite builds.
+const notificationBatch = 150
+
+// CreateNotifications adds notifications in a single transaction, many rows
+// to a statement, so a comment on a popular post doesn't make an INSERT per
+// subscriber.
+func (s *Sqlite) CreateNotifications(notifications []models.Notification) error {
+	op := "sqlite.CreateNotifications"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	for batch := range slices.Chunk(notifications, notificationBatch) {
+		stmt := `INSERT INTO notifications (user_id, actor_id, kind, post_id, comment_id, created) VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)` +
+			strings.Repeat(", (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)", len(batch)-1)
+		args := make([]any, 0, 5*len(batch))
+		for _, n := range batch {
+			var commentID sql.NullInt64
+			if n.CommentID != 0 {
+				commentID = sql.NullInt64{Int64: int64(n.CommentID), Valid: true}
+			}
+			args = append(args, n.UserID, n.ActorID, n.Kind, n.PostID, commentID)
+		}
+		if _, err := tx.Exec(stmt, args...); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CreateNotificationOnce adds n unless the user already has one like it,
+// from the same actor about the same post or comment, read or not. created
+// tells whether it was added.
+func (s *Sqlite) CreateNotificationOnce(n models.Notification) (bool, error) {
+	op := "sqlite.CreateNotificationOnce"
+	stmt := `INSERT INTO notifications (user_id, actor_id, kind, post_id, comment_id, created)
+	SELECT :user, :actor, :kind, :post, :comment, CURRENT_TIMESTAMP
+	WHERE NOT EXISTS (SELECT 1 FROM notifications
+		WHERE user_id = :user AND actor_id = :actor AND kind = :kind AND post_id = :post AND comment_id IS :comment)`
+	var commentID sql.NullInt64
+	if n.CommentID != 0 {
+		commentID = sql.NullInt64{Int64: int64(n.CommentID), Valid: true}
+	}
+	res, err := s.db.Exec(stmt, sql.Named("user", n.UserID), sql.Named("actor", n.ActorID), sql.Named("kind", n.Kind),
+		sql.Named("post", n.PostID), sql.Named("comment", commentID))
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	created, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return created == 1, nil
+}
+
+func (s *Sqlite) GetDigestRecipients() (*[]models.DigestRecipient, error) {
+	op := "sqlite.GetDigestRecipients"
+	stmt := `SELECT id, name, email, digest, last_digest FROM users WHERE digest <> ?`
+
+	rows, err := s.db.Query(stmt, models.DigestOff)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var recipients []models.DigestRecipient
+	for rows.Next() {
+		var r models.DigestRecipient
+		if err := rows.Scan(&r.UserID, &r.Name, &r.Email, &r.Digest, &r.LastDigest); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		recipients = append(recipients, r)
+	}
+	return &recipients, nil
+}
+
+// ClaimDigest moves the user's digest window from last to now and hands back
+// the notifications that weren't mailed yet, marking them as notified. The
+// window only moves if it still starts at last, so of two runs racing for the
+// same window, or a run repeated after a restart, only one gets it; the other
+// gets ErrNoRecord.
+func (s *Sqlite) ClaimDigest(userID int, last *time.Time, now time.Time) ([]models.Notification, error) {
+	op := "sqlite.ClaimDigest"
+
+	var lastDigest any
+	if last != nil {
+		lastDigest = last.UTC().Format(timestampLayout)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt := `UPDATE users SET last_digest = ? WHERE id = ? AND last_digest IS ?`
+	result, err := tx.Exec(stmt, now.UTC().Format(timestampLayout), userID, lastDigest)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		tx.Rollback()
+		return nil, models.ErrNoRecord
+	}
+
+	stmt = `SELECT n.id, n.user_id, n.actor_id, u.name, n.kind, n.post_id, p.title, COALESCE(n.comment_id, 0), n.created
+	FROM notifications n
+	JOIN users u ON n.actor_id = u.id
+	JOIN posts p ON n.post_id = p.id
+	JOIN users r ON n.user_id = r.id
+	WHERE n.user_id = ? AND n.read = FALSE AND n.id > r.notifications_read AND n.notified = FALSE
+	ORDER BY n.created, n.id`
+	rows, err := tx.Query(stmt, userID)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.ActorID, &n.ActorName, &n.Kind, &n.PostID, &n.PostTitle, &n.CommentID, &n.Created); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notifications = append(notifications, n)
+	}
+	rows.Close()
+
+	stmt = `UPDATE notifications SET notified = TRUE WHERE id = ?`
+	for _, n := range notifications {
+		if _, err = tx.Exec(stmt, n.ID); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return notifications, nil
+}
+
+// CountUnread is served by idx_notifications_unread, so it stays cheap
+// enough to run on every page. Notifications up to the notifications_read
+// cursor of the user count as read, see MarkAllNotificationsRead.
+func (s *Sqlite) CountUnread(userID int) (int, error) {
+	op := "sqlite.CountUnread"
+	stmt := `SELECT COUNT(*) FROM notifications
+	WHERE user_id = ?1 AND read = FALSE AND id > (SELECT notifications_read FROM users WHERE id = ?1)`
+
+	var count int
+	if err := s.db.QueryRow(stmt, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+func (s *Sqlite) GetNotifications(userID, limit int) (*[]models.Notification, error) {
+	op := "sqlite.GetNotifications"
+	stmt := `SELECT n.id, n.user_id, n.actor_id, u.name, n.kind, n.post_id, p.title, COALESCE(n.comment_id, 0), n.created,
+		n.read OR n.id <= r.notifications_read
+	FROM notifications n
+	JOIN users u ON n.actor_id = u.id
+	JOIN posts p ON n.post_id = p.id
+	JOIN users r ON n.user_id = r.id
+	WHERE n.user_id = ?
+	ORDER BY n.created DESC, n.id DESC
+	LIMIT ?`
+
+	rows, err := s.db.Query(stmt, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.ActorID, &n.ActorName, &n.Kind, &n.PostID, &n.PostTitle, &n.CommentID, &n.Created, &n.Read); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notifications = append(notifications, n)
+	}
+	return &notifications, nil
+}
+
+// MarkNotificationRead marks one of the user's notifications as read and
+// returns it. Notifications of other users are ErrNoRecord.
+func (s *Sqlite) MarkNotificationRead(userID, notificationID int) (*models.Notification, error) {
+	op := "sqlite.MarkNotificationRead"
+	stmt := `UPDATE notifications SET read = TRUE WHERE id = ? AND user_id = ?`
+
+	result, err := s.db.Exec(stmt, notificationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		return nil, models.ErrNoRecord
+	}
+
+	n := &models.Notification{ID: notificationID, UserID: userID, Read: true}
+	stmt = `SELECT post_id, COALESCE(comment_id, 0) FROM notifications WHERE id = ?`
+	if err := s.db.QueryRow(stmt, notificationID).Scan(&n.PostID, &n.CommentID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
+// MarkAllNotificationsRead marks the user's notifications up to upTo as
+// read by moving their notifications_read cursor, instead of every row. The
+// cursor never moves back, nor past the newest notification of the user.
+func (s *Sqlite) MarkAllNotificationsRead(userID, upTo int) error {
+	op := "sqlite.MarkAllNotificationsRead"
+	stmt := `UPDATE users SET notifications_read = MAX(notifications_read,
+		COALESCE((SELECT MAX(id) FROM notifications WHERE user_id = ?1 AND id <= ?2), 0))
+	WHERE id = ?1`
+
+	if _, err := s.db.Exec(stmt, userID, upTo); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
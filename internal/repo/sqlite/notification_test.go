@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"forum/models"
+	"testing"
+)
+
+func TestCreateNotifications(t *testing.T) {
+	s := newTestDB(t)
+
+	// More subscribers than fit one INSERT, so the batch is split.
+	const subscribers = notificationBatch + 10
+	exec(t, s, `WITH RECURSIVE n(id) AS (SELECT 1 UNION ALL SELECT id + 1 FROM n WHERE id <= ?)
+		INSERT INTO users (id, name, email, hashed_password) SELECT id, 'user' || id, 'user' || id || '@gmail.com', '' FROM n`, subscribers)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content) VALUES (1, 1, 'Hello', 'content')`)
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content) VALUES (1, 1, 1, 'hi')`)
+
+	var notifications []models.Notification
+	for id := 2; id <= subscribers+1; id++ {
+		notifications = append(notifications, models.Notification{UserID: id, ActorID: 1, Kind: models.NotificationComment, PostID: 1, CommentID: 1})
+	}
+	notifications[0].CommentID = 0
+	if err := s.CreateNotifications(notifications); err != nil {
+		t.Fatal(err)
+	}
+
+	var count, withComment int
+	if err := s.db.QueryRow(`SELECT COUNT(*), COUNT(comment_id) FROM notifications`).Scan(&count, &withComment); err != nil {
+		t.Fatal(err)
+	}
+	if count != subscribers || withComment != subscribers-1 {
+		t.Errorf("got %d notifications, %d on a comment; expected %d, %d", count, withComment, subscribers, subscribers-1)
+	}
+
+}
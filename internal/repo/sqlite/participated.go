@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"forum/models"
+)
+
+// participatedQuery picks the posts :user commented on with the time and id
+// of their latest comment, approved or the user's own.
+const participatedQuery = `SELECT post_id, MAX(created) AS last, MAX(id) AS last_id FROM comments
+	WHERE approved OR user_id = :user
+	GROUP BY post_id
+	HAVING SUM(user_id = :user AND NOT deleted) > 0`
+
+// GetParticipatedPostsPaginated returns the posts userID commented on, once
+// each, the one with the latest comment first.
+func (s *Sqlite) GetParticipatedPostsPaginated(userID, page, pageSize int) (*[]models.Post, error) {
+	op := "sqlite.GetParticipatedPostsPaginated"
+	offset := (page - 1) * pageSize
+	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved)
+	FROM posts p
+	JOIN users u ON p.user_id = u.id
+	JOIN (` + participatedQuery + `) a ON a.post_id = p.id
+	ORDER BY a.last DESC, a.last_id DESC
+	LIMIT :limit OFFSET :offset`
+
+	rows, err := s.db.Query(stmt, sql.Named("user", userID), sql.Named("limit", pageSize), sql.Named("offset", offset))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &posts, nil
+}
+
+func (s *Sqlite) GetPageNumberParticipated(pageSize int, userID int) (int, error) {
+	var total int
+	op := "sqlite.GetPageNumberParticipated"
+	stmt := `SELECT COUNT(*) FROM (` + participatedQuery + `) a JOIN posts p ON p.id = a.post_id`
+
+	if err := s.db.QueryRow(stmt, sql.Named("user", userID)).Scan(&total); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return (total + pageSize - 1) / pageSize, nil
+}
@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParticipatedPosts(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES
+		(1, 2, 'one', 'c', 'Nan'), (2, 2, 'two', 'c', 'Nan'), (3, 2, 'three', 'c', 'Nan'), (4, 2, 'four', 'c', 'Nan')`)
+	// alice commented twice on post 1 and once on post 2, where bob replied
+	// last. Post 3 has only bob's comment, and post 4 alice's pending one.
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content, created, approved) VALUES
+		(1, 1, 1, 'a', '2024-01-01 10:00:00', TRUE),
+		(2, 2, 1, 'b', '2024-01-01 11:00:00', TRUE),
+		(3, 1, 1, 'c', '2024-01-01 12:00:00', TRUE),
+		(4, 3, 2, 'd', '2024-01-01 13:00:00', TRUE),
+		(5, 2, 2, 'e', '2024-01-01 14:00:00', TRUE),
+		(6, 4, 1, 'f', '2024-01-01 09:00:00', FALSE)`)
+
+	check := func(userID, page, pageSize int, want ...int) {
+		t.Helper()
+		posts, err := s.GetParticipatedPostsPaginated(userID, page, pageSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		for _, post := range *posts {
+			got = append(got, post.PostID)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got posts %v for user %d; expected %v", got, userID, want)
+		}
+	}
+
+	check(1, 1, 10, 2, 1, 4)
+	check(1, 2, 2, 4)
+	check(2, 1, 10, 2, 3)
+
+	if pages, err := s.GetPageNumberParticipated(2, 1); err != nil || pages != 2 {
+		t.Errorf("got %d pages, %v; expected 2", pages, err)
+	}
+
+	// Deleted comments leave the conversation.
+	exec(t, s, `UPDATE comments SET deleted = TRUE WHERE id = 2`)
+	check(1, 1, 10, 1, 4)
+}
@@ -0,0 +1,24 @@
+package sqlite
+
+import "fmt"
+
+// SetProfilePinned pins the post to the top of its author's profile, or
+// unpins it.
+func (s *Sqlite) SetProfilePinned(postID int, pinned bool) error {
+	op := "sqlite.SetProfilePinned"
+	stmt := `UPDATE posts SET profile_pinned = ? WHERE id = ?`
+	if _, err := s.db.Exec(stmt, pinned, postID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) CountProfilePins(userID int) (int, error) {
+	op := "sqlite.CountProfilePins"
+	var count int
+	stmt := `SELECT COUNT(*) FROM posts WHERE user_id = ? AND profile_pinned = TRUE`
+	if err := s.db.QueryRow(stmt, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
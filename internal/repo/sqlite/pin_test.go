@@ -0,0 +1,46 @@
+package sqlite
+
+import "testing"
+
+func TestProfilePinnedFirst(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+		(1, 1, 'old', 'c', 'Nan', '2024-01-01 10:00:00'),
+		(2, 1, 'middle', 'c', 'Nan', '2024-01-02 10:00:00'),
+		(3, 1, 'new', 'c', 'Nan', '2024-01-03 10:00:00'),
+		(4, 2, 'bob', 'c', 'Nan', '2024-01-04 10:00:00')`)
+
+	if err := s.SetProfilePinned(1, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetProfilePinned(4, true); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := s.CountProfilePins(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got %d pins; expected bob's pin not to count for alice", count)
+	}
+
+	posts, err := s.GetAllPostByUserIDPaginated(1, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1, 3, 2}
+	if len(*posts) != len(want) {
+		t.Fatalf("got %d posts; expected %d", len(*posts), len(want))
+	}
+	for i, post := range *posts {
+		if post.PostID != want[i] {
+			t.Errorf("post %d: got %d; expected %d", i, post.PostID, want[i])
+		}
+	}
+	if !(*posts)[0].ProfilePinned {
+		t.Error("expected the first post to be marked pinned")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"forum/models"
+	"time"
 )
 
 func (s *Sqlite) CheckPostExists(postID int) bool {
@@ -35,23 +36,40 @@ func (s *Sqlite) CreatePost(userID int, title, content, imageName string) (int,
 
 func (s *Sqlite) GetPostByID(postID int) (*models.Post, error) {
 	op := "sqlite.GetPostByID"
-	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name
+	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), COALESCE(p.accepted_answer_comment_id, 0), p.locked, p.lock_reason, p.archived, p.profile_pinned, p.deleted, NOT p.approved, p.last_activity
 	FROM posts p
 	JOIN users u ON p.user_id = u.id 
 	WHERE p.id = ?
 `
 	post := models.Post{}
+	var activity sql.NullTime
 
-	err := s.db.QueryRow(stmt, postID).Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName)
+	err := s.db.QueryRow(stmt, postID).Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.AcceptedAnswerID, &post.Locked, &post.LockReason, &post.Archived, &post.ProfilePinned, &post.Deleted, &post.Pending, &activity)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrNoRecord
 		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	lastActivity(&post, activity)
 	return &post, nil
 }
 
+// SetAcceptedAnswer marks commentID as the accepted answer of the post, 0
+// unsets it.
+func (s *Sqlite) SetAcceptedAnswer(postID, commentID int) error {
+	op := "sqlite.SetAcceptedAnswer"
+	var answer sql.NullInt64
+	if commentID != 0 {
+		answer = sql.NullInt64{Int64: int64(commentID), Valid: true}
+	}
+	stmt := `UPDATE posts SET accepted_answer_comment_id = ? WHERE id = ?`
+	if _, err := s.db.Exec(stmt, answer, postID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
 func (s *Sqlite) GetAllPost() ([]models.Post, error) {
 	const query = `SELECT post_id, user_id, title, content, created, like, dislike, image_name FROM Post`
 	rows, err := s.db.Query(query)
@@ -74,11 +92,12 @@ func (s *Sqlite) GetAllPost() ([]models.Post, error) {
 
 func (s *Sqlite) GetAllPostByUserIDPaginated(userID, page, pageSize int) (*[]models.Post, error) {
 	offset := (page - 1) * pageSize
-	const query = `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id) 
+	// Posts the author pinned come first.
+	const query = `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved), p.profile_pinned
 	FROM posts p 
 	JOIN users u ON p.user_id = u.id
-	WHERE p.user_id = ?
-	ORDER BY p.created DESC
+	WHERE p.user_id = ? AND p.approved
+	ORDER BY p.profile_pinned DESC, p.created DESC
 	LIMIT ? OFFSET ?`
 
 	rows, err := s.db.Query(query, userID, pageSize, offset)
@@ -91,7 +110,7 @@ func (s *Sqlite) GetAllPostByUserIDPaginated(userID, page, pageSize int) (*[]mod
 
 	for rows.Next() {
 		var post models.Post
-		err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.CommentCount)
+		err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount, &post.ProfilePinned)
 		if err != nil {
 			return nil, err
 		}
@@ -105,7 +124,7 @@ func (s *Sqlite) GetAllPostByCategory(categoryID int) (*[]models.Post, error) {
 	query := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name
               FROM posts AS p
               INNER JOIN post_category AS pc ON p.id = pc.post_id
-              WHERE pc.category_id IN (?)
+              WHERE pc.category_id IN (?) AND p.approved
               GROUP BY p.id`
 
 	rows, err := s.db.Query(query, categoryID)
@@ -126,16 +145,48 @@ func (s *Sqlite) GetAllPostByCategory(categoryID int) (*[]models.Post, error) {
 	return &posts, nil
 }
 
-func (s *Sqlite) GetAllPostByCategoryPaginated(page int, pageSize int, categoryID int) (*[]models.Post, error) {
+// sortOrders are the ORDER BY clauses of the home page sort orders. Hot is
+// the score per hour of age, so a post needs ever more votes to stay on top.
+var sortOrders = map[string]string{
+	models.SortNewest: `p.created DESC, p.id DESC`,
+	models.SortTop:    `(p.like - p.dislike) DESC, p.created DESC, p.id DESC`,
+	models.SortHot:    `(p.like - p.dislike + 1) / ((julianday('now') - julianday(p.created)) * 24 + 2) DESC, p.created DESC, p.id DESC`,
+	models.SortActive: `COALESCE(p.last_activity, p.created) DESC, p.id DESC`,
+}
+
+// backfillLastActivity sets last_activity from the comments of posts that
+// got them without it being kept: before the column, or by an import.
+const backfillLastActivity = `UPDATE posts SET last_activity = (
+	SELECT MAX(created) FROM comments c WHERE c.post_id = posts.id AND c.approved
+) WHERE last_activity IS NULL`
+
+// lastActivity fills in the post's LastActivityAt from the last_activity
+// column, which stays NULL until the post is first commented on.
+func lastActivity(post *models.Post, at sql.NullTime) {
+	post.LastActivityAt = post.Created
+	if at.Valid {
+		post.LastActivityAt = at.Time
+	}
+}
+
+// orderBy falls back to newest first for an unknown sort.
+func orderBy(sort string) string {
+	if order, ok := sortOrders[sort]; ok {
+		return order
+	}
+	return sortOrders[models.SortNewest]
+}
+
+func (s *Sqlite) GetAllPostByCategoryPaginated(page int, pageSize int, categoryID int, sort string) (*[]models.Post, error) {
 	// op := "sqlite.GetAllPostByCategoryPaginated"
 	offset := (page - 1) * pageSize
-	query := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id)
+	query := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved), p.last_activity
               FROM posts AS p
               INNER JOIN post_category AS pc ON p.id = pc.post_id
 			  JOIN users u ON p.user_id = u.id 
-              WHERE pc.category_id IN (?)
+              WHERE pc.category_id IN (?) AND p.approved
               GROUP BY p.id
-			  ORDER BY p.created DESC
+			  ORDER BY ` + orderBy(sort) + `
 			  LIMIT ? OFFSET ?`
 
 	rows, err := s.db.Query(query, categoryID, pageSize, offset)
@@ -147,16 +198,18 @@ func (s *Sqlite) GetAllPostByCategoryPaginated(page int, pageSize int, categoryI
 	var posts []models.Post
 	for rows.Next() {
 		var post models.Post
-		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.CommentCount); err != nil {
+		var activity sql.NullTime
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount, &activity); err != nil {
 			return nil, err
 		}
+		lastActivity(&post, activity)
 		posts = append(posts, post)
 	}
 
 	return &posts, nil
 }
 
-func (s *Sqlite) GetAllPostPaginated(page, pageSize int) (*[]models.Post, error) {
+func (s *Sqlite) GetAllPostPaginated(page, pageSize int, sort string) (*[]models.Post, error) {
 	op := "sqlite.GetAllPostPaginated"
 	offset := (page - 1) * pageSize
 	// stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COUNT(c.id)
@@ -167,10 +220,11 @@ func (s *Sqlite) GetAllPostPaginated(page, pageSize int) (*[]models.Post, error)
 	// LIMIT ? OFFSET ?
 	// `
 
-	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id)
+	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved), p.last_activity
 	FROM posts p 
 	Inner JOIN users u ON p.user_id = u.id 
-	ORDER BY p.created DESC
+	WHERE p.approved
+	ORDER BY ` + orderBy(sort) + `
 	LIMIT ? OFFSET ?
 	`
 
@@ -183,9 +237,11 @@ func (s *Sqlite) GetAllPostPaginated(page, pageSize int) (*[]models.Post, error)
 	var posts []models.Post
 	for rows.Next() {
 		var post models.Post
-		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.CommentCount); err != nil {
+		var activity sql.NullTime
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount, &activity); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
+		lastActivity(&post, activity)
 		posts = append(posts, post)
 	}
 	return &posts, nil
@@ -193,11 +249,11 @@ func (s *Sqlite) GetAllPostPaginated(page, pageSize int) (*[]models.Post, error)
 
 func (s *Sqlite) GetLikedPostsPaginated(userID, page, pageSize int) (*[]models.Post, error) {
 	offset := (page - 1) * pageSize
-	const query = `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id) 
+	const query = `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved) 
 	FROM posts p 
 	JOIN users u ON p.user_id = u.id
 	JOIN post_user_Like l ON p.id = l.post_id
-	WHERE l.user_id = ? AND l.is_like = TRUE
+	WHERE l.user_id = ? AND l.is_like = TRUE AND p.approved
 	GROUP BY p.id
 	ORDER BY p.created DESC
 	LIMIT ? OFFSET ?`
@@ -212,7 +268,7 @@ func (s *Sqlite) GetLikedPostsPaginated(userID, page, pageSize int) (*[]models.P
 
 	for rows.Next() {
 		var post models.Post
-		err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.CommentCount)
+		err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount)
 		if err != nil {
 			return nil, err
 		}
@@ -226,7 +282,7 @@ func (s *Sqlite) GetPageNumber(pageSize int, category int) (int, error) {
 	var totalPosts int
 	op := "sqlite.GetPageNumber"
 	if category == 0 {
-		stmt := `SELECT COUNT(*) FROM posts`
+		stmt := `SELECT COUNT(*) FROM posts WHERE approved`
 		err := s.db.QueryRow(stmt).Scan(&totalPosts)
 		if err != nil {
 			return 0, fmt.Errorf("%s: %w", op, err)
@@ -235,7 +291,7 @@ func (s *Sqlite) GetPageNumber(pageSize int, category int) (int, error) {
 		stmt := `SELECT COUNT (*)
 			FROM posts AS p
 			INNER JOIN post_category AS pc ON p.id = pc.post_id
-			WHERE pc.category_id = (?)
+			WHERE pc.category_id = (?) AND p.approved
 			`
 		err := s.db.QueryRow(stmt, category).Scan(&totalPosts)
 		if err != nil {
@@ -256,7 +312,7 @@ func (s *Sqlite) GetPageNumberLikedPosts(pageSize int, userID int) (int, error)
 	FROM posts p 
 	JOIN users u ON p.user_id = u.id
 	JOIN post_user_Like l ON p.id = l.post_id
-	WHERE l.user_id = ? AND l.is_like = TRUE
+	WHERE l.user_id = ? AND l.is_like = TRUE AND p.approved
 	`
 	err := s.db.QueryRow(stmt, userID).Scan(&totalPosts)
 	if err != nil {
@@ -274,7 +330,7 @@ func (s *Sqlite) GetPageNumberMyPosts(pageSize int, userID int) (int, error) {
 	stmt := `SELECT COUNT(*) 
 	FROM posts p 
 	JOIN users u ON p.user_id = u.id
-	WHERE p.user_id = ?
+	WHERE p.user_id = ? AND p.approved
 	`
 	err := s.db.QueryRow(stmt, userID).Scan(&totalPosts)
 	if err != nil {
@@ -284,3 +340,70 @@ func (s *Sqlite) GetPageNumberMyPosts(pageSize int, userID int) (int, error) {
 	totalPages := (totalPosts + pageSize - 1) / pageSize
 	return totalPages, nil
 }
+
+// GetPostsAfter returns the posts that come after the post with ID after in
+// the home page order, newest first. after 0 starts from the top and
+// category 0 means every category.
+func (s *Sqlite) GetPostsAfter(after, category, limit int) (*[]models.Post, error) {
+	op := "sqlite.GetPostsAfter"
+	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved)
+	FROM posts p
+	JOIN users u ON p.user_id = u.id
+	WHERE p.approved AND (? = 0 OR (p.created, p.id) < (SELECT created, id FROM posts WHERE id = ?))
+	AND (? = 0 OR EXISTS (SELECT 1 FROM post_category pc WHERE pc.post_id = p.id AND pc.category_id = ?))
+	ORDER BY p.created DESC, p.id DESC
+	LIMIT ?`
+
+	rows, err := s.db.Query(stmt, after, after, category, category, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		posts = append(posts, post)
+	}
+	return &posts, nil
+}
+
+// CountPostsSince counts the posts the user created after since, or all of
+// them for a zero since.
+func (s *Sqlite) CountPostsSince(userID int, since time.Time) (int, error) {
+	op := "sqlite.CountPostsSince"
+	var count int
+	stmt := `SELECT COUNT(*) FROM posts WHERE user_id = ? AND datetime(created) >= ?`
+	if err := s.db.QueryRow(stmt, userID, since.UTC().Format(timestampLayout)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+// GetPostTimesSince returns when the user created each of their posts after
+// since, oldest first.
+func (s *Sqlite) GetPostTimesSince(userID int, since time.Time) ([]time.Time, error) {
+	op := "sqlite.GetPostTimesSince"
+	stmt := `SELECT created FROM posts WHERE user_id = ? AND datetime(created) >= ? ORDER BY created, id`
+	rows, err := s.db.Query(stmt, userID, since.UTC().Format(timestampLayout))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var created time.Time
+		if err := rows.Scan(&created); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		times = append(times, created)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return times, nil
+}
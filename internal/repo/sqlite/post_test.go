@@ -0,0 +1,280 @@
+package sqlite
+
+import (
+	"fmt"
+	"forum/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetPostsAfter(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Sports')`)
+	// Posts 11 to 25 share a timestamp, so only the ID keeps the order stable.
+	for id := 1; id <= 25; id++ {
+		created := fmt.Sprintf("2024-01-%02d 10:00:00", id)
+		if id > 10 {
+			created = "2024-02-01 10:00:00"
+		}
+		exec(t, s, fmt.Sprintf(`INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES (%d, 1, 'p', 'c', 'Nan', '%s')`, id, created))
+		exec(t, s, fmt.Sprintf(`INSERT INTO post_category (category_id, post_id) VALUES (%d, %d)`, id%2+1, id))
+	}
+
+	first, err := s.GetAllPostPaginated(1, 20, models.SortNewest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*first) != 20 {
+		t.Fatalf("got %d posts on the first page; expected 20", len(*first))
+	}
+
+	feedFirst, err := s.GetPostsAfter(0, 0, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range *first {
+		if (*first)[i].PostID != (*feedFirst)[i].PostID {
+			t.Fatalf("page and feed order differ at %d: %d vs %d", i, (*first)[i].PostID, (*feedFirst)[i].PostID)
+		}
+	}
+
+	last := (*first)[19].PostID
+	second, err := s.GetPostsAfter(last, 0, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*second) != 5 {
+		t.Fatalf("got %d posts on the second page; expected 5", len(*second))
+	}
+	seen := map[int]bool{}
+	for _, post := range append(*first, *second...) {
+		if seen[post.PostID] {
+			t.Errorf("post %d returned twice", post.PostID)
+		}
+		seen[post.PostID] = true
+	}
+	if len(seen) != 25 {
+		t.Errorf("got %d distinct posts; expected 25", len(seen))
+	}
+
+	sports, err := s.GetPostsAfter(0, 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, post := range *sports {
+		if post.PostID%2 != 1 {
+			t.Errorf("post %d is not in the category", post.PostID)
+		}
+	}
+	if len(*sports) != 13 {
+		t.Errorf("got %d posts in the category; expected 13", len(*sports))
+	}
+}
+
+func TestGetAllPostPaginatedSort(t *testing.T) {
+	s := newTestDB(t)
+
+	now := time.Now().UTC()
+	ago := func(d time.Duration) string { return now.Add(-d).Format(timestampLayout) }
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Technology')`)
+	// 1 is old and loved, 2 is fresh with a few likes, 3 is the newest but
+	// disliked.
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, like, dislike, created) VALUES
+		(1, 1, 'p', 'c', 'Nan', 50, 0, ?),
+		(2, 1, 'p', 'c', 'Nan', 5, 0, ?),
+		(3, 1, 'p', 'c', 'Nan', 0, 1, ?)`, ago(30*24*time.Hour), ago(2*time.Hour), ago(time.Minute))
+	exec(t, s, `INSERT INTO post_category (category_id, post_id) VALUES (1, 1), (1, 2), (1, 3)`)
+
+	tests := []struct {
+		sort string
+		want []int
+	}{
+		{sort: models.SortNewest, want: []int{3, 2, 1}},
+		{sort: models.SortTop, want: []int{1, 2, 3}},
+		{sort: models.SortHot, want: []int{2, 1, 3}},
+		{sort: models.SortActive, want: []int{3, 2, 1}},
+		{sort: "", want: []int{3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			all, err := s.GetAllPostPaginated(1, 10, tt.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+			byCategory, err := s.GetAllPostByCategoryPaginated(1, 10, 1, tt.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, posts := range []*[]models.Post{all, byCategory} {
+				if len(*posts) != len(tt.want) {
+					t.Fatalf("got %d posts; expected %d", len(*posts), len(tt.want))
+				}
+				for i, post := range *posts {
+					if post.PostID != tt.want[i] {
+						t.Errorf("post %d: got %d; expected %d", i, post.PostID, tt.want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLastActivity(t *testing.T) {
+	s := newTestDB(t)
+
+	now := time.Now().UTC()
+	ago := func(d time.Duration) string { return now.Add(-d).Format(timestampLayout) }
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	// 1 is a month old, 2 and 3 are newer but nobody comments on them.
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+		(1, 1, 'p', 'c', 'Nan', ?),
+		(2, 1, 'p', 'c', 'Nan', ?),
+		(3, 1, 'p', 'c', 'Nan', ?)`, ago(30*24*time.Hour), ago(2*time.Hour), ago(time.Hour))
+
+	ids := func() []int {
+		t.Helper()
+		posts, err := s.GetAllPostPaginated(1, 10, models.SortActive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for _, post := range *posts {
+			ids = append(ids, post.PostID)
+		}
+		return ids
+	}
+	if got, want := ids(), []int{3, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("got %v before commenting; expected %v", got, want)
+	}
+
+	post, err := s.GetPostByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !post.LastActivityAt.Equal(post.Created) {
+		t.Errorf("got last activity %v; expected the creation time %v", post.LastActivityAt, post.Created)
+	}
+
+	// A pending comment bumps nothing until it is approved.
+	if _, err := s.CommentPost(models.CommentForm{PostID: 1, UserID: 1, Content: "later", Pending: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(), []int{3, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("got %v after a pending comment; expected %v", got, want)
+	}
+
+	if _, err := s.CommentPost(models.CommentForm{PostID: 1, UserID: 1, Content: "bump"}); err != nil {
+		t.Fatal(err)
+	}
+	post, err = s.GetPostByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !post.LastActivityAt.After(now.Add(-time.Minute)) {
+		t.Errorf("got last activity %v; expected about %v", post.LastActivityAt, now)
+	}
+	if got, want := ids(), []int{1, 3, 2}; !slices.Equal(got, want) {
+		t.Errorf("got %v after commenting; expected %v", got, want)
+	}
+
+	// Comments written around CommentPost, like those of an import, are
+	// picked up by the backfill.
+	exec(t, s, `INSERT INTO comments (post_id, user_id, content, created) VALUES (2, 1, 'imported', ?)`, now.Add(time.Minute).Format(timestampLayout))
+	if err := s.FinishImport("backup"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(), []int{2, 1, 3}; !slices.Equal(got, want) {
+		t.Errorf("got %v after the backfill; expected %v", got, want)
+	}
+}
+
+func TestPostIndexCounts(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Sports')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+		(1, 1, 'p', 'c', 'Nan', '2024-01-01 10:00:00'),
+		(2, 1, 'p', 'c', 'Nan', '2024-01-02 10:00:00'),
+		(3, 1, 'p', 'c', 'Nan', '2024-01-03 10:00:00')`)
+	exec(t, s, `INSERT INTO post_category (category_id, post_id) VALUES (1, 1), (2, 1), (2, 2)`)
+	// Comments waiting for approval don't count.
+	exec(t, s, `INSERT INTO comments (post_id, user_id, content, approved) VALUES
+		(1, 1, 'a', TRUE), (1, 1, 'b', TRUE), (1, 1, 'c', TRUE), (1, 1, 'd', FALSE),
+		(2, 1, 'e', TRUE), (3, 1, 'f', FALSE)`)
+
+	posts, err := s.GetAllPostPaginated(1, 10, models.SortNewest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCounts := map[int]int{1: 3, 2: 1, 3: 0}
+	for _, post := range *posts {
+		if post.CommentCount != wantCounts[post.PostID] {
+			t.Errorf("post %d: got %d comments; expected %d", post.PostID, post.CommentCount, wantCounts[post.PostID])
+		}
+	}
+
+	categories, err := s.GetCategoriesByPostIDs([]int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != 2 || len(categories[1]) != 2 || categories[1][1] != "Technology" || categories[2][2] != "Sports" || categories[3] != nil {
+		t.Errorf("got categories %v", categories)
+	}
+	if categories, err = s.GetCategoriesByPostIDs(nil); err != nil || len(categories) != 0 {
+		t.Errorf("got %v, %v for no posts; expected none", categories, err)
+	}
+}
+
+func TestCountPostsSince(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+		(1, 1, 'p', 'c', 'Nan', '2024-01-01 10:00:00'),
+		(2, 1, 'p', 'c', 'Nan', '2024-01-02 09:00:00'),
+		(3, 1, 'p', 'c', 'Nan', '2024-01-02 11:00:00'),
+		(4, 2, 'p', 'c', 'Nan', '2024-01-02 11:00:00')`)
+
+	tests := []struct {
+		name   string
+		userID int
+		since  time.Time
+		want   int
+	}{
+		{"All posts", 1, time.Time{}, 3},
+		{"Since a time", 1, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), 3},
+		{"Last day", 1, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), 2},
+		{"Other zone", 1, time.Date(2024, 1, 2, 19, 0, 0, 0, time.FixedZone("UTC+9", 9*60*60)), 1},
+		{"Other user", 2, time.Time{}, 1},
+		{"No posts", 3, time.Time{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.CountPostsSince(tt.userID, tt.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d posts; expected %d", got, tt.want)
+			}
+			times, err := s.GetPostTimesSince(tt.userID, tt.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(times) != tt.want {
+				t.Errorf("got %d post times; expected %d", len(times), tt.want)
+			}
+			if !slices.IsSortedFunc(times, time.Time.Compare) {
+				t.Errorf("got post times %v; expected oldest first", times)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"forum/models"
+)
+
+// SetPostReactionType gives the post the user's reaction of form.Type,
+// replacing the one they had.
+func (s *Sqlite) SetPostReactionType(form models.ReactionForm) error {
+	op := "sqlite.SetPostReactionType"
+	stmt := `INSERT INTO post_reactions (user_id, post_id, type, created) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+	ON CONFLICT (user_id, post_id) DO UPDATE SET type = excluded.type, created = excluded.created`
+	if _, err := s.db.Exec(stmt, form.UserID, form.ID, form.Type); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) DeletePostReactionType(userID, postID int) error {
+	op := "sqlite.DeletePostReactionType"
+	stmt := `DELETE FROM post_reactions WHERE user_id = ? AND post_id = ?`
+	if _, err := s.db.Exec(stmt, userID, postID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// GetPostReactionType returns the type of the user's reaction to the post,
+// empty if they have none.
+func (s *Sqlite) GetPostReactionType(userID, postID int) (string, error) {
+	op := "sqlite.GetPostReactionType"
+	stmt := `SELECT type FROM post_reactions WHERE user_id = ? AND post_id = ?`
+	var t string
+	err := s.db.QueryRow(stmt, userID, postID).Scan(&t)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return t, nil
+}
+
+// CountPostReactionTypes returns how many reactions of each type the post
+// has. Unused types are left out.
+func (s *Sqlite) CountPostReactionTypes(postID int) (map[string]int, error) {
+	op := "sqlite.CountPostReactionTypes"
+	stmt := `SELECT type, COUNT(*) FROM post_reactions WHERE post_id = ? GROUP BY type`
+
+	rows, err := s.db.Query(stmt, postID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var t string
+		var n int
+		if err := rows.Scan(&t, &n); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		counts[t] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return counts, nil
+}
+
+// reactionsQuery selects the user_id, type and created of the reactions to
+// target :id of type :type, or of any type when it is empty. Posts have
+// emoji reactions besides likes and dislikes, comments only the latter.
+func reactionsQuery(target models.ModerationTarget) string {
+	likes := `SELECT user_id, CASE WHEN is_like THEN '` + models.ReactionLike + `' ELSE '` + models.ReactionDislike + `' END AS type, created
+		FROM comment_user_Like WHERE comment_id = :id`
+	if target.Kind == models.TargetPost {
+		likes = `SELECT user_id, type, created FROM post_reactions WHERE post_id = :id
+		UNION ALL
+		SELECT user_id, CASE WHEN is_like THEN '` + models.ReactionLike + `' ELSE '` + models.ReactionDislike + `' END, created
+		FROM post_user_Like WHERE post_id = :id`
+	}
+	return `SELECT * FROM (` + likes + `) WHERE :type = '' OR type = :type`
+}
+
+// GetReactors lists a page of the users who reacted to target with
+// reactionType, or any type when it is empty, the latest first. Reactors
+// without an account are Deleted.
+func (s *Sqlite) GetReactors(target models.ModerationTarget, reactionType string, page, pageSize int) (*[]models.Reactor, error) {
+	op := "sqlite.GetReactors"
+	stmt := `SELECT r.user_id, COALESCE(u.name, ''), COALESCE(u.display_name, ''), COALESCE(u.privacy, 0), r.type, u.id IS NULL
+	FROM (` + reactionsQuery(target) + `) r
+	LEFT JOIN users u ON r.user_id = u.id
+	ORDER BY COALESCE(r.created, '') DESC, r.user_id, r.type
+	LIMIT :limit OFFSET :offset`
+
+	rows, err := s.db.Query(stmt, sql.Named("id", target.ID), sql.Named("type", reactionType),
+		sql.Named("limit", pageSize), sql.Named("offset", (page-1)*pageSize))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	reactors := []models.Reactor{}
+	for rows.Next() {
+		var r models.Reactor
+		if err := rows.Scan(&r.UserID, &r.Name, &r.DisplayName, &r.Privacy, &r.Type, &r.Deleted); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		reactors = append(reactors, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &reactors, nil
+}
+
+// CountReactors is how many reactions of reactionType GetReactors lists
+// over all pages.
+func (s *Sqlite) CountReactors(target models.ModerationTarget, reactionType string) (int, error) {
+	op := "sqlite.CountReactors"
+	stmt := `SELECT COUNT(*) FROM (` + reactionsQuery(target) + `)`
+
+	var count int
+	if err := s.db.QueryRow(stmt, sql.Named("id", target.ID), sql.Named("type", reactionType)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"forum/models"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestPostReactionTypes(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', ''), (3, 'carol', 'carol@gmail.com', '')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'go', 'c', 'Nan'), (2, 1, 'rust', 'c', 'Nan')`)
+
+	react := func(userID, postID int, reaction string) {
+		t.Helper()
+		if err := s.SetPostReactionType(models.ReactionForm{UserID: userID, ID: postID, Type: reaction}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantCounts := func(postID int, want map[string]int) {
+		t.Helper()
+		got, err := s.CountPostReactionTypes(postID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !maps.Equal(got, want) {
+			t.Errorf("post %d: got %v; expected %v", postID, got, want)
+		}
+	}
+
+	react(1, 1, "👍")
+	react(2, 1, "👍")
+	react(3, 1, "😂")
+	react(1, 2, "❤️")
+	wantCounts(1, map[string]int{"👍": 2, "😂": 1})
+	wantCounts(2, map[string]int{"❤️": 1})
+
+	// A second reaction replaces the first.
+	react(1, 1, "😂")
+	wantCounts(1, map[string]int{"👍": 1, "😂": 2})
+	got, err := s.GetPostReactionType(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "😂" {
+		t.Errorf("got %q; expected %q", got, "😂")
+	}
+
+	if err := s.DeletePostReactionType(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	wantCounts(1, map[string]int{"👍": 1, "😂": 1})
+	got, err = s.GetPostReactionType(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("got %q; expected no reaction", got)
+	}
+}
+
+func TestGetReactors(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password, display_name, privacy) VALUES
+		(1, 'alice', 'alice@gmail.com', '', 'Alice', 0), (2, 'bob', 'bob@gmail.com', '', '', 2), (3, 'carol', 'carol@gmail.com', '', '', 0)`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'go', 'c', 'Nan')`)
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content) VALUES (1, 1, 1, 'hi')`)
+	exec(t, s, `INSERT INTO post_reactions (user_id, post_id, type, created) VALUES (1, 1, '❤️', '2024-01-01 10:00:00'), (3, 1, '👍', '2024-01-01 11:00:00')`)
+	// User 9 reacted before their account was removed.
+	exec(t, s, `INSERT INTO post_user_Like (user_id, post_id, is_like, created) VALUES
+		(2, 1, TRUE, '2024-01-01 12:00:00'), (9, 1, FALSE, '2024-01-01 09:00:00')`)
+	exec(t, s, `INSERT INTO comment_user_Like (user_id, comment_id, is_like, created) VALUES (3, 1, TRUE, '2024-01-01 10:00:00')`)
+
+	post := models.ModerationTarget{Kind: models.TargetPost, ID: 1}
+	list := func(target models.ModerationTarget, reactionType string, page, pageSize int) []models.Reactor {
+		t.Helper()
+		reactors, err := s.GetReactors(target, reactionType, page, pageSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *reactors
+	}
+
+	// The latest first, across likes and emoji.
+	want := []models.Reactor{
+		{UserID: 2, Name: "bob", Privacy: models.PrivacyPrivate, Type: models.ReactionLike},
+		{UserID: 3, Name: "carol", Type: "👍"},
+		{UserID: 1, Name: "alice", DisplayName: "Alice", Type: "❤️"},
+		{UserID: 9, Type: models.ReactionDislike, Deleted: true},
+	}
+	if got := append(list(post, "", 1, 3), list(post, "", 2, 3)...); !slices.Equal(got, want) {
+		t.Errorf("got %+v; expected %+v", got, want)
+	}
+	if got := list(post, "", 3, 3); len(got) != 0 {
+		t.Errorf("got %+v past the last page; expected none", got)
+	}
+	if got := list(post, "❤️", 1, 3); !slices.Equal(got, want[2:3]) {
+		t.Errorf("got %+v for hearts; expected %+v", got, want[2:3])
+	}
+
+	counts := []struct {
+		target       models.ModerationTarget
+		reactionType string
+		want         int
+	}{
+		{post, "", 4},
+		{post, models.ReactionLike, 1},
+		{post, "😂", 0},
+		{models.ModerationTarget{Kind: models.TargetComment, ID: 1}, "", 1},
+		// Comments have no emoji, whatever the post does.
+		{models.ModerationTarget{Kind: models.TargetComment, ID: 1}, "❤️", 0},
+	}
+	for _, tt := range counts {
+		got, err := s.CountReactors(tt.target, tt.reactionType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%v %q: got %d reactors; expected %d", tt.target, tt.reactionType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"fmt"
+	"forum/models"
+	"time"
+)
+
+// GetRelatedPosts returns posts sharing categories with postID, the ones with
+// more categories in common first and the newest among equals.
+func (s *Sqlite) GetRelatedPosts(postID, limit int) (*[]models.Post, error) {
+	op := "sqlite.GetRelatedPosts"
+	stmt := `SELECT p.id, p.user_id, p.title, p.created, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), COUNT(*) AS overlap
+	FROM post_category pc
+	JOIN post_category current ON current.category_id = pc.category_id AND current.post_id = ?
+	JOIN posts p ON p.id = pc.post_id
+	JOIN users u ON p.user_id = u.id
+	WHERE pc.post_id != current.post_id AND p.approved = TRUE
+	GROUP BY p.id
+	ORDER BY overlap DESC, p.created DESC
+	LIMIT ?`
+
+	rows, err := s.db.Query(stmt, postID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		var overlap int
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Created, &post.UserName, &post.DisplayName, &overlap); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		posts = append(posts, post)
+	}
+	return &posts, nil
+}
+
+// GetTrendingCategories returns the names of the categories with the most
+// posts created after since.
+func (s *Sqlite) GetTrendingCategories(since time.Time, limit int) ([]string, error) {
+	op := "sqlite.GetTrendingCategories"
+	stmt := `SELECT c.name
+	FROM post_category pc
+	JOIN category c ON c.id = pc.category_id
+	JOIN posts p ON p.id = pc.post_id
+	WHERE datetime(p.created) >= ?
+	GROUP BY c.id
+	ORDER BY COUNT(*) DESC, c.id ASC
+	LIMIT ?`
+
+	rows, err := s.db.Query(stmt, since.UTC().Format(timestampLayout), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var categories []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		categories = append(categories, name)
+	}
+	return categories, nil
+}
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetRelatedPosts(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Science'), (3, 'Sports')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+		(1, 1, 'current', 'c', 'Nan', '2024-01-01 10:00:00'),
+		(2, 1, 'one shared', 'c', 'Nan', '2024-01-05 10:00:00'),
+		(3, 1, 'two shared', 'c', 'Nan', '2024-01-02 10:00:00'),
+		(4, 1, 'one shared newer', 'c', 'Nan', '2024-01-06 10:00:00'),
+		(5, 1, 'unrelated', 'c', 'Nan', '2024-01-07 10:00:00'),
+		(6, 1, 'unapproved', 'c', 'Nan', '2024-01-08 10:00:00')`)
+	exec(t, s, `UPDATE posts SET approved = FALSE WHERE id = 6`)
+	exec(t, s, `INSERT INTO post_category (category_id, post_id) VALUES
+		(1, 1), (2, 1),
+		(1, 2),
+		(1, 3), (2, 3),
+		(2, 4),
+		(3, 5),
+		(1, 6), (2, 6)`)
+
+	posts, err := s.GetRelatedPosts(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{3, 4, 2}
+	if len(*posts) != len(want) {
+		t.Fatalf("got %d posts; expected %d", len(*posts), len(want))
+	}
+	for i, post := range *posts {
+		if post.PostID != want[i] {
+			t.Errorf("post %d: got %d; expected %d", i, post.PostID, want[i])
+		}
+	}
+
+	posts, err = s.GetRelatedPosts(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*posts) != 1 || (*posts)[0].PostID != 3 {
+		t.Errorf("limit not applied: got %v", *posts)
+	}
+}
+
+func TestGetTrendingCategories(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', '')`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Science'), (3, 'Sports')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+		(1, 1, 'a', 'c', 'Nan', '2024-01-10 10:00:00'),
+		(2, 1, 'b', 'c', 'Nan', '2024-01-11 10:00:00'),
+		(3, 1, 'c', 'c', 'Nan', '2024-01-12 10:00:00'),
+		(4, 1, 'old', 'c', 'Nan', '2023-01-01 10:00:00'),
+		(5, 1, 'old', 'c', 'Nan', '2023-01-02 10:00:00')`)
+	exec(t, s, `INSERT INTO post_category (category_id, post_id) VALUES (2, 1), (2, 2), (1, 3), (3, 4), (3, 5)`)
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	categories, err := s.GetTrendingCategories(since, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Science", "Technology"}
+	if len(categories) != len(want) {
+		t.Fatalf("got %v; expected %v", categories, want)
+	}
+	for i := range want {
+		if categories[i] != want[i] {
+			t.Errorf("got %v; expected %v", categories, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"forum/models"
+	"time"
+)
+
+// CreatePasswordReset stores a new reset token and invalidates the ones the
+// user requested before, so only the latest link works.
+func (s *Sqlite) CreatePasswordReset(reset *models.PasswordReset) error {
+	op := "sqlite.CreatePasswordReset"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt := `UPDATE password_resets SET used = TRUE WHERE user_id = ? AND used = FALSE`
+	if _, err = tx.Exec(stmt, reset.UserID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt = `INSERT INTO password_resets (user_id, token_hash, expires, created) VALUES (?, ?, ?, CURRENT_TIMESTAMP)`
+	if _, err = tx.Exec(stmt, reset.UserID, reset.TokenHash, reset.Expires.UTC().Format(timestampLayout)); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tx.Commit()
+}
+
+// ResetPassword consumes the token and sets the new password in one
+// transaction. The user's sessions are dropped, so whoever was logged in
+// with the old password is logged out.
+func (s *Sqlite) ResetPassword(tokenHash string, hashedPassword []byte) (int, error) {
+	op := "sqlite.ResetPassword"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var userID int
+	stmt := `SELECT user_id FROM password_resets WHERE token_hash = ? AND used = FALSE AND expires > ?`
+	err = tx.QueryRow(stmt, tokenHash, time.Now().UTC().Format(timestampLayout)).Scan(&userID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, models.ErrInvalidResetToken
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt = `UPDATE password_resets SET used = TRUE WHERE user_id = ?`
+	if _, err = tx.Exec(stmt, userID); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt = `UPDATE users SET hashed_password = ? WHERE id = ?`
+	if _, err = tx.Exec(stmt, string(hashedPassword), userID); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt = `DELETE FROM sessions WHERE user_id = ?`
+	if _, err = tx.Exec(stmt, userID); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"errors"
+	"forum/models"
+	"testing"
+	"time"
+)
+
+func TestResetPassword(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', 'old')`)
+
+	newReset := func(ttl time.Duration) *models.PasswordReset {
+		t.Helper()
+		reset, err := models.NewPasswordReset(1, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.CreatePasswordReset(reset); err != nil {
+			t.Fatal(err)
+		}
+		return reset
+	}
+
+	t.Run("Token is stored hashed", func(t *testing.T) {
+		reset := newReset(time.Hour)
+		var count int
+		if err := s.db.QueryRow(`SELECT COUNT(*) FROM password_resets WHERE token_hash = ?`, reset.Token).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("plain token found in the database")
+		}
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		reset := newReset(-time.Minute)
+		_, err := s.ResetPassword(reset.TokenHash, []byte("new"))
+		if !errors.Is(err, models.ErrInvalidResetToken) {
+			t.Errorf("got %v; expected %v", err, models.ErrInvalidResetToken)
+		}
+	})
+
+	t.Run("Superseded token", func(t *testing.T) {
+		old := newReset(time.Hour)
+		newReset(time.Hour)
+		_, err := s.ResetPassword(old.TokenHash, []byte("new"))
+		if !errors.Is(err, models.ErrInvalidResetToken) {
+			t.Errorf("got %v; expected %v", err, models.ErrInvalidResetToken)
+		}
+	})
+
+	t.Run("Successful reset rejects reuse", func(t *testing.T) {
+		reset := newReset(time.Hour)
+		exec(t, s, `INSERT INTO sessions (user_id, token, exp_time) VALUES (1, 'token', '2999-01-01 00:00:00')`)
+
+		if _, err := s.ResetPassword(reset.TokenHash, []byte("new")); err != nil {
+			t.Fatal(err)
+		}
+		var password string
+		if err := s.db.QueryRow(`SELECT hashed_password FROM users WHERE id = 1`).Scan(&password); err != nil {
+			t.Fatal(err)
+		}
+		if password != "new" {
+			t.Errorf("password not updated: got %q", password)
+		}
+		var sessions int
+		if err := s.db.QueryRow(`SELECT COUNT(*) FROM sessions WHERE user_id = 1`).Scan(&sessions); err != nil {
+			t.Fatal(err)
+		}
+		if sessions != 0 {
+			t.Errorf("got %d sessions after reset; expected 0", sessions)
+		}
+
+		_, err := s.ResetPassword(reset.TokenHash, []byte("again"))
+		if !errors.Is(err, models.ErrInvalidResetToken) {
+			t.Errorf("got %v; expected %v", err, models.ErrInvalidResetToken)
+		}
+	})
+}
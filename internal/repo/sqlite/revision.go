@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"forum/models"
+)
+
+// UpdatePost saves the current title and content of the post as a revision
+// made by rev.EditorID, then replaces them.
+func (s *Sqlite) UpdatePost(rev *models.PostRevision, title, content string) error {
+	op := "sqlite.UpdatePost"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt := `INSERT INTO post_revisions (post_id, editor_id, by_moderator, silent, title, content, created)
+	SELECT id, ?, ?, ?, title, content, CURRENT_TIMESTAMP FROM posts WHERE id = ?`
+	result, err := tx.Exec(stmt, rev.EditorID, rev.ByModerator, rev.Silent, rev.PostID)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	saved, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if saved == 0 {
+		tx.Rollback()
+		return models.ErrNoRecord
+	}
+
+	stmt = `UPDATE posts SET title = ?, content = ? WHERE id = ?`
+	if _, err = tx.Exec(stmt, title, content, rev.PostID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tx.Commit()
+}
+
+// GetLastRevision returns the last edit of the post that marks it edited,
+// leaving out silent ones.
+func (s *Sqlite) GetLastRevision(postID int) (*models.PostRevision, error) {
+	op := "sqlite.GetLastRevision"
+	stmt := `SELECT r.id, r.post_id, r.editor_id, u.name, r.by_moderator, r.title, r.content, r.created
+	FROM post_revisions r
+	JOIN users u ON r.editor_id = u.id
+	WHERE r.post_id = ? AND NOT r.silent
+	ORDER BY r.id DESC
+	LIMIT 1`
+
+	var rev models.PostRevision
+	err := s.db.QueryRow(stmt, postID).Scan(&rev.ID, &rev.PostID, &rev.EditorID, &rev.EditorName, &rev.ByModerator, &rev.Title, &rev.Content, &rev.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &rev, nil
+}
@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"errors"
+	"forum/models"
+	"testing"
+)
+
+func TestUpdatePostRevisions(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password, status) VALUES (1, 'alice', 'alice@gmail.com', '', 0), (2, 'mod', 'mod@gmail.com', '', 1)`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'first', 'original', 'Nan')`)
+
+	if _, err := s.GetLastRevision(1); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("got %v; expected %v", err, models.ErrNoRecord)
+	}
+
+	if err := s.UpdatePost(&models.PostRevision{PostID: 1, EditorID: 1}, "second", "by author"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdatePost(&models.PostRevision{PostID: 1, EditorID: 2, ByModerator: true}, "third", "by mod"); err != nil {
+		t.Fatal(err)
+	}
+
+	rev, err := s.GetLastRevision(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev.EditorName != "mod" || !rev.ByModerator {
+		t.Errorf("got editor %q moderator %v; expected mod, true", rev.EditorName, rev.ByModerator)
+	}
+	if rev.Title != "second" || rev.Content != "by author" {
+		t.Errorf("revision must keep the previous version, got %q %q", rev.Title, rev.Content)
+	}
+
+	post, err := s.GetPostByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Title != "third" || post.Content != "by mod" {
+		t.Errorf("post not updated, got %q %q", post.Title, post.Content)
+	}
+
+	var byAuthor bool
+	if err := s.db.QueryRow(`SELECT by_moderator FROM post_revisions WHERE editor_id = 1`).Scan(&byAuthor); err != nil {
+		t.Fatal(err)
+	}
+	if byAuthor {
+		t.Errorf("author edit must not be flagged as a moderator edit")
+	}
+
+	err = s.UpdatePost(&models.PostRevision{PostID: 42, EditorID: 1}, "x", "y")
+	if !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("got %v; expected %v", err, models.ErrNoRecord)
+	}
+}
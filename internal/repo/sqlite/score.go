@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"fmt"
+	"forum/models"
+	"strings"
+)
+
+// GetPostScores returns the scores of the posts, each like adding and each
+// dislike taking away the weight of its user's status. Posts without
+// reactions are left out.
+func (s *Sqlite) GetPostScores(postIDs []int, weights models.VoteWeights) (map[int]int, error) {
+	op := "sqlite.GetPostScores"
+	scores := make(map[int]int)
+	if len(postIDs) == 0 {
+		return scores, nil
+	}
+
+	args := []any{weights[models.StatusModerator], weights[models.StatusAdmin], weights[models.StatusUser]}
+	for _, id := range postIDs {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(postIDs)), ", ")
+	stmt := fmt.Sprintf(`SELECT l.post_id, SUM(CASE WHEN l.is_like THEN 1 ELSE -1 END *
+		CASE u.status WHEN %d THEN ? WHEN %d THEN ? ELSE ? END)
+	FROM post_user_Like l
+	JOIN users u ON u.id = l.user_id
+	WHERE l.post_id IN (%s)
+	GROUP BY l.post_id`, models.StatusModerator, models.StatusAdmin, placeholders)
+
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var postID, score int
+		if err := rows.Scan(&postID, &score); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		scores[postID] = score
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return scores, nil
+}
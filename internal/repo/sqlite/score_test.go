@@ -0,0 +1,48 @@
+package sqlite
+
+import (
+	"forum/models"
+	"testing"
+)
+
+func TestGetPostScores(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password, status) VALUES
+		(1, 'user', 'user@gmail.com', '', ?), (2, 'other', 'other@gmail.com', '', ?),
+		(3, 'mod', 'mod@gmail.com', '', ?), (4, 'admin', 'admin@gmail.com', '', ?)`,
+		models.StatusUser, models.StatusUser, models.StatusModerator, models.StatusAdmin)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content) VALUES (1, 1, 'a', 'a'), (2, 1, 'b', 'b'), (3, 1, 'c', 'c')`)
+	// Post 1: two users dislike, an admin likes. Post 2: a moderator likes,
+	// a user dislikes. Post 3 has no reactions.
+	exec(t, s, `INSERT INTO post_user_Like (user_id, post_id, is_like) VALUES
+		(1, 1, FALSE), (2, 1, FALSE), (4, 1, TRUE), (3, 2, TRUE), (1, 2, FALSE)`)
+
+	tests := []struct {
+		name    string
+		weights models.VoteWeights
+		want    map[int]int
+	}{
+		{name: "Equal weights", weights: models.DefaultVoteWeights, want: map[int]int{1: -1, 2: 0}},
+		{name: "Trusted count more", weights: models.VoteWeights{1, 2, 3}, want: map[int]int{1: 1, 2: 1}},
+		{name: "Users ignored", weights: models.VoteWeights{0, 1, 1}, want: map[int]int{1: 1, 2: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores, err := s.GetPostScores([]int{1, 2, 3}, tt.weights)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(scores) != len(tt.want) {
+				t.Fatalf("got %v; want %v", scores, tt.want)
+			}
+			for id, want := range tt.want {
+				if scores[id] != want {
+					t.Errorf("post %d: got score %d; want %d", id, scores[id], want)
+				}
+			}
+		})
+	}
+
+}
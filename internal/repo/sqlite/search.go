@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"fmt"
+	"forum/models"
+	"strings"
+	"time"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchConditions turns the filter into a WHERE clause, skipping the empty
+// parts of it.
+func searchConditions(filter models.SearchFilter) (string, []any) {
+	conditions := []string{"p.approved"}
+	var args []any
+
+	if filter.Query != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Query) + "%"
+		conditions = append(conditions, `(p.title LIKE ? ESCAPE '\' OR p.content LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	if filter.Author != "" {
+		conditions = append(conditions, `u.name = ?`)
+		args = append(args, filter.Author)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, `datetime(p.created) >= ?`)
+		args = append(args, filter.From.Format(timestampLayout))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, `datetime(p.created) < ?`)
+		args = append(args, filter.To.Add(24*time.Hour).Format(timestampLayout))
+	}
+	if filter.CategoryID != 0 {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM post_category pc WHERE pc.post_id = p.id AND pc.category_id = ?)`)
+		args = append(args, filter.CategoryID)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func (s *Sqlite) SearchPostsPaginated(filter models.SearchFilter, page, pageSize int) (*[]models.Post, error) {
+	op := "sqlite.SearchPostsPaginated"
+	offset := (page - 1) * pageSize
+	where, args := searchConditions(filter)
+	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved)
+	FROM posts p
+	JOIN users u ON p.user_id = u.id
+	WHERE ` + where + `
+	ORDER BY p.created DESC
+	LIMIT ? OFFSET ?`
+
+	rows, err := s.db.Query(stmt, append(args, pageSize, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		posts = append(posts, post)
+	}
+	return &posts, nil
+}
+
+// SearchCount returns how many posts match filter, over all pages of
+// SearchPostsPaginated.
+func (s *Sqlite) SearchCount(filter models.SearchFilter) (int, error) {
+	var total int
+	op := "sqlite.SearchCount"
+	where, args := searchConditions(filter)
+	stmt := `SELECT COUNT(*)
+	FROM posts p
+	JOIN users u ON p.user_id = u.id
+	WHERE ` + where
+
+	if err := s.db.QueryRow(stmt, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return total, nil
+}
+
+// GetSuggestions returns up to limit categories, users and posts each whose
+// name starts with prefix, or whose title has a word that does. Titles that
+// start with it come first, then newer posts.
+func (s *Sqlite) GetSuggestions(prefix string, limit int) ([]models.Suggestion, error) {
+	op := "sqlite.GetSuggestions"
+	pattern := likeEscaper.Replace(prefix) + "%"
+	stmt := `SELECT * FROM (SELECT ?, name, id FROM category WHERE name LIKE ? ESCAPE '\' ORDER BY name LIMIT ?)
+	UNION ALL
+	SELECT * FROM (SELECT ?, name, id FROM users WHERE name LIKE ? ESCAPE '\' AND NOT is_guest ORDER BY name LIMIT ?)
+	UNION ALL
+	SELECT * FROM (SELECT ?, title, id FROM posts WHERE approved AND (title LIKE ? ESCAPE '\' OR title LIKE ? ESCAPE '\')
+		ORDER BY title LIKE ? ESCAPE '\' DESC, created DESC, id DESC LIMIT ?)`
+
+	rows, err := s.db.Query(stmt,
+		models.SuggestCategory, pattern, limit,
+		models.SuggestUser, pattern, limit,
+		models.SuggestPost, pattern, "% "+pattern, pattern, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var suggestions []models.Suggestion
+	for rows.Next() {
+		var suggestion models.Suggestion
+		if err := rows.Scan(&suggestion.Kind, &suggestion.Text, &suggestion.ID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return suggestions, nil
+}
+
+// SuggestMentions returns up to limit users whose names start with prefix,
+// the people on the post postID first, then by name. Users with a private
+// profile are left out unless they are on the post or are viewerID. Being
+// on a post means having written it or commented on it.
+func (s *Sqlite) SuggestMentions(prefix string, viewerID, postID, limit int) ([]models.MentionSuggestion, error) {
+	op := "sqlite.SuggestMentions"
+	stmt := `SELECT name, display_name FROM (
+		SELECT u.name, u.display_name, u.privacy, u.id,
+			u.id IN (SELECT user_id FROM posts WHERE id = ?1 UNION SELECT user_id FROM comments WHERE post_id = ?1) AS on_post
+		FROM users u
+		WHERE u.name LIKE ?2 ESCAPE '\' AND NOT u.is_guest)
+	WHERE privacy != ?3 OR on_post OR id = ?4
+	ORDER BY on_post DESC, name
+	LIMIT ?5`
+
+	rows, err := s.db.Query(stmt, postID, likeEscaper.Replace(prefix)+"%", models.PrivacyPrivate, viewerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var users []models.MentionSuggestion
+	for rows.Next() {
+		var user models.MentionSuggestion
+		if err := rows.Scan(&user.Name, &user.DisplayName); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return users, nil
+}
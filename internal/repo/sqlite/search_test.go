@@ -0,0 +1,270 @@
+package sqlite
+
+import (
+	"forum/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSearchPostsPaginated(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Sports')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+		(1, 1, 'Learning Go', 'goroutines are fun', 'Nan', '2024-01-01 10:00:00'),
+		(2, 2, 'Go tips', 'use gofmt', 'Nan', '2024-01-10 10:00:00'),
+		(3, 1, 'Football', 'nothing about code', 'Nan', '2024-01-20 10:00:00'),
+		(4, 1, '100% sure', 'percent', 'Nan', '2024-01-21 10:00:00')`)
+	exec(t, s, `INSERT INTO post_category (category_id, post_id) VALUES (1, 1), (1, 2), (2, 3)`)
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse(models.DateLayout, s)
+		return d
+	}
+
+	tests := []struct {
+		name   string
+		filter models.SearchFilter
+		want   []int
+	}{
+		{
+			name:   "Text only",
+			filter: models.SearchFilter{Query: "go"},
+			want:   []int{2, 1},
+		},
+		{
+			name:   "Text and author",
+			filter: models.SearchFilter{Query: "go", Author: "alice"},
+			want:   []int{1},
+		},
+		{
+			name:   "Author only",
+			filter: models.SearchFilter{Author: "alice"},
+			want:   []int{4, 3, 1},
+		},
+		{
+			name:   "Date range is inclusive",
+			filter: models.SearchFilter{From: day("2024-01-10"), To: day("2024-01-20")},
+			want:   []int{3, 2},
+		},
+		{
+			name:   "Category",
+			filter: models.SearchFilter{CategoryID: 1},
+			want:   []int{2, 1},
+		},
+		{
+			name:   "Wildcards are literal",
+			filter: models.SearchFilter{Query: "%"},
+			want:   []int{4},
+		},
+		{
+			name: "No filters",
+			want: []int{4, 3, 2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, err := s.SearchPostsPaginated(tt.filter, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(*posts) != len(tt.want) {
+				t.Fatalf("got %d posts; expected %d", len(*posts), len(tt.want))
+			}
+			for i, post := range *posts {
+				if post.PostID != tt.want[i] {
+					t.Errorf("post %d: got %d; expected %d", i, post.PostID, tt.want[i])
+				}
+			}
+
+			total, err := s.SearchCount(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != len(tt.want) {
+				t.Errorf("got a count of %d; expected %d", total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestSearchCount(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'alice', 'alice@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	for i := 1; i <= 23; i++ {
+		title := "Go news"
+		if i%3 == 0 {
+			title = "Weather"
+		}
+		exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES (?, ?, ?, 'content', 'Nan', datetime('2024-01-01', ? || ' hours'))`,
+			i, 1+i%2, title, i)
+	}
+
+	for _, filter := range []models.SearchFilter{
+		{},
+		{Query: "go"},
+		{Query: "go", Author: "bob"},
+		{Query: "nothing"},
+	} {
+		total, err := s.SearchCount(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := map[int]bool{}
+		for page := 1; ; page++ {
+			posts, err := s.SearchPostsPaginated(filter, page, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(*posts) == 0 {
+				break
+			}
+			for _, post := range *posts {
+				if seen[post.PostID] {
+					t.Errorf("%+v: post %d is on two pages", filter, post.PostID)
+				}
+				seen[post.PostID] = true
+			}
+		}
+		if total != len(seen) {
+			t.Errorf("%+v: got a count of %d; expected %d", filter, total, len(seen))
+		}
+	}
+}
+
+func TestGetSuggestions(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password) VALUES (1, 'gopher', 'gopher@gmail.com', ''), (2, 'bob', 'bob@gmail.com', '')`)
+	exec(t, s, `INSERT INTO users (id, name, email, hashed_password, is_guest) VALUES (3, 'guest', 'guest@localhost', '', TRUE)`)
+	exec(t, s, `INSERT INTO category (id, name) VALUES (1, 'Golang'), (2, 'Sports')`)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+		(1, 1, 'Learning go', 'c', 'Nan', '2024-01-01 10:00:00'),
+		(2, 2, 'Go tips', 'c', 'Nan', '2024-01-10 10:00:00'),
+		(3, 1, 'Ergonomics', 'c', 'Nan', '2024-01-20 10:00:00'),
+		(4, 2, 'Good_news', 'c', 'Nan', '2024-01-21 10:00:00')`)
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []models.Suggestion
+	}{
+		{
+			name:   "All kinds",
+			prefix: "go",
+			limit:  5,
+			want: []models.Suggestion{
+				{Kind: models.SuggestCategory, Text: "Golang", ID: 1},
+				{Kind: models.SuggestUser, Text: "gopher", ID: 1},
+				{Kind: models.SuggestPost, Text: "Good_news", ID: 4},
+				{Kind: models.SuggestPost, Text: "Go tips", ID: 2},
+				{Kind: models.SuggestPost, Text: "Learning go", ID: 1},
+			},
+		},
+		{
+			name:   "Capped per kind",
+			prefix: "go",
+			limit:  1,
+			want: []models.Suggestion{
+				{Kind: models.SuggestCategory, Text: "Golang", ID: 1},
+				{Kind: models.SuggestUser, Text: "gopher", ID: 1},
+				{Kind: models.SuggestPost, Text: "Good_news", ID: 4},
+			},
+		},
+		{
+			name:   "Wildcards are literal",
+			prefix: "good_",
+			limit:  5,
+			want:   []models.Suggestion{{Kind: models.SuggestPost, Text: "Good_news", ID: 4}},
+		},
+		{
+			name:   "Guests aren't suggested",
+			prefix: "gu",
+			limit:  5,
+		},
+		{
+			name:   "Prefix only",
+			prefix: "ports",
+			limit:  5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetSuggestions(tt.prefix, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v; expected %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("suggestion %d: got %+v; expected %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestMentions(t *testing.T) {
+	s := newTestDB(t)
+
+	exec(t, s, `INSERT INTO users (id, name, display_name, email, hashed_password, privacy) VALUES
+		(1, 'alice', 'Alice A.', 'alice@gmail.com', '', 0),
+		(2, 'albert', '', 'albert@gmail.com', '', 1),
+		(3, 'alfred', '', 'alfred@gmail.com', '', 2),
+		(4, 'alma', '', 'alma@gmail.com', '', 0),
+		(5, 'bob', '', 'bob@gmail.com', '', 0),
+		(6, 'al_x', '', 'alx@gmail.com', '', 0)`)
+	exec(t, s, `INSERT INTO users (name, email, hashed_password, is_guest) VALUES (?, 'guest@gmail.com', '', TRUE)`, models.GuestAccount)
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 4, 'hello', 'c', 'Nan')`)
+	exec(t, s, `INSERT INTO comments (post_id, user_id, content) VALUES (1, 3, 'hi')`)
+
+	names := func(prefix string, viewerID, postID, limit int) []string {
+		t.Helper()
+		users, err := s.SuggestMentions(prefix, viewerID, postID, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, u := range users {
+			names = append(names, u.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name     string
+		prefix   string
+		viewerID int
+		postID   int
+		limit    int
+		want     []string
+	}{
+		{name: "Prefix", prefix: "AL", viewerID: 5, limit: 10, want: []string{"al_x", "albert", "alice", "alma"}},
+		{name: "Wildcards are literal", prefix: "al_", viewerID: 5, limit: 10, want: []string{"al_x"}},
+		{name: "Capped", prefix: "al", viewerID: 5, limit: 2, want: []string{"al_x", "albert"}},
+		{name: "Private profile is left out", prefix: "alf", viewerID: 5, limit: 10, want: nil},
+		{name: "Private profile sees itself", prefix: "alf", viewerID: 3, limit: 10, want: []string{"alfred"}},
+		{name: "People on the post first", prefix: "al", viewerID: 5, postID: 1, limit: 3, want: []string{"alfred", "alma", "al_x"}},
+		{name: "Guest account", prefix: models.GuestAccount, viewerID: 5, limit: 10, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(tt.prefix, tt.viewerID, tt.postID, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q; expected %q", got, tt.want)
+			}
+		})
+	}
+
+	users, err := s.SuggestMentions("alice", 5, 0, 10)
+	if err != nil || len(users) != 1 || users[0].DisplayName != "Alice A." {
+		t.Errorf("got %+v, %v; expected alice shown as Alice A.", users, err)
+	}
+}
@@ -23,8 +23,8 @@ func (s *Sqlite) GetUserIDByToken(token string) (int, error) {
 
 func (s *Sqlite) CreateSession(session *models.Session) error {
 	op := "sqlite.CreateSession"
-	stmt := `INSERT INTO sessions(user_id, token, exp_time) VALUES(?, ?, ?)`
-	_, err := s.db.Exec(stmt, session.UserID, session.Token, session.ExpTime)
+	stmt := `INSERT INTO sessions(user_id, token, exp_time, last_seen) VALUES(?, ?, ?, ?)`
+	_, err := s.db.Exec(stmt, session.UserID, session.Token, session.ExpTime, lastSeen(session))
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -68,3 +68,78 @@ func (s *Sqlite) DeleteSessionByToken(token string) error {
 	}
 	return nil
 }
+
+// SessionStore keeps sessions in the sessions table of the database.
+type SessionStore struct {
+	db *sql.DB
+}
+
+// Sessions returns the sessions table of s as a session store.
+func (s *Sqlite) Sessions() *SessionStore {
+	return &SessionStore{db: s.db}
+}
+
+// Get returns the session of token, or ErrNoRecord if there is none or it
+// expired.
+func (s *SessionStore) Get(token string) (*models.Session, error) {
+	op := "sqlite.SessionStore.Get"
+	stmt := `SELECT user_id, token, exp_time, last_seen FROM sessions WHERE token = ?`
+	var session models.Session
+	var lastSeen sql.NullTime
+	err := s.db.QueryRow(stmt, token).Scan(&session.UserID, &session.Token, &session.ExpTime, &lastSeen)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !session.ExpTime.After(time.Now()) {
+		return nil, models.ErrNoRecord
+	}
+	session.LastSeen = lastSeen.Time
+	return &session, nil
+}
+
+// Set stores session, dropping the expired sessions of its user on the way.
+func (s *SessionStore) Set(session *models.Session) error {
+	op := "sqlite.SessionStore.Set"
+	stmt := `DELETE FROM sessions WHERE user_id = ? AND datetime(exp_time) <= ?`
+	if _, err := s.db.Exec(stmt, session.UserID, time.Now().UTC().Format(timestampLayout)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	stmt = `INSERT INTO sessions(user_id, token, exp_time, last_seen) VALUES(?, ?, ?, ?)`
+	if _, err := s.db.Exec(stmt, session.UserID, session.Token, session.ExpTime, lastSeen(session)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Touch sets the LastSeen of the session of token, if there is one.
+func (s *SessionStore) Touch(token string, lastSeen time.Time) error {
+	op := "sqlite.SessionStore.Touch"
+	if _, err := s.db.Exec(`UPDATE sessions SET last_seen = ? WHERE token = ?`, lastSeen, token); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *SessionStore) Delete(token string) error {
+	op := "sqlite.SessionStore.Delete"
+	if _, err := s.db.Exec(`DELETE FROM sessions WHERE token = ?`, token); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *SessionStore) DeleteByUser(userID int) error {
+	op := "sqlite.SessionStore.DeleteByUser"
+	if _, err := s.db.Exec(`DELETE FROM sessions WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// lastSeen is the LastSeen of session as stored, NULL when it has none.
+func lastSeen(session *models.Session) sql.NullTime {
+	return sql.NullTime{Time: session.LastSeen, Valid: !session.LastSeen.IsZero()}
+}
@@ -3,6 +3,7 @@ package sqlite
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 )
 
 type Sqlite struct {
@@ -82,6 +83,141 @@ func NewDB(storagePath string) (*Sqlite, error) {
 			FOREIGN KEY (user_id) REFERENCES users(user_id),
 			FOREIGN KEY (comment_id) REFERENCES comments(comment_id)
 		);`,
+		`CREATE TABLE IF NOT EXISTS invites (
+			id INTEGER PRIMARY KEY,
+			code TEXT NOT NULL UNIQUE,
+			inviter_id INTEGER NOT NULL,
+			invitee_id INTEGER,
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (inviter_id) REFERENCES users(id),
+			FOREIGN KEY (invitee_id) REFERENCES users(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS category_moderators (
+			user_id INTEGER NOT NULL,
+			category_id INTEGER NOT NULL,
+			PRIMARY KEY (user_id, category_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (category_id) REFERENCES category(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS follows (
+			follower_id INTEGER NOT NULL,
+			followee_id INTEGER NOT NULL,
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (follower_id, followee_id),
+			FOREIGN KEY (follower_id) REFERENCES users(id),
+			FOREIGN KEY (followee_id) REFERENCES users(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS post_subscriptions (
+			user_id INTEGER NOT NULL,
+			post_id INTEGER NOT NULL,
+			subscribed BOOLEAN NOT NULL DEFAULT TRUE,
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, post_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (post_id) REFERENCES posts(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS post_views (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			post_id INTEGER NOT NULL,
+			UNIQUE (user_id, post_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (post_id) REFERENCES posts(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS backup_imports (
+			source TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			old_id INTEGER NOT NULL,
+			new_id INTEGER NOT NULL,
+			PRIMARY KEY (source, kind, old_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS notifications (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			actor_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			post_id INTEGER NOT NULL,
+			comment_id INTEGER,
+			read BOOLEAN NOT NULL DEFAULT FALSE,
+			notified BOOLEAN NOT NULL DEFAULT FALSE,
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (actor_id) REFERENCES users(id),
+			FOREIGN KEY (post_id) REFERENCES posts(id),
+			FOREIGN KEY (comment_id) REFERENCES comments(id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications (user_id, read);`,
+		`CREATE TABLE IF NOT EXISTS post_revisions (
+			id INTEGER PRIMARY KEY,
+			post_id INTEGER NOT NULL,
+			editor_id INTEGER NOT NULL,
+			by_moderator BOOLEAN NOT NULL DEFAULT FALSE,
+			title TEXT NOT NULL,
+			content TEXT NOT NULL,
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (post_id) REFERENCES posts(id),
+			FOREIGN KEY (editor_id) REFERENCES users(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY,
+			actor_id INTEGER,
+			action TEXT NOT NULL,
+			target TEXT NOT NULL DEFAULT '',
+			ip TEXT NOT NULL DEFAULT '',
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (actor_id) REFERENCES users(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS password_resets (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			expires TIMESTAMP NOT NULL,
+			used BOOLEAN NOT NULL DEFAULT FALSE,
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS drafts (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			session TEXT NOT NULL,
+			title TEXT NOT NULL DEFAULT '',
+			content TEXT NOT NULL DEFAULT '',
+			updated TIMESTAMP NOT NULL,
+			UNIQUE (user_id, session),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS announcements (
+			id INTEGER PRIMARY KEY,
+			message TEXT NOT NULL,
+			category_id INTEGER NOT NULL DEFAULT 0,
+			post_id INTEGER NOT NULL DEFAULT 0,
+			starts_at TIMESTAMP NOT NULL,
+			ends_at TIMESTAMP NOT NULL,
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS post_labels (
+			post_id INTEGER NOT NULL,
+			label TEXT NOT NULL,
+			PRIMARY KEY (post_id, label),
+			FOREIGN KEY (post_id) REFERENCES posts(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS post_reactions (
+			user_id INTEGER NOT NULL,
+			post_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, post_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (post_id) REFERENCES posts(id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS category_subscriptions (
+			user_id INTEGER NOT NULL,
+			category_id INTEGER NOT NULL,
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, category_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (category_id) REFERENCES category(id)
+		);`,
 	}
 
 	for _, query := range tableCreationQueries {
@@ -96,6 +232,58 @@ func NewDB(storagePath string) (*Sqlite, error) {
 		stmt.Close()
 	}
 
+	// Columns added after the first release. SQLite has no
+	// "ADD COLUMN IF NOT EXISTS", so an already applied migration is skipped
+	// by its duplicate column error.
+	alterTableQueries := []string{
+		`ALTER TABLE comments ADD COLUMN quoted_comment_id INTEGER REFERENCES comments(id)`,
+		`ALTER TABLE comments ADD COLUMN quote_excerpt TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE post_user_Like ADD COLUMN created TIMESTAMP`,
+		`ALTER TABLE comment_user_Like ADD COLUMN created TIMESTAMP`,
+		`ALTER TABLE users ADD COLUMN privacy INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE posts ADD COLUMN accepted_answer_comment_id INTEGER REFERENCES comments(id)`,
+		`ALTER TABLE posts ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE posts ADD COLUMN approved BOOLEAN NOT NULL DEFAULT TRUE`,
+		`ALTER TABLE comments ADD COLUMN approved BOOLEAN NOT NULL DEFAULT TRUE`,
+		`ALTER TABLE posts ADD COLUMN profile_pinned BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE users ADD COLUMN digest INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE users ADD COLUMN last_digest TIMESTAMP`,
+		`ALTER TABLE users ADD COLUMN sort TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE posts ADD COLUMN lock_reason TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE posts ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE posts ADD COLUMN guest_name TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE posts ADD COLUMN guest_ip TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE comments ADD COLUMN guest_name TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE comments ADD COLUMN guest_ip TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE category ADD COLUMN comment_mode TEXT NOT NULL DEFAULT 'flat'`,
+		`ALTER TABLE category ADD COLUMN post_status INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE posts ADD COLUMN deleted BOOLEAN NOT NULL DEFAULT FALSE`,
+		// NULL until the first comment, see lastActivity.
+		`ALTER TABLE posts ADD COLUMN last_activity TIMESTAMP`,
+		backfillLastActivity,
+		`ALTER TABLE comments ADD COLUMN deleted BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE post_revisions ADD COLUMN silent BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE sessions ADD COLUMN last_seen TIMESTAMP`,
+		`ALTER TABLE users ADD COLUMN notifications_read INTEGER NOT NULL DEFAULT 0`,
+		// Users from before have no key, NULLs never clash.
+		`ALTER TABLE users ADD COLUMN email_key TEXT`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_key ON users (email_key)`,
+		`ALTER TABLE users ADD COLUMN is_guest BOOLEAN NOT NULL DEFAULT FALSE`,
+		// The guest account used to be known by its name alone.
+		`UPDATE users SET is_guest = TRUE
+			WHERE name = 'guest' AND email = 'guest@localhost' AND hashed_password = ''
+			AND NOT EXISTS (SELECT 1 FROM users WHERE is_guest)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_guest ON users (is_guest) WHERE is_guest`,
+	}
+
+	for _, query := range alterTableQueries {
+		if _, err := db.Exec(query); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
 	// defaultCategories := []string{"Technology", "Entertainment", "Sports", "Education"}
 	// for _, category := range defaultCategories {
 	// 	insertQuery := `INSERT INTO category (name) VALUES (?)`
@@ -110,5 +298,9 @@ func NewDB(storagePath string) (*Sqlite, error) {
 	// 	stmt.Close()
 	// }
 
-	return &Sqlite{db: db}, nil
+	s := &Sqlite{db: db}
+	if err := s.moveGuestPosts(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s, nil
 }
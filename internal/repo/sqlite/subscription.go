@@ -0,0 +1,184 @@
+package sqlite
+
+import (
+	"fmt"
+	"forum/models"
+	"strings"
+)
+
+// Subscribe subscribes the user to the post unless they already decided
+// either way.
+func (s *Sqlite) Subscribe(userID, postID int) error {
+	op := "sqlite.Subscribe"
+	stmt := `INSERT OR IGNORE INTO post_subscriptions (user_id, post_id, subscribed, created) VALUES (?, ?, TRUE, CURRENT_TIMESTAMP)`
+	if _, err := s.db.Exec(stmt, userID, postID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) SetSubscription(sub models.Subscription) error {
+	op := "sqlite.SetSubscription"
+	stmt := `INSERT INTO post_subscriptions (user_id, post_id, subscribed, created) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+	ON CONFLICT (user_id, post_id) DO UPDATE SET subscribed = excluded.subscribed`
+	if _, err := s.db.Exec(stmt, sub.UserID, sub.PostID, sub.Subscribed); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// IsSubscribed reports the user's choice for the post. Without one, only the
+// author of the post is subscribed.
+func (s *Sqlite) IsSubscribed(userID, postID int) (bool, error) {
+	op := "sqlite.IsSubscribed"
+	stmt := `SELECT COALESCE(
+		(SELECT subscribed FROM post_subscriptions WHERE user_id = ? AND post_id = ?),
+		EXISTS(SELECT 1 FROM posts WHERE id = ? AND user_id = ?))`
+	var ok bool
+	if err := s.db.QueryRow(stmt, userID, postID, postID, userID).Scan(&ok); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
+
+// GetSubscribers returns the ids of the users subscribed to the post,
+// including its author unless they unsubscribed.
+func (s *Sqlite) GetSubscribers(postID int) ([]int, error) {
+	op := "sqlite.GetSubscribers"
+	stmt := `SELECT user_id FROM post_subscriptions WHERE post_id = ? AND subscribed
+	UNION
+	SELECT p.user_id FROM posts p
+	WHERE p.id = ? AND NOT EXISTS (SELECT 1 FROM post_subscriptions s WHERE s.post_id = p.id AND s.user_id = p.user_id)
+	ORDER BY user_id`
+
+	rows, err := s.db.Query(stmt, postID, postID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
+func (s *Sqlite) SubscribeCategory(sub models.CategorySubscription) error {
+	op := "sqlite.SubscribeCategory"
+	stmt := `INSERT OR IGNORE INTO category_subscriptions (user_id, category_id, created) VALUES (?, ?, CURRENT_TIMESTAMP)`
+	if _, err := s.db.Exec(stmt, sub.UserID, sub.CategoryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) UnsubscribeCategory(sub models.CategorySubscription) error {
+	op := "sqlite.UnsubscribeCategory"
+	stmt := `DELETE FROM category_subscriptions WHERE user_id = ? AND category_id = ?`
+	if _, err := s.db.Exec(stmt, sub.UserID, sub.CategoryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) IsSubscribedCategory(userID, categoryID int) (bool, error) {
+	op := "sqlite.IsSubscribedCategory"
+	stmt := `SELECT EXISTS(SELECT 1 FROM category_subscriptions WHERE user_id = ? AND category_id = ?)`
+	var ok bool
+	if err := s.db.QueryRow(stmt, userID, categoryID).Scan(&ok); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
+
+// GetCategorySubscribers returns the ids of the users subscribed to any of
+// the categories, each once.
+func (s *Sqlite) GetCategorySubscribers(categoryIDs []int) ([]int, error) {
+	op := "sqlite.GetCategorySubscribers"
+	if len(categoryIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(categoryIDs))
+	for i, id := range categoryIDs {
+		args[i] = id
+	}
+	stmt := `SELECT DISTINCT user_id FROM category_subscriptions
+	WHERE category_id IN (?` + strings.Repeat(", ?", len(categoryIDs)-1) + `)
+	ORDER BY user_id`
+
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
+// GetSubscribedCategoriesPostsPaginated returns the posts in the categories
+// userID subscribed to, newest first.
+func (s *Sqlite) GetSubscribedCategoriesPostsPaginated(userID, page, pageSize int) (*[]models.Post, error) {
+	op := "sqlite.GetSubscribedCategoriesPostsPaginated"
+	offset := (page - 1) * pageSize
+	stmt := `SELECT p.id, p.user_id, p.title, p.content, p.created, p.like, p.dislike, p.image_name, u.name, COALESCE(NULLIF(p.guest_name, ''), u.display_name), (SELECT COUNT(*) FROM comments c WHERE c.post_id=p.id AND c.approved)
+	FROM posts p
+	JOIN users u ON p.user_id = u.id
+	WHERE p.id IN (SELECT pc.post_id FROM post_category pc
+		JOIN category_subscriptions cs ON cs.category_id = pc.category_id
+		WHERE cs.user_id = ?) AND p.approved
+	ORDER BY p.created DESC, p.id DESC
+	LIMIT ? OFFSET ?`
+
+	rows, err := s.db.Query(stmt, userID, pageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Content, &post.Created, &post.Like, &post.Dislike, &post.ImageName, &post.UserName, &post.DisplayName, &post.CommentCount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &posts, nil
+}
+
+func (s *Sqlite) GetPageNumberSubscribedCategories(pageSize int, userID int) (int, error) {
+	var total int
+	op := "sqlite.GetPageNumberSubscribedCategories"
+	stmt := `SELECT COUNT(DISTINCT pc.post_id)
+	FROM post_category pc
+	JOIN category_subscriptions cs ON cs.category_id = pc.category_id
+	JOIN posts p ON p.id = pc.post_id
+	WHERE cs.user_id = ? AND p.approved`
+
+	if err := s.db.QueryRow(stmt, userID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return (total + pageSize - 1) / pageSize, nil
+}
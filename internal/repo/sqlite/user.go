@@ -12,8 +12,8 @@ import (
 func (s *Sqlite) GetUserByEmail(email string) (*models.User, error) {
 	op := "sqlite.GetUserByEmail"
 	var u models.User
-	stmt := `SELECT id, name, email, created FROM users WHERE id=?`
-	err := s.db.QueryRow(stmt, email).Scan(&u.ID, &u.Name, &u.Email, &u.Created)
+	stmt := `SELECT id, name, email, created, status, privacy, digest, sort, display_name, timezone FROM users WHERE email=?`
+	err := s.db.QueryRow(stmt, email).Scan(&u.ID, &u.Name, &u.Email, &u.Created, &u.Status, &u.Privacy, &u.Digest, &u.Sort, &u.DisplayName, &u.Timezone)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrNoRecord
@@ -25,12 +25,57 @@ func (s *Sqlite) GetUserByEmail(email string) (*models.User, error) {
 
 func (s *Sqlite) UpdateUserByID(string) (*models.User, error) { return nil, nil }
 
+func (s *Sqlite) UpdateUserPrivacy(userID, privacy int) error {
+	op := "sqlite.UpdateUserPrivacy"
+	stmt := `UPDATE users SET privacy = ? WHERE id = ?`
+	if _, err := s.db.Exec(stmt, privacy, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) UpdateUserDigest(userID, digest int) error {
+	op := "sqlite.UpdateUserDigest"
+	stmt := `UPDATE users SET digest = ? WHERE id = ?`
+	if _, err := s.db.Exec(stmt, digest, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) UpdateUserSort(userID int, sort string) error {
+	op := "sqlite.UpdateUserSort"
+	stmt := `UPDATE users SET sort = ? WHERE id = ?`
+	if _, err := s.db.Exec(stmt, sort, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) UpdateUserDisplayName(userID int, displayName string) error {
+	op := "sqlite.UpdateUserDisplayName"
+	stmt := `UPDATE users SET display_name = ? WHERE id = ?`
+	if _, err := s.db.Exec(stmt, displayName, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Sqlite) UpdateUserTimezone(userID int, zone string) error {
+	op := "sqlite.UpdateUserTimezone"
+	stmt := `UPDATE users SET timezone = ? WHERE id = ?`
+	if _, err := s.db.Exec(stmt, zone, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
 func (s *Sqlite) CreateUser(u models.User) error {
 	op := "sqlite.CreateUser"
-	stmt := `INSERT INTO users (name, email,hashed_password, created) VALUES(?, ?, ?, CURRENT_TIMESTAMP)`
-	_, err := s.db.Exec(stmt, u.Name, u.Email, string(u.HashedPassword))
+	stmt := `INSERT INTO users (name, display_name, email, email_key, hashed_password, created) VALUES(?, ?, ?, NULLIF(?, ''), ?, CURRENT_TIMESTAMP)`
+	_, err := s.db.Exec(stmt, u.Name, u.DisplayName, u.Email, u.EmailKey, string(u.HashedPassword))
 	if err != nil {
-		if err.Error() == "UNIQUE constraint failed: users.email" {
+		if err.Error() == "UNIQUE constraint failed: users.email" || err.Error() == "UNIQUE constraint failed: users.email_key" {
 			return models.ErrDuplicateEmail
 		}
 		if err.Error() == "UNIQUE constraint failed: users.name" {
@@ -44,8 +89,8 @@ func (s *Sqlite) CreateUser(u models.User) error {
 func (s *Sqlite) GetUserByID(id int) (*models.User, error) {
 	op := "sqlite.GetUserByID"
 	var u models.User
-	stmt := `SELECT id, name, email, created FROM users WHERE id=?`
-	err := s.db.QueryRow(stmt, id).Scan(&u.ID, &u.Name, &u.Email, &u.Created)
+	stmt := `SELECT id, name, email, created, status, privacy, digest, sort, display_name, timezone FROM users WHERE id=?`
+	err := s.db.QueryRow(stmt, id).Scan(&u.ID, &u.Name, &u.Email, &u.Created, &u.Status, &u.Privacy, &u.Digest, &u.Sort, &u.DisplayName, &u.Timezone)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrNoRecord
@@ -55,24 +100,66 @@ func (s *Sqlite) GetUserByID(id int) (*models.User, error) {
 	return &u, nil
 }
 
+func (s *Sqlite) GetUserNames() ([]string, error) {
+	op := "sqlite.GetUserNames"
+	rows, err := s.db.Query(`SELECT name FROM users`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return names, nil
+}
+
+func (s *Sqlite) GetUserByName(name string) (*models.User, error) {
+	op := "sqlite.GetUserByName"
+	var u models.User
+	stmt := `SELECT id, name, email, created, status, privacy, digest, sort, display_name, timezone FROM users WHERE name=?`
+	err := s.db.QueryRow(stmt, name).Scan(&u.ID, &u.Name, &u.Email, &u.Created, &u.Status, &u.Privacy, &u.Digest, &u.Sort, &u.DisplayName, &u.Timezone)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &u, nil
+}
+
+// dummyHash is a bcrypt hash of no one's password, at the cost of real
+// ones, compared against for unknown emails.
+var dummyHash = []byte("$2a$12$i8vjvPjNVa6j6TcPnREiCuUKwImFgjGPBmUidM3Bur4cPWErlxp.2")
+
+// compareHash is bcrypt.CompareHashAndPassword, counted by tests.
+var compareHash = bcrypt.CompareHashAndPassword
+
+// Authenticate returns the id of the user with the email and password, and
+// models.ErrInvalidCredentials for an unknown email as for a wrong password.
+// Both take a bcrypt comparison, so logins can't tell which emails have
+// accounts by the time they take either.
 func (s *Sqlite) Authenticate(email, password string) (int, error) {
 	op := "sqlite.Authenticate"
 	var id int
 	var hashed_password []byte
 	stmt := `SELECT id, hashed_password FROM users WHERE email=?`
 	err := s.db.QueryRow(stmt, email).Scan(&id, &hashed_password)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return 0, models.ErrNoRecord
-		}
+	if errors.Is(err, sql.ErrNoRows) {
+		id, hashed_password = 0, dummyHash
+	} else if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	err = bcrypt.CompareHashAndPassword(hashed_password, []byte(password))
-	if err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return 0, models.ErrInvalidCredentials
-		}
-		return 0, fmt.Errorf("%s: %w", op, err)
+	if err := compareHash(hashed_password, []byte(password)); err != nil || id == 0 {
+		return 0, models.ErrInvalidCredentials
 	}
 	return id, nil
 }
@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"errors"
+	"forum/models"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestDisplayName(t *testing.T) {
+	s := newTestDB(t)
+
+	if err := s.CreateUser(models.User{Name: "alice", DisplayName: "Alice A.", Email: "alice@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateUser(models.User{Name: "bob", Email: "bob@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+	exec(t, s, `INSERT INTO posts (id, user_id, title, content, image_name) VALUES (1, 1, 'hello', 'c', 'Nan')`)
+	exec(t, s, `INSERT INTO comments (id, post_id, user_id, content) VALUES (1, 1, 2, 'hi')`)
+
+	alice, err := s.GetUserByName("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.ShownName() != "Alice A." {
+		t.Errorf("got %q; expected alice to be shown as Alice A.", alice.ShownName())
+	}
+	post, err := s.GetPostByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.UserName != "alice" || post.AuthorName() != "Alice A." {
+		t.Errorf("got %q shown as %q; expected alice shown as Alice A.", post.UserName, post.AuthorName())
+	}
+
+	// Without a display name the username is shown.
+	comments, err := s.GetCommentsByPostID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*comments) != 1 || (*comments)[0].AuthorName() != "bob" {
+		t.Errorf("got %+v; expected bob's comment shown under bob", *comments)
+	}
+
+	if err := s.UpdateUserDisplayName(int(alice.ID), ""); err != nil {
+		t.Fatal(err)
+	}
+	alice, err = s.GetUserByID(int(alice.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.ShownName() != "alice" {
+		t.Errorf("got %q; expected a cleared display name to fall back to alice", alice.ShownName())
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	s := newTestDB(t)
+
+	if err := s.CreateUser(models.User{Name: "alice", Email: "alice@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateUserTimezone(1, "Asia/Tokyo"); err != nil {
+		t.Fatal(err)
+	}
+	alice, err := s.GetUserByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Timezone != "Asia/Tokyo" {
+		t.Errorf("got timezone %q; expected Asia/Tokyo", alice.Timezone)
+	}
+	// The preference only changes how dates are shown, not how they are
+	// stored.
+	if alice.Created.Location() != time.UTC {
+		t.Errorf("got created in %v; expected UTC", alice.Created.Location())
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := newTestDB(t)
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("right password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateUser(models.User{Name: "alice", Email: "alice@gmail.com", HashedPassword: hashed}); err != nil {
+		t.Fatal(err)
+	}
+
+	var compared int
+	defer func(compare func([]byte, []byte) error) { compareHash = compare }(compareHash)
+	compareHash = func(hash, password []byte) error {
+		compared++
+		return bcrypt.CompareHashAndPassword(hash, password)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantID   int
+		wantErr  error
+	}{
+		{name: "Right password", email: "alice@gmail.com", password: "right password", wantID: 1},
+		{name: "Wrong password", email: "alice@gmail.com", password: "wrong password", wantErr: models.ErrInvalidCredentials},
+		{name: "Unknown email", email: "nobody@gmail.com", password: "right password", wantErr: models.ErrInvalidCredentials},
+		{name: "Unknown email, blank password", email: "nobody@gmail.com", wantErr: models.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compared = 0
+			id, err := s.Authenticate(tt.email, tt.password)
+			if id != tt.wantID || !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %d, %v; expected %d, %v", id, err, tt.wantID, tt.wantErr)
+			}
+			if compared != 1 {
+				t.Errorf("got %d hash comparisons; expected 1", compared)
+			}
+		})
+	}
+}
+
+func TestEmailKey(t *testing.T) {
+	s := newTestDB(t)
+	policy := models.DefaultEmailPolicy
+	user := func(name, email string) models.User {
+		return models.User{Name: name, Email: email, EmailKey: policy.Normalize(email)}
+	}
+
+	if err := s.CreateUser(user("user", "user@gmail.com")); err != nil {
+		t.Fatal(err)
+	}
+	for name, email := range map[string]string{"tag": "user+tag@gmail.com", "dots": "u.ser@gmail.com"} {
+		if err := s.CreateUser(user(name, email)); !errors.Is(err, models.ErrDuplicateEmail) {
+			t.Errorf("got %v for %s; expected %v", err, email, models.ErrDuplicateEmail)
+		}
+	}
+	if err := s.CreateUserWithInvite(user("invited", "u.s.e.r+x@gmail.com"), "any"); !errors.Is(err, models.ErrDuplicateEmail) {
+		t.Errorf("got %v with an invite; expected %v", err, models.ErrDuplicateEmail)
+	}
+
+	// The address is kept as given, and users without a key don't clash.
+	if err := s.CreateUser(user("tagged", "other+tag@gmail.com")); err != nil {
+		t.Fatal(err)
+	}
+	tagged, err := s.GetUserByName("tagged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagged.Email != "other+tag@gmail.com" {
+		t.Errorf("got %q; expected the email as given", tagged.Email)
+	}
+	for _, name := range []string{"old1", "old2"} {
+		if err := s.CreateUser(models.User{Name: name, Email: name + "@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"forum/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CreateAnnouncement stores a for the admin of token. An announcement is
+// scoped to at most one category or post, and ends after it starts.
+func (s *service) CreateAnnouncement(token string, a models.Announcement) (*models.Announcement, error) {
+	if _, err := s.adminByToken(token); err != nil {
+		return nil, err
+	}
+	a.Message = strings.TrimSpace(a.Message)
+	if a.Message == "" || utf8.RuneCountInString(a.Message) > models.AnnouncementMaxLen {
+		return nil, models.ErrInvalidAnnouncement
+	}
+	if a.CategoryID < 0 || a.PostID < 0 || a.CategoryID != 0 && a.PostID != 0 {
+		return nil, models.ErrInvalidAnnouncement
+	}
+	if !a.End.After(a.Start) {
+		return nil, models.ErrInvalidDateRange
+	}
+	if a.CategoryID != 0 {
+		categories, err := s.repo.GetALLCategory()
+		if err != nil {
+			return nil, err
+		}
+		if a.CategoryID > len(categories) {
+			return nil, models.UnknownCategory
+		}
+	}
+	if a.PostID != 0 && !s.repo.CheckPostExists(a.PostID) {
+		return nil, models.ErrNoRecord
+	}
+	if err := s.repo.CreateAnnouncement(&a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (s *service) DeleteAnnouncement(token string, id int) error {
+	if _, err := s.adminByToken(token); err != nil {
+		return err
+	}
+	return s.repo.DeleteAnnouncement(id)
+}
+
+func (s *service) GetAnnouncements(token string) (*[]models.Announcement, error) {
+	if _, err := s.adminByToken(token); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAnnouncements()
+}
+
+// GetActiveAnnouncements returns the announcements of scope that show at now,
+// nil when there are none.
+func (s *service) GetActiveAnnouncements(now time.Time, scope models.AnnouncementScope) ([]models.Announcement, error) {
+	announcements, err := s.repo.GetActiveAnnouncements(now, scope)
+	if err != nil {
+		return nil, err
+	}
+	return *announcements, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAnnouncements(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []models.User{
+		{Name: "admin", Email: "admin@gmail.com"},
+		{Name: "bob", Email: "bob@gmail.com"},
+	} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.UpdateUserStatus(1, models.StatusAdmin, &models.AuditEntry{ActorID: 1, Action: models.AuditRoleChange}); err != nil {
+		t.Fatal(err)
+	}
+	admin, bob := models.NewSession(1), models.NewSession(2)
+	for _, session := range []*models.Session{admin, bob} {
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := New(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	postID, err := s.CreatePost("Hello", "content", bob.Token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	week := models.Announcement{Message: " Maintenance ", Start: now, End: now.Add(7 * 24 * time.Hour)}
+	rejected := []struct {
+		name    string
+		token   string
+		change  func(a *models.Announcement)
+		wantErr error
+	}{
+		{name: "Not an admin", token: bob.Token, change: func(a *models.Announcement) {}, wantErr: models.ErrForbidden},
+		{name: "Blank message", token: admin.Token, change: func(a *models.Announcement) { a.Message = "  " }, wantErr: models.ErrInvalidAnnouncement},
+		{name: "Two scopes", token: admin.Token, change: func(a *models.Announcement) { a.CategoryID, a.PostID = 1, postID }, wantErr: models.ErrInvalidAnnouncement},
+		{name: "Ends before start", token: admin.Token, change: func(a *models.Announcement) { a.End = a.Start }, wantErr: models.ErrInvalidDateRange},
+		{name: "Unknown post", token: admin.Token, change: func(a *models.Announcement) { a.PostID = postID + 1 }, wantErr: models.ErrNoRecord},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			a := week
+			tt.change(&a)
+			if _, err := s.CreateAnnouncement(tt.token, a); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v; want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	created, err := s.CreateAnnouncement(admin.Token, week)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Message != "Maintenance" {
+		t.Errorf("got message %q; want it trimmed", created.Message)
+	}
+	onPost := models.Announcement{Message: "Featured", PostID: postID, Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}
+	if _, err := s.CreateAnnouncement(admin.Token, onPost); err != nil {
+		t.Fatal(err)
+	}
+
+	postPage := models.AnnouncementScope{PostID: postID}
+	clock := []struct {
+		name  string
+		now   time.Time
+		scope models.AnnouncementScope
+		want  int
+	}{
+		{name: "Before start", now: now.Add(-time.Minute), scope: postPage, want: 0},
+		{name: "At start", now: now, scope: postPage, want: 1},
+		{name: "Post one active", now: now.Add(90 * time.Minute), scope: postPage, want: 2},
+		{name: "Post one elsewhere", now: now.Add(90 * time.Minute), want: 1},
+		{name: "Post one over", now: now.Add(2 * time.Hour), scope: postPage, want: 1},
+		{name: "After end", now: week.End, scope: postPage, want: 0},
+	}
+	for _, tt := range clock {
+		t.Run(tt.name, func(t *testing.T) {
+			active, err := s.GetActiveAnnouncements(tt.now, tt.scope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(active) != tt.want {
+				t.Errorf("got %d active; want %d", len(active), tt.want)
+			}
+		})
+	}
+
+	if err := s.DeleteAnnouncement(bob.Token, created.ID); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("non-admin delete: got %v; want %v", err, models.ErrForbidden)
+	}
+	if err := s.DeleteAnnouncement(admin.Token, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	all, err := s.GetAnnouncements(admin.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*all) != 1 {
+		t.Errorf("got %d announcements; want 1", len(*all))
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommentApproval(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []models.User{
+		{Name: "alice", Email: "alice@gmail.com"},
+		{Name: "bob", Email: "bob@gmail.com"},
+		{Name: "mod", Email: "mod@gmail.com"},
+	} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.UpdateUserStatus(3, models.StatusModerator, &models.AuditEntry{ActorID: 3, Action: models.AuditRoleChange}); err != nil {
+		t.Fatal(err)
+	}
+	alice, bob, mod := models.NewSession(1), models.NewSession(2), models.NewSession(3)
+	for _, session := range []*models.Session{alice, bob, mod} {
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := New(db)
+	// Accounts need a post to be trusted, which only alice has.
+	rules := models.CommentRules{NewUser: models.NewUserRules{MinPosts: 1}, ApproveNewUsers: true}
+	postID, err := s.CreatePost("Hello", "content", alice.Token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := func(token, content string) {
+		t.Helper()
+		if err := s.CommentPost(models.CommentForm{PostID: postID, Token: token, Content: content}, rules); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(wantCount, wantPending, wantUnread int) {
+		t.Helper()
+		posts, err := s.GetAllPostPaginated(1, 10, models.SortNewest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := (*posts)[0].CommentCount; got != wantCount {
+			t.Errorf("got %d comments; expected %d", got, wantCount)
+		}
+		pending, err := s.GetPendingComments(mod.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*pending) != wantPending {
+			t.Errorf("got %d pending comments; expected %d", len(*pending), wantPending)
+		}
+		unread, err := s.CountUnread(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unread != wantUnread {
+			t.Errorf("got %d unread notifications; expected %d", unread, wantUnread)
+		}
+	}
+
+	// bob's comment waits, uncounted, and alice hears of it only once it is
+	// approved.
+	comment(bob.Token, "first!")
+	check(0, 1, 0)
+	pending, err := s.GetPendingComments(mod.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commentID := (*pending)[0].CommentID
+
+	if err := s.ReviewComment(bob.Token, commentID, true); err != models.ErrForbidden {
+		t.Errorf("got error %v reviewing as bob; expected %v", err, models.ErrForbidden)
+	}
+	if _, err := s.GetPendingComments(bob.Token); err != models.ErrForbidden {
+		t.Errorf("got error %v listing as bob; expected %v", err, models.ErrForbidden)
+	}
+
+	if err := s.ReviewComment(mod.Token, commentID, true); err != nil {
+		t.Fatal(err)
+	}
+	check(1, 0, 1)
+
+	// Rejected comments are gone, trusted users skip the queue.
+	comment(bob.Token, "buy now")
+	pending, err = s.GetPendingComments(mod.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReviewComment(mod.Token, (*pending)[0].CommentID, false); err != nil {
+		t.Fatal(err)
+	}
+	comment(alice.Token, "thanks")
+	check(2, 0, 1)
+
+	// Without the setting nobody waits.
+	rules.ApproveNewUsers = false
+	comment(bob.Token, "again")
+	check(3, 0, 2)
+}
+
+func TestQuoteHiddenComment(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []models.User{
+		{Name: "alice", Email: "alice@gmail.com"},
+		{Name: "bob", Email: "bob@gmail.com"},
+		{Name: "mod", Email: "mod@gmail.com"},
+	} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.UpdateUserStatus(3, models.StatusModerator, &models.AuditEntry{ActorID: 3, Action: models.AuditRoleChange}); err != nil {
+		t.Fatal(err)
+	}
+	alice, bob, mod := models.NewSession(1), models.NewSession(2), models.NewSession(3)
+	for _, session := range []*models.Session{alice, bob, mod} {
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := New(db)
+	rules := models.CommentRules{NewUser: models.NewUserRules{MinPosts: 1}, ApproveNewUsers: true}
+	postID, err := s.CreatePost("Hello", "content", alice.Token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quote := func(token string, commentID int) error {
+		return s.CommentPost(models.CommentForm{PostID: postID, Token: token, Content: "reply", QuotedCommentID: commentID}, rules)
+	}
+
+	// Comment 1 is bob's and waits for approval.
+	if err := s.CommentPost(models.CommentForm{PostID: postID, Token: bob.Token, Content: "secret"}, rules); err != nil {
+		t.Fatal(err)
+	}
+	if err := quote(alice.Token, 1); err != models.ErrInvalidQuote {
+		t.Errorf("got error %v quoting a pending comment; expected %v", err, models.ErrInvalidQuote)
+	}
+	if err := quote(bob.Token, 1); err != nil {
+		t.Errorf("got error %v quoting own pending comment", err)
+	}
+	if err := quote(mod.Token, 1); err != nil {
+		t.Errorf("got error %v quoting a pending comment as a moderator", err)
+	}
+
+	// Comment 4 is alice's and soft deleted.
+	if err := s.CommentPost(models.CommentForm{PostID: postID, Token: alice.Token, Content: "oops"}, rules); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DeleteComment(alice.Token, 4, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := quote(bob.Token, 4); err != models.ErrInvalidQuote {
+		t.Errorf("got error %v quoting a deleted comment; expected %v", err, models.ErrInvalidQuote)
+	}
+	if err := quote(mod.Token, 4); err != nil {
+		t.Errorf("got error %v quoting a deleted comment as a moderator", err)
+	}
+}
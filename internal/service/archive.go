@@ -0,0 +1,28 @@
+package service
+
+import (
+	"forum/models"
+	"time"
+)
+
+// ArchiveInactivePosts archives the posts that saw no new comment for
+// inactivity as of now, and returns how many it archived. now is passed in
+// so the schedule can be tested.
+func (s *service) ArchiveInactivePosts(now time.Time, inactivity time.Duration) (int, error) {
+	if inactivity <= 0 {
+		return 0, nil
+	}
+	return s.repo.ArchiveInactivePosts(now.Add(-inactivity))
+}
+
+// checkArchived returns ErrPostArchived if the post is archived.
+func (s *service) checkArchived(postID int) error {
+	archived, err := s.repo.IsPostArchived(postID)
+	if err != nil {
+		return err
+	}
+	if archived {
+		return models.ErrPostArchived
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiveInactivePosts(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateUser(models.User{Name: "alice", Email: "alice@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+	session := models.NewSession(1)
+	if err := db.CreateSession(session); err != nil {
+		t.Fatal(err)
+	}
+	s := New(db)
+	postID, err := s.CreatePost("Hello", "content", session.Token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CommentPost(models.CommentForm{PostID: postID, Token: session.Token, Content: "first"}, models.CommentRules{}); err != nil {
+		t.Fatal(err)
+	}
+	comment := func() error {
+		return s.CommentPost(models.CommentForm{PostID: postID, Token: session.Token, Content: "still here?"}, models.CommentRules{})
+	}
+
+	const inactivity = 30 * 24 * time.Hour
+	now := time.Now()
+
+	// Inside the window nothing changes.
+	archived, err := s.ArchiveInactivePosts(now.Add(inactivity-time.Hour), inactivity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived != 0 {
+		t.Errorf("got %d posts archived inside the window; expected 0", archived)
+	}
+	if err := comment(); err != nil {
+		t.Fatalf("commenting on an active post: %v", err)
+	}
+
+	// Turned off, nothing is ever archived.
+	if archived, _ := s.ArchiveInactivePosts(now.Add(10*inactivity), 0); archived != 0 {
+		t.Errorf("got %d posts archived with archiving off; expected 0", archived)
+	}
+
+	archived, err = s.ArchiveInactivePosts(now.Add(inactivity+time.Hour), inactivity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived != 1 {
+		t.Fatalf("got %d posts archived past the window; expected 1", archived)
+	}
+	post, err := s.GetPostByID(postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !post.Archived {
+		t.Error("post isn't marked archived")
+	}
+
+	if err := comment(); !errors.Is(err, models.ErrPostArchived) {
+		t.Errorf("got %v commenting on an archived post; expected %v", err, models.ErrPostArchived)
+	}
+	if _, err := s.PostReaction(models.ReactionForm{ID: postID, Token: session.Token, Reaction: true}, 0); !errors.Is(err, models.ErrPostArchived) {
+		t.Errorf("got %v reacting to an archived post; expected %v", err, models.ErrPostArchived)
+	}
+	if _, err := s.CommentReaction(models.ReactionForm{ID: 1, Token: session.Token, Reaction: true}, 0); !errors.Is(err, models.ErrPostArchived) {
+		t.Errorf("got %v reacting to a comment of an archived post; expected %v", err, models.ErrPostArchived)
+	}
+
+	// Archived posts aren't counted again.
+	if archived, _ := s.ArchiveInactivePosts(now.Add(2*inactivity), inactivity); archived != 0 {
+		t.Errorf("got %d posts archived again; expected 0", archived)
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"forum/models"
+	"forum/pkg/validator"
+)
+
+func (s *service) RecordAudit(entry models.AuditEntry) error {
+	return s.repo.CreateAuditEntry(&entry)
+}
+
+// ChangeRole sets the status of the user called name. Only admins may do it,
+// and the change is audited in the same transaction.
+func (s *service) ChangeRole(token, name string, status int, ip string) error {
+	admin, err := s.adminByToken(token)
+	if err != nil {
+		return err
+	}
+	if !models.ValidStatus(status) {
+		return models.ErrInvalidStatus
+	}
+	user, err := s.repo.GetUserByName(name)
+	if err != nil {
+		return err
+	}
+	entry := models.AuditEntry{
+		ActorID: int(admin.ID),
+		Action:  models.AuditRoleChange,
+		Target:  fmt.Sprintf("user:%d status:%d->%d", user.ID, user.Status, status),
+		IP:      ip,
+	}
+	return s.repo.UpdateUserStatus(int(user.ID), status, &entry)
+}
+
+// CreateAdmin makes form.Name an admin from the command line, where there
+// may be no admin yet to do it. An existing user by that name is promoted
+// as is, and promoted reports so. Otherwise the account is made from form,
+// whose name, email and password must follow the policies. Reserved names
+// are allowed, as they are kept for staff. The change is audited as made
+// by the user themselves.
+func (s *service) CreateAdmin(form models.UserSignupForm, names models.UsernamePolicy, emails models.EmailPolicy) (promoted bool, err error) {
+	user, err := s.repo.GetUserByName(form.Name)
+	switch {
+	case err == nil:
+		promoted = true
+	case errors.Is(err, models.ErrNoRecord):
+		names.Reserved = nil
+		if err := names.Check(form.Name); err != nil {
+			return false, err
+		}
+		if !validator.IsEmail(form.Email) {
+			return false, models.ErrInvalidEmail
+		}
+		if !validator.MinChars(form.Password, models.PasswordMinChars) {
+			return false, models.ErrWeakPassword
+		}
+		newUser := form.FormToUser()
+		newUser.EmailKey = emails.Normalize(newUser.Email)
+		if err := s.repo.CreateUser(newUser); err != nil {
+			return false, err
+		}
+		if user, err = s.repo.GetUserByName(form.Name); err != nil {
+			return false, err
+		}
+	default:
+		return false, err
+	}
+	if user.IsAdmin() {
+		return promoted, nil
+	}
+	entry := models.AuditEntry{
+		ActorID: int(user.ID),
+		Action:  models.AuditRoleChange,
+		Target:  fmt.Sprintf("user:%d status:%d->%d", user.ID, user.Status, models.StatusAdmin),
+	}
+	return promoted, s.repo.UpdateUserStatus(int(user.ID), models.StatusAdmin, &entry)
+}
+
+func (s *service) GetAuditLogPaginated(token string, filter models.AuditFilter, curentPage, pageSize int) (*models.AuditPage, error) {
+	if _, err := s.adminByToken(token); err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.GetAuditLogPaginated(filter, curentPage, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := s.repo.GetPageNumberAudit(pageSize, filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.AuditPage{Entries: *entries, Page: curentPage, Pages: pages}
+	if page.Entries == nil {
+		page.Entries = []models.AuditEntry{}
+	}
+	return page, nil
+}
+
+func (s *service) adminByToken(token string) (*models.User, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsAdmin() {
+		return nil, models.ErrForbidden
+	}
+	return user, nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"forum/models"
+	"io"
+	"time"
+)
+
+// ExportBackup writes a backup of the whole forum to w as NDJSON, one record
+// per line, starting with a header that holds the format version. Records are
+// written as they are read, so the backup never has to fit in memory. Only
+// admins may export, and every export is audited.
+func (s *service) ExportBackup(token, ip string, w io.Writer) error {
+	admin, err := s.adminByToken(token)
+	if err != nil {
+		return err
+	}
+	err = s.RecordAudit(models.AuditEntry{
+		ActorID: int(admin.ID),
+		Action:  models.AuditExport,
+		Target:  "backup",
+		IP:      ip,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.WriteBackup(w)
+}
+
+// WriteBackup writes the backup for ExportBackup without checking who asks
+// for it, for the backup command.
+func (s *service) WriteBackup(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	header := models.BackupRecord{
+		Type: models.BackupHeaderType,
+		Data: models.BackupHeader{Version: models.BackupVersion, Created: time.Now().UTC()},
+	}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	return s.repo.ExportBackup(func(record models.BackupRecord) error {
+		return enc.Encode(record)
+	})
+}
+
+// ImportBackup reads a backup written by WriteBackup and imports it batch
+// records at a time, each batch in its own transaction. An import that was
+// interrupted can be run again: what it already imported is skipped.
+func (s *service) ImportBackup(r io.Reader, batch int) (*models.ImportSummary, error) {
+	dec := json.NewDecoder(r)
+	raw, err := nextRawRecord(dec)
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: empty backup", models.ErrInvalidBackup)
+	}
+	if err != nil {
+		return nil, err
+	}
+	header, err := unmarshalBackup[models.BackupHeader](raw.Data)
+	if err != nil {
+		return nil, err
+	}
+	if raw.Type != models.BackupHeaderType || header.Version != models.BackupVersion {
+		return nil, fmt.Errorf("%w: expected a version %d header", models.ErrInvalidBackup, models.BackupVersion)
+	}
+	// Records are mapped per backup, so two backups taken at different
+	// times don't mix up their ids.
+	source := header.Created.UTC().Format(time.RFC3339Nano)
+
+	batch = max(batch, 1)
+	summary := models.NewImportSummary()
+	records := make([]models.BackupRecord, 0, batch)
+	flush := func() error {
+		if len(records) == 0 {
+			return nil
+		}
+		imported, err := s.repo.ImportBackup(source, records)
+		if err != nil {
+			return err
+		}
+		summary.Add(imported)
+		records = records[:0]
+		return nil
+	}
+	for {
+		record, err := nextBackupRecord(dec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return summary, err
+		}
+		records = append(records, record)
+		if len(records) == batch {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return summary, err
+	}
+	return summary, s.repo.FinishImport(source)
+}
+
+// nextBackupRecord decodes the next record with Data of the type its Type
+// calls for.
+func nextBackupRecord(dec *json.Decoder) (models.BackupRecord, error) {
+	raw, err := nextRawRecord(dec)
+	if err != nil {
+		return models.BackupRecord{}, err
+	}
+
+	var data any
+	switch raw.Type {
+	case models.BackupUserType:
+		data, err = unmarshalBackup[models.BackupUser](raw.Data)
+	case models.BackupCategoryType:
+		data, err = unmarshalBackup[models.BackupCategory](raw.Data)
+	case models.BackupPostType:
+		data, err = unmarshalBackup[models.BackupPost](raw.Data)
+	case models.BackupCommentType:
+		data, err = unmarshalBackup[models.BackupComment](raw.Data)
+	case models.BackupPostReactionType, models.BackupCommentReactionType:
+		data, err = unmarshalBackup[models.BackupReaction](raw.Data)
+	default:
+		err = fmt.Errorf("%w: unknown record type %q", models.ErrInvalidBackup, raw.Type)
+	}
+	return models.BackupRecord{Type: raw.Type, Data: data}, err
+}
+
+type rawBackupRecord struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// nextRawRecord returns io.EOF at the end of the backup.
+func nextRawRecord(dec *json.Decoder) (rawBackupRecord, error) {
+	var raw rawBackupRecord
+	if err := dec.Decode(&raw); err != nil {
+		if errors.Is(err, io.EOF) {
+			return raw, err
+		}
+		return raw, fmt.Errorf("%w: %v", models.ErrInvalidBackup, err)
+	}
+	return raw, nil
+}
+
+func unmarshalBackup[T any](data json.RawMessage) (T, error) {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("%w: %v", models.ErrInvalidBackup, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// seedBackup fills a fresh database with a bit of everything a backup holds.
+func seedBackup(t *testing.T, path string) {
+	t.Helper()
+	if _, err := sqlite.NewDB(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`INSERT INTO users (id, name, display_name, email, hashed_password, created) VALUES
+			(1, 'alice', 'Alice A.', 'alice@gmail.com', 'hash', '2024-01-01 10:00:00'),
+			(2, 'bob', '', 'bob@gmail.com', 'hash', '2024-01-01 11:00:00')`,
+		`INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Sports')`,
+		`INSERT INTO posts (id, user_id, title, content, image_name, created) VALUES
+			(1, 1, 'question', 'how?', 'Nan', '2024-01-02 10:00:00'),
+			(2, 2, 'news', 'hi', 'Nan', '2024-01-02 11:00:00')`,
+		`INSERT INTO post_category (category_id, post_id) VALUES (1, 1), (2, 1), (2, 2)`,
+		`INSERT INTO comments (id, post_id, user_id, content, created) VALUES (1, 1, 2, 'like this', '2024-01-03 10:00:00')`,
+		`INSERT INTO comments (id, post_id, user_id, content, created, quoted_comment_id, quote_excerpt) VALUES
+			(2, 1, 1, 'thanks', '2024-01-03 11:00:00', 1, 'like')`,
+		`UPDATE posts SET accepted_answer_comment_id = 1 WHERE id = 1`,
+		`INSERT INTO post_user_Like (user_id, post_id, is_like) VALUES (2, 1, TRUE), (1, 2, FALSE)`,
+		`INSERT INTO comment_user_Like (user_id, comment_id, is_like) VALUES (1, 1, TRUE)`,
+		`UPDATE posts SET like = 1 WHERE id = 1`,
+		`UPDATE posts SET dislike = 1 WHERE id = 2`,
+		`UPDATE comments SET like = 1 WHERE id = 1`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// describeBackup lists the records of a backup by what they are rather than
+// by id, so backups of different databases with the same content compare
+// equal.
+func describeBackup(t *testing.T, backup []byte) []string {
+	t.Helper()
+	users := map[int]string{}
+	categories := map[int]string{}
+	posts := map[int]string{}
+	comments := map[int]string{}
+	answers := map[string]int{}
+	var lines []string
+
+	dec := json.NewDecoder(bytes.NewReader(backup))
+	if _, err := nextRawRecord(dec); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		record, err := nextBackupRecord(dec)
+		if err != nil {
+			break
+		}
+		switch data := record.Data.(type) {
+		case models.BackupUser:
+			users[data.ID] = data.Name
+			lines = append(lines, fmt.Sprintf("user %s %q %s", data.Name, data.DisplayName, data.Email))
+		case models.BackupCategory:
+			categories[data.ID] = data.Name
+		case models.BackupPost:
+			posts[data.ID] = data.Title
+			var names []string
+			for _, id := range data.Categories {
+				names = append(names, categories[id])
+			}
+			slices.Sort(names)
+			lines = append(lines, fmt.Sprintf("post %s by %s in %v at %s", data.Title, users[data.UserID], names, data.Created))
+			if data.AcceptedAnswerID != 0 {
+				answers[data.Title] = data.AcceptedAnswerID
+			}
+		case models.BackupComment:
+			comments[data.ID] = data.Content
+			lines = append(lines, fmt.Sprintf("comment %s by %s on %s quoting %q", data.Content, users[data.UserID], posts[data.PostID], comments[data.QuotedCommentID]))
+		case models.BackupReaction:
+			target := posts[data.TargetID]
+			if record.Type == models.BackupCommentReactionType {
+				target = comments[data.TargetID]
+			}
+			lines = append(lines, fmt.Sprintf("%s %s on %s: %t", record.Type, users[data.UserID], target, data.Like))
+		}
+	}
+	for title, id := range answers {
+		lines = append(lines, fmt.Sprintf("answer to %s: %s", title, comments[id]))
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	seedBackup(t, filepath.Join(dir, "source.db"))
+	source, err := sqlite.NewDB(filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backup bytes.Buffer
+	if err := New(source).WriteBackup(&backup); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(backup.String(), "hash") {
+		t.Fatalf("expected no password hashes in the backup:\n%s", backup.String())
+	}
+
+	target, err := sqlite.NewDB(filepath.Join(dir, "target.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Someone signed up before the import, so the ids can't be kept.
+	if err := target.CreateUser(models.User{Name: "carol", Email: "carol@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+	s := New(target)
+	summary, err := s.ImportBackup(bytes.NewReader(backup.Bytes()), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{
+		models.BackupUserType:            2,
+		models.BackupCategoryType:        2,
+		models.BackupPostType:            2,
+		models.BackupCommentType:         2,
+		models.BackupPostReactionType:    2,
+		models.BackupCommentReactionType: 1,
+	}
+	for kind, n := range want {
+		if summary.Imported[kind] != n || summary.Skipped[kind] != 0 {
+			t.Errorf("%s: got %d imported, %d skipped; expected %d imported", kind, summary.Imported[kind], summary.Skipped[kind], n)
+		}
+	}
+
+	var again bytes.Buffer
+	if err := s.WriteBackup(&again); err != nil {
+		t.Fatal(err)
+	}
+	got := describeBackup(t, again.Bytes())
+	got = slices.DeleteFunc(got, func(line string) bool { return strings.HasPrefix(line, "user carol ") })
+	if expected := describeBackup(t, backup.Bytes()); !slices.Equal(got, expected) {
+		t.Errorf("got\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	post, err := target.GetPostByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Title != "question" || post.UserName != "alice" || post.Like != 1 || post.AcceptedAnswerID == 0 {
+		t.Errorf("got %+v; expected alice's question with 1 like and its answer", post)
+	}
+
+	// Importing again finds everything already there.
+	summary, err = s.ImportBackup(bytes.NewReader(backup.Bytes()), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for kind, n := range want {
+		if summary.Imported[kind] != 0 || summary.Skipped[kind] != n {
+			t.Errorf("%s: got %d imported, %d skipped on the second import; expected %d skipped", kind, summary.Imported[kind], summary.Skipped[kind], n)
+		}
+	}
+}
+
+func TestBackupImportResumes(t *testing.T) {
+	dir := t.TempDir()
+	seedBackup(t, filepath.Join(dir, "source.db"))
+	source, err := sqlite.NewDB(filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backup bytes.Buffer
+	if err := New(source).WriteBackup(&backup); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := sqlite.NewDB(filepath.Join(dir, "target.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(target)
+
+	// The first run dies in the middle of a line, after the header and 6
+	// records.
+	lines := strings.SplitAfter(backup.String(), "\n")
+	cut := strings.Join(lines[:7], "") + lines[7][:len(lines[7])/2]
+	summary, err := s.ImportBackup(strings.NewReader(cut), 3)
+	if err == nil {
+		t.Fatal("expected the cut off backup to fail")
+	}
+	var first int
+	for _, n := range summary.Imported {
+		first += n
+	}
+	if first != 6 {
+		t.Errorf("got %d records imported before the failure; expected 6", first)
+	}
+
+	summary, err = s.ImportBackup(bytes.NewReader(backup.Bytes()), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var imported, skipped int
+	for kind := range summary.Imported {
+		imported += summary.Imported[kind]
+	}
+	for kind := range summary.Skipped {
+		skipped += summary.Skipped[kind]
+	}
+	if imported+skipped != 11 || skipped != 6 {
+		t.Errorf("got %d imported and %d skipped; expected 6 of 11 records skipped", imported, skipped)
+	}
+
+	var again bytes.Buffer
+	if err := s.WriteBackup(&again); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := describeBackup(t, again.Bytes()), describeBackup(t, backup.Bytes()); !slices.Equal(got, expected) {
+		t.Errorf("got\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestBackupGuests(t *testing.T) {
+	dir := t.TempDir()
+	source, err := sqlite.NewDB(filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	guestID, err := source.GuestAccountID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued := &models.GuestPost{Name: "Visitor", IP: "10.0.0.1", Title: "hello", Content: "from a guest"}
+	if err := source.CreateGuestPost(guestID, queued); err != nil {
+		t.Fatal(err)
+	}
+	postID := queued.ID
+	if _, err := source.BulkModerate(models.ModerationApprove, "", []models.ModerationTarget{{Kind: models.TargetPost, ID: postID}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.CommentPost(models.CommentForm{PostID: postID, UserID: guestID, Content: "me again", GuestName: "Stranger"}); err != nil {
+		t.Fatal(err)
+	}
+	var backup bytes.Buffer
+	if err := New(source).WriteBackup(&backup); err != nil {
+		t.Fatal(err)
+	}
+
+	// The target's own guest account is taken over, its name is not.
+	target, err := sqlite.NewDB(filepath.Join(dir, "target.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := target.CreateUser(models.User{Name: "carol", Email: "carol@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+	targetGuestID, err := target.GuestAccountID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(target).ImportBackup(bytes.NewReader(backup.Bytes()), 10); err != nil {
+		t.Fatal(err)
+	}
+	post, err := target.GetPostByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.UserID != targetGuestID || post.AuthorName() != "Visitor" {
+		t.Errorf("got post by %d shown as %q; expected the guest account %d as Visitor", post.UserID, post.AuthorName(), targetGuestID)
+	}
+	comments, err := target.GetCommentsByPostID(post.PostID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*comments) != 1 || (*comments)[0].AuthorName() != "Stranger" {
+		t.Errorf("got comments %+v; expected one by Stranger", *comments)
+	}
+	if again, err := target.GuestAccountID(); err != nil || again != targetGuestID {
+		t.Errorf("got guest account %d, %v after the import; expected %d", again, err, targetGuestID)
+	}
+}
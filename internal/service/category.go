@@ -1,5 +1,12 @@
 package service
 
+import "time"
+
+const (
+	trendingWindow = 7 * 24 * time.Hour
+	trendingLimit  = 3
+)
+
 func (s *service) GetAllCategory() ([]string, error) {
 
 	categories, err := s.repo.GetALLCategory()
@@ -8,3 +15,7 @@ func (s *service) GetAllCategory() ([]string, error) {
 	}
 	return categories, nil
 }
+
+func (s *service) GetTrendingCategories() ([]string, error) {
+	return s.repo.GetTrendingCategories(time.Now().Add(-trendingWindow), trendingLimit)
+}
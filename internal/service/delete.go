@@ -0,0 +1,47 @@
+package service
+
+import (
+	"forum/models"
+	"time"
+)
+
+// DeletePost deletes a post of the user's own. Within grace of posting, a
+// post nobody commented on yet is removed for good; any other is blanked,
+// keeping its comments and their threads. removed tells which.
+func (s *service) DeletePost(token string, postID int, grace time.Duration) (bool, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return false, err
+	}
+	post, err := s.repo.GetPostByID(postID)
+	if err != nil {
+		return false, err
+	}
+	if post.UserID != userID {
+		return false, models.ErrForbidden
+	}
+	if post.Deleted {
+		return false, models.ErrNoRecord
+	}
+	return s.repo.DeletePost(postID, time.Since(post.Created) < grace)
+}
+
+// DeleteComment is DeletePost for a comment, which is kept when anyone
+// replied to it.
+func (s *service) DeleteComment(token string, commentID int, grace time.Duration) (bool, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return false, err
+	}
+	comment, err := s.repo.GetCommentByID(commentID)
+	if err != nil {
+		return false, err
+	}
+	if comment.UserID != userID {
+		return false, models.ErrForbidden
+	}
+	if comment.Deleted {
+		return false, models.ErrNoRecord
+	}
+	return s.repo.DeleteComment(commentID, time.Since(comment.Created) < grace)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"errors"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeleteWithGrace(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		if err := db.CreateUser(models.User{Name: name, Email: name + "@gmail.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob := models.NewSession(1), models.NewSession(2)
+	for _, session := range []*models.Session{alice, bob} {
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := New(db)
+
+	post := func(session *models.Session) int {
+		t.Helper()
+		postID, err := s.CreatePost("Hello", "content", session.Token, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return postID
+	}
+	comment := func(session *models.Session, postID, quoted int) int {
+		t.Helper()
+		form := models.CommentForm{PostID: postID, Token: session.Token, Content: "hi", QuotedCommentID: quoted}
+		if quoted != 0 {
+			form.QuoteExcerpt = "hi"
+		}
+		if err := s.CommentPost(form, models.CommentRules{}); err != nil {
+			t.Fatal(err)
+		}
+		comments, err := db.GetCommentsByPostID(postID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return (*comments)[len(*comments)-1].CommentID
+	}
+	const grace = time.Hour
+
+	t.Run("Within grace without replies", func(t *testing.T) {
+		postID := post(alice)
+		commentID := comment(alice, post(bob), 0)
+
+		removed, err := s.DeletePost(alice.Token, postID, grace)
+		if err != nil || !removed {
+			t.Fatalf("got removed %v, %v; expected true", removed, err)
+		}
+		if _, err := s.GetPostByID(postID); !errors.Is(err, models.ErrNoRecord) {
+			t.Errorf("got %v for the removed post; expected %v", err, models.ErrNoRecord)
+		}
+		removed, err = s.DeleteComment(alice.Token, commentID, grace)
+		if err != nil || !removed {
+			t.Fatalf("got removed %v, %v; expected true", removed, err)
+		}
+		if _, err := s.GetComment(commentID); !errors.Is(err, models.ErrNoRecord) {
+			t.Errorf("got %v for the removed comment; expected %v", err, models.ErrNoRecord)
+		}
+	})
+
+	t.Run("Within grace with replies", func(t *testing.T) {
+		postID := post(alice)
+		commentID := comment(alice, postID, 0)
+		replyID := comment(bob, postID, commentID)
+
+		removed, err := s.DeleteComment(alice.Token, commentID, grace)
+		if err != nil || removed {
+			t.Fatalf("got removed %v, %v; expected false", removed, err)
+		}
+		deleted, err := s.GetComment(commentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !deleted.Deleted || deleted.Content != models.DeletedContent || deleted.AuthorName() != models.DeletedContent {
+			t.Errorf("got %+v; expected a blanked comment", deleted)
+		}
+		reply, err := s.GetComment(replyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply.QuotedCommentID != commentID {
+			t.Errorf("got the reply quoting %d; expected it to still quote %d", reply.QuotedCommentID, commentID)
+		}
+		comments, err := db.GetCommentsByPostID(postID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range *comments {
+			if c.CommentID == replyID && (c.QuotedAuthorName() != models.DeletedContent || c.QuoteExcerpt != "") {
+				t.Errorf("got the reply quoting %q from %q; expected neither", c.QuoteExcerpt, c.QuotedAuthorName())
+			}
+		}
+
+		removed, err = s.DeletePost(alice.Token, postID, grace)
+		if err != nil || removed {
+			t.Fatalf("got removed %v, %v; expected false", removed, err)
+		}
+		p, err := s.GetPostByID(postID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.Deleted || p.Title != models.DeletedContent || p.Content != models.DeletedContent {
+			t.Errorf("got %+v; expected a blanked post", p)
+		}
+		if _, err := s.DeletePost(alice.Token, postID, grace); !errors.Is(err, models.ErrNoRecord) {
+			t.Errorf("got %v deleting twice; expected %v", err, models.ErrNoRecord)
+		}
+	})
+
+	t.Run("After grace", func(t *testing.T) {
+		postID := post(alice)
+		commentID := comment(alice, post(bob), 0)
+
+		// Nothing is younger than a nanosecond by the time it is deleted.
+		removed, err := s.DeletePost(alice.Token, postID, time.Nanosecond)
+		if err != nil || removed {
+			t.Fatalf("got removed %v, %v; expected false", removed, err)
+		}
+		removed, err = s.DeleteComment(alice.Token, commentID, 0)
+		if err != nil || removed {
+			t.Fatalf("got removed %v, %v; expected false", removed, err)
+		}
+		if c, err := s.GetComment(commentID); err != nil || !c.Deleted {
+			t.Errorf("got %+v, %v; expected a blanked comment", c, err)
+		}
+	})
+
+	t.Run("Deleted post", func(t *testing.T) {
+		postID := post(alice)
+		if _, err := s.DeletePost(alice.Token, postID, 0); err != nil {
+			t.Fatal(err)
+		}
+		before, err := s.CountUnread(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		form := models.CommentForm{PostID: postID, Token: bob.Token, Content: "hi"}
+		if err := s.CommentPost(form, models.CommentRules{}); !errors.Is(err, models.ErrNoRecord) {
+			t.Errorf("got %v commenting; expected %v", err, models.ErrNoRecord)
+		}
+		if _, err := s.PostReaction(models.ReactionForm{ID: postID, Token: bob.Token, Reaction: true}, 0); !errors.Is(err, models.ErrNoRecord) {
+			t.Errorf("got %v liking; expected %v", err, models.ErrNoRecord)
+		}
+		unread, err := s.CountUnread(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unread != before {
+			t.Errorf("got %d unread notifications; expected %d", unread, before)
+		}
+	})
+
+	t.Run("Someone else's", func(t *testing.T) {
+		postID := post(alice)
+		if _, err := s.DeletePost(bob.Token, postID, grace); !errors.Is(err, models.ErrForbidden) {
+			t.Errorf("got %v; expected %v", err, models.ErrForbidden)
+		}
+	})
+}
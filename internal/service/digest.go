@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"forum/models"
+	"forum/pkg/mailer"
+	"forum/pkg/urls"
+	"time"
+)
+
+// SendDigests mails every user whose digest window ended by now the
+// notifications they haven't read or been mailed yet, rendered from t with
+// links built by ub, and returns how many digests went out. now is passed in so the schedule can be
+// tested. Users have no locale, so digests are in the fallback one of t.
+//
+// A window is claimed before its mail is sent, so a crash or restart can
+// lose a digest but never send one twice.
+func (s *service) SendDigests(m mailer.Mailer, t *mailer.Templates, ub *urls.Builder, now time.Time) (int, error) {
+	recipients, err := s.repo.GetDigestRecipients()
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int
+	var errs []error
+	for _, r := range *recipients {
+		if r.LastDigest != nil && now.Sub(*r.LastDigest) < models.DigestPeriod(r.Digest) {
+			continue
+		}
+		notifications, err := s.repo.ClaimDigest(r.UserID, r.LastDigest, now)
+		if err != nil {
+			if !errors.Is(err, models.ErrNoRecord) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if len(notifications) == 0 {
+			continue
+		}
+		subject, body, err := t.Render("", mailer.Digest, digestData(r, notifications, ub))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("digest for user %d: %w", r.UserID, err))
+			continue
+		}
+		if err := m.SendHTML(r.Email, subject, body); err != nil {
+			errs = append(errs, fmt.Errorf("digest for user %d: %w", r.UserID, err))
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+func (s *service) UpdateDigest(token string, digest int) error {
+	if !models.ValidDigest(digest) {
+		return models.ErrInvalidDigest
+	}
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateUserDigest(userID, digest)
+}
+
+func digestData(r models.DigestRecipient, notifications []models.Notification, ub *urls.Builder) mailer.DigestData {
+	data := mailer.DigestData{Name: r.Name}
+	for _, n := range notifications {
+		data.Items = append(data.Items, mailer.DigestItem{Summary: n.Summary(), Link: n.URL(ub)})
+	}
+	return data
+}
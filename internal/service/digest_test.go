@@ -0,0 +1,124 @@
+package service
+
+import (
+	"fmt"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"forum/pkg/mailer"
+	"forum/pkg/urls"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type mail struct {
+	to, subject, body string
+}
+
+type mockMailer struct {
+	sent []mail
+}
+
+func (m *mockMailer) Send(to, subject, body string) error {
+	m.sent = append(m.sent, mail{to, subject, body})
+	return nil
+}
+
+func (m *mockMailer) SendHTML(to, subject, body string) error {
+	return m.Send(to, subject, body)
+}
+
+func TestSendDigests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sqlite.NewDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []models.User{
+		{Name: "alice", Email: "alice@gmail.com"},
+		{Name: "bob", Email: "bob@gmail.com"},
+	} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// alice (1) gets a daily digest, bob (2) doesn't want any.
+	if err := db.UpdateUserDigest(1, models.DigestDaily); err != nil {
+		t.Fatal(err)
+	}
+	postID, err := db.CreatePost(1, "Hello", "content", "Nan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notify := func() {
+		t.Helper()
+		err := db.CreateNotifications([]models.Notification{
+			{UserID: 1, ActorID: 2, Kind: models.NotificationReply, PostID: postID},
+			{UserID: 2, ActorID: 1, Kind: models.NotificationMention, PostID: postID},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := &mockMailer{}
+	ub, err := urls.New("https://forum.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	emails, err := mailer.LoadTemplates(nil, mailer.DefaultLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(db)
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	send := func(s ServiceI, now time.Time, want int) {
+		t.Helper()
+		sent, err := s.SendDigests(m, emails, ub, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sent != want {
+			t.Errorf("%s: sent %d digests; expected %d", now.Sub(start), sent, want)
+		}
+	}
+
+	notify()
+	send(s, start, 1)
+	send(s, start.Add(time.Hour), 0)
+
+	// Notifications that arrive during the window wait for the next one,
+	// also across a restart.
+	notify()
+	send(s, start.Add(2*time.Hour), 0)
+	restarted, err := sqlite.NewDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send(New(restarted), start.Add(3*time.Hour), 0)
+
+	send(s, start.Add(24*time.Hour), 1)
+	send(New(restarted), start.Add(24*time.Hour+time.Minute), 0)
+
+	if len(m.sent) != 2 {
+		t.Fatalf("got %d mails; expected 2", len(m.sent))
+	}
+	for _, mail := range m.sent {
+		if mail.to != "alice@gmail.com" {
+			t.Errorf("got mail to %s; expected alice@gmail.com", mail.to)
+		}
+		if mail.subject != "1 new notification" {
+			t.Errorf("got subject %q; expected every notification to be mailed once", mail.subject)
+		}
+		if !strings.Contains(mail.body, `bob replied to &#34;Hello&#34;`) {
+			t.Errorf("expected %q to mention bob's reply", mail.body)
+		}
+		if link := fmt.Sprintf(`href="https://forum.example/post/%d"`, postID); !strings.Contains(mail.body, link) {
+			t.Errorf("expected %q to link to the post with %s", mail.body, link)
+		}
+	}
+}
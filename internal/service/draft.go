@@ -0,0 +1,46 @@
+package service
+
+import (
+	"errors"
+	"forum/models"
+	"time"
+)
+
+// draftSessionMaxLen caps the editor session ids clients may send.
+const draftSessionMaxLen = 64
+
+// AutosaveDraft stores the user's draft for the editor session. A save less
+// than interval after the last one is ignored and the stored draft returned
+// with false, so an editor saving on every keystroke costs one write per
+// interval.
+func (s *service) AutosaveDraft(token string, form models.DraftForm, interval time.Duration) (*models.Draft, bool, error) {
+	if form.Session == "" || len(form.Session) > draftSessionMaxLen {
+		return nil, false, &models.ValidationError{Fields: map[string]string{
+			"session": "Session must be 1 to 64 characters",
+		}}
+	}
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, false, err
+	}
+
+	last, err := s.repo.GetDraft(userID, form.Session)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		return nil, false, err
+	}
+	if last != nil && time.Since(last.Updated) < interval {
+		return last, false, nil
+	}
+
+	draft := &models.Draft{
+		UserID:  userID,
+		Session: form.Session,
+		Title:   form.Title,
+		Content: form.Content,
+		Updated: time.Now().UTC(),
+	}
+	if err = s.repo.SaveDraft(draft); err != nil {
+		return nil, false, err
+	}
+	return draft, true, nil
+}
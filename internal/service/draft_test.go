@@ -0,0 +1,58 @@
+package service
+
+import (
+	"errors"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAutosaveDraft(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateUser(models.User{Name: "alice", Email: "alice@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+	session := models.NewSession(1)
+	if err := db.CreateSession(session); err != nil {
+		t.Fatal(err)
+	}
+	s := New(db)
+
+	save := func(content string, interval time.Duration) (*models.Draft, bool) {
+		t.Helper()
+		draft, saved, err := s.AutosaveDraft(session.Token, models.DraftForm{Session: "tab-1", Content: content}, interval)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return draft, saved
+	}
+
+	first, saved := save("one", 0)
+	if !saved {
+		t.Fatal("first save was ignored")
+	}
+	second, saved := save("two", 0)
+	if !saved || second.ID != first.ID {
+		t.Fatalf("got draft %d saved %v; expected draft %d saved", second.ID, saved, first.ID)
+	}
+
+	// A save within the interval is dropped and the stored draft returned.
+	third, saved := save("three", time.Hour)
+	if saved {
+		t.Error("save within the interval was stored")
+	}
+	if third.ID != first.ID || third.Content != "two" {
+		t.Errorf("got draft %d %q; expected draft %d %q", third.ID, third.Content, first.ID, "two")
+	}
+
+	_, _, err = s.AutosaveDraft(session.Token, models.DraftForm{}, 0)
+	var validation *models.ValidationError
+	if !errors.As(err, &validation) || validation.Fields["session"] == "" {
+		t.Errorf("got error %v; expected a session validation error", err)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"forum/models"
+)
+
+func (s *service) FollowUser(token, name string) error {
+	f, err := s.follow(token, name)
+	if err != nil {
+		return err
+	}
+	return s.repo.Follow(*f)
+}
+
+func (s *service) UnfollowUser(token, name string) error {
+	f, err := s.follow(token, name)
+	if err != nil {
+		return err
+	}
+	return s.repo.Unfollow(*f)
+}
+
+func (s *service) follow(token, name string) (*models.Follow, error) {
+	followerID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	followee, err := s.repo.GetUserByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if int(followee.ID) == followerID {
+		return nil, models.ErrSelfFollow
+	}
+	return &models.Follow{FollowerID: followerID, FolloweeID: int(followee.ID)}, nil
+}
+
+// IsFollowing reports whether viewer follows profile. Guests follow nobody.
+func (s *service) IsFollowing(viewer, profile *models.User) (bool, error) {
+	if viewer == nil {
+		return false, nil
+	}
+	return s.repo.IsFollowing(int(viewer.ID), int(profile.ID))
+}
+
+func (s *service) GetFollowingPostsPaginated(userID, curentPage, pageSize int) (*[]models.Post, error) {
+	posts, err := s.repo.GetFollowingPostsPaginated(userID, curentPage, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.getCategoryToPost(posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import "forum/models"
+
+// CreateGuestPost stores a post sent without an account as a pending post
+// of the guest account, held until a moderator approves it. The categories
+// are the form's, counted from 0 like CreatePost's.
+func (s *service) CreateGuestPost(p models.GuestPost) (int, error) {
+	categories, err := s.postCategories(p.Categories)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.canPostIn(0, categories); err != nil {
+		return 0, err
+	}
+	guestID, err := s.repo.GuestAccountID()
+	if err != nil {
+		return 0, err
+	}
+	p.Categories = categories
+	if err := s.repo.CreateGuestPost(guestID, &p); err != nil {
+		return 0, err
+	}
+	return p.ID, nil
+}
+
+// GetGuestPosts returns the guest posts waiting for approval. Only
+// moderators may see them.
+func (s *service) GetGuestPosts(token string) (*[]models.GuestPost, error) {
+	if _, err := s.moderatorByToken(token); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGuestPosts()
+}
+
+// ReviewGuestPost approves a pending guest post, which publishes it, or
+// rejects and deletes it. It returns the id of the post when approved, 0
+// when rejected. Category moderators may review guest posts in their
+// categories.
+func (s *service) ReviewGuestPost(token string, id int, approve bool) (int, error) {
+	post, err := s.repo.GetPostByID(id)
+	if err != nil {
+		return 0, err
+	}
+	if !post.Pending {
+		return 0, models.ErrNoRecord
+	}
+	action := models.ModerationDelete
+	if approve {
+		action = models.ModerationApprove
+	}
+	results, err := s.BulkModerate(token, action, "", []models.ModerationTarget{{Kind: models.TargetPost, ID: id}})
+	if err != nil {
+		return 0, err
+	}
+	if !results[0].OK {
+		return 0, models.ErrNoRecord
+	}
+	if !approve {
+		return 0, nil
+	}
+	return id, nil
+}
+
+// moderatorByToken returns the session's user, or ErrForbidden if they
+// aren't a moderator.
+func (s *service) moderatorByToken(token string) (*models.User, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsModerator() {
+		return nil, models.ErrForbidden
+	}
+	return user, nil
+}
@@ -9,6 +9,7 @@ import (
 
 const (
 	pageSize    = 5
+	maxPageSize = 100
 	defaultPage = 1
 )
 
@@ -21,11 +22,28 @@ func AddCategory(arr []int) []int {
 	return arr
 }
 
+// postCategories turns the categories picked for a new post, indexes of
+// GetAllCategory, into category ids. A post without any goes in the
+// models.Uncategorized category.
+func (s *service) postCategories(picked []int) ([]int, error) {
+	if len(picked) > 0 {
+		return AddCategory(picked), nil
+	}
+	id, err := s.repo.UncategorizedID()
+	if err != nil {
+		return nil, err
+	}
+	return []int{id}, nil
+}
+
 func (s *service) SetUpPage(data *models.TemplateData, r *http.Request) (*models.TemplateData, error) {
 	var err error
 	currentPageStr := r.URL.Query().Get("page")
-	data.Limit = validateLimit(r.URL.Query().Get("limit"))
+	data.Limit = ValidateLimit(r.URL.Query().Get("limit"), data.Limit, data.MaxLimit)
+	data.Sort = resolveSort(r.URL.Query().Get("sort"), data.User, data.Sort)
+	data.SortOrders = models.SortOrders
 
+	data.Label = r.URL.Query().Get("label")
 	data.Category = strings.Title(r.URL.Query().Get("category"))
 	data.Categories, err = s.GetAllCategory()
 	if err != nil {
@@ -45,8 +63,24 @@ func (s *service) SetUpPage(data *models.TemplateData, r *http.Request) (*models
 
 	if r.URL.Path == "/user/posts" {
 		data.NumberOfPage, err = s.repo.GetPageNumberMyPosts(data.Limit, int(data.User.ID))
+	} else if r.URL.Path == "/feed/following" {
+		data.NumberOfPage, err = s.repo.GetPageNumberFollowing(data.Limit, int(data.User.ID))
+	} else if r.URL.Path == "/feed/subscribed-categories" {
+		data.NumberOfPage, err = s.repo.GetPageNumberSubscribedCategories(data.Limit, int(data.User.ID))
+	} else if r.URL.Path == "/my/participated" {
+		data.NumberOfPage, err = s.repo.GetPageNumberParticipated(data.Limit, int(data.User.ID))
 	} else if r.URL.Path == "/user/liked" {
 		data.NumberOfPage, err = s.repo.GetPageNumberLikedPosts(data.Limit, int(data.User.ID))
+	} else if data.Profile != nil && strings.HasSuffix(r.URL.Path, "/activity") {
+		data.NumberOfPage, err = s.repo.GetPageNumberActivity(data.Limit, int(data.Profile.ID), canSeeReactions(data.User, data.Profile))
+	} else if data.Search != nil {
+		data.Search.CategoryID = data.Category_id
+		data.SearchTotal, err = s.repo.SearchCount(*data.Search)
+		data.NumberOfPage = (data.SearchTotal + data.Limit - 1) / data.Limit
+	} else if data.Profile != nil {
+		data.NumberOfPage, err = s.repo.GetPageNumberMyPosts(data.Limit, int(data.Profile.ID))
+	} else if data.Label != "" {
+		data.NumberOfPage, err = s.repo.GetPageNumberLabel(data.Limit, data.Label)
 	} else {
 		data.NumberOfPage, err = s.repo.GetPageNumber(data.Limit, data.Category_id)
 	}
@@ -63,13 +97,55 @@ func (s *service) SetUpPage(data *models.TemplateData, r *http.Request) (*models
 	return data, nil
 }
 
-func validateLimit(limitStr string) int {
+// resolveSort picks the order of the home page: the "sort" parameter, then
+// the user's preference, then def, the site default.
+func resolveSort(param string, user *models.User, def string) string {
+	if models.ValidSort(param) {
+		return param
+	}
+	if user != nil && models.ValidSort(user.Sort) {
+		return user.Sort
+	}
+	if models.ValidSort(def) {
+		return def
+	}
+	return models.SortNewest
+}
+
+// ValidateLimit parses the page size, falling back to def, or to pageSize
+// when the caller has no default of its own. Larger sizes than max, or
+// maxPageSize without one, are clamped to it.
+func ValidateLimit(limitStr string, def, max int) int {
+	if def <= 0 {
+		def = pageSize
+	}
+	if max <= 0 {
+		max = maxPageSize
+	}
 	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		limit = def
+	}
+	return min(limit, max)
+}
+
+// canPostIn returns models.ErrForbidden unless the user's status is as high
+// as every category asks for to post in it. Guests, with userID 0, may post
+// in the categories open to every user only.
+func (s *service) canPostIn(userID int, categories []int) error {
+	required, err := s.repo.GetPostStatus(categories)
+	if err != nil || required == models.StatusUser {
+		return err
+	}
+	if userID == 0 {
+		return models.ErrForbidden
+	}
+	user, err := s.repo.GetUserByID(userID)
 	if err != nil {
-		limit = pageSize
+		return err
 	}
-	if limit <= 0 {
-		limit = pageSize
+	if user.Status < required {
+		return models.ErrForbidden
 	}
-	return limit
+	return nil
 }
@@ -0,0 +1,29 @@
+package service
+
+import "testing"
+
+func TestValidateLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit string
+		def   int
+		max   int
+		want  int
+	}{
+		{name: "Unspecified", limit: "", def: 10, max: 100, want: 10},
+		{name: "Unspecified without a default", limit: "", want: pageSize},
+		{name: "Invalid", limit: "lots", def: 10, max: 100, want: 10},
+		{name: "Within the cap", limit: "50", def: 10, max: 100, want: 50},
+		{name: "Above the cap", limit: "1000", def: 10, max: 100, want: 100},
+		{name: "Above the built-in cap", limit: "1000", want: maxPageSize},
+		{name: "Default above the cap", limit: "", def: 20, max: 15, want: 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateLimit(tt.limit, tt.def, tt.max); got != tt.want {
+				t.Errorf("got limit %d; expected %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package service
+
+import "forum/models"
+
+// RecordView adds postID to the user's recently viewed posts, keeping at most
+// keep of them. A keep of 0 turns the history off.
+func (s *service) RecordView(userID, postID, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	return s.repo.RecordView(userID, postID, keep)
+}
+
+func (s *service) GetHistory(userID int) (*[]models.Post, error) {
+	return s.repo.GetHistory(userID)
+}
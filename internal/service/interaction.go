@@ -1,82 +1,362 @@
 package service
 
 import (
+	"errors"
 	"forum/models"
+	"strings"
+	"time"
 )
 
-func (s *service) CommentPost(form models.CommentForm) error {
+func (s *service) CommentPost(form models.CommentForm, rules models.CommentRules) error {
 	var err error
-	form.UserID, err = s.repo.GetUserIDByToken(form.Token)
+	if form.GuestName != "" {
+		// Guests have no account of their own to hold the cooldown, and
+		// their comments always wait for approval.
+		if form.UserID, err = s.repo.GuestAccountID(); err != nil {
+			return err
+		}
+		form.Pending = true
+	} else {
+		form.UserID, err = s.repo.GetUserIDByToken(form.Token)
+		if err != nil {
+			return err
+		}
+		if err = s.checkCooldown(form.UserID, rules.Cooldown); err != nil {
+			return err
+		}
+	}
+	if rules.ApproveNewUsers && form.GuestName == "" {
+		user, err := s.repo.GetUserByID(form.UserID)
+		if err != nil {
+			return err
+		}
+		if form.Pending, err = s.isNewUser(user, rules.NewUser); err != nil {
+			return err
+		}
+	}
+	post, err := s.repo.GetPostByID(form.PostID)
 	if err != nil {
 		return err
 	}
-	return s.repo.CommentPost(form)
+	if post.Deleted || post.Pending {
+		return models.ErrNoRecord
+	}
+	if post.Locked {
+		return models.ErrPostLocked
+	}
+	if post.Archived {
+		return models.ErrPostArchived
+	}
+	if form.QuotedCommentID != 0 {
+		if err = s.checkQuote(&form); err != nil {
+			return err
+		}
+		if err = s.checkDepth(&form, rules); err != nil {
+			return err
+		}
+	}
+	commentID, err := s.repo.CommentPost(form)
+	if err != nil {
+		return err
+	}
+	// Nobody hears of a pending comment before it is approved.
+	if !form.Pending {
+		if err = s.notifyComment(form, post, commentID); err != nil {
+			return err
+		}
+	}
+	if form.GuestName != "" {
+		return nil
+	}
+	return s.repo.Subscribe(form.UserID, post.PostID)
 }
 
-func (s *service) PostReaction(form models.ReactionForm) error {
-	var err error
-	form.UserID, err = s.repo.GetUserIDByToken(form.Token)
+// GetPendingComments returns the comments waiting for approval. Only
+// moderators may see them.
+func (s *service) GetPendingComments(token string) (*[]models.Comment, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsModerator() {
+		return nil, models.ErrForbidden
+	}
+	return s.repo.GetPendingComments()
+}
+
+// ReviewComment approves a pending comment, sending the notifications held
+// back until then, or rejects and deletes it. Category moderators may review
+// comments in their categories.
+func (s *service) ReviewComment(token string, commentID int, approve bool) error {
+	comment, err := s.repo.GetCommentByID(commentID)
 	if err != nil {
 		return err
 	}
-	ok := s.repo.CheckPostExists(form.ID)
-	if !ok {
+	if !comment.Pending {
 		return models.ErrNoRecord
 	}
-	exists, isLike, err := s.repo.GetReactionPost(form.UserID, form.ID)
+	action := models.ModerationDelete
+	if approve {
+		action = models.ModerationApprove
+	}
+	results, err := s.BulkModerate(token, action, "", []models.ModerationTarget{{Kind: models.TargetComment, ID: commentID}})
 	if err != nil {
 		return err
 	}
-	if exists {
-		err := s.repo.DeleteReactionPost(form, isLike)
-		if err != nil {
-			return err
-		}
-		if isLike == form.Reaction {
+	if !results[0].OK {
+		return models.ErrNoRecord
+	}
+	if !approve {
+		return nil
+	}
+	post, err := s.repo.GetPostByID(comment.PostID)
+	if err != nil {
+		return err
+	}
+	form := models.CommentForm{PostID: comment.PostID, UserID: comment.UserID, Content: comment.Content}
+	return s.notifyComment(form, post, commentID)
+}
+
+// GetPostComment returns the comment if it was made on the post, and
+// ErrNoRecord otherwise.
+func (s *service) GetComment(commentID int) (*models.Comment, error) {
+	return s.repo.GetCommentByID(commentID)
+}
+
+func (s *service) GetPostComment(postID, commentID int) (*models.Comment, error) {
+	comment, err := s.repo.GetCommentByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.PostID != postID {
+		return nil, models.ErrNoRecord
+	}
+	return comment, nil
+}
+
+// checkCooldown returns a CooldownError if the user commented less than
+// cooldown ago. Moderators can comment as often as they like.
+func (s *service) checkCooldown(userID int, cooldown time.Duration) error {
+	if cooldown <= 0 {
+		return nil
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsModerator() {
+		return nil
+	}
+	last, err := s.repo.GetLastCommentTime(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
 			return nil
 		}
+		return err
 	}
+	if wait := cooldown - time.Since(last); wait > 0 {
+		return &models.CooldownError{RetryAfter: wait}
+	}
+	return nil
+}
 
-	err = s.repo.AddReactionPost(form)
+// checkDepth keeps reply chains within rules.MaxDepth. A reply to a comment
+// that is already as deep as allowed is either rejected or, with the flatten
+// policy, attached to the deepest ancestor it may still reply to.
+func (s *service) checkDepth(form *models.CommentForm, rules models.CommentRules) error {
+	if rules.MaxDepth <= 0 {
+		return nil
+	}
+	chain, err := s.replyChain(form.QuotedCommentID)
 	if err != nil {
 		return err
 	}
-
+	// chain[0] is the quoted comment, the last one starts the thread at
+	// depth 0.
+	depth := len(chain) - 1
+	if depth < rules.MaxDepth {
+		return nil
+	}
+	if rules.DepthPolicy != models.DepthFlatten {
+		return models.ErrThreadTooDeep
+	}
+	ancestor := chain[len(chain)-rules.MaxDepth]
+	form.QuotedCommentID = ancestor.CommentID
+	if !strings.Contains(ancestor.Content, form.QuoteExcerpt) {
+		form.QuoteExcerpt = ancestor.Content
+	}
 	return nil
 }
 
-func (s *service) CommentReaction(form models.ReactionForm) error {
-	var err error
-	form.UserID, err = s.repo.GetUserIDByToken(form.Token)
+// replyChain returns the comment and the comments it replies to, nearest
+// first. A deleted comment ends the chain.
+func (s *service) replyChain(commentID int) ([]*models.Comment, error) {
+	var chain []*models.Comment
+	for commentID != 0 {
+		comment, err := s.repo.GetCommentByID(commentID)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) && len(chain) > 0 {
+				break
+			}
+			return nil, err
+		}
+		chain = append(chain, comment)
+		commentID = comment.QuotedCommentID
+	}
+	return chain, nil
+}
+
+// notifyComment lets the people mentioned in a new comment and the
+// subscribers of the post know about it. Nobody is notified of their own
+// comment, and a subscriber who is also mentioned gets only the mention. The
+// author hears of it as a reply, the other subscribers as a comment.
+func (s *service) notifyComment(form models.CommentForm, post *models.Post, commentID int) error {
+	var notifications []models.Notification
+	notified := map[int]bool{form.UserID: true}
+	for _, name := range models.Mentions(form.Content) {
+		user, err := s.repo.GetUserByName(name)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				continue
+			}
+			return err
+		}
+		if notified[int(user.ID)] {
+			continue
+		}
+		notified[int(user.ID)] = true
+		notifications = append(notifications, models.Notification{UserID: int(user.ID), ActorID: form.UserID, Kind: models.NotificationMention, PostID: post.PostID, CommentID: commentID})
+	}
+	subscribers, err := s.repo.GetSubscribers(post.PostID)
 	if err != nil {
 		return err
 	}
-	ok := s.repo.CheckCommentExists(form.ID)
-
-	if !ok {
-		return models.ErrNoRecord
+	for _, userID := range subscribers {
+		if notified[userID] {
+			continue
+		}
+		notified[userID] = true
+		kind := models.NotificationComment
+		if userID == post.UserID {
+			kind = models.NotificationReply
+		}
+		notifications = append(notifications, models.Notification{UserID: userID, ActorID: form.UserID, Kind: kind, PostID: post.PostID, CommentID: commentID})
+	}
+	if len(notifications) == 0 {
+		return nil
 	}
+	if err := s.repo.CreateNotifications(notifications); err != nil {
+		return err
+	}
+	for _, n := range notifications {
+		s.unread.invalidate(n.UserID)
+	}
+	return nil
+}
 
-	exists, isLike, err := s.repo.CheckReactionComment(form)
+// checkQuote makes sure the quoted comment lives in the same thread and that
+// the excerpt is really a part of it. An empty excerpt quotes the whole comment.
+// Pending and deleted comments may only be quoted by those who can see them,
+// their author and moderators.
+func (s *service) checkQuote(form *models.CommentForm) error {
+	quoted, err := s.repo.GetCommentByID(form.QuotedCommentID)
 	if err != nil {
 		return err
 	}
-	if exists {
-		err := s.repo.DeleteReactionComment(form, isLike)
+	if quoted.PostID != form.PostID {
+		return models.ErrInvalidQuote
+	}
+	if (quoted.Pending || quoted.Deleted) && (form.GuestName != "" || quoted.UserID != form.UserID) {
+		user, err := s.repo.GetUserByID(form.UserID)
 		if err != nil {
 			return err
 		}
-		if isLike == form.Reaction {
-			return nil
+		if !user.IsModerator() {
+			return models.ErrInvalidQuote
 		}
 	}
+	if form.QuoteExcerpt == "" {
+		form.QuoteExcerpt = quoted.Content
+		return nil
+	}
+	if !strings.Contains(quoted.Content, form.QuoteExcerpt) {
+		return models.ErrInvalidQuote
+	}
+	return nil
+}
 
-	err = s.repo.AddReactionComment(form)
+// PostReaction likes or dislikes a post, or takes the vote back, see
+// repo.TogglePostReaction, and returns the state it left. The author is
+// told about likes, see notifyLike.
+func (s *service) PostReaction(form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error) {
+	var err error
+	form.UserID, err = s.repo.GetUserIDByToken(form.Token)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	ok := s.repo.CheckPostExists(form.ID)
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+	post, err := s.repo.GetPostByID(form.ID)
+	if err != nil {
+		return nil, err
+	}
+	if post.Deleted || post.Pending {
+		return nil, models.ErrNoRecord
+	}
+	if err = s.checkArchived(form.ID); err != nil {
+		return nil, err
+	}
+	state, err := s.repo.TogglePostReaction(form, dedup)
+	if err != nil || state.Mine != 1 {
+		return state, err
+	}
+	return state, s.notifyLike(form.UserID, post.UserID, form.ID, 0)
+}
 
-	return nil
+// CommentReaction is PostReaction for a comment.
+func (s *service) CommentReaction(form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error) {
+	var err error
+	form.UserID, err = s.repo.GetUserIDByToken(form.Token)
+	if err != nil {
+		return nil, err
+	}
+	ok := s.repo.CheckCommentExists(form.ID)
+
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+	comment, err := s.repo.GetCommentByID(form.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkArchived(comment.PostID); err != nil {
+		return nil, err
+	}
+	state, err := s.repo.ToggleCommentReaction(form, dedup)
+	if err != nil || state.Mine != 1 {
+		return state, err
+	}
+	return state, s.notifyLike(form.UserID, comment.UserID, comment.PostID, comment.CommentID)
+}
+
+// notifyLike tells authorID that likerID liked their post, or their comment
+// when commentID isn't 0. They are told once per liker and item, so taking
+// a like back and giving it again doesn't notify them twice.
+func (s *service) notifyLike(likerID, authorID, postID, commentID int) error {
+	if likerID == authorID {
+		return nil
+	}
+	created, err := s.repo.CreateNotificationOnce(models.Notification{UserID: authorID, ActorID: likerID, Kind: models.NotificationLike, PostID: postID, CommentID: commentID})
+	if created {
+		s.unread.invalidate(authorID)
+	}
+	return err
 }
 
 func (s *service) GetReactionPosts(token string) (map[int]bool, error) {
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"forum/models"
+)
+
+// CreateInvite generates a new invite code for the user. Admins are not
+// limited by quota.
+func (s *service) CreateInvite(token string, quota int) (*models.Invite, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsAdmin() {
+		count, err := s.repo.CountInvitesByUserID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= quota {
+			return nil, models.ErrInviteQuota
+		}
+	}
+
+	invite := models.NewInvite(userID)
+	if err = s.repo.CreateInvite(invite); err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+func (s *service) GetInvites(token string) (*[]models.Invite, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetInvitesByUserID(userID)
+}
+
+func (s *service) CreateUserWithInvite(user models.User, code string) error {
+	return s.repo.CreateUserWithInvite(user, code)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"forum/models"
+	"slices"
+)
+
+// SetPostLabel puts a label on a post, or takes it off when add is false.
+// Moderators may label any post, category moderators the posts of their
+// categories. Only the allowed labels can be added, while any label can be
+// removed, so the ones dropped from the configuration can still be cleaned up.
+func (s *service) SetPostLabel(token string, l models.PostLabel, allowed []string, add bool) error {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if add && !slices.Contains(allowed, l.Label) {
+		return models.ErrUnknownLabel
+	}
+	if !s.repo.CheckPostExists(l.PostID) {
+		return models.ErrNoRecord
+	}
+	if !user.IsModerator() {
+		ok, err := s.repo.IsCategoryModerator(userID, l.PostID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return models.ErrForbidden
+		}
+	}
+	if add {
+		return s.repo.AddPostLabel(l)
+	}
+	return s.repo.RemovePostLabel(l)
+}
+
+func (s *service) GetAllPostByLabelPaginated(curentPage, pageSize int, label, sort string) (*[]models.Post, error) {
+	posts, err := s.repo.GetAllPostByLabelPaginated(curentPage, pageSize, label, sort)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.getCategoryToPost(posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
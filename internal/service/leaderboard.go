@@ -0,0 +1,65 @@
+package service
+
+import (
+	"forum/models"
+	"sync"
+	"time"
+)
+
+const (
+	// leaderboardSize is how many users the leaderboard ranks.
+	leaderboardSize = 10
+	// leaderboardCacheTTL is how long a leaderboard is served before it is
+	// counted again. Rankings move slowly and the counts scan whole tables.
+	leaderboardCacheTTL = time.Minute
+)
+
+// leaderboardCache keeps the leaderboard of each query for a short while.
+// There are only a handful of queries, so entries are never dropped.
+type leaderboardCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[models.LeaderboardQuery]*models.Leaderboard
+	now     func() time.Time
+}
+
+func newLeaderboardCache(ttl time.Duration) *leaderboardCache {
+	return &leaderboardCache{
+		ttl:     ttl,
+		entries: make(map[models.LeaderboardQuery]*models.Leaderboard),
+		now:     time.Now,
+	}
+}
+
+func (c *leaderboardCache) get(q models.LeaderboardQuery) (*models.Leaderboard, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	board, ok := c.entries[q]
+	if !ok || !c.now().Before(board.Updated.Add(c.ttl)) {
+		return nil, false
+	}
+	return board, true
+}
+
+func (c *leaderboardCache) set(board *models.Leaderboard) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[board.Query] = board
+}
+
+// GetLeaderboard ranks the top contributors by the metric and over the
+// period of q. Leaderboards are shared by everyone and may be up to
+// leaderboardCacheTTL old.
+func (s *service) GetLeaderboard(q models.LeaderboardQuery) (*models.Leaderboard, error) {
+	if board, ok := s.leaderboard.get(q); ok {
+		return board, nil
+	}
+	now := s.leaderboard.now()
+	contributors, err := s.repo.GetTopContributors(q.Metric, q.Since(now), leaderboardSize)
+	if err != nil {
+		return nil, err
+	}
+	board := &models.Leaderboard{Query: q, Contributors: contributors, Updated: now}
+	s.leaderboard.set(board)
+	return board, nil
+}
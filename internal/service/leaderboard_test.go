@@ -0,0 +1,53 @@
+package service
+
+import (
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLeaderboardCache(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateUser(models.User{Name: "alice", Email: "alice@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(db).(*service)
+	now := time.Now()
+	s.leaderboard.now = func() time.Time { return now }
+	q := models.LeaderboardQuery{Metric: models.LeaderboardPosts, Period: models.LeaderboardMonth}
+	score := func(want int) {
+		t.Helper()
+		board, err := s.GetLeaderboard(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := 0
+		if len(board.Contributors) > 0 {
+			got = board.Contributors[0].Score
+		}
+		if got != want {
+			t.Errorf("got a score of %d; expected %d", got, want)
+		}
+	}
+	post := func() {
+		t.Helper()
+		if _, err := db.CreatePost(1, "Hello", "content", "Nan"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post()
+	score(1)
+
+	// New posts wait for the cached leaderboard to expire.
+	post()
+	score(1)
+	now = now.Add(leaderboardCacheTTL)
+	score(2)
+}
@@ -3,11 +3,17 @@ package service
 import (
 	"forum/internal/repo"
 	"forum/models"
+	"forum/pkg/mailer"
+	"forum/pkg/urls"
+	"io"
 	"net/http"
+	"time"
 )
 
 type service struct {
-	repo repo.RepoI
+	repo        repo.RepoI
+	unread      *unreadCache
+	leaderboard *leaderboardCache
 }
 
 type ServiceI interface {
@@ -15,17 +21,132 @@ type ServiceI interface {
 	CategoryServiceI
 	PostServiceI
 	InteractionServiceI
+	InviteServiceI
+	ModerationServiceI
+	PasswordResetServiceI
+	AuditServiceI
+	FollowServiceI
+	NotificationServiceI
+	SubscriptionServiceI
+	HistoryServiceI
+	BackupServiceI
+	DraftServiceI
+	ArchiveServiceI
+	AnnouncementServiceI
+	LabelServiceI
+	GuestServiceI
+	LeaderboardServiceI
+}
+
+type LeaderboardServiceI interface {
+	GetLeaderboard(q models.LeaderboardQuery) (*models.Leaderboard, error)
+}
+
+type GuestServiceI interface {
+	CreateGuestPost(p models.GuestPost) (int, error)
+	GetGuestPosts(token string) (*[]models.GuestPost, error)
+	ReviewGuestPost(token string, id int, approve bool) (int, error)
+}
+
+type LabelServiceI interface {
+	SetPostLabel(token string, l models.PostLabel, allowed []string, add bool) error
+	GetAllPostByLabelPaginated(curentPage, pageSize int, label, sort string) (*[]models.Post, error)
+}
+
+type AnnouncementServiceI interface {
+	CreateAnnouncement(token string, a models.Announcement) (*models.Announcement, error)
+	DeleteAnnouncement(token string, id int) error
+	GetAnnouncements(token string) (*[]models.Announcement, error)
+	GetActiveAnnouncements(now time.Time, scope models.AnnouncementScope) ([]models.Announcement, error)
+}
+
+type ArchiveServiceI interface {
+	ArchiveInactivePosts(now time.Time, inactivity time.Duration) (int, error)
+}
+
+type DraftServiceI interface {
+	AutosaveDraft(token string, form models.DraftForm, interval time.Duration) (*models.Draft, bool, error)
+}
+
+type BackupServiceI interface {
+	ExportBackup(token, ip string, w io.Writer) error
+	WriteBackup(w io.Writer) error
+	ImportBackup(r io.Reader, batch int) (*models.ImportSummary, error)
+}
+
+type HistoryServiceI interface {
+	RecordView(userID, postID, keep int) error
+	GetHistory(userID int) (*[]models.Post, error)
+}
+
+type NotificationServiceI interface {
+	SendDigests(m mailer.Mailer, t *mailer.Templates, ub *urls.Builder, now time.Time) (int, error)
+	UpdateDigest(token string, digest int) error
+	CountUnread(userID int) (int, error)
+	GetNotifications(token string, limit int) (*[]models.Notification, error)
+	ReadNotification(token string, notificationID int) (*models.Notification, error)
+	ReadAllNotifications(token string, upTo int) error
+}
+
+type SubscriptionServiceI interface {
+	ToggleSubscription(token string, postID int) (bool, error)
+	IsSubscribed(viewer *models.User, postID int) (bool, error)
+	ToggleCategorySubscription(token, category string) (bool, error)
+	IsSubscribedCategory(viewer *models.User, categoryID int) (bool, error)
+	GetSubscribedCategoriesPostsPaginated(userID, curentPage, pageSize int) (*[]models.Post, error)
+}
+
+type FollowServiceI interface {
+	FollowUser(token, name string) error
+	UnfollowUser(token, name string) error
+	IsFollowing(viewer, profile *models.User) (bool, error)
+	GetFollowingPostsPaginated(userID, curentPage, pageSize int) (*[]models.Post, error)
+}
+
+type PasswordResetServiceI interface {
+	SendPasswordReset(m mailer.Mailer, t *mailer.Templates, ub *urls.Builder, email string, ttl time.Duration) error
+	ResetPassword(token, password string) (int, error)
+}
+
+type AuditServiceI interface {
+	RecordAudit(entry models.AuditEntry) error
+	ChangeRole(token, name string, status int, ip string) error
+	CreateAdmin(form models.UserSignupForm, names models.UsernamePolicy, emails models.EmailPolicy) (promoted bool, err error)
+	GetAuditLogPaginated(token string, filter models.AuditFilter, curentPage, pageSize int) (*models.AuditPage, error)
+}
+
+type ModerationServiceI interface {
+	BulkModerate(token, action, reason string, targets []models.ModerationTarget) ([]models.ModerationResult, error)
+	SetCategoryModerator(token, name string, categoryID int, grant bool, ip string) error
+	SetCategoryCommentMode(token string, categoryID int, mode, ip string) error
+	SetCategoryPostStatus(token string, categoryID, status int, ip string) error
+	MovePost(token string, postID, categoryID int, notify bool, ip string) error
+}
+
+type InviteServiceI interface {
+	CreateInvite(token string, quota int) (*models.Invite, error)
+	GetInvites(token string) (*[]models.Invite, error)
+	CreateUserWithInvite(user models.User, code string) error
 }
 
 type InteractionServiceI interface {
-	CommentPost(models.CommentForm) error
-	PostReaction(models.ReactionForm) error
-	CommentReaction(models.ReactionForm) error
+	CommentPost(form models.CommentForm, rules models.CommentRules) error
+	GetComment(commentID int) (*models.Comment, error)
+	GetPostComment(postID, commentID int) (*models.Comment, error)
+	GetPendingComments(token string) (*[]models.Comment, error)
+	ReviewComment(token string, commentID int, approve bool) error
+	PostReaction(form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error)
+	ReactToPost(form models.ReactionForm, types []string) error
+	GetPostReactions(viewer *models.User, postID int, types []string) ([]models.ReactionCount, error)
+	GetReactors(viewer *models.User, postID, commentID int, reactionType string, types []string, page, limit int) (*[]models.Reactor, int, error)
+	CommentReaction(form models.ReactionForm, dedup time.Duration) (*models.ReactionState, error)
 	GetReactionPosts(token string) (map[int]bool, error)
 	GetReactionPost(token string, postID int) (bool, bool, error)
 	IsLikedPost(posts *[]models.Post, reactions map[int]bool) *[]models.Post
 	IsLikedComment(posts *models.Post, reactions map[int]bool) *models.Post
 	GetReactionComment(token string, postID int) (map[int]bool, error)
+	ScorePosts(posts *[]models.Post, weights models.VoteWeights) error
+	ScorePost(post *models.Post, weights models.VoteWeights) error
 }
 
 type UserServiceI interface {
@@ -34,25 +155,50 @@ type UserServiceI interface {
 	CreateUser(models.User) error
 	Authenticate(string, string) (*models.Session, error)
 	DeleteSession(string) error
+	GetUserByName(string) (*models.User, error)
+	SimilarName(name string) (string, error)
+	GetUserActivityPaginated(viewer, profile *models.User, curentPage, pageSize int) (*[]models.Activity, error)
+	UpdatePrivacy(token string, privacy int) error
+	UpdateSort(token, sort string) error
+	UpdateDisplayName(token, displayName string) error
+	UpdateTimezone(token, zone string) error
+	CheckNewUser(token string, rules models.NewUserRules, posting bool, texts ...string) error
 }
 
 type PostServiceI interface {
+	CheckPostLimit(token string, limits models.DailyPostLimits) error
 	CreatePost(string, string, string, []int) (int, error)
+	SetAcceptedAnswer(token string, postID, commentID int) error
+	ToggleProfilePin(token string, postID, limit int) error
+	EditPost(token string, postID int, title, content string, grace time.Duration) error
+	GetPostForEdit(token string, postID int) (*models.Post, error)
+	DeletePost(token string, postID int, grace time.Duration) (bool, error)
+	DeleteComment(token string, commentID int, grace time.Duration) (bool, error)
 	GetPostByID(int) (*models.Post, error)
-	GetAllPostPaginated(curentPage, pageSize int) (*[]models.Post, error)
-	GetAllPostByCategoryPaginated(curentPage, pageSize, category int) (*[]models.Post, error)
+	GetAllPostPaginated(curentPage, pageSize int, sort string) (*[]models.Post, error)
+	GetAllPostByCategoryPaginated(curentPage, pageSize, category int, sort string) (*[]models.Post, error)
 	GetAllPostByCategory(category int) (*[]models.Post, error)
 	GetAllPostByUserPaginated(token string, curentPage, pageSize int) (*[]models.Post, error)
 	GetLikedPostsPaginated(token string, curentPage, pageSize int) (*[]models.Post, error)
+	GetParticipatedPostsPaginated(userID, curentPage, pageSize int) (*[]models.Post, error)
+	GetPostsByUserIDPaginated(userID, curentPage, pageSize int) (*[]models.Post, error)
+	SearchPostsPaginated(filter models.SearchFilter, curentPage, pageSize int) (*[]models.Post, error)
+	GetSuggestions(query string) ([]models.Suggestion, error)
+	SuggestMentions(token, prefix string, postID int) ([]models.MentionSuggestion, error)
+	GetRelatedPosts(postID int) (*[]models.Post, error)
+	GetPostsAfter(after, category, limit int) (*[]models.Post, error)
 	SetUpPage(data *models.TemplateData, r *http.Request) (*models.TemplateData, error)
 }
 
 type CategoryServiceI interface {
 	GetAllCategory() ([]string, error)
+	GetTrendingCategories() ([]string, error)
 }
 
 func New(r repo.RepoI) ServiceI {
 	return &service{
 		r,
+		newUnreadCache(unreadCacheTTL),
+		newLeaderboardCache(leaderboardCacheTTL),
 	}
 }
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"forum/models"
+)
+
+// BulkModerate applies action to targets. reason is kept with locks only.
+func (s *service) BulkModerate(token, action, reason string, targets []models.ModerationTarget) ([]models.ModerationResult, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.ValidModerationAction(action) {
+		return nil, models.ErrInvalidAction
+	}
+	if !user.IsModerator() {
+		if err = s.checkCategoryScope(userID, targets); err != nil {
+			return nil, err
+		}
+	}
+	if action != models.ModerationLock {
+		reason = ""
+	}
+	return s.repo.BulkModerate(action, reason, targets)
+}
+
+// checkCategoryScope makes sure every target lives in a category the user
+// moderates. Global moderators skip this check.
+func (s *service) checkCategoryScope(userID int, targets []models.ModerationTarget) error {
+	for _, target := range targets {
+		postID := target.ID
+		if target.Kind == models.TargetComment {
+			comment, err := s.repo.GetCommentByID(target.ID)
+			if err != nil {
+				if errors.Is(err, models.ErrNoRecord) {
+					return models.ErrForbidden
+				}
+				return err
+			}
+			postID = comment.PostID
+		}
+		ok, err := s.repo.IsCategoryModerator(userID, postID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return models.ErrForbidden
+		}
+	}
+	return nil
+}
+
+// SetCategoryModerator grants or revokes moderation of a category for the
+// user called name. Only admins may do it.
+func (s *service) SetCategoryModerator(token, name string, categoryID int, grant bool, ip string) error {
+	admin, err := s.adminByToken(token)
+	if err != nil {
+		return err
+	}
+	user, err := s.repo.GetUserByName(name)
+	if err != nil {
+		return err
+	}
+	sign := "-"
+	if grant {
+		sign = "+"
+		err = s.repo.AddCategoryModerator(int(user.ID), categoryID)
+	} else {
+		err = s.repo.RemoveCategoryModerator(int(user.ID), categoryID)
+	}
+	if err != nil {
+		return err
+	}
+	return s.RecordAudit(models.AuditEntry{
+		ActorID: int(admin.ID),
+		Action:  models.AuditRoleChange,
+		Target:  fmt.Sprintf("user:%d category:%s%d", user.ID, sign, categoryID),
+		IP:      ip,
+	})
+}
+
+// SetCategoryCommentMode switches the posts of a category between flat and
+// threaded comments. Only admins may do it.
+func (s *service) SetCategoryCommentMode(token string, categoryID int, mode, ip string) error {
+	admin, err := s.adminByToken(token)
+	if err != nil {
+		return err
+	}
+	if !models.ValidCommentMode(mode) {
+		return models.ErrInvalidCommentMode
+	}
+	if err := s.repo.SetCategoryCommentMode(categoryID, mode); err != nil {
+		return err
+	}
+	return s.RecordAudit(models.AuditEntry{
+		ActorID: int(admin.ID),
+		Action:  models.AuditCommentMode,
+		Target:  fmt.Sprintf("category:%d mode:%s", categoryID, mode),
+		IP:      ip,
+	})
+}
+
+// SetCategoryPostStatus limits posting in a category to users of the status
+// or above, like models.StatusAdmin for announcements. Anyone may still
+// comment. Only admins may do it.
+func (s *service) SetCategoryPostStatus(token string, categoryID, status int, ip string) error {
+	admin, err := s.adminByToken(token)
+	if err != nil {
+		return err
+	}
+	if !models.ValidStatus(status) {
+		return models.ErrInvalidStatus
+	}
+	if err := s.repo.SetCategoryPostStatus(categoryID, status); err != nil {
+		return err
+	}
+	return s.RecordAudit(models.AuditEntry{
+		ActorID: int(admin.ID),
+		Action:  models.AuditPostStatus,
+		Target:  fmt.Sprintf("category:%d status:%d", categoryID, status),
+		IP:      ip,
+	})
+}
+
+// MovePost moves the post to the category, in place of the ones it was
+// posted in, and lets the author know when notify is set. Only moderators
+// may do it.
+func (s *service) MovePost(token string, postID, categoryID int, notify bool, ip string) error {
+	moderator, err := s.moderatorByToken(token)
+	if err != nil {
+		return err
+	}
+	userID := int(moderator.ID)
+	post, err := s.repo.GetPostByID(postID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.MovePostToCategory(postID, categoryID); err != nil {
+		return err
+	}
+	err = s.RecordAudit(models.AuditEntry{
+		ActorID: userID,
+		Action:  models.AuditMovePost,
+		Target:  fmt.Sprintf("post:%d category:%d", postID, categoryID),
+		IP:      ip,
+	})
+	if err != nil || !notify || post.UserID == userID {
+		return err
+	}
+	return s.repo.CreateNotifications([]models.Notification{{
+		UserID:  post.UserID,
+		ActorID: userID,
+		Kind:    models.NotificationMoved,
+		PostID:  postID,
+	}})
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"maps"
+	"path/filepath"
+	"testing"
+)
+
+func TestMovePost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sqlite.NewDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	if _, err := raw.Exec(`INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Sports'), (3, 'Music')`); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alice", "mod"} {
+		if err := db.CreateUser(models.User{Name: name, Email: name + "@gmail.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.UpdateUserStatus(2, models.StatusModerator, &models.AuditEntry{ActorID: 2, Action: models.AuditRoleChange}); err != nil {
+		t.Fatal(err)
+	}
+	alice, mod := models.NewSession(1), models.NewSession(2)
+	for _, session := range []*models.Session{alice, mod} {
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := New(db)
+	postID, err := s.CreatePost("Hello", "content", alice.Token, []int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.MovePost(alice.Token, postID, 3, true, "10.0.0.1"); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("got %v moving as the author; expected %v", err, models.ErrForbidden)
+	}
+	if err := s.MovePost(mod.Token, postID, 9, true, "10.0.0.1"); !errors.Is(err, models.UnknownCategory) {
+		t.Errorf("got %v moving to a missing category; expected %v", err, models.UnknownCategory)
+	}
+	if err := s.MovePost(mod.Token, postID+1, 3, true, "10.0.0.1"); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("got %v moving a missing post; expected %v", err, models.ErrNoRecord)
+	}
+	if err := s.MovePost(mod.Token, postID, 3, true, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	post, err := s.GetPostByID(postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int]string{3: "Music"}; !maps.Equal(post.Categories, want) {
+		t.Errorf("got categories %v; expected %v", post.Categories, want)
+	}
+
+	entries, err := db.GetAuditLogPaginated(models.AuditFilter{Action: models.AuditMovePost}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*entries) != 1 {
+		t.Fatalf("got %d audit entries; expected 1", len(*entries))
+	}
+	if entry := (*entries)[0]; entry.ActorName != "mod" || entry.Target != "post:1 category:3" || entry.IP != "10.0.0.1" {
+		t.Errorf("got audit entry %+v", entry)
+	}
+
+	notifications, err := s.GetNotifications(alice.Token, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*notifications) != 1 || (*notifications)[0].Kind != models.NotificationMoved {
+		t.Fatalf("got notifications %+v; expected the move", *notifications)
+	}
+	if got, want := (*notifications)[0].Summary(), `mod moved "Hello" to another category`; got != want {
+		t.Errorf("got %q; expected %q", got, want)
+	}
+
+	// Quiet moves leave the author be.
+	if err := s.MovePost(mod.Token, postID, 1, false, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if notifications, err = s.GetNotifications(alice.Token, 10); err != nil || len(*notifications) != 1 {
+		t.Errorf("got %v, %v after a quiet move; expected the first notification only", notifications, err)
+	}
+}
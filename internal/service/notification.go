@@ -0,0 +1,110 @@
+package service
+
+import (
+	"forum/models"
+	"sync"
+	"time"
+)
+
+// unreadCacheTTL bounds how stale an unread count can get when notifications
+// change without going through the service.
+const unreadCacheTTL = 30 * time.Second
+
+// unreadCache keeps the unread notification count of each user for a short
+// while, as it is shown on every page. Entries are dropped as soon as the
+// service creates or reads a notification of the user.
+type unreadCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[int]unreadEntry
+	now     func() time.Time
+}
+
+type unreadEntry struct {
+	count   int
+	expires time.Time
+}
+
+func newUnreadCache(ttl time.Duration) *unreadCache {
+	return &unreadCache{
+		ttl:     ttl,
+		entries: make(map[int]unreadEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *unreadCache) get(userID int) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[userID]
+	if !ok || !c.now().Before(e.expires) {
+		return 0, false
+	}
+	return e.count, true
+}
+
+func (c *unreadCache) set(userID, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	// Expired entries of users who went away would otherwise pile up.
+	for id, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, id)
+		}
+	}
+	c.entries[userID] = unreadEntry{count: count, expires: now.Add(c.ttl)}
+}
+
+func (c *unreadCache) invalidate(userID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, userID)
+}
+
+func (s *service) CountUnread(userID int) (int, error) {
+	if count, ok := s.unread.get(userID); ok {
+		return count, nil
+	}
+	count, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return 0, err
+	}
+	s.unread.set(userID, count)
+	return count, nil
+}
+
+func (s *service) GetNotifications(token string, limit int) (*[]models.Notification, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetNotifications(userID, limit)
+}
+
+func (s *service) ReadNotification(token string, notificationID int) (*models.Notification, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.repo.MarkNotificationRead(userID, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	s.unread.invalidate(userID)
+	return n, nil
+}
+
+// ReadAllNotifications marks the user's notifications up to upTo, the
+// newest one they were shown, as read. Those that came after stay unread.
+func (s *service) ReadAllNotifications(token string, upTo int) error {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.MarkAllNotificationsRead(userID, upTo); err != nil {
+		return err
+	}
+	s.unread.invalidate(userID)
+	return nil
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"errors"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCountUnread(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []models.User{
+		{Name: "alice", Email: "alice@gmail.com"},
+		{Name: "bob", Email: "bob@gmail.com"},
+	} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob := models.NewSession(1), models.NewSession(2)
+	for _, session := range []*models.Session{alice, bob} {
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	postID, err := db.CreatePost(1, "Hello", "content", "Nan")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(db).(*service)
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s.unread.now = func() time.Time { return now }
+	count := func(want int) {
+		t.Helper()
+		got, err := s.CountUnread(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %d unread notifications; expected %d", got, want)
+		}
+	}
+	comment := func() {
+		t.Helper()
+		form := models.CommentForm{PostID: postID, Token: bob.Token, Content: "hi"}
+		if err := s.CommentPost(form, models.CommentRules{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count(0)
+
+	// Notifications created by the service show up right away.
+	comment()
+	comment()
+	count(2)
+
+	// Changes made behind the service's back wait for the cache to expire.
+	err = db.CreateNotifications([]models.Notification{{UserID: 1, ActorID: 2, Kind: models.NotificationMention, PostID: postID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count(2)
+	now = now.Add(unreadCacheTTL)
+	count(3)
+
+	notifications, err := s.GetNotifications(alice.Token, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*notifications) != 3 {
+		t.Fatalf("got %d notifications; expected 3", len(*notifications))
+	}
+	n, err := s.ReadNotification(alice.Token, (*notifications)[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.PostID != postID {
+		t.Errorf("got post %d; expected %d", n.PostID, postID)
+	}
+	count(2)
+
+	// Bob can't read alice's notifications.
+	if _, err := s.ReadNotification(bob.Token, (*notifications)[1].ID); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("got %v; expected ErrNoRecord", err)
+	}
+	count(2)
+}
+
+func TestReadAllNotifications(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		if err := db.CreateUser(models.User{Name: name, Email: name + "@gmail.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob := models.NewSession(1), models.NewSession(2)
+	for _, session := range []*models.Session{alice, bob} {
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	postID, err := db.CreatePost(1, "Hello", "content", "Nan")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(db).(*service)
+	count := func(userID, want int) {
+		t.Helper()
+		got, err := s.CountUnread(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %d unread notifications for user %d; expected %d", got, userID, want)
+		}
+	}
+	notify := func(userID int) {
+		t.Helper()
+		err := db.CreateNotifications([]models.Notification{{UserID: userID, ActorID: 3 - userID, Kind: models.NotificationMention, PostID: postID}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Made behind the service's back, so the count is stale.
+		s.unread.invalidate(userID)
+	}
+	latest := func() []models.Notification {
+		t.Helper()
+		notifications, err := s.GetNotifications(alice.Token, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *notifications
+	}
+
+	notify(1)
+	notify(1)
+	notify(2)
+	count(1, 2)
+	shown := latest()
+
+	// A notification that came after the page was shown stays unread.
+	notify(1)
+	if err := s.ReadAllNotifications(alice.Token, shown[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	count(1, 1)
+	count(2, 1)
+	for _, n := range latest() {
+		if want := n.ID <= shown[0].ID; n.Read != want {
+			t.Errorf("got notification %d read %t; expected %t", n.ID, n.Read, want)
+		}
+	}
+
+	if err := s.ReadAllNotifications(alice.Token, latest()[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	count(1, 0)
+
+	// The cursor doesn't move back, and new notifications are unread.
+	if err := s.ReadAllNotifications(alice.Token, shown[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	count(1, 0)
+	notify(1)
+	count(1, 1)
+
+	// Reading one by one still works past the cursor.
+	if _, err := s.ReadNotification(alice.Token, latest()[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	count(1, 0)
+}
+
+func TestLikeNotifications(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		if err := db.CreateUser(models.User{Name: name, Email: name + "@gmail.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob := models.NewSession(1), models.NewSession(2)
+	for _, session := range []*models.Session{alice, bob} {
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := New(db)
+	postID, err := s.CreatePost("Hello", "content", alice.Token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CommentPost(models.CommentForm{PostID: postID, Token: alice.Token, Content: "hi"}, models.CommentRules{}); err != nil {
+		t.Fatal(err)
+	}
+	unread := func(want int) {
+		t.Helper()
+		if notifications := mustNotifications(t, s, alice.Token); len(notifications) != want {
+			t.Fatalf("got notifications %+v; expected %d", notifications, want)
+		}
+	}
+
+	// A like, taken back and given again, is told once.
+	for _, reaction := range []bool{true, true, true} {
+		if _, err := s.PostReaction(models.ReactionForm{ID: postID, Token: bob.Token, Reaction: reaction}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unread(1)
+	if got, want := mustNotifications(t, s, alice.Token)[0].Summary(), `bob liked "Hello"`; got != want {
+		t.Errorf("got %q; expected %q", got, want)
+	}
+
+	// Dislikes and liking your own aren't told.
+	if _, err := s.PostReaction(models.ReactionForm{ID: postID, Token: bob.Token, Reaction: false}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CommentReaction(models.ReactionForm{ID: 1, Token: alice.Token, Reaction: true}, 0); err != nil {
+		t.Fatal(err)
+	}
+	unread(1)
+
+	if _, err := s.CommentReaction(models.ReactionForm{ID: 1, Token: bob.Token, Reaction: true}, 0); err != nil {
+		t.Fatal(err)
+	}
+	unread(2)
+	if got, want := mustNotifications(t, s, alice.Token)[0].Summary(), `bob liked your comment on "Hello"`; got != want {
+		t.Errorf("got %q; expected %q", got, want)
+	}
+}
+
+func mustNotifications(t *testing.T, s ServiceI, token string) []models.Notification {
+	t.Helper()
+	notifications, err := s.GetNotifications(token, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *notifications
+}
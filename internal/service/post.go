@@ -1,7 +1,21 @@
 package service
 
 import (
+	"errors"
 	"forum/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	relatedPostsLimit = 5
+	// suggestLimit caps the search suggestions of each kind.
+	suggestLimit = 5
+	// mentionSuggestLimit caps the users offered for an @-mention.
+	mentionSuggestLimit = 8
+	// postLimitWindow is the rolling window of the daily post limits.
+	postLimitWindow = 24 * time.Hour
 )
 
 func (s *service) CreatePost(title, content, token string, categories []int) (int, error) {
@@ -9,15 +23,25 @@ func (s *service) CreatePost(title, content, token string, categories []int) (in
 	if err != nil {
 		return 0, err
 	}
-	postID, err := s.repo.CreatePost(userID, title, content, "Nan")
+	categories, err = s.postCategories(categories)
 	if err != nil {
 		return 0, err
 	}
+	if err = s.canPostIn(userID, categories); err != nil {
+		return 0, err
+	}
 
-	if err = s.repo.AddCategoryToPost(postID, AddCategory(categories)); err != nil {
+	postID, err := s.repo.CreatePost(userID, title, content, "Nan")
+	if err != nil {
 		return 0, err
 	}
-	return postID, err
+	if err = s.repo.AddCategoryToPost(postID, categories); err != nil {
+		return 0, err
+	}
+	if err = s.notifyNewPost(userID, postID, categories); err != nil {
+		return 0, err
+	}
+	return postID, nil
 }
 
 func (s *service) GetPostByID(id int) (*models.Post, error) {
@@ -32,19 +56,149 @@ func (s *service) GetPostByID(id int) (*models.Post, error) {
 	}
 	post.Categories = categories
 
+	labels, err := s.repo.GetLabelsByPostIDs([]int{id})
+	if err != nil {
+		return nil, err
+	}
+	post.Labels = labels[id]
+
+	post.CommentMode, err = s.repo.GetCommentMode(id)
+	if err != nil {
+		return nil, err
+	}
+
+	post.LastEdit, err = s.repo.GetLastRevision(id)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		return nil, err
+	}
+
 	comment, err := s.repo.GetCommentsByPostID(id)
 	if err != nil {
 		return nil, err
 	}
 	if *comment != nil {
 		post.Comment = comment
+		for i := range *comment {
+			if (*comment)[i].CommentID == post.AcceptedAnswerID {
+				post.AcceptedAnswer = &(*comment)[i]
+				break
+			}
+		}
 	}
 
 	return post, nil
 }
 
-func (s *service) GetAllPostPaginated(curentPage, pageSize int) (*[]models.Post, error) {
-	posts, err := s.repo.GetAllPostPaginated(curentPage, pageSize)
+// SetAcceptedAnswer lets the author of the post or a moderator pick one of
+// the post's comments as the answer. commentID 0 unsets it.
+func (s *service) SetAcceptedAnswer(token string, postID, commentID int) error {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	post, err := s.repo.GetPostByID(postID)
+	if err != nil {
+		return err
+	}
+	if !user.CanManagePost(post) {
+		return models.ErrForbidden
+	}
+	if commentID != 0 {
+		comment, err := s.repo.GetCommentByID(commentID)
+		if err != nil {
+			return err
+		}
+		if comment.PostID != postID {
+			return models.ErrForeignComment
+		}
+	}
+	return s.repo.SetAcceptedAnswer(postID, commentID)
+}
+
+// ToggleProfilePin pins the post to its author's profile or unpins it. Only
+// the author may do so, and no more than limit posts can be pinned at once.
+func (s *service) ToggleProfilePin(token string, postID, limit int) error {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	post, err := s.repo.GetPostByID(postID)
+	if err != nil {
+		return err
+	}
+	if post.UserID != userID {
+		return models.ErrForbidden
+	}
+	if !post.ProfilePinned {
+		count, err := s.repo.CountProfilePins(userID)
+		if err != nil {
+			return err
+		}
+		if count >= limit {
+			return models.ErrPinLimit
+		}
+	}
+	return s.repo.SetProfilePinned(postID, !post.ProfilePinned)
+}
+
+// EditPost changes the title and content of a post. The author, moderators
+// and moderators of the post's categories may edit it; an edit by anyone but
+// the author is marked as a moderator edit. Edits the author makes within
+// grace of posting are silent, 0 for none.
+func (s *service) EditPost(token string, postID int, title, content string, grace time.Duration) error {
+	post, userID, err := s.editablePost(token, postID)
+	if err != nil {
+		return err
+	}
+	rev := &models.PostRevision{
+		PostID:      postID,
+		EditorID:    userID,
+		ByModerator: userID != post.UserID,
+		Silent:      userID == post.UserID && time.Since(post.Created) < grace,
+	}
+	return s.repo.UpdatePost(rev, title, content)
+}
+
+// GetPostForEdit returns the post if the user may edit it.
+func (s *service) GetPostForEdit(token string, postID int) (*models.Post, error) {
+	post, _, err := s.editablePost(token, postID)
+	return post, err
+}
+
+func (s *service) editablePost(token string, postID int) (*models.Post, int, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, 0, err
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	post, err := s.repo.GetPostByID(postID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if post.Deleted {
+		return nil, 0, models.ErrNoRecord
+	}
+	if !user.CanManagePost(post) {
+		ok, err := s.repo.IsCategoryModerator(userID, postID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !ok {
+			return nil, 0, models.ErrForbidden
+		}
+	}
+	return post, userID, nil
+}
+
+func (s *service) GetAllPostPaginated(curentPage, pageSize int, sort string) (*[]models.Post, error) {
+	posts, err := s.repo.GetAllPostPaginated(curentPage, pageSize, sort)
 	if err != nil {
 		return nil, err
 	}
@@ -54,8 +208,8 @@ func (s *service) GetAllPostPaginated(curentPage, pageSize int) (*[]models.Post,
 	return posts, nil
 }
 
-func (s *service) GetAllPostByCategoryPaginated(curentPage, pageSize, category int) (*[]models.Post, error) {
-	posts, err := s.repo.GetAllPostByCategoryPaginated(curentPage, pageSize, category)
+func (s *service) GetAllPostByCategoryPaginated(curentPage, pageSize, category int, sort string) (*[]models.Post, error) {
+	posts, err := s.repo.GetAllPostByCategoryPaginated(curentPage, pageSize, category, sort)
 	if err != nil {
 		return nil, err
 	}
@@ -93,6 +247,84 @@ func (s *service) GetAllPostByUserPaginated(token string, curentPage, pageSize i
 	return posts, nil
 }
 
+func (s *service) GetPostsByUserIDPaginated(userID, curentPage, pageSize int) (*[]models.Post, error) {
+	posts, err := s.repo.GetAllPostByUserIDPaginated(userID, curentPage, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.getCategoryToPost(posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (s *service) GetPostsAfter(after, category, limit int) (*[]models.Post, error) {
+	posts, err := s.repo.GetPostsAfter(after, category, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.getCategoryToPost(posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (s *service) GetRelatedPosts(postID int) (*[]models.Post, error) {
+	return s.repo.GetRelatedPosts(postID, relatedPostsLimit)
+}
+
+func (s *service) SearchPostsPaginated(filter models.SearchFilter, curentPage, pageSize int) (*[]models.Post, error) {
+	posts, err := s.repo.SearchPostsPaginated(filter, curentPage, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.getCategoryToPost(posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// GetSuggestions returns what to offer for a search query being typed, up
+// to suggestLimit of each kind. Queries shorter than MinSuggestLength get
+// none.
+func (s *service) GetSuggestions(query string) ([]models.Suggestion, error) {
+	query = strings.TrimSpace(query)
+	if utf8.RuneCountInString(query) < models.MinSuggestLength {
+		return []models.Suggestion{}, nil
+	}
+	suggestions, err := s.repo.GetSuggestions(query, suggestLimit)
+	if err != nil {
+		return nil, err
+	}
+	if suggestions == nil {
+		suggestions = []models.Suggestion{}
+	}
+	return suggestions, nil
+}
+
+// SuggestMentions returns the users whose names start with prefix, an
+// @-mention being typed, as the user of token may see them. With postID set
+// the people on that post come first.
+func (s *service) SuggestMentions(token, prefix string, postID int) ([]models.MentionSuggestion, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	prefix = strings.TrimPrefix(strings.TrimSpace(prefix), "@")
+	if prefix == "" {
+		return []models.MentionSuggestion{}, nil
+	}
+	users, err := s.repo.SuggestMentions(prefix, userID, postID, mentionSuggestLimit)
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = []models.MentionSuggestion{}
+	}
+	return users, nil
+}
+
 func (s *service) GetLikedPostsPaginated(token string, curentPage, pageSize int) (*[]models.Post, error) {
 	userID, err := s.repo.GetUserIDByToken(token)
 	if err != nil {
@@ -109,13 +341,69 @@ func (s *service) GetLikedPostsPaginated(token string, curentPage, pageSize int)
 	return posts, nil
 }
 
+// GetParticipatedPostsPaginated returns the posts userID commented on, the
+// one with the latest comment first.
+func (s *service) GetParticipatedPostsPaginated(userID, curentPage, pageSize int) (*[]models.Post, error) {
+	posts, err := s.repo.GetParticipatedPostsPaginated(userID, curentPage, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.getCategoryToPost(posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// getCategoryToPost fills in the categories and labels of posts with a
+// query each. Their comment counts come with the posts already.
 func (s *service) getCategoryToPost(posts *[]models.Post) error {
+	if posts == nil || len(*posts) == 0 {
+		return nil
+	}
+	ids := make([]int, len(*posts))
+	for i, post := range *posts {
+		ids[i] = post.PostID
+	}
+	categories, err := s.repo.GetCategoriesByPostIDs(ids)
+	if err != nil {
+		return err
+	}
+	labels, err := s.repo.GetLabelsByPostIDs(ids)
+	if err != nil {
+		return err
+	}
 	for i := range *posts {
-		categories, err := s.repo.GetCategoriesByPostID((*posts)[i].PostID)
-		if err != nil {
-			return err
-		}
-		(*posts)[i].Categories = categories
+		(*posts)[i].Categories = categories[(*posts)[i].PostID]
+		(*posts)[i].Labels = labels[(*posts)[i].PostID]
 	}
 	return nil
 }
+
+// CheckPostLimit returns a PostLimitError if the user made as many posts in
+// the last 24 hours as their trust level allows.
+func (s *service) CheckPostLimit(token string, limits models.DailyPostLimits) error {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	limit := limits.For(user)
+	if limit <= 0 {
+		return nil
+	}
+	now := time.Now()
+	times, err := s.repo.GetPostTimesSince(userID, now.Add(-postLimitWindow))
+	if err != nil {
+		return err
+	}
+	if len(times) < limit {
+		return nil
+	}
+	// The next post is allowed once enough of the day's posts are older
+	// than the window to make room for it.
+	next := times[len(times)-limit].Add(postLimitWindow)
+	return &models.PostLimitError{Limit: limit, RetryAfter: next.Sub(now)}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"maps"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCreatePostUncategorized(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateUser(models.User{Name: "alice", Email: "alice@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+	alice := models.NewSession(1)
+	if err := db.CreateSession(alice); err != nil {
+		t.Fatal(err)
+	}
+	s := New(db)
+
+	// Both posts share the category made for the first.
+	var want map[int]string
+	for _, title := range []string{"first", "second"} {
+		postID, err := s.CreatePost(title, "content", alice.Token, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		post, err := s.GetPostByID(postID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			for id := range post.Categories {
+				want = map[int]string{id: models.Uncategorized}
+			}
+		}
+		if len(post.Categories) != 1 || !maps.Equal(post.Categories, want) {
+			t.Errorf("got the %s post in %v; expected it in %v", title, post.Categories, want)
+		}
+	}
+	categories, err := s.GetAllCategory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != 1 {
+		t.Errorf("got categories %q; expected %s only", categories, models.Uncategorized)
+	}
+}
+
+func TestEditGrace(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []models.User{{Name: "alice", Email: "alice@gmail.com"}, {Name: "mod", Email: "mod@gmail.com"}} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.UpdateUserStatus(2, models.StatusModerator, &models.AuditEntry{ActorID: 2, Action: models.AuditRoleChange}); err != nil {
+		t.Fatal(err)
+	}
+	alice, mod := models.NewSession(1), models.NewSession(2)
+	for _, session := range []*models.Session{alice, mod} {
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := New(db)
+	const grace = time.Hour
+
+	edit := func(session *models.Session, postID int, grace time.Duration) *models.PostRevision {
+		t.Helper()
+		if err := s.EditPost(session.Token, postID, "Hello", "fixed a typo", grace); err != nil {
+			t.Fatal(err)
+		}
+		post, err := s.GetPostByID(postID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if post.Content != "fixed a typo" {
+			t.Errorf("got content %q; expected the edit", post.Content)
+		}
+		return post.LastEdit
+	}
+
+	postID, err := s.CreatePost("Hello", "a typo", alice.Token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev := edit(alice, postID, grace); rev != nil {
+		t.Errorf("got %+v within grace; expected no edit mark", rev)
+	}
+	// Nothing is younger than a nanosecond by the time it is edited.
+	if rev := edit(alice, postID, time.Nanosecond); rev == nil || rev.ByModerator {
+		t.Errorf("got %+v after grace; expected the author's edit mark", rev)
+	}
+
+	// Moderators mark their edits however soon they make them.
+	postID, err = s.CreatePost("Hello", "a typo", alice.Token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev := edit(mod, postID, grace); rev == nil || !rev.ByModerator {
+		t.Errorf("got %+v; expected a moderator edit mark", rev)
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"forum/models"
+	"slices"
+)
+
+// ReactToPost gives the post the user's reaction of form.Type, one of
+// types. Users have one reaction per post: reacting with another type
+// replaces it, and reacting with the same one takes it back.
+func (s *service) ReactToPost(form models.ReactionForm, types []string) error {
+	if !slices.Contains(types, form.Type) {
+		return models.ErrUnknownReaction
+	}
+	var err error
+	form.UserID, err = s.repo.GetUserIDByToken(form.Token)
+	if err != nil {
+		return err
+	}
+	if !s.repo.CheckPostExists(form.ID) {
+		return models.ErrNoRecord
+	}
+	if err = s.checkArchived(form.ID); err != nil {
+		return err
+	}
+	current, err := s.repo.GetPostReactionType(form.UserID, form.ID)
+	if err != nil {
+		return err
+	}
+	if current == form.Type {
+		return s.repo.DeletePostReactionType(form.UserID, form.ID)
+	}
+	return s.repo.SetPostReactionType(form)
+}
+
+// GetPostReactions counts the reactions to the post of each of types, in
+// that order, marking the one of viewer. Guests have none.
+func (s *service) GetPostReactions(viewer *models.User, postID int, types []string) ([]models.ReactionCount, error) {
+	counts, err := s.repo.CountPostReactionTypes(postID)
+	if err != nil {
+		return nil, err
+	}
+	var mine string
+	if viewer != nil {
+		if mine, err = s.repo.GetPostReactionType(int(viewer.ID), postID); err != nil {
+			return nil, err
+		}
+	}
+	return models.CountReactions(types, counts, mine), nil
+}
+
+// GetReactors lists a page of the users who reacted to the post, or to its
+// comment commentID when that isn't 0, with reactionType, or any type when
+// it is empty, limit to a page, and how many pages there are. types are
+// the emoji posts may be reacted with. Reactors whose profile viewer may
+// not see are Hidden and listed without their name.
+func (s *service) GetReactors(viewer *models.User, postID, commentID int, reactionType string, types []string, page, limit int) (*[]models.Reactor, int, error) {
+	target := models.ModerationTarget{Kind: models.TargetPost, ID: postID}
+	if commentID != 0 {
+		target = models.ModerationTarget{Kind: models.TargetComment, ID: commentID}
+		types = nil
+	}
+	if reactionType != "" && reactionType != models.ReactionLike && reactionType != models.ReactionDislike && !slices.Contains(types, reactionType) {
+		return nil, 0, models.ErrUnknownReaction
+	}
+	var err error
+	if commentID != 0 {
+		_, err = s.GetPostComment(postID, commentID)
+	} else {
+		_, err = s.repo.GetPostByID(postID)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := s.repo.CountReactors(target, reactionType)
+	if err != nil {
+		return nil, 0, err
+	}
+	if limit < 1 {
+		limit = pageSize
+	}
+	pages := (count + limit - 1) / limit
+	reactors, err := s.repo.GetReactors(target, reactionType, max(page, 1), limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, r := range *reactors {
+		profile := models.User{ID: int64(r.UserID), Privacy: r.Privacy}
+		if !r.Deleted && !profile.ProfileVisibleTo(viewer) {
+			(*reactors)[i] = models.Reactor{Type: r.Type, Hidden: true}
+		}
+	}
+	return reactors, pages, nil
+}
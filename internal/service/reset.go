@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+	"forum/models"
+	"forum/pkg/mailer"
+	"forum/pkg/urls"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SendPasswordReset issues a reset token valid for ttl and mails its link
+// to email. It returns ErrNoRecord for an unknown email, which callers must
+// not reveal.
+func (s *service) SendPasswordReset(m mailer.Mailer, t *mailer.Templates, ub *urls.Builder, email string, ttl time.Duration) error {
+	user, err := s.repo.GetUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	reset, err := models.NewPasswordReset(int(user.ID), ttl)
+	if err != nil {
+		return err
+	}
+	if err = s.repo.CreatePasswordReset(reset); err != nil {
+		return err
+	}
+	subject, body, err := t.Render("", mailer.PasswordReset, mailer.ResetData{
+		Name:    user.Name,
+		Link:    ub.PasswordReset(reset.Token),
+		Expires: reset.Expires,
+	})
+	if err != nil {
+		return fmt.Errorf("password reset for user %d: %w", reset.UserID, err)
+	}
+	if err := m.SendHTML(user.Email, subject, body); err != nil {
+		return fmt.Errorf("password reset for user %d: %w", reset.UserID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets the new password and returns the ID of the user whose
+// password was reset.
+func (s *service) ResetPassword(token, password string) (int, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return 0, err
+	}
+	userID, err := s.repo.ResetPassword(models.HashResetToken(token), hashedPassword)
+	if err != nil {
+		return 0, err
+	}
+	// The database drops the sessions along with the reset, other session
+	// stores are told here.
+	if err := s.repo.DeleteSessionByUserID(userID); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
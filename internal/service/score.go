@@ -0,0 +1,37 @@
+package service
+
+import "forum/models"
+
+// ScorePosts sets the Score of posts, weighting each reaction by the trust
+// level of its user. Zero weights, as in a config that never set them, count
+// every reaction once.
+func (s *service) ScorePosts(posts *[]models.Post, weights models.VoteWeights) error {
+	if posts == nil || len(*posts) == 0 {
+		return nil
+	}
+	if weights == (models.VoteWeights{}) {
+		weights = models.DefaultVoteWeights
+	}
+	ids := make([]int, len(*posts))
+	for i, post := range *posts {
+		ids[i] = post.PostID
+	}
+	scores, err := s.repo.GetPostScores(ids, weights)
+	if err != nil {
+		return err
+	}
+	for i := range *posts {
+		(*posts)[i].Score = scores[(*posts)[i].PostID]
+	}
+	return nil
+}
+
+// ScorePost is ScorePosts for a single post.
+func (s *service) ScorePost(post *models.Post, weights models.VoteWeights) error {
+	posts := []models.Post{*post}
+	if err := s.ScorePosts(&posts, weights); err != nil {
+		return err
+	}
+	post.Score = posts[0].Score
+	return nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"forum/models"
+	"strings"
+)
+
+// ToggleSubscription flips the user's subscription to the post and returns
+// whether they are subscribed now.
+func (s *service) ToggleSubscription(token string, postID int) (bool, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return false, err
+	}
+	if !s.repo.CheckPostExists(postID) {
+		return false, models.ErrNoRecord
+	}
+	subscribed, err := s.repo.IsSubscribed(userID, postID)
+	if err != nil {
+		return false, err
+	}
+	sub := models.Subscription{UserID: userID, PostID: postID, Subscribed: !subscribed}
+	if err = s.repo.SetSubscription(sub); err != nil {
+		return false, err
+	}
+	return sub.Subscribed, nil
+}
+
+func (s *service) IsSubscribed(viewer *models.User, postID int) (bool, error) {
+	if viewer == nil {
+		return false, nil
+	}
+	return s.repo.IsSubscribed(int(viewer.ID), postID)
+}
+
+// ToggleCategorySubscription flips the user's subscription to the category,
+// named as on the home page, and returns whether they are subscribed now.
+func (s *service) ToggleCategorySubscription(token, category string) (bool, error) {
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return false, err
+	}
+	categoryID, err := s.categoryID(category)
+	if err != nil {
+		return false, err
+	}
+	sub := models.CategorySubscription{UserID: userID, CategoryID: categoryID}
+	subscribed, err := s.repo.IsSubscribedCategory(userID, categoryID)
+	if err != nil {
+		return false, err
+	}
+	if subscribed {
+		return false, s.repo.UnsubscribeCategory(sub)
+	}
+	return true, s.repo.SubscribeCategory(sub)
+}
+
+func (s *service) IsSubscribedCategory(viewer *models.User, categoryID int) (bool, error) {
+	if viewer == nil || categoryID == 0 {
+		return false, nil
+	}
+	return s.repo.IsSubscribedCategory(int(viewer.ID), categoryID)
+}
+
+func (s *service) GetSubscribedCategoriesPostsPaginated(userID, curentPage, pageSize int) (*[]models.Post, error) {
+	posts, err := s.repo.GetSubscribedCategoriesPostsPaginated(userID, curentPage, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.getCategoryToPost(posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// categoryID resolves a category name, in any case, to its id.
+func (s *service) categoryID(name string) (int, error) {
+	categories, err := s.repo.GetALLCategory()
+	if err != nil {
+		return 0, err
+	}
+	for i, c := range categories {
+		if strings.EqualFold(c, name) {
+			return i + 1, nil
+		}
+	}
+	return 0, models.ErrNoRecord
+}
+
+// notifyNewPost lets the subscribers of the post's categories know about it,
+// except its author.
+func (s *service) notifyNewPost(userID, postID int, categories []int) error {
+	subscribers, err := s.repo.GetCategorySubscribers(categories)
+	if err != nil {
+		return err
+	}
+	var notifications []models.Notification
+	for _, id := range subscribers {
+		if id == userID {
+			continue
+		}
+		notifications = append(notifications, models.Notification{UserID: id, ActorID: userID, Kind: models.NotificationNewPost, PostID: postID})
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := s.repo.CreateNotifications(notifications); err != nil {
+		return err
+	}
+	for _, n := range notifications {
+		s.unread.invalidate(n.UserID)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"forum/internal/repo/sqlite"
+	"forum/models"
+	"path/filepath"
+	"testing"
+)
+
+func TestSubscriptions(t *testing.T) {
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"alice", "bob", "carol", "dave"}
+	tokens := map[string]string{}
+	for i, name := range names {
+		if err := db.CreateUser(models.User{Name: name, Email: name + "@gmail.com"}); err != nil {
+			t.Fatal(err)
+		}
+		session := models.NewSession(i + 1)
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+		tokens[name] = session.Token
+	}
+	s := New(db)
+	postID, err := s.CreatePost("Hello", "content", tokens["alice"], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := func(name string) {
+		t.Helper()
+		form := models.CommentForm{PostID: postID, Token: tokens[name], Content: "hi"}
+		if err := s.CommentPost(form, models.CommentRules{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	toggle := func(name string, want bool) {
+		t.Helper()
+		subscribed, err := s.ToggleSubscription(tokens[name], postID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subscribed != want {
+			t.Errorf("%s: got subscribed %v; expected %v", name, subscribed, want)
+		}
+	}
+	unread := func(want map[string]int) {
+		t.Helper()
+		for i, name := range names {
+			got, err := db.CountUnread(i + 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want[name] {
+				t.Errorf("%s: got %d unread notifications; expected %d", name, got, want[name])
+			}
+		}
+	}
+
+	// The author is subscribed, bob is subscribed by commenting.
+	comment("bob")
+	unread(map[string]int{"alice": 1})
+
+	toggle("carol", true)
+	comment("dave")
+	unread(map[string]int{"alice": 2, "bob": 1, "carol": 1})
+
+	// An unsubscribe sticks even when commenting again.
+	toggle("bob", false)
+	comment("bob")
+	comment("alice")
+	unread(map[string]int{"alice": 3, "bob": 1, "carol": 3, "dave": 2})
+
+	toggle("alice", false)
+	comment("carol")
+	unread(map[string]int{"alice": 3, "bob": 1, "carol": 3, "dave": 3})
+
+	notifications, err := s.GetNotifications(tokens["dave"], 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range *notifications {
+		if n.Kind != models.NotificationComment {
+			t.Errorf("got kind %q; expected %q", n.Kind, models.NotificationComment)
+		}
+	}
+}
+
+func TestCategorySubscriptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sqlite.NewDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	if _, err := raw.Exec(`INSERT INTO category (id, name) VALUES (1, 'Technology'), (2, 'Sports')`); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"alice", "bob", "carol"}
+	tokens := map[string]string{}
+	for i, name := range names {
+		if err := db.CreateUser(models.User{Name: name, Email: name + "@gmail.com"}); err != nil {
+			t.Fatal(err)
+		}
+		session := models.NewSession(i + 1)
+		if err := db.CreateSession(session); err != nil {
+			t.Fatal(err)
+		}
+		tokens[name] = session.Token
+	}
+	categories, err := db.GetALLCategory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(db)
+
+	toggle := func(name string, want bool) {
+		t.Helper()
+		subscribed, err := s.ToggleCategorySubscription(tokens[name], categories[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subscribed != want {
+			t.Errorf("%s: got subscribed %v; expected %v", name, subscribed, want)
+		}
+	}
+	unread := func(want map[string]int) {
+		t.Helper()
+		for i, name := range names {
+			got, err := db.CountUnread(i + 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want[name] {
+				t.Errorf("%s: got %d unread notifications; expected %d", name, got, want[name])
+			}
+		}
+	}
+
+	toggle("alice", true)
+	toggle("bob", true)
+	toggle("carol", true)
+	toggle("carol", false)
+
+	// The form counts categories from 0. The author isn't notified of their
+	// own post, and posts in other categories notify nobody.
+	if _, err := s.CreatePost("Hello", "content", tokens["alice"], []int{0}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreatePost("Other", "content", tokens["carol"], []int{1}); err != nil {
+		t.Fatal(err)
+	}
+	unread(map[string]int{"bob": 1})
+
+	notifications, err := s.GetNotifications(tokens["bob"], 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*notifications) != 1 || (*notifications)[0].Kind != models.NotificationNewPost {
+		t.Errorf("got notifications %+v; expected one of kind %q", *notifications, models.NotificationNewPost)
+	}
+
+	posts, err := s.GetSubscribedCategoriesPostsPaginated(2, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*posts) != 1 || (*posts)[0].Title != "Hello" {
+		t.Errorf("got feed %+v; expected only Hello", *posts)
+	}
+
+	if _, err := s.ToggleCategorySubscription(tokens["alice"], "nope"); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("got error %v; expected %v", err, models.ErrNoRecord)
+	}
+}
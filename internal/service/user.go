@@ -2,8 +2,11 @@ package service
 
 import (
 	"forum/models"
+	"forum/pkg/confusable"
 	"forum/pkg/cookie"
+	"forum/pkg/markdown"
 	"net/http"
+	"time"
 )
 
 func (s *service) GetUser(r *http.Request) (*models.User, error) {
@@ -48,3 +51,128 @@ func (s *service) CreateUser(user models.User) error {
 	err := s.repo.CreateUser(user)
 	return err
 }
+
+func (s *service) GetUserByName(name string) (*models.User, error) {
+	return s.repo.GetUserByName(name)
+}
+
+// SimilarName returns the name of an existing user that name could be
+// mistaken for, or "" if there is none. The name itself being taken is left
+// to CreateUser.
+func (s *service) SimilarName(name string) (string, error) {
+	names, err := s.repo.GetUserNames()
+	if err != nil {
+		return "", err
+	}
+	skeleton := confusable.Skeleton(name)
+	for _, existing := range names {
+		if existing != name && confusable.Skeleton(existing) == skeleton {
+			return existing, nil
+		}
+	}
+	return "", nil
+}
+
+func (s *service) GetUserActivityPaginated(viewer, profile *models.User, curentPage, pageSize int) (*[]models.Activity, error) {
+	return s.repo.GetUserActivityPaginated(int(profile.ID), canSeeReactions(viewer, profile), curentPage, pageSize)
+}
+
+// canSeeReactions reports whether viewer may see what profile liked and
+// disliked. Reactions are private, so only the owner gets to see them.
+func canSeeReactions(viewer, profile *models.User) bool {
+	return viewer != nil && viewer.ID == profile.ID
+}
+
+func (s *service) UpdatePrivacy(token string, privacy int) error {
+	if !models.ValidPrivacy(privacy) {
+		return models.ErrInvalidPrivacy
+	}
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateUserPrivacy(userID, privacy)
+}
+
+func (s *service) UpdateDisplayName(token, displayName string) error {
+	if !models.ValidDisplayName(displayName) {
+		return models.ErrInvalidDisplayName
+	}
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateUserDisplayName(userID, displayName)
+}
+
+func (s *service) UpdateTimezone(token, zone string) error {
+	if !models.ValidTimezone(zone) {
+		return models.ErrInvalidTimezone
+	}
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateUserTimezone(userID, zone)
+}
+
+func (s *service) UpdateSort(token, sort string) error {
+	if sort != "" && !models.ValidSort(sort) {
+		return models.ErrInvalidSort
+	}
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateUserSort(userID, sort)
+}
+
+// CheckNewUser returns ErrNewUserLinks if the user's account is still new by
+// rules and one of texts links somewhere, or ErrNewUserPostLimit if it is
+// posting and made its posts for the day already.
+func (s *service) CheckNewUser(token string, rules models.NewUserRules, posting bool, texts ...string) error {
+	if !rules.Enabled() {
+		return nil
+	}
+	userID, err := s.repo.GetUserIDByToken(token)
+	if err != nil {
+		return err
+	}
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	isNew, err := s.isNewUser(user, rules)
+	if err != nil || !isNew {
+		return err
+	}
+
+	for _, text := range texts {
+		if markdown.HasLink(text) {
+			return models.ErrNewUserLinks
+		}
+	}
+	if posting && rules.PostsPerDay > 0 {
+		today, err := s.repo.CountPostsSince(userID, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			return err
+		}
+		if today >= rules.PostsPerDay {
+			return models.ErrNewUserPostLimit
+		}
+	}
+	return nil
+}
+
+// isNewUser reports whether the account of user is still new by rules.
+// Moderators never are.
+func (s *service) isNewUser(user *models.User, rules models.NewUserRules) (bool, error) {
+	if !rules.Enabled() || user.IsModerator() {
+		return false, nil
+	}
+	posts, err := s.repo.CountPostsSince(int(user.ID), time.Time{})
+	if err != nil {
+		return false, err
+	}
+	return time.Since(user.Created) < rules.MinAge || posts < rules.MinPosts, nil
+}
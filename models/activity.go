@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	ActivityPost     = "post"
+	ActivityComment  = "comment"
+	ActivityReaction = "reaction"
+)
+
+// Activity is a single entry of a user's timeline: a post, a comment or a
+// reaction to a post or comment.
+type Activity struct {
+	Kind      string
+	PostID    int
+	PostTitle string
+	CommentID int
+	Content   string
+	IsLike    bool
+	Created   time.Time
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// AnnouncementMaxLen caps the length of an announcement message, in runes.
+const AnnouncementMaxLen = 300
+
+// Announcement is a banner admins show from Start until End. It is site-wide
+// when CategoryID and PostID are 0, otherwise it shows only on the pages of
+// that category or post.
+type Announcement struct {
+	ID         int       `json:"id"`
+	Message    string    `json:"message"`
+	CategoryID int       `json:"category_id"`
+	PostID     int       `json:"post_id"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Created    time.Time `json:"created"`
+}
+
+// Active reports whether the announcement shows at now.
+func (a Announcement) Active(now time.Time) bool {
+	return !now.Before(a.Start) && now.Before(a.End)
+}
+
+// AnnouncementScope selects the announcements of a page: the site-wide ones,
+// plus those of Categories and of PostID.
+type AnnouncementScope struct {
+	Categories []int
+	PostID     int
+}
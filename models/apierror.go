@@ -0,0 +1,49 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// Codes of the errors the JSON API answers with.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidation       = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeTooLarge         = "too_large"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal"
+	CodeUnavailable      = "unavailable"
+)
+
+// APIErrorBody is the envelope of every JSON API error:
+// {"error":{"code":"...","message":"...","fields":{...}}}. Fields maps form
+// fields to what is wrong with them and is only set for validation errors.
+type APIErrorBody struct {
+	Error APIError `json:"error"`
+}
+
+type APIError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// ValidationError is returned for input that failed validation, with the
+// message of each bad field.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return "models: invalid " + strings.Join(fields, ", ")
+}
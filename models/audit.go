@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	AuditLogin         = "login"
+	AuditLogout        = "logout"
+	AuditPasswordReset = "password_reset"
+	AuditRoleChange    = "role_change"
+	AuditDelete        = "delete"
+	AuditExport        = "export"
+	AuditCommentMode   = "comment_mode"
+	AuditMovePost      = "move_post"
+	AuditPostStatus    = "post_status"
+)
+
+// AuditEntry records who did what to which target, and from where.
+type AuditEntry struct {
+	ID        int       `json:"id"`
+	ActorID   int       `json:"actor_id"`
+	ActorName string    `json:"actor_name"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	IP        string    `json:"ip"`
+	Created   time.Time `json:"created"`
+}
+
+type AuditFilter struct {
+	Actor  string
+	Action string
+}
+
+// AuditPage is one page of the audit log as served to admins.
+type AuditPage struct {
+	Entries []AuditEntry `json:"entries"`
+	Page    int          `json:"page"`
+	Pages   int          `json:"pages"`
+}
+
+func ValidStatus(status int) bool {
+	return status >= StatusUser && status <= StatusAdmin
+}
@@ -0,0 +1,108 @@
+package models
+
+import "time"
+
+// BackupVersion is the version of the backup format. It changes whenever a
+// record changes in a way an older import can't read.
+const BackupVersion = 1
+
+// A backup is NDJSON: one BackupRecord per line, the header first, then the
+// records in the order below, so everything a record refers to comes before
+// it. The only exception is a post's accepted answer, which is a comment.
+const (
+	BackupHeaderType          = "header"
+	BackupUserType            = "user"
+	BackupCategoryType        = "category"
+	BackupPostType            = "post"
+	BackupCommentType         = "comment"
+	BackupPostReactionType    = "post_reaction"
+	BackupCommentReactionType = "comment_reaction"
+)
+
+type BackupRecord struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+type BackupHeader struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// BackupUser leaves out the password hash, so imported users have to reset
+// their password before they can log in.
+type BackupUser struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Email       string    `json:"email"`
+	Created     time.Time `json:"created"`
+	Status      int       `json:"status"`
+	Privacy     int       `json:"privacy"`
+	// Guest marks the account guests post under.
+	Guest bool `json:"guest,omitempty"`
+}
+
+type BackupCategory struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type BackupPost struct {
+	ID               int       `json:"id"`
+	UserID           int       `json:"user_id"`
+	Title            string    `json:"title"`
+	Content          string    `json:"content"`
+	ImageName        string    `json:"image_name"`
+	Created          time.Time `json:"created"`
+	Locked           bool      `json:"locked"`
+	LockReason       string    `json:"lock_reason,omitempty"`
+	Approved         bool      `json:"approved"`
+	ProfilePinned    bool      `json:"profile_pinned,omitempty"`
+	AcceptedAnswerID int       `json:"accepted_answer_id,omitempty"`
+	Categories       []int     `json:"categories"`
+	// GuestName is the name a guest post was sent under.
+	GuestName string `json:"guest_name,omitempty"`
+}
+
+type BackupComment struct {
+	ID              int       `json:"id"`
+	PostID          int       `json:"post_id"`
+	UserID          int       `json:"user_id"`
+	Content         string    `json:"content"`
+	Created         time.Time `json:"created"`
+	QuotedCommentID int       `json:"quoted_comment_id,omitempty"`
+	QuoteExcerpt    string    `json:"quote_excerpt,omitempty"`
+	Approved        bool      `json:"approved"`
+	GuestName       string    `json:"guest_name,omitempty"`
+}
+
+// BackupReaction is a like or dislike of the post or comment TargetID.
+// Reactions from before reaction times were recorded have no Created.
+type BackupReaction struct {
+	UserID   int        `json:"user_id"`
+	TargetID int        `json:"target_id"`
+	Like     bool       `json:"like"`
+	Created  *time.Time `json:"created,omitempty"`
+}
+
+// ImportSummary counts, per record type, the records an import created and
+// the ones it skipped because they were already there.
+type ImportSummary struct {
+	Imported map[string]int
+	Skipped  map[string]int
+}
+
+func NewImportSummary() *ImportSummary {
+	return &ImportSummary{Imported: map[string]int{}, Skipped: map[string]int{}}
+}
+
+// Add adds the counts of other to s.
+func (s *ImportSummary) Add(other *ImportSummary) {
+	for kind, n := range other.Imported {
+		s.Imported[kind] += n
+	}
+	for kind, n := range other.Skipped {
+		s.Skipped[kind] += n
+	}
+}
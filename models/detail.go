@@ -0,0 +1,82 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// PostDetail is a post and a page of its comments as sent to clients that
+// ask for JSON. Mine is the viewer's own vote, as in ReactionState. While
+// the reactions of the post are held back, ScoresHidden is set and Score is
+// 0. The comments keep their scores, as on the page.
+type PostDetail struct {
+	ID           int             `json:"id"`
+	Title        string          `json:"title"`
+	Content      string          `json:"content"`
+	Author       string          `json:"author"`
+	Created      time.Time       `json:"created"`
+	Categories   []string        `json:"categories"`
+	Score        int             `json:"score"`
+	Mine         int             `json:"mine"`
+	ScoresHidden bool            `json:"scores_hidden,omitempty"`
+	Comments     []CommentDetail `json:"comments"`
+	Page         int             `json:"page"`
+	Pages        int             `json:"pages"`
+}
+
+// CommentDetail is a comment of a PostDetail. Score is its likes minus its
+// dislikes, and Depth how deep it is shown in a threaded post.
+type CommentDetail struct {
+	ID        int       `json:"id"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	Created   time.Time `json:"created"`
+	ReplyTo   int       `json:"reply_to,omitempty"`
+	Depth     int       `json:"depth"`
+	Score     int       `json:"score"`
+	Mine      int       `json:"mine"`
+	Collapsed bool      `json:"collapsed,omitempty"`
+}
+
+// NewPostDetail sends the post as it is shown, with the comments already
+// picked, its own and the viewer's reactions filled in. It looks nothing
+// up by itself.
+func NewPostDetail(post *Post, page, pages int, scoresHidden bool) PostDetail {
+	detail := PostDetail{
+		ID:           post.PostID,
+		Title:        post.Title,
+		Content:      post.Content,
+		Author:       post.AuthorName(),
+		Created:      post.Created,
+		Categories:   []string{},
+		Mine:         post.IsLiked,
+		ScoresHidden: scoresHidden,
+		Comments:     []CommentDetail{},
+		Page:         page,
+		Pages:        pages,
+	}
+	for _, name := range post.Categories {
+		detail.Categories = append(detail.Categories, name)
+	}
+	slices.Sort(detail.Categories)
+	if !scoresHidden {
+		detail.Score = post.Score
+	}
+	if post.Comment == nil {
+		return detail
+	}
+	for _, c := range *post.Comment {
+		detail.Comments = append(detail.Comments, CommentDetail{
+			ID:        c.CommentID,
+			Author:    c.AuthorName(),
+			Content:   c.Content,
+			Created:   c.Created,
+			ReplyTo:   c.QuotedCommentID,
+			Depth:     c.Depth,
+			Score:     c.Score(),
+			Mine:      c.IsLiked,
+			Collapsed: c.Collapsed,
+		})
+	}
+	return detail
+}
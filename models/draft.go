@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Draft is unsent work on a post, stored by the editor while the user types
+// so it survives a crash. A user has one draft per editor Session.
+type Draft struct {
+	ID      int
+	UserID  int
+	Session string
+	Title   string
+	Content string
+	Updated time.Time
+}
+
+// DraftForm is the body of an autosave request.
+type DraftForm struct {
+	Session string `json:"session"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// DraftSaved answers an autosave. Saved is false when the save came too soon
+// after the last one and was ignored, ID and UpdatedAt are then those of the
+// stored draft.
+type DraftSaved struct {
+	ID        int       `json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Saved     bool      `json:"saved"`
+}
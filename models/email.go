@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+)
+
+// EmailRule is how a provider reads the local part of its addresses:
+// StripDots ignores the dots in it and StripTags anything from a "+" on.
+type EmailRule struct {
+	StripDots bool
+	StripTags bool
+}
+
+// EmailPolicy maps email domains to their rules, so that aliases of one
+// mailbox count as the same address. Domains without a rule are only
+// lowercased.
+type EmailPolicy map[string]EmailRule
+
+// DefaultEmailPolicy knows Gmail, which ignores both dots and tags.
+var DefaultEmailPolicy = EmailPolicy{
+	"gmail.com":      {StripDots: true, StripTags: true},
+	"googlemail.com": {StripDots: true, StripTags: true},
+}
+
+// ParseEmailPolicy reads a comma separated list of domains and their rules,
+// dots, tags or both joined by "+", like "gmail.com=dots+tags,outlook.com=tags".
+// An empty list has no rules.
+func ParseEmailPolicy(s string) (EmailPolicy, error) {
+	policy := EmailPolicy{}
+	for _, field := range strings.Split(s, ",") {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		domain, rules, _ := strings.Cut(field, "=")
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" || strings.Contains(domain, "@") {
+			return nil, ErrInvalidEmailPolicy
+		}
+		var rule EmailRule
+		for _, r := range strings.Split(rules, "+") {
+			switch strings.TrimSpace(r) {
+			case "dots":
+				rule.StripDots = true
+			case "tags":
+				rule.StripTags = true
+			default:
+				return nil, ErrInvalidEmailPolicy
+			}
+		}
+		policy[domain] = rule
+	}
+	return policy, nil
+}
+
+// Normalize returns the address email is checked for uniqueness by. The
+// email itself is kept as given for sending mail to.
+func (p EmailPolicy) Normalize(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return email
+	}
+	local, domain := email[:i], email[i+1:]
+	rule := p[domain]
+	if rule.StripTags {
+		local, _, _ = strings.Cut(local, "+")
+	}
+	if rule.StripDots {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	return local + "@" + domain
+}
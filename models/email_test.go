@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEmailPolicyNormalize(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"user@gmail.com", "user@gmail.com"},
+		{"user+tag@gmail.com", "user@gmail.com"},
+		{"u.ser@gmail.com", "user@gmail.com"},
+		{" U.Ser+a+b@GMail.com", "user@gmail.com"},
+		{"u.ser+tag@googlemail.com", "user@googlemail.com"},
+		{"u.ser+tag@example.com", "u.ser+tag@example.com"},
+		{"User@Example.com", "user@example.com"},
+	}
+	for _, tt := range tests {
+		if got := DefaultEmailPolicy.Normalize(tt.email); got != tt.want {
+			t.Errorf("Normalize(%q) = %q; expected %q", tt.email, got, tt.want)
+		}
+	}
+
+	var none EmailPolicy
+	if got := none.Normalize("U.ser+tag@gmail.com"); got != "u.ser+tag@gmail.com" {
+		t.Errorf("got %q without rules; expected the address lowercased", got)
+	}
+}
+
+func TestParseEmailPolicy(t *testing.T) {
+	policy, err := ParseEmailPolicy(" Outlook.com=tags, gmail.com=dots+tags,,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := policy.Normalize("u.ser+tag@outlook.com"), "u.ser@outlook.com"; got != want {
+		t.Errorf("got %q; expected %q", got, want)
+	}
+	if got, want := policy.Normalize("u.ser+tag@gmail.com"), "user@gmail.com"; got != want {
+		t.Errorf("got %q; expected %q", got, want)
+	}
+	if policy, err := ParseEmailPolicy(""); err != nil || len(policy) != 0 {
+		t.Errorf("got %v, %v for no rules; expected an empty policy", policy, err)
+	}
+	for _, s := range []string{"gmail.com", "gmail.com=dashes", "=tags", "a@gmail.com=tags"} {
+		if _, err := ParseEmailPolicy(s); !errors.Is(err, ErrInvalidEmailPolicy) {
+			t.Errorf("ParseEmailPolicy(%q) = %v; expected %v", s, err, ErrInvalidEmailPolicy)
+		}
+	}
+}
@@ -11,5 +11,70 @@ var (
 
 	ErrDuplicateName = errors.New("models: duplicate name")
 
+	ErrInvalidName = errors.New("models: name breaks the username policy")
+
+	ErrReservedName = errors.New("models: name is reserved")
+
+	ErrInvalidEmail = errors.New("models: invalid email address")
+
+	ErrWeakPassword = errors.New("models: password breaks the password policy")
+
 	UnknownCategory = errors.New("models: category doesnt exist")
+
+	ErrInvalidQuote = errors.New("models: quoted comment doesnt belong to the post")
+
+	ErrInvalidInvite = errors.New("models: invalid or already used invite code")
+
+	ErrInviteQuota = errors.New("models: invite quota exceeded")
+
+	ErrPinLimit = errors.New("models: too many pinned posts")
+
+	ErrInvalidPrivacy = errors.New("models: unknown privacy setting")
+
+	ErrForeignComment = errors.New("models: comment doesnt belong to the post")
+
+	ErrForbidden = errors.New("models: action not allowed")
+
+	ErrInvalidAction = errors.New("models: unknown moderation action")
+
+	ErrPostLocked = errors.New("models: post is locked")
+
+	ErrPostArchived = errors.New("models: post is archived")
+
+	ErrThreadTooDeep = errors.New("models: replies nested too deep")
+
+	ErrInvalidDateRange = errors.New("models: invalid date range")
+
+	ErrInvalidStatus = errors.New("models: unknown user status")
+
+	ErrInvalidSort = errors.New("models: unknown sort order")
+
+	ErrInvalidDigest = errors.New("models: unknown digest frequency")
+
+	ErrInvalidDisplayName = errors.New("models: invalid display name")
+	ErrInvalidTimezone    = errors.New("models: invalid timezone")
+
+	ErrNewUserLinks     = errors.New("models: new accounts can't post links")
+	ErrNewUserPostLimit = errors.New("models: new accounts made too many posts today")
+
+	ErrCommentCooldown = errors.New("models: commenting too fast")
+	ErrPostLimit       = errors.New("models: too many posts today")
+
+	ErrInvalidBackup = errors.New("models: invalid or unsupported backup")
+
+	ErrSelfFollow = errors.New("models: users can't follow themselves")
+
+	ErrInvalidAnnouncement = errors.New("models: invalid announcement")
+
+	ErrUnknownLabel = errors.New("models: unknown post label")
+
+	ErrUnknownReaction = errors.New("models: unknown reaction type")
+
+	ErrInvalidLeaderboard = errors.New("models: unknown leaderboard metric or period")
+
+	ErrInvalidCommentMode = errors.New("models: unknown comment mode")
+
+	ErrInvalidResetToken = errors.New("models: invalid, expired or already used reset token")
+
+	ErrInvalidEmailPolicy = errors.New("models: invalid email policy")
 )
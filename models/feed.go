@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// FeedItem is a post as sent by the JSON feed. HTML is the post card, the
+// same markup the home page renders. While the reactions of the post are
+// held back, ScoresHidden is set and Score is 0.
+type FeedItem struct {
+	ID           int       `json:"id"`
+	Title        string    `json:"title"`
+	URL          string    `json:"url"`
+	Author       string    `json:"author"`
+	Created      time.Time `json:"created"`
+	Score        int       `json:"score"`
+	ScoresHidden bool      `json:"scores_hidden,omitempty"`
+	HTML         string    `json:"html"`
+	Stats        TextStats `json:"stats"`
+}
+
+// FeedPage is one page of the feed. Next is the cursor for the following
+// page, 0 when there is nothing more to load.
+type FeedPage struct {
+	Posts []FeedItem `json:"posts"`
+	Next  int        `json:"next"`
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type Follow struct {
+	FollowerID int
+	FolloweeID int
+	Created    time.Time
+}
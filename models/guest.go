@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// GuestAccount is the account that posts and comments sent without one are
+// published under. The name each guest gave is kept with what they wrote and
+// shown in place of the account's.
+const GuestAccount = "guest"
+
+// GuestNameMaxLen caps the name a guest posts under, in runes.
+const GuestNameMaxLen = 30
+
+// GuestPost is a post sent without an account as moderators review it. It
+// is stored as a pending post of GuestAccount, see Post.Pending, and ID is
+// the post's.
+type GuestPost struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	IP         string    `json:"ip"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	Categories []int     `json:"categories"`
+	Created    time.Time `json:"created"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Invite struct {
+	ID          int
+	Code        string
+	InviterID   int
+	InviteeID   int
+	InviteeName string
+	Created     time.Time
+}
+
+func NewInvite(inviterID int) *Invite {
+	return &Invite{
+		Code:      uuid.New().String(),
+		InviterID: inviterID,
+	}
+}
+
+func (i Invite) Used() bool {
+	return i.InviteeID != 0
+}
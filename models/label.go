@@ -0,0 +1,34 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultPostLabels are the labels moderators pick from unless configured
+// otherwise.
+var DefaultPostLabels = []string{"Announcement", "Resolved", "Pinned"}
+
+// PostLabel is a flair a moderator put on a post, such as "Resolved". A post
+// may carry several of them.
+type PostLabel struct {
+	PostID int
+	Label  string
+}
+
+// ParsePostLabels reads a comma separated list of labels, like
+// "Announcement,Resolved". Blanks and repeats are dropped.
+func ParsePostLabels(s string) ([]string, error) {
+	var labels []string
+	for _, label := range strings.Split(s, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" || slices.Contains(labels, label) {
+			continue
+		}
+		labels = append(labels, label)
+	}
+	if len(labels) == 0 {
+		return nil, ErrUnknownLabel
+	}
+	return labels, nil
+}
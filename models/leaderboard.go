@@ -0,0 +1,91 @@
+package models
+
+import (
+	"net/url"
+	"time"
+)
+
+// What the leaderboard ranks users by: the posts and the comments they
+// made, or the likes and emoji reactions others gave to them.
+const (
+	LeaderboardPosts     = "posts"
+	LeaderboardComments  = "comments"
+	LeaderboardReactions = "reactions"
+)
+
+// The periods the leaderboard counts over.
+const (
+	LeaderboardAllTime = "all"
+	LeaderboardMonth   = "30d"
+)
+
+// leaderboardMonth is the length of the LeaderboardMonth period.
+const leaderboardMonth = 30 * 24 * time.Hour
+
+// LeaderboardQuery picks the metric and period of the leaderboard.
+type LeaderboardQuery struct {
+	Metric string
+	Period string
+}
+
+// ParseLeaderboardQuery reads the query from the metric and period
+// parameters. Missing ones default to posts of all time.
+func ParseLeaderboardQuery(values url.Values) (LeaderboardQuery, error) {
+	q := LeaderboardQuery{Metric: values.Get("metric"), Period: values.Get("period")}
+	switch q.Metric {
+	case "":
+		q.Metric = LeaderboardPosts
+	case LeaderboardPosts, LeaderboardComments, LeaderboardReactions:
+	default:
+		return q, ErrInvalidLeaderboard
+	}
+	switch q.Period {
+	case "":
+		q.Period = LeaderboardAllTime
+	case LeaderboardAllTime, LeaderboardMonth:
+	default:
+		return q, ErrInvalidLeaderboard
+	}
+	return q, nil
+}
+
+// Since returns when the period of q started at now, the zero time for all
+// time.
+func (q LeaderboardQuery) Since(now time.Time) time.Time {
+	if q.Period == LeaderboardMonth {
+		return now.Add(-leaderboardMonth)
+	}
+	return time.Time{}
+}
+
+// Contributor is a user ranked on the leaderboard, with their Score for
+// the metric.
+type Contributor struct {
+	Rank        int
+	UserID      int
+	Name        string
+	DisplayName string
+	Score       int
+}
+
+// ShownName is the name the contributor is shown under.
+func (c Contributor) ShownName() string {
+	return shownName(c.DisplayName, c.Name)
+}
+
+// Leaderboard is the ranking for Query as it was at Updated.
+type Leaderboard struct {
+	Query        LeaderboardQuery
+	Contributors []Contributor
+	Updated      time.Time
+}
+
+// Metrics lists the metrics the leaderboard may rank by, for switching.
+func (Leaderboard) Metrics() []string {
+	return []string{LeaderboardPosts, LeaderboardComments, LeaderboardReactions}
+}
+
+// Periods lists the periods the leaderboard may count over, for switching.
+func (Leaderboard) Periods() []string {
+	return []string{LeaderboardAllTime, LeaderboardMonth}
+}
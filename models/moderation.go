@@ -0,0 +1,38 @@
+package models
+
+const (
+	ModerationDelete  = "delete"
+	ModerationLock    = "lock"
+	ModerationUnlock  = "unlock"
+	ModerationApprove = "approve"
+)
+
+// LockReasonMaxLen caps the reason a moderator gives for locking a post.
+const LockReasonMaxLen = 100
+
+const (
+	TargetPost    = "post"
+	TargetComment = "comment"
+)
+
+type ModerationTarget struct {
+	Kind string
+	ID   int
+}
+
+// ModerationResult is the outcome of a bulk moderation action on a single
+// post or comment.
+type ModerationResult struct {
+	Kind  string `json:"kind"`
+	ID    int    `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func ValidModerationAction(action string) bool {
+	switch action {
+	case ModerationDelete, ModerationLock, ModerationUnlock, ModerationApprove:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"fmt"
+	"forum/pkg/urls"
+	"regexp"
+	"time"
+)
+
+const (
+	NotificationReply   = "reply"
+	NotificationMention = "mention"
+	NotificationComment = "comment"
+	NotificationNewPost = "new_post"
+	NotificationMoved   = "moved"
+	NotificationLike    = "like"
+)
+
+// How often a user wants unread notifications mailed to them.
+const (
+	DigestOff = iota
+	DigestDaily
+	DigestWeekly
+)
+
+// Notification tells UserID that ActorID replied to or mentioned them in a
+// post, commented on a post they subscribed to, posted in a category they
+// subscribed to, moved their post to another category or liked their post or
+// comment. Notified is set
+// once the notification went out in an email digest.
+type Notification struct {
+	ID        int
+	UserID    int
+	ActorID   int
+	ActorName string
+	Kind      string
+	PostID    int
+	PostTitle string
+	CommentID int
+	Created   time.Time
+	Read      bool
+	Notified  bool
+}
+
+// URL links to the comment the notification is about, or to the post when
+// there is none.
+func (n Notification) URL(ub *urls.Builder) string {
+	if n.CommentID != 0 {
+		return ub.Comment(n.PostID, n.CommentID)
+	}
+	return ub.Post(n.PostID)
+}
+
+func (n Notification) Summary() string {
+	switch n.Kind {
+	case NotificationMention:
+		return fmt.Sprintf("%s mentioned you in %q", n.ActorName, n.PostTitle)
+	case NotificationComment:
+		return fmt.Sprintf("%s commented on %q", n.ActorName, n.PostTitle)
+	case NotificationNewPost:
+		return fmt.Sprintf("%s posted %q", n.ActorName, n.PostTitle)
+	case NotificationMoved:
+		return fmt.Sprintf("%s moved %q to another category", n.ActorName, n.PostTitle)
+	case NotificationLike:
+		if n.CommentID != 0 {
+			return fmt.Sprintf("%s liked your comment on %q", n.ActorName, n.PostTitle)
+		}
+		return fmt.Sprintf("%s liked %q", n.ActorName, n.PostTitle)
+	}
+	return fmt.Sprintf("%s replied to %q", n.ActorName, n.PostTitle)
+}
+
+// DigestRecipient is a user who opted into email digests. LastDigest is nil
+// until the first digest window was claimed.
+type DigestRecipient struct {
+	UserID     int
+	Name       string
+	Email      string
+	Digest     int
+	LastDigest *time.Time
+}
+
+func ValidDigest(digest int) bool {
+	return digest >= DigestOff && digest <= DigestWeekly
+}
+
+// DigestPeriod is the length of a digest window, zero when digests are off.
+func DigestPeriod(digest int) time.Duration {
+	switch digest {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// The @ must not follow a word character, so emails aren't mentions.
+var mentionRX = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])@([\p{L}\p{N}_]+)`)
+
+// Mentions returns the distinct user names mentioned as @name in content.
+func Mentions(content string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range mentionRX.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
@@ -1,15 +1,19 @@
 package models
 
 import (
+	"fmt"
 	"forum/pkg/validator"
+	"slices"
 	"strconv"
 	"time"
 )
 
 type Post struct {
-	PostID       int
-	UserID       int
-	UserName     string
+	PostID   int
+	UserID   int
+	UserName string
+	// DisplayName is the author's display name, empty if they have none.
+	DisplayName  string
 	Title        string
 	Content      string
 	ImageName    string
@@ -20,40 +24,350 @@ type Post struct {
 	Categories   map[int]string
 	IsLiked      int
 	CommentCount int
+	// AcceptedAnswerID is 0 while the question has no accepted answer.
+	AcceptedAnswerID int
+	AcceptedAnswer   *Comment
+	Locked           bool
+	// LockReason is the moderator's note shown on a locked post, if any.
+	LockReason string
+	// Archived posts went inactive and take no more comments or reactions.
+	Archived bool
+	// ProfilePinned posts are shown first on their author's profile.
+	ProfilePinned bool
+	// LastEdit is nil for posts that were never edited.
+	LastEdit *PostRevision
+	// Score sums the reactions weighted by the trust level of who reacted,
+	// see VoteWeights.
+	Score int
+	// HotComment is the best scored comment, surfaced above the thread. It
+	// is nil when no comment scores high enough.
+	HotComment *Comment
+	// Labels are the flairs moderators put on the post, see PostLabel.
+	Labels []string
+	// Reactions are the counts of the configured reaction types.
+	Reactions []ReactionCount
+	// CommentMode is how the comments are shown, CommentsFlat or
+	// CommentsThreaded, as chosen by the post's categories.
+	CommentMode string
+	// Deleted posts were taken down by their author but kept for their
+	// comments, with DeletedContent in place of the title and content.
+	Deleted bool
+	// Pending posts wait for a moderator's approval. They are left out of
+	// every listing and only their author and moderators may open them.
+	Pending bool
+	// LastActivityAt is when the post was last commented on, or Created
+	// while it has no comments.
+	LastActivityAt time.Time
 }
 
 type Comment struct {
-	CommentID int
-	PostID    int
-	UserID    int
-	UserName  string
-	Content   string
-	Created   time.Time
-	Like      string
-	Dislike   string
-	IsLiked   int
+	CommentID       int
+	PostID          int
+	UserID          int
+	UserName        string
+	DisplayName     string
+	Content         string
+	Created         time.Time
+	Like            string
+	Dislike         string
+	IsLiked         int
+	QuotedCommentID int
+	QuotedUserName  string
+	// QuotedDisplayName is the display name of the author of the quoted
+	// comment, empty if they have none.
+	QuotedDisplayName string
+	QuoteExcerpt      string
+	// Collapsed comments scored below the collapse threshold and are hidden
+	// until the reader expands them.
+	Collapsed bool
+	// Pending comments wait for a moderator's approval. Only their author
+	// and moderators see them.
+	Pending bool
+	// Depth is how many replies deep the comment is shown, always 0 in
+	// flat mode.
+	Depth int
+	// ContinueThread is set on threaded comments whose replies are too deep
+	// to show and are left for the page of their thread.
+	ContinueThread bool
+	// Deleted comments were taken down by their author but kept for their
+	// replies, with DeletedContent in place of the content.
+	Deleted bool
+}
+
+// DeletedContent stands in for what authors deleted, and for their name.
+const DeletedContent = "[deleted]"
+
+// AuthorName is the name the post is shown under.
+func (p Post) AuthorName() string {
+	if p.Deleted {
+		return DeletedContent
+	}
+	return shownName(p.DisplayName, p.UserName)
+}
+
+// Indent is how deep the comment is indented, see MaxCommentIndent.
+func (c Comment) Indent() int {
+	return min(c.Depth, MaxCommentIndent)
+}
+
+// AuthorName is the name the comment is shown under.
+func (c Comment) AuthorName() string {
+	if c.Deleted {
+		return DeletedContent
+	}
+	return shownName(c.DisplayName, c.UserName)
+}
+
+// QuotedAuthorName is the name the quoted comment is shown under.
+func (c Comment) QuotedAuthorName() string {
+	return shownName(c.QuotedDisplayName, c.QuotedUserName)
+}
+
+// Score is the number of likes minus the number of dislikes.
+func (c *Comment) Score() int {
+	like, _ := strconv.Atoi(c.Like)
+	dislike, _ := strconv.Atoi(c.Dislike)
+	return like - dislike
+}
+
+// VisibleComments returns the comments viewer may see: all of them for
+// moderators, the others without pending ones, except the viewer's own.
+func VisibleComments(comments []Comment, viewer *User) []Comment {
+	if viewer.IsModerator() {
+		return comments
+	}
+	visible := comments[:0:0]
+	for _, c := range comments {
+		if !c.Pending || (viewer != nil && int(viewer.ID) == c.UserID) {
+			visible = append(visible, c)
+		}
+	}
+	return visible
+}
+
+// HotComment returns the best scored of comments if it scores at least
+// minScore, nil otherwise or when minScore isn't positive. Ties go to the
+// first one, the older in a thread. Pending comments and the accepted answer, shown apart
+// already, are left out.
+func HotComment(comments []Comment, minScore, acceptedID int) *Comment {
+	if minScore <= 0 {
+		return nil
+	}
+	var hot *Comment
+	for i := range comments {
+		c := &comments[i]
+		if c.Pending || c.CommentID == acceptedID || c.Score() < minScore {
+			continue
+		}
+		if hot == nil || c.Score() > hot.Score() {
+			hot = c
+		}
+	}
+	return hot
+}
+
+// CollapseComments marks the comments whose score is below threshold as
+// collapsed.
+func CollapseComments(comments []Comment, threshold int) {
+	for i := range comments {
+		comments[i].Collapsed = comments[i].Score() < threshold
+	}
+}
+
+// How the comments of the posts in a category are shown: in the order they
+// were made, replies quoting what they answer, or each reply nested under
+// the comment it answers. A post in any flat category is shown flat.
+const (
+	CommentsFlat     = "flat"
+	CommentsThreaded = "threaded"
+)
+
+// MaxCommentIndent is the deepest a reply is indented; deeper ones are
+// shown at this depth.
+const MaxCommentIndent = 8
+
+func ValidCommentMode(mode string) bool {
+	return mode == CommentsFlat || mode == CommentsThreaded
+}
+
+// ThreadComments orders comments for threaded display: each one followed by
+// its replies, with Depth set. Siblings keep their order. Replies to
+// comments that aren't in comments, e.g. pending ones, start threads of
+// their own. Replies deeper than maxDepth, 0 for no limit, are left out and
+// the comment they'd go under gets ContinueThread set instead.
+func ThreadComments(comments []Comment, maxDepth int) []Comment {
+	replies := repliesByParent(comments)
+	return walkThreads(replies, replies[0], maxDepth)
+}
+
+// CommentThread returns the thread of the comment rootID for the page of
+// the thread: the comment at depth 0, followed by the replies below it as
+// ThreadComments orders them. It returns nil if rootID isn't in comments.
+func CommentThread(comments []Comment, rootID, maxDepth int) []Comment {
+	i := slices.IndexFunc(comments, func(c Comment) bool { return c.CommentID == rootID })
+	if i < 0 {
+		return nil
+	}
+	return walkThreads(repliesByParent(comments), comments[i:i+1], maxDepth)
+}
+
+// CommentPages splits comments, in the order the post page shows them,
+// into pages of perPage threads: a comment at depth 0 and the replies below
+// it, which are never split. In flat mode that is perPage comments. perPage
+// 0 keeps them all on one page.
+func CommentPages(comments []Comment, perPage int) [][]Comment {
+	if len(comments) == 0 {
+		return nil
+	}
+	if perPage <= 0 {
+		return [][]Comment{comments}
+	}
+	var pages [][]Comment
+	start, threads := 0, 0
+	for i, c := range comments {
+		if c.Depth != 0 {
+			continue
+		}
+		if threads > 0 && threads%perPage == 0 {
+			pages = append(pages, comments[start:i])
+			start = i
+		}
+		threads++
+	}
+	return append(pages, comments[start:])
+}
+
+// CommentPage returns the page of pages, counted from 1, the comment
+// commentID is on, or 0 if it is on none.
+func CommentPage(pages [][]Comment, commentID int) int {
+	for i, page := range pages {
+		if slices.ContainsFunc(page, func(c Comment) bool { return c.CommentID == commentID }) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// repliesByParent groups comments by the comment they reply to, 0 for
+// those that start a thread.
+func repliesByParent(comments []Comment) map[int][]Comment {
+	present := make(map[int]bool, len(comments))
+	for _, c := range comments {
+		present[c.CommentID] = true
+	}
+	replies := make(map[int][]Comment)
+	for _, c := range comments {
+		parent := c.QuotedCommentID
+		if !present[parent] || parent == c.CommentID {
+			parent = 0
+		}
+		replies[parent] = append(replies[parent], c)
+	}
+	return replies
+}
+
+func walkThreads(replies map[int][]Comment, roots []Comment, maxDepth int) []Comment {
+	var threaded []Comment
+	var walk func(level []Comment, depth int)
+	walk = func(level []Comment, depth int) {
+		for _, c := range level {
+			c.Depth = depth
+			below := replies[c.CommentID]
+			c.ContinueThread = maxDepth > 0 && depth == maxDepth && len(below) > 0
+			threaded = append(threaded, c)
+			if !c.ContinueThread {
+				walk(below, depth+1)
+			}
+		}
+	}
+	walk(roots, 0)
+	return threaded
 }
 
 type CommentForm struct {
-	PostID  int
-	UserID  int
-	Content string
-	Token   string
+	PostID          int
+	UserID          int
+	Content         string
+	Token           string
+	QuotedCommentID int
+	QuoteExcerpt    string
+	// Pending comments are stored unapproved.
+	Pending bool
+	// GuestName and GuestIP are set for comments sent without an account,
+	// see GuestAccount.
+	GuestName string
+	GuestIP   string
 	validator.Validator
 }
 
+// What happens to a reply to a comment that is already MaxDepth replies deep.
+const (
+	DepthFlatten = "flatten"
+	DepthReject  = "reject"
+)
+
+// CommentRules are the site settings a new comment is checked against.
+// MaxDepth 0 means replies can nest without limit. Cooldown is the least time
+// between two comments of a user, 0 for none; moderators are exempt.
+type CommentRules struct {
+	MaxDepth    int
+	DepthPolicy string
+	Cooldown    time.Duration
+	// Comments of accounts that are new by NewUser wait for approval when
+	// ApproveNewUsers is set.
+	NewUser         NewUserRules
+	ApproveNewUsers bool
+}
+
+// CooldownError is returned for a comment sent before the user's cooldown
+// ran out. RetryAfter is the time left.
+type CooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrCommentCooldown, e.RetryAfter)
+}
+
+func (e *CooldownError) Unwrap() error {
+	return ErrCommentCooldown
+}
+
+// PostLimitError is returned for a post over the user's daily posts. The
+// next post is allowed after RetryAfter.
+type PostLimitError struct {
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *PostLimitError) Error() string {
+	return fmt.Sprintf("%s, %d allowed, retry after %s", ErrPostLimit, e.Limit, e.RetryAfter)
+}
+
+func (e *PostLimitError) Unwrap() error {
+	return ErrPostLimit
+}
+
 type ReactionForm struct {
 	ID       int
 	UserID   int
 	Reaction bool
 	Token    string
+	// Type is the emoji of a reaction, see ReactionCount. It is empty for
+	// a like or dislike, which Reaction tells apart.
+	Type string
 }
 
+// Uncategorized is the category of posts made without one, where posts
+// aren't required to have one.
+const Uncategorized = "Uncategorized"
+
 type PostForm struct {
 	Title               string   `form:"title"`
 	Content             string   `form:"content"`
 	Categories          []int    `form:"category"`
 	CategoriesString    []string `form:"category"`
+	GuestName           string   `form:"guest_name"`
 	validator.Validator `form:"-"`
 }
 
@@ -63,10 +377,42 @@ func (f *PostForm) ConverCategories(categories []string) error {
 		if err != nil {
 			return err
 		}
-		if nb > len(categories) || nb < 0 {
+		if nb >= len(categories) || nb < 0 {
 			return UnknownCategory
 		}
 		f.Categories = append(f.Categories, nb)
 	}
 	return nil
 }
+
+// DefaultTagLimits are the limits on the categories of posts unless
+// configured otherwise.
+var DefaultTagLimits = TagLimits{MaxTags: 5, MaxChars: 30, MaxTotalChars: 100}
+
+// TagLimits cap the categories, the tags posts are filed under, a post can
+// have: MaxTags of them, each at most MaxChars long and MaxTotalChars long
+// together. Zero leaves a limit out.
+type TagLimits struct {
+	MaxTags       int
+	MaxChars      int
+	MaxTotalChars int
+}
+
+// CheckTags adds a field error on categories when the picked ones, named
+// in categories, break limits. Call it after ConverCategories succeeded.
+func (f *PostForm) CheckTags(categories []string, limits TagLimits) {
+	if limits.MaxTags > 0 {
+		f.CheckField(len(f.Categories) <= limits.MaxTags, "categories", fmt.Sprintf("Pick at most %d categories", limits.MaxTags))
+	}
+	var total string
+	for _, nb := range f.Categories {
+		name := categories[nb]
+		if limits.MaxChars > 0 {
+			f.CheckField(validator.MaxChars(name, limits.MaxChars), "categories", fmt.Sprintf("Category %q is longer than %d characters", name, limits.MaxChars))
+		}
+		total += name
+	}
+	if limits.MaxTotalChars > 0 {
+		f.CheckField(validator.MaxChars(total, limits.MaxTotalChars), "categories", fmt.Sprintf("The categories must be at most %d characters together", limits.MaxTotalChars))
+	}
+}
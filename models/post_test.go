@@ -0,0 +1,50 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommentPages(t *testing.T) {
+	// 1 and 4 start threads, 2 and 3 reply below 1.
+	threaded := []Comment{{CommentID: 1}, {CommentID: 2, Depth: 1}, {CommentID: 3, Depth: 2}, {CommentID: 4}, {CommentID: 5}}
+	ids := func(pages [][]Comment) [][]int {
+		var got [][]int
+		for _, page := range pages {
+			var ids []int
+			for _, c := range page {
+				ids = append(ids, c.CommentID)
+			}
+			got = append(got, ids)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name    string
+		perPage int
+		want    [][]int
+	}{
+		{"One thread a page", 1, [][]int{{1, 2, 3}, {4}, {5}}},
+		{"Two threads a page", 2, [][]int{{1, 2, 3, 4}, {5}}},
+		{"All on one page", 0, [][]int{{1, 2, 3, 4, 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(CommentPages(threaded, tt.perPage))
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("got %v; expected %v", got, tt.want)
+			}
+		})
+	}
+
+	pages := CommentPages(threaded, 2)
+	for id, want := range map[int]int{3: 1, 5: 2, 9: 0} {
+		if got := CommentPage(pages, id); got != want {
+			t.Errorf("CommentPage(%d) = %d; expected %d", id, got, want)
+		}
+	}
+	if pages := CommentPages(nil, 2); pages != nil {
+		t.Errorf("got %v without comments; expected no pages", pages)
+	}
+}
@@ -0,0 +1,8 @@
+package models
+
+// Preview is rendered Markdown sent back by the preview endpoint, with the
+// stats of the source.
+type Preview struct {
+	HTML  string    `json:"html"`
+	Stats TextStats `json:"stats"`
+}
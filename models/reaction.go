@@ -0,0 +1,104 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultReactionTypes are the emoji users react to posts with unless
+// configured otherwise.
+var DefaultReactionTypes = []string{"👍", "❤️", "😂"}
+
+// maxReactionTypeBytes bounds a reaction type. Emoji with modifiers and
+// joiners take a few code points, but never this many bytes.
+const maxReactionTypeBytes = 32
+
+// ReactionCount is how many users reacted to a post with Type, and whether
+// the viewer is one of them.
+type ReactionCount struct {
+	Type  string
+	Count int
+	Mine  bool
+}
+
+// ReactionState is where a like or dislike left a post or comment: its
+// likes and dislikes, and the user's own vote, 1 for a like, -1 for a
+// dislike and 0 for none. While the reactions of a post are held back,
+// Hidden is set and the counts are 0.
+type ReactionState struct {
+	Like    int  `json:"like"`
+	Dislike int  `json:"dislike"`
+	Mine    int  `json:"mine"`
+	Hidden  bool `json:"hidden,omitempty"`
+}
+
+// ReactionsHidden reports whether the reaction counts of a post made at
+// created are still held back at now, which they are for window after
+// posting so that early votes don't sway later ones. A zero window shows
+// them right away.
+func ReactionsHidden(created time.Time, window time.Duration, now time.Time) bool {
+	return window > 0 && now.Before(created.Add(window))
+}
+
+// ParseReactionTypes reads a comma separated list of reaction types, like
+// "👍,❤️,😂". Blanks and repeats are dropped.
+func ParseReactionTypes(s string) ([]string, error) {
+	var types []string
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" || slices.Contains(types, t) {
+			continue
+		}
+		if len(t) > maxReactionTypeBytes {
+			return nil, ErrUnknownReaction
+		}
+		types = append(types, t)
+	}
+	if len(types) == 0 {
+		return nil, ErrUnknownReaction
+	}
+	return types, nil
+}
+
+// CountReactions lists the counts of every type in types, in that order,
+// with zeros for the unused ones. Counts of types that aren't in types any
+// more are left out. mine is the viewer's reaction, empty for none.
+func CountReactions(types []string, counts map[string]int, mine string) []ReactionCount {
+	reactions := make([]ReactionCount, len(types))
+	for i, t := range types {
+		reactions[i] = ReactionCount{Type: t, Count: counts[t], Mine: t == mine}
+	}
+	return reactions
+}
+
+// The types of likes and dislikes in lists of reactors, next to the emoji
+// of posts.
+const (
+	ReactionLike    = "like"
+	ReactionDislike = "dislike"
+)
+
+// Reactor is a user who reacted to a post or comment with Type. Deleted is
+// set when their account is gone, and Hidden when their profile is private
+// to the viewer; neither is shown under their name.
+type Reactor struct {
+	UserID      int
+	Name        string
+	DisplayName string
+	Privacy     int
+	Type        string
+	Deleted     bool
+	Hidden      bool
+}
+
+// ShownName is the name the reactor is listed under.
+func (r Reactor) ShownName() string {
+	switch {
+	case r.Deleted:
+		return DeletedContent
+	case r.Hidden:
+		return "A private user"
+	}
+	return shownName(r.DisplayName, r.Name)
+}
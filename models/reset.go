@@ -0,0 +1,49 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"forum/pkg/validator"
+	"time"
+)
+
+// PasswordReset is a single-use token that lets a user choose a new
+// password. Only TokenHash is stored, Token is handed to the user once.
+type PasswordReset struct {
+	UserID    int
+	Token     string
+	TokenHash string
+	Expires   time.Time
+}
+
+func NewPasswordReset(userID int, ttl time.Duration) (*PasswordReset, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return &PasswordReset{
+		UserID:    userID,
+		Token:     token,
+		TokenHash: HashResetToken(token),
+		Expires:   time.Now().Add(ttl),
+	}, nil
+}
+
+func HashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type PasswordForgotForm struct {
+	Email               string `form:"email"`
+	validator.Validator `form:"-"`
+}
+
+type PasswordResetForm struct {
+	Token               string `form:"token"`
+	Password            string `form:"password"`
+	validator.Validator `form:"-"`
+}
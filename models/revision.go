@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// PostRevision keeps the title and content a post had before an edit, and
+// who made the edit. ByModerator is set when someone other than the author
+// edited the post. Silent edits, quick fixes the author made within the edit
+// grace period, don't mark the post edited.
+type PostRevision struct {
+	ID          int
+	PostID      int
+	EditorID    int
+	EditorName  string
+	ByModerator bool
+	Silent      bool
+	Title       string
+	Content     string
+	Created     time.Time
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"forum/pkg/urls"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+// SearchFilter narrows down the post search. Zero values are ignored.
+type SearchFilter struct {
+	Query      string
+	Author     string
+	From       time.Time
+	To         time.Time
+	CategoryID int
+	Category   string
+}
+
+// ParseSearchFilter reads the filter from the query string. Dates are
+// inclusive days in the DateLayout format.
+func ParseSearchFilter(values url.Values) (SearchFilter, error) {
+	filter := SearchFilter{
+		Query:    strings.TrimSpace(values.Get("q")),
+		Author:   strings.TrimSpace(values.Get("author")),
+		Category: values.Get("category"),
+	}
+
+	var err error
+	if from := values.Get("from"); from != "" {
+		filter.From, err = time.Parse(DateLayout, from)
+		if err != nil {
+			return filter, ErrInvalidDateRange
+		}
+	}
+	if to := values.Get("to"); to != "" {
+		filter.To, err = time.Parse(DateLayout, to)
+		if err != nil {
+			return filter, ErrInvalidDateRange
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return filter, ErrInvalidDateRange
+	}
+	return filter, nil
+}
+
+// Values encodes the filter back into query parameters, used to keep it
+// across pages.
+func (f SearchFilter) Values() url.Values {
+	values := url.Values{}
+	if f.Query != "" {
+		values.Set("q", f.Query)
+	}
+	if f.Author != "" {
+		values.Set("author", f.Author)
+	}
+	if !f.From.IsZero() {
+		values.Set("from", f.From.Format(DateLayout))
+	}
+	if !f.To.IsZero() {
+		values.Set("to", f.To.Format(DateLayout))
+	}
+	if f.Category != "" {
+		values.Set("category", f.Category)
+	}
+	return values
+}
+
+// Kinds of search suggestions. Categories are the tags posts are filed
+// under.
+const (
+	SuggestCategory = "category"
+	SuggestUser     = "user"
+	SuggestPost     = "post"
+)
+
+// MinSuggestLength is the shortest query, in characters, that gets
+// suggestions. Shorter ones match too much to be useful.
+const MinSuggestLength = 2
+
+// Suggestion is a match offered while a search query is being typed.
+type Suggestion struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+	URL  string `json:"url"`
+	// ID is the id of the post, category or user.
+	ID int `json:"-"`
+}
+
+// Link returns where picking the suggestion leads.
+func (s Suggestion) Link(ub *urls.Builder) string {
+	switch s.Kind {
+	case SuggestPost:
+		return ub.Post(s.ID)
+	case SuggestCategory:
+		return ub.Path("/?category=" + url.QueryEscape(strings.ToLower(s.Text)))
+	default:
+		return ub.Path("/user/" + url.PathEscape(s.Text))
+	}
+}
+
+// Suggestions is the answer of the search suggestion endpoint.
+type Suggestions struct {
+	Query       string       `json:"query"`
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+// MentionSuggestion is a user offered while an @-mention is being typed.
+type MentionSuggestion struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// MentionSuggestions is the answer of the mention suggestion endpoint.
+type MentionSuggestions struct {
+	Query string              `json:"query"`
+	Users []MentionSuggestion `json:"users"`
+}
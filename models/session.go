@@ -10,12 +10,37 @@ type Session struct {
 	UserID  int
 	Token   string
 	ExpTime time.Time
+	// LastSeen is when the session was last used, for the idle timeout.
+	// Sessions stored before it was kept have none.
+	LastSeen time.Time
 }
 
 func NewSession(UserID int) *Session {
+	now := time.Now()
 	return &Session{
-		UserID:  UserID,
-		Token:   uuid.New().String(),
-		ExpTime: time.Now().Add(100 * time.Minute),
+		UserID:   UserID,
+		Token:    uuid.New().String(),
+		ExpTime:  now.Add(DefaultSessionLimits.Lifetime),
+		LastSeen: now,
 	}
 }
+
+// DefaultSessionLimits are the limits sessions have unless configured
+// otherwise.
+var DefaultSessionLimits = SessionLimits{Lifetime: 100 * time.Minute, Idle: 30 * time.Minute}
+
+// SessionLimits are how long sessions last: Lifetime from login whatever
+// happens, and less when unused for Idle. Zero Idle keeps idle sessions.
+type SessionLimits struct {
+	Lifetime time.Duration
+	Idle     time.Duration
+}
+
+// Expired reports whether session is past its ExpTime or idle for too
+// long at now. Sessions without LastSeen only expire by their ExpTime.
+func (l SessionLimits) Expired(session *Session, now time.Time) bool {
+	if !session.ExpTime.After(now) {
+		return true
+	}
+	return l.Idle > 0 && !session.LastSeen.IsZero() && !session.LastSeen.Add(l.Idle).After(now)
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Orders the home page can list posts in. Hot favours well scored posts that
+// are also recent, and active bumps posts with new comments.
+const (
+	SortNewest = "newest"
+	SortTop    = "top"
+	SortHot    = "hot"
+	SortActive = "active"
+)
+
+var SortOrders = []string{SortNewest, SortTop, SortHot, SortActive}
+
+func ValidSort(sort string) bool {
+	for _, s := range SortOrders {
+		if s == sort {
+			return true
+		}
+	}
+	return false
+}
+
+// SortOldest shows comments in the order they were made. Comments can be
+// shown newest or top first too, ties going to the older one.
+const SortOldest = "oldest"
+
+var CommentSortOrders = []string{SortOldest, SortNewest, SortTop}
+
+func ValidCommentSort(sort string) bool {
+	return slices.Contains(CommentSortOrders, sort)
+}
+
+// CommentSorts is the order the comments of a post are shown in unless the
+// viewer picks one, by the categories of the post, keyed by lower case name.
+// Posts in none of them use Default.
+type CommentSorts struct {
+	Default    string
+	Categories map[string]string
+}
+
+// DefaultCommentSorts show questions best answers first and every other
+// post as a discussion, oldest first.
+var DefaultCommentSorts = CommentSorts{Default: SortOldest, Categories: map[string]string{"q&a": SortTop}}
+
+// ParseCommentSorts reads a comma separated list of orders by category, and
+// the order of the rest without one, like "oldest,Q&A=top,Help=top". The
+// rest are shown oldest first unless told otherwise.
+func ParseCommentSorts(s string) (CommentSorts, error) {
+	sorts := CommentSorts{Default: SortOldest, Categories: map[string]string{}}
+	for _, field := range strings.Split(s, ",") {
+		category, sort, ok := strings.Cut(field, "=")
+		if !ok {
+			category, sort = "", category
+		}
+		category = strings.ToLower(strings.TrimSpace(category))
+		sort = strings.TrimSpace(sort)
+		if !ValidCommentSort(sort) || ok && category == "" {
+			return CommentSorts{}, ErrInvalidSort
+		}
+		if ok {
+			sorts.Categories[category] = sort
+		} else {
+			sorts.Default = sort
+		}
+	}
+	return sorts, nil
+}
+
+// For returns the order of the comments of a post in categories. When they
+// disagree the category with the lowest ID wins.
+func (c CommentSorts) For(categories map[int]string) string {
+	sort, first := c.Default, 0
+	for id, name := range categories {
+		if s, ok := c.Categories[strings.ToLower(name)]; ok && (first == 0 || id < first) {
+			sort, first = s, id
+		}
+	}
+	if sort == "" {
+		return SortOldest
+	}
+	return sort
+}
+
+// SortComments sorts comments in place by sort, one of CommentSortOrders.
+// Threading them afterwards keeps replies to the same comment in this
+// order.
+func SortComments(comments []Comment, sort string) {
+	oldest := func(a, b Comment) int { return cmp.Compare(a.CommentID, b.CommentID) }
+	switch sort {
+	case SortNewest:
+		slices.SortStableFunc(comments, func(a, b Comment) int { return oldest(b, a) })
+	case SortTop:
+		slices.SortStableFunc(comments, func(a, b Comment) int {
+			return cmp.Or(cmp.Compare(b.Score(), a.Score()), oldest(a, b))
+		})
+	default:
+		slices.SortStableFunc(comments, oldest)
+	}
+}
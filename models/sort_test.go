@@ -0,0 +1,36 @@
+package models
+
+import "testing"
+
+func TestCommentSorts(t *testing.T) {
+	sorts, err := ParseCommentSorts(" Q&A = top ,Help=newest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		categories map[int]string
+		want       string
+	}{
+		{name: "Q&A", categories: map[int]string{3: "Q&A"}, want: SortTop},
+		{name: "Discussion", categories: map[int]string{1: "Discussion"}, want: SortOldest},
+		{name: "No categories", want: SortOldest},
+		{name: "Lowest category wins", categories: map[int]string{1: "Discussion", 4: "Q&A", 2: "help"}, want: SortNewest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sorts.For(tt.categories); got != tt.want {
+				t.Errorf("got %q; expected %q", got, tt.want)
+			}
+		})
+	}
+
+	if sorts, err = ParseCommentSorts("top,Discussion=oldest"); err != nil || sorts.For(nil) != SortTop {
+		t.Errorf("got %+v, %v; expected top by default", sorts, err)
+	}
+	for _, s := range []string{"random", "Q&A=random", "=top", ""} {
+		if _, err := ParseCommentSorts(s); err != ErrInvalidSort {
+			t.Errorf("got %v for %q; expected %v", err, s, ErrInvalidSort)
+		}
+	}
+}
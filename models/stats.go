@@ -0,0 +1,33 @@
+package models
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultWordsPerMinute is the reading speed used when none is configured.
+const DefaultWordsPerMinute = 200
+
+// TextStats describes the length of a post body, for clients that show it
+// next to an editor. Everything is counted on the Markdown source.
+type TextStats struct {
+	Characters int `json:"characters"`
+	Words      int `json:"words"`
+	// ReadMinutes is Words read at the given speed, rounded up to whole
+	// minutes, so any text takes at least a minute and an empty one none.
+	ReadMinutes int `json:"read_minutes"`
+}
+
+// NewTextStats counts the characters and the whitespace separated words of
+// content. A wordsPerMinute of 0 or less means DefaultWordsPerMinute.
+func NewTextStats(content string, wordsPerMinute int) TextStats {
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = DefaultWordsPerMinute
+	}
+	words := len(strings.Fields(content))
+	return TextStats{
+		Characters:  utf8.RuneCountInString(content),
+		Words:       words,
+		ReadMinutes: (words + wordsPerMinute - 1) / wordsPerMinute,
+	}
+}
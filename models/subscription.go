@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Subscription records whether UserID wants to hear about every new comment
+// on PostID. Authors and commenters are subscribed automatically, an explicit
+// unsubscribe is kept as Subscribed false so it isn't undone by the next
+// comment.
+type Subscription struct {
+	UserID     int
+	PostID     int
+	Subscribed bool
+	Created    time.Time
+}
+
+// CategorySubscription means UserID hears about every new post in
+// CategoryID.
+type CategorySubscription struct {
+	UserID     int
+	CategoryID int
+}
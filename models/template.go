@@ -1,5 +1,10 @@
 package models
 
+import (
+	"forum/pkg/captcha"
+	"forum/pkg/embed"
+)
+
 type TemplateData struct {
 	Post            *Post
 	Posts           *[]Post
@@ -12,9 +17,67 @@ type TemplateData struct {
 	NumberOfPage    int
 	CurrentPage     int
 	Limit           int
+	MaxLimit        int
 	Category        string
 	Category_id     int
 	URL             string
 	LimitVariation  []int
+	Sort            string
+	SortOrders      []string
 	Quote           string
+	Profile         *User
+	Activities      *[]Activity
+	Invites         *[]Invite
+	InviteOnly      bool
+	DisplayNames    bool
+	Search          *SearchFilter
+	Related         *[]Post
+	Trending        []string
+	// SearchTotal is how many posts match Search over all pages.
+	SearchTotal int
+	// FeedNext is the cursor for "load more", 0 when the page isn't full.
+	FeedNext int
+	// IsFollowing tells whether the user follows Profile.
+	IsFollowing bool
+	// IsSubscribed tells whether the user gets notified of new comments on Post.
+	IsSubscribed bool
+	// IsSubscribedCategory tells whether the user gets notified of new posts
+	// in Category.
+	IsSubscribedCategory bool
+	// Captcha is the challenge shown on the signup form, nil for none.
+	Captcha *captcha.Widget
+	// FormStamp is the render time sent back by signup and comment forms.
+	FormStamp string
+	// UnreadCount is the number of unread notifications of User.
+	UnreadCount   int
+	Notifications *[]Notification
+	// PendingComments is the queue of comments waiting for approval.
+	PendingComments *[]Comment
+	// Announcements are the banners active on the page.
+	Announcements []Announcement
+	// Label filters the posts listed, empty for all. PostLabels are the
+	// labels moderators may pick from.
+	Label      string
+	PostLabels []string
+	// GuestPosting shows the guest name field on forms to visitors.
+	GuestPosting bool
+	Leaderboard  *Leaderboard
+	// Embeds are the players of the links in Post to trusted sites.
+	Embeds []embed.Embed
+	// ThreadRoot is the comment whose thread alone the post page shows, 0
+	// for all of the comments.
+	ThreadRoot int
+	// CommentSort is the order the comments on the post page are in, one of
+	// CommentSortOrders.
+	CommentSort string
+	// SignupClosed is why new registrations are turned away, empty while
+	// they are open.
+	SignupClosed string
+	// Reactors are the users listed as having reacted to ReactionsOf, the
+	// address of a post or comment, with ReactionType, or any type when it
+	// is empty. ReactionTypes are the types the list can be narrowed to.
+	Reactors      *[]Reactor
+	ReactionsOf   string
+	ReactionType  string
+	ReactionTypes []string
 }
@@ -2,18 +2,166 @@ package models
 
 import (
 	"forum/pkg/validator"
+	"regexp"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// PasswordMinChars is how long passwords must be at least.
+const PasswordMinChars = 8
+
+const (
+	StatusUser = iota
+	StatusModerator
+	StatusAdmin
+)
+
+// Who can see a user's email and post history.
+const (
+	PrivacyPublic = iota
+	PrivacyLoggedIn
+	PrivacyPrivate
+)
+
 type User struct {
-	ID             int64
-	Name           string
-	Email          string
+	ID   int64
+	Name string
+	// DisplayName is shown instead of Name when set. Name stays the handle
+	// in URLs and mentions.
+	DisplayName string
+	Email       string
+	// EmailKey is Email normalized by the EmailPolicy, which no two users
+	// may share. Mail is still sent to Email.
+	EmailKey       string
 	HashedPassword []byte
 	Created        time.Time
 	Status         int
+	Privacy        int
+	Digest         int
+	// Sort is the preferred order of the home page, empty for the site
+	// default.
+	Sort string
+	// Timezone is the IANA zone dates are shown to the user in, empty for
+	// UTC.
+	Timezone string
+}
+
+// What happens to a signup whose name looks like an existing user's, see
+// confusable.Skeleton. Warned users may send the form again to keep the name.
+const (
+	SimilarNamesOff    = "off"
+	SimilarNamesWarn   = "warn"
+	SimilarNamesReject = "reject"
+)
+
+// DisplayNameMaxChars is the longest display name allowed.
+const DisplayNameMaxChars = 30
+
+var displayNameRX = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} ._-]*$`)
+
+// ValidDisplayName reports whether name may be used as a display name. It
+// may be empty; otherwise it has to start with a letter or digit and use
+// only letters, digits, spaces, dots, dashes and underscores.
+func ValidDisplayName(name string) bool {
+	return name == "" || validator.MaxChars(name, DisplayNameMaxChars) && displayNameRX.MatchString(name)
+}
+
+// ValidTimezone reports whether zone is empty or a known IANA zone name.
+// "Local" would mean the server's zone, so it isn't allowed.
+func ValidTimezone(zone string) bool {
+	if zone == "" {
+		return true
+	}
+	if zone == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(zone)
+	return err == nil
+}
+
+// Location is the zone dates are shown to u in. Guests and users without a
+// valid zone get UTC.
+func (u *User) Location() *time.Location {
+	if u == nil || u.Timezone == "" || u.Timezone == "Local" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ShownName is the name u is shown under, the display name if they set one.
+func (u *User) ShownName() string {
+	return shownName(u.DisplayName, u.Name)
+}
+
+func shownName(displayName, name string) string {
+	if displayName != "" {
+		return displayName
+	}
+	return name
+}
+
+func (u *User) IsAdmin() bool {
+	return u != nil && u.Status == StatusAdmin
+}
+
+func (u *User) IsModerator() bool {
+	return u != nil && u.Status >= StatusModerator
+}
+
+// CanReact reports whether the account of u is at least minAge old, which
+// it takes to like, dislike or react to anything. Moderators always can.
+func (u *User) CanReact(minAge time.Duration) bool {
+	return u.IsModerator() || time.Since(u.Created) >= minAge
+}
+
+// NewUserRules restrict accounts younger than MinAge or with fewer than
+// MinPosts posts: they can't post links and may make PostsPerDay posts a
+// day, 0 for no limit. Moderators are exempt.
+type NewUserRules struct {
+	MinAge      time.Duration
+	MinPosts    int
+	PostsPerDay int
+}
+
+// Enabled reports whether any account counts as new.
+func (r NewUserRules) Enabled() bool {
+	return r.MinAge > 0 || r.MinPosts > 0
+}
+
+// CanManagePost reports whether u is the author of p or a moderator.
+func (u *User) CanManagePost(p *Post) bool {
+	return u != nil && (int(u.ID) == p.UserID || u.IsModerator())
+}
+
+// IsAuthor reports whether u is the user userID, the author of what they
+// made.
+func (u *User) IsAuthor(userID int) bool {
+	return u != nil && int(u.ID) == userID
+}
+
+// ProfileVisibleTo reports whether viewer may see the full profile of u.
+// viewer is nil for guests. The owner always sees their own profile.
+func (u *User) ProfileVisibleTo(viewer *User) bool {
+	if viewer != nil && viewer.ID == u.ID {
+		return true
+	}
+	switch u.Privacy {
+	case PrivacyPublic:
+		return true
+	case PrivacyLoggedIn:
+		return viewer != nil
+	default:
+		return false
+	}
+}
+
+func ValidPrivacy(privacy int) bool {
+	return privacy >= PrivacyPublic && privacy <= PrivacyPrivate
 }
 
 type UserLoginForm struct {
@@ -24,8 +172,11 @@ type UserLoginForm struct {
 
 type UserSignupForm struct {
 	Name                string `form:"name"`
+	DisplayName         string `form:"display_name"`
 	Email               string `form:"email"`
 	Password            string `form:"password"`
+	InviteCode          string `form:"invite"`
+	SimilarName         string `form:"-"`
 	validator.Validator `form:"-"`
 }
 
@@ -33,6 +184,7 @@ func (u UserSignupForm) FormToUser() User {
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(u.Password), 12)
 	return User{
 		Name:           u.Name,
+		DisplayName:    u.DisplayName,
 		Email:          u.Email,
 		HashedPassword: hashedPassword,
 	}
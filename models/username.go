@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// UsernameCharsets are the character sets usernames can be limited to by
+// name, as regexp character classes, with how they are told to users.
+var UsernameCharsets = map[string]UsernameCharset{
+	"unicode": {Class: `\p{L}\p{N}_.-`, Description: "letters, digits, dots, dashes and underscores"},
+	"ascii":   {Class: `a-zA-Z0-9_.-`, Description: "Latin letters, digits, dots, dashes and underscores"},
+	"lower":   {Class: `a-z0-9_`, Description: "lowercase Latin letters, digits and underscores"},
+}
+
+type UsernameCharset struct {
+	Class       string
+	Description string
+}
+
+// ParseUsernameCharset reads one of the UsernameCharsets by name, or any
+// other regexp character class, like "a-z0-9", which is shown as is.
+func ParseUsernameCharset(s string) (UsernameCharset, error) {
+	if charset, ok := UsernameCharsets[s]; ok {
+		return charset, nil
+	}
+	if s == "" {
+		return UsernameCharset{}, ErrInvalidName
+	}
+	if _, err := regexp.Compile(`^[` + s + `]+$`); err != nil {
+		return UsernameCharset{}, fmt.Errorf("%w: %w", ErrInvalidName, err)
+	}
+	return UsernameCharset{Class: s, Description: "the characters " + s}, nil
+}
+
+// DefaultUsernamePolicy is the policy usernames follow unless configured
+// otherwise.
+var DefaultUsernamePolicy = UsernamePolicy{
+	Charset:  UsernameCharsets["unicode"],
+	MinChars: 3,
+	MaxChars: 12,
+	Reserved: []string{"admin", "administrator", "root", "api", "moderator", "system", "support", GuestAccount},
+}
+
+// UsernamePolicy is what usernames may look like: MinChars to MaxChars
+// characters of Charset, 0 for no bound, and none of the Reserved names
+// whatever the case. The zero policy takes any name that isn't blank.
+type UsernamePolicy struct {
+	Charset  UsernameCharset
+	MinChars int
+	MaxChars int
+	Reserved []string
+}
+
+// ParseReservedNames reads a comma separated list of reserved usernames,
+// like "admin,root". Blanks and repeats are dropped, and the list may be
+// empty.
+func ParseReservedNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || slices.Contains(names, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Check returns ErrReservedName for a reserved name, even one the rest of
+// the policy would turn down, and ErrInvalidName for one that is too short,
+// too long or has characters out of the charset.
+func (p UsernamePolicy) Check(name string) error {
+	for _, reserved := range p.Reserved {
+		if strings.EqualFold(name, reserved) {
+			return ErrReservedName
+		}
+	}
+	n := utf8.RuneCountInString(name)
+	if n == 0 || n < p.MinChars || p.MaxChars > 0 && n > p.MaxChars {
+		return ErrInvalidName
+	}
+	if p.Charset.Class != "" && !regexp.MustCompile(`^[`+p.Charset.Class+`]+$`).MatchString(name) {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+// Rule tells users what names the policy takes.
+func (p UsernamePolicy) Rule() string {
+	var length string
+	switch {
+	case p.MinChars > 1 && p.MaxChars > 0:
+		length = fmt.Sprintf("%d to %d characters long", p.MinChars, p.MaxChars)
+	case p.MaxChars > 0:
+		length = fmt.Sprintf("at most %d characters long", p.MaxChars)
+	case p.MinChars > 1:
+		length = fmt.Sprintf("at least %d characters long", p.MinChars)
+	default:
+		length = "not blank"
+	}
+	if p.Charset.Class == "" {
+		return "Names must be " + length
+	}
+	return fmt.Sprintf("Names must be %s and use only %s", length, p.Charset.Description)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUsernamePolicy(t *testing.T) {
+	lower, err := ParseUsernameCharset("lower")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := UsernamePolicy{Charset: lower, MinChars: 3, MaxChars: 5, Reserved: ParseReservedNames(" Admin, api,,admin")}
+
+	tests := []struct {
+		name string
+		want error
+	}{
+		{"ab", ErrInvalidName},
+		{"abc", nil},
+		{"abcde", nil},
+		{"abcdef", ErrInvalidName},
+		{"ab_9", nil},
+		{"Abc", ErrInvalidName},
+		{"ab-c", ErrInvalidName},
+		{"ab c", ErrInvalidName},
+		{"", ErrInvalidName},
+		{"admin", ErrReservedName},
+		{"api", ErrReservedName},
+		{"apis", nil},
+	}
+	for _, tt := range tests {
+		if err := policy.Check(tt.name); !errors.Is(err, tt.want) {
+			t.Errorf("Check(%q) = %v; expected %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Letters of any script count as one character each.
+	if err := DefaultUsernamePolicy.Check("Ерлан"); err != nil {
+		t.Errorf("got %v for a Cyrillic name; expected none", err)
+	}
+	if err := DefaultUsernamePolicy.Check("ROOT"); !errors.Is(err, ErrReservedName) {
+		t.Errorf("got %v for a reserved name in capitals; expected %v", err, ErrReservedName)
+	}
+	if err := (UsernamePolicy{}).Check("any name at all!"); err != nil {
+		t.Errorf("got %v from the zero policy; expected none", err)
+	}
+}
+
+func TestParseUsernameCharset(t *testing.T) {
+	custom, err := ParseUsernameCharset("a-z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custom.Class != "a-z" {
+		t.Errorf("got class %q; expected %q", custom.Class, "a-z")
+	}
+	for _, s := range []string{"", "z-a"} {
+		if _, err := ParseUsernameCharset(s); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("ParseUsernameCharset(%q) = %v; expected %v", s, err, ErrInvalidName)
+		}
+	}
+}
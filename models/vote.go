@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VoteWeights is how much a reaction counts toward the score of a post, by
+// the trust level of the user who reacted: their status, from StatusUser to
+// StatusAdmin.
+type VoteWeights [StatusAdmin + 1]int
+
+// DefaultVoteWeights count every reaction once.
+var DefaultVoteWeights = VoteWeights{1, 1, 1}
+
+// ParseVoteWeights reads the comma separated weights of users, moderators
+// and admins, e.g. "1,2,3".
+func ParseVoteWeights(s string) (VoteWeights, error) {
+	var weights VoteWeights
+	fields := strings.Split(s, ",")
+	if len(fields) != len(weights) {
+		return weights, fmt.Errorf("want %d weights, got %d", len(weights), len(fields))
+	}
+	for i, field := range fields {
+		weight, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || weight < 0 {
+			return weights, fmt.Errorf("invalid weight %q", field)
+		}
+		weights[i] = weight
+	}
+	return weights, nil
+}
+
+// DailyPostLimits is how many posts a user may make per rolling 24 hours, by
+// their trust level as for VoteWeights, 0 for no limit.
+type DailyPostLimits [StatusAdmin + 1]int
+
+// ParseDailyPostLimits reads the comma separated limits of users, moderators
+// and admins, e.g. "10,50,0".
+func ParseDailyPostLimits(s string) (DailyPostLimits, error) {
+	var limits DailyPostLimits
+	fields := strings.Split(s, ",")
+	if len(fields) != len(limits) {
+		return limits, fmt.Errorf("want %d limits, got %d", len(limits), len(fields))
+	}
+	for i, field := range fields {
+		limit, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || limit < 0 {
+			return limits, fmt.Errorf("invalid limit %q", field)
+		}
+		limits[i] = limit
+	}
+	return limits, nil
+}
+
+// For returns the limit of u, 0 for a status without one.
+func (l DailyPostLimits) For(u *User) int {
+	if u.Status < 0 || u.Status >= len(l) {
+		return 0
+	}
+	return l[u.Status]
+}
@@ -0,0 +1,46 @@
+// Package antispam tells cheap bots from people filling in a form. People
+// leave the hidden honeypot field empty and take a moment to fill the form
+// in; bots tend to fill in every field and submit right away.
+package antispam
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// HoneypotField is hidden from people, so anything in it came from a bot.
+	HoneypotField = "website"
+	// StampField carries the time the form was rendered, see Stamp.
+	StampField = "form_time"
+)
+
+// Rules configures the checks. A zero Rules lets every submission through.
+type Rules struct {
+	Honeypot bool
+	// MinTime is the least time between rendering and submitting a form, 0
+	// to skip the timing check.
+	MinTime time.Duration
+}
+
+// Stamp returns the StampField value of a form rendered at t.
+func Stamp(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+// IsBot reports whether the form submitted in r looks automated at now. A
+// missing or garbled stamp fails the timing check.
+func (rules Rules) IsBot(r *http.Request, now time.Time) bool {
+	if rules.Honeypot && r.FormValue(HoneypotField) != "" {
+		return true
+	}
+	if rules.MinTime <= 0 {
+		return false
+	}
+	ms, err := strconv.ParseInt(r.FormValue(StampField), 10, 64)
+	if err != nil {
+		return true
+	}
+	return now.Sub(time.UnixMilli(ms)) < rules.MinTime
+}
@@ -0,0 +1,37 @@
+package antispam
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsBot(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rules := Rules{Honeypot: true, MinTime: 3 * time.Second}
+
+	tests := []struct {
+		name  string
+		rules Rules
+		form  url.Values
+		want  bool
+	}{
+		{"person", rules, url.Values{StampField: {Stamp(now.Add(-10 * time.Second))}}, false},
+		{"filled honeypot", rules, url.Values{HoneypotField: {"spam.example"}, StampField: {Stamp(now.Add(-10 * time.Second))}}, true},
+		{"too fast", rules, url.Values{StampField: {Stamp(now.Add(-time.Second))}}, true},
+		{"no stamp", rules, url.Values{}, true},
+		{"garbled stamp", rules, url.Values{StampField: {"soon"}}, true},
+		{"checks off", Rules{}, url.Values{HoneypotField: {"spam.example"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if got := tt.rules.IsBot(r, now); got != tt.want {
+				t.Errorf("got %v; expected %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package blocklist
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// What to do with content that contains a blocked term.
+const (
+	ModeReject = "reject"
+	ModeMask   = "mask"
+)
+
+// Blocklist matches blocked words and phrases case-insensitively, on whole
+// words only, so "ass" doesn't match "classic". It is safe for concurrent
+// use and can be reloaded while the server runs.
+type Blocklist struct {
+	mu   sync.RWMutex
+	path string
+	re   *regexp.Regexp
+}
+
+func New(terms []string) *Blocklist {
+	b := &Blocklist{}
+	b.Set(terms)
+	return b
+}
+
+// Load reads the terms from path, one per line. Empty lines and lines
+// starting with # are skipped. An empty path gives an empty list.
+func Load(path string) (*Blocklist, error) {
+	b := &Blocklist{path: path}
+	if err := b.Reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Reload reads the file the list was loaded from again. The old terms are
+// kept if the file can't be read.
+func (b *Blocklist) Reload() error {
+	if b.path == "" {
+		return nil
+	}
+	f, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var terms []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	b.Set(terms)
+	return nil
+}
+
+func (b *Blocklist) Set(terms []string) {
+	var quoted []string
+	for _, term := range terms {
+		if term = strings.TrimSpace(term); term != "" {
+			quoted = append(quoted, regexp.QuoteMeta(term))
+		}
+	}
+	var re *regexp.Regexp
+	if len(quoted) > 0 {
+		re = regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)`)
+	}
+
+	b.mu.Lock()
+	b.re = re
+	b.mu.Unlock()
+}
+
+// Contains reports whether text has any blocked term in it.
+func (b *Blocklist) Contains(text string) bool {
+	return len(b.find(text)) > 0
+}
+
+// Mask replaces every blocked term in text with asterisks.
+func (b *Blocklist) Mask(text string) string {
+	matches := b.find(text)
+	if len(matches) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(text[last:m[0]])
+		sb.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[m[0]:m[1]])))
+		last = m[1]
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// find returns the positions of the blocked terms that stand as whole words.
+// A match inside a longer word is skipped and the search goes on from the
+// next rune, so it can't hide a real match overlapping it.
+func (b *Blocklist) find(text string) [][2]int {
+	b.mu.RLock()
+	re := b.re
+	b.mu.RUnlock()
+	if re == nil {
+		return nil
+	}
+
+	var matches [][2]int
+	for i := 0; i < len(text); {
+		loc := re.FindStringIndex(text[i:])
+		if loc == nil {
+			break
+		}
+		start, end := i+loc[0], i+loc[1]
+		if wordBoundary(text, start, end) {
+			matches = append(matches, [2]int{start, end})
+			i = end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		i = start + size
+	}
+	return matches
+}
+
+func wordBoundary(text string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
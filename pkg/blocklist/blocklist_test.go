@@ -0,0 +1,87 @@
+package blocklist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContains(t *testing.T) {
+	b := New([]string{"ass", "bad word", "плохо"})
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"you ass", true},
+		{"ASS!", true},
+		{"(ass)", true},
+		{"classic assessment", false},
+		{"Scunthorpe has a class", false},
+		{"this is a bad word here", true},
+		{"bad words", false},
+		{"очень плохо", true},
+		{"плохой", false},
+		{"passass ass", true},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := b.Contains(tt.text); got != tt.want {
+			t.Errorf("Contains(%q) = %v; expected %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	b := New([]string{"ass", "плохо"})
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"you ass", "you ***"},
+		{"Ass, ass and class", "***, *** and class"},
+		{"очень плохо", "очень *****"},
+		{"nothing here", "nothing here"},
+	}
+
+	for _, tt := range tests {
+		if got := b.Mask(tt.text); got != tt.want {
+			t.Errorf("Mask(%q) = %q; expected %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte("# comment\nfoo\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Contains("foo") || b.Contains("bar") {
+		t.Fatalf("unexpected terms after load")
+	}
+
+	if err := os.WriteFile(path, []byte("bar\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if b.Contains("foo") || !b.Contains("bar") {
+		t.Errorf("terms not replaced on reload")
+	}
+
+	os.Remove(path)
+	if err := b.Reload(); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+	if !b.Contains("bar") {
+		t.Errorf("old terms must be kept when reload fails")
+	}
+}
@@ -0,0 +1,143 @@
+// Package captcha checks the challenge a CAPTCHA widget solved in a form.
+// The providers differ only in their endpoints and field names, so the rest
+// of the forum doesn't depend on any one of them.
+package captcha
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Providers selectable in the config.
+const (
+	ProviderNone      = "none"
+	ProviderHCaptcha  = "hcaptcha"
+	ProviderReCaptcha = "recaptcha"
+)
+
+type Verifier interface {
+	// Verify reports whether the form in r carries a solved challenge.
+	// remoteIP is the client address, passed on to the provider.
+	Verify(r *http.Request, remoteIP string) (bool, error)
+	// Widget is what a form needs to show the challenge, nil if it shows
+	// none.
+	Widget() *Widget
+}
+
+// Widget is the provider's script and the element it renders the challenge
+// into. Origins are the sources it loads scripts and frames from, for the
+// Content-Security-Policy.
+type Widget struct {
+	Script  string
+	Class   string
+	SiteKey string
+	Origins string
+}
+
+// New returns the verifier of provider. An empty provider is the same as
+// ProviderNone.
+func New(provider, siteKey, secret string) (Verifier, error) {
+	switch provider {
+	case "", ProviderNone:
+		return Noop{}, nil
+	case ProviderHCaptcha:
+		return NewHCaptcha(siteKey, secret), nil
+	case ProviderReCaptcha:
+		return NewReCaptcha(siteKey, secret), nil
+	}
+	return nil, fmt.Errorf("captcha: unknown provider %q", provider)
+}
+
+// Noop lets every form through. It is what the server uses when no provider
+// is configured, and what tests use.
+type Noop struct{}
+
+func (Noop) Verify(r *http.Request, remoteIP string) (bool, error) {
+	return true, nil
+}
+
+func (Noop) Widget() *Widget {
+	return nil
+}
+
+// SiteVerifier checks responses against a siteverify endpoint, the protocol
+// both hCaptcha and reCAPTCHA speak.
+type SiteVerifier struct {
+	verifyURL string
+	field     string
+	secret    string
+	widget    Widget
+	client    *http.Client
+}
+
+func NewHCaptcha(siteKey, secret string) *SiteVerifier {
+	return &SiteVerifier{
+		verifyURL: "https://api.hcaptcha.com/siteverify",
+		field:     "h-captcha-response",
+		secret:    secret,
+		widget: Widget{
+			Script:  "https://js.hcaptcha.com/1/api.js",
+			Class:   "h-captcha",
+			SiteKey: siteKey,
+			Origins: "https://hcaptcha.com https://*.hcaptcha.com",
+		},
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func NewReCaptcha(siteKey, secret string) *SiteVerifier {
+	return &SiteVerifier{
+		verifyURL: "https://www.google.com/recaptcha/api/siteverify",
+		field:     "g-recaptcha-response",
+		secret:    secret,
+		widget: Widget{
+			Script:  "https://www.google.com/recaptcha/api.js",
+			Class:   "g-recaptcha",
+			SiteKey: siteKey,
+			Origins: "https://www.google.com https://www.gstatic.com",
+		},
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Verify fails a form without a response right away, without asking the
+// provider.
+func (v *SiteVerifier) Verify(r *http.Request, remoteIP string) (bool, error) {
+	response := r.FormValue(v.field)
+	if response == "" {
+		return false, nil
+	}
+	form := url.Values{"secret": {v.secret}, "response": {response}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, fmt.Errorf("captcha: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("captcha: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("captcha: siteverify returned %s", resp.Status)
+	}
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("captcha: %w", err)
+	}
+	return result.Success, nil
+}
+
+func (v *SiteVerifier) Widget() *Widget {
+	return &v.widget
+}
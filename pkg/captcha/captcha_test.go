@@ -0,0 +1,61 @@
+package captcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSiteVerifier(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("secret") != "secret" || r.FormValue("remoteip") != "1.2.3.4" {
+			t.Errorf("got secret %q and remote ip %q", r.FormValue("secret"), r.FormValue("remoteip"))
+		}
+		if r.FormValue("response") == "solved" {
+			w.Write([]byte(`{"success": true}`))
+			return
+		}
+		w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+	}))
+	defer provider.Close()
+
+	for _, v := range []*SiteVerifier{NewHCaptcha("key", "secret"), NewReCaptcha("key", "secret")} {
+		v.verifyURL = provider.URL
+		for _, tt := range []struct {
+			response string
+			want     bool
+		}{
+			{"solved", true},
+			{"guessed", false},
+			{"", false},
+		} {
+			form := url.Values{v.field: {tt.response}}
+			r := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			got, err := v.Verify(r, "1.2.3.4")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("%s with %q: got %v; expected %v", v.widget.Class, tt.response, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, provider := range []string{"", ProviderNone, ProviderHCaptcha, ProviderReCaptcha} {
+		if _, err := New(provider, "key", "secret"); err != nil {
+			t.Errorf("%q: %v", provider, err)
+		}
+	}
+	if _, err := New("turnstile", "key", "secret"); err == nil {
+		t.Error("expected an unknown provider to fail")
+	}
+	ok, err := Noop{}.Verify(httptest.NewRequest(http.MethodPost, "/signup", nil), "")
+	if !ok || err != nil {
+		t.Errorf("got %v, %v; expected the no-op verifier to pass", ok, err)
+	}
+}
@@ -0,0 +1,44 @@
+// Package confusable tells whether two names look alike to a reader, so a
+// newcomer can't pass for "admin" by signing up as "Adm1n" or "ad.min".
+package confusable
+
+import (
+	"strings"
+	"unicode"
+)
+
+// lookalikes maps runes to the letter they are mistaken for. Letters that
+// are mistaken for one another map to the same one, like 1, i and l.
+var lookalikes = map[rune]rune{
+	'0': 'o', '1': 'l', 'i': 'l', '|': 'l', '!': 'l', '3': 'e', '4': 'a',
+	'@': 'a', '5': 's', '$': 's', '7': 't', '8': 'b', '9': 'g',
+	// Cyrillic and Greek letters drawn like Latin ones.
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'і': 'l', 'ј': 'j', 'к': 'k',
+	'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y',
+	'х': 'x', 'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'l', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// Letter pairs read as a single letter at a glance.
+var digraphs = strings.NewReplacer("rn", "m", "vv", "w")
+
+// Skeleton reduces name to what it looks like: case is folded, lookalike
+// runes become the letter they pass for and everything but letters and
+// digits is dropped. Names with the same skeleton are confusable.
+func Skeleton(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if l, ok := lookalikes[r]; ok {
+			r = l
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return digraphs.Replace(b.String())
+}
+
+// Similar reports whether a and b are confusable.
+func Similar(a, b string) bool {
+	return Skeleton(a) == Skeleton(b)
+}
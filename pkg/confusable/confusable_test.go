@@ -0,0 +1,25 @@
+package confusable
+
+import "testing"
+
+func TestSimilar(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Admin", "admin", true},
+		{"Adm1n", "Admin", true},
+		{"ad.min", "admin", true},
+		{"_admin_", "admin", true},
+		{"аdmin", "admin", true}, // Cyrillic а
+		{"rnoderator", "moderator", true},
+		{"l0rd", "lord", true},
+		{"admins", "admin", false},
+		{"bob", "rob", false},
+	}
+	for _, tt := range tests {
+		if got := Similar(tt.a, tt.b); got != tt.want {
+			t.Errorf("Similar(%q, %q) = %t; expected %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
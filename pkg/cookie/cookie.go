@@ -2,6 +2,7 @@ package cookie
 
 import (
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -35,3 +36,48 @@ func ExpireSessionCookie(w http.ResponseWriter) {
 	}
 	http.SetCookie(w, &cookie)
 }
+
+const (
+	redirectCookieName = "redirect"
+	// redirectTTL is how long the page asked for before logging in is
+	// remembered.
+	redirectTTL = 15 * time.Minute
+)
+
+// SetRedirectCookie remembers target, the page to go back to after logging
+// in.
+func SetRedirectCookie(w http.ResponseWriter, target string) {
+	cookie := http.Cookie{
+		Name:     redirectCookieName,
+		Value:    url.QueryEscape(target),
+		Path:     "/",
+		MaxAge:   int(redirectTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	http.SetCookie(w, &cookie)
+}
+
+// GetRedirectCookie returns the page remembered by SetRedirectCookie, empty
+// when there is none. The caller must check it before redirecting there.
+func GetRedirectCookie(r *http.Request) string {
+	cookie, err := r.Cookie(redirectCookieName)
+	if err != nil {
+		return ""
+	}
+	target, err := url.QueryUnescape(cookie.Value)
+	if err != nil {
+		return ""
+	}
+	return target
+}
+
+func ExpireRedirectCookie(w http.ResponseWriter) {
+	cookie := http.Cookie{
+		Name:   redirectCookieName,
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	}
+	http.SetCookie(w, &cookie)
+}
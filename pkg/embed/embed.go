@@ -0,0 +1,173 @@
+// Package embed shows links to a few trusted sites, like YouTube videos, as
+// players under the post rather than as plain links. Which of the sites are
+// trusted is configured; links anywhere else are never embedded.
+package embed
+
+import (
+	"fmt"
+	"forum/pkg/markdown"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxEmbeds bounds the players on a post, the links after them stay links.
+const maxEmbeds = 3
+
+// Embed is the player of a link, framed from Src.
+type Embed struct {
+	URL   string
+	Src   string
+	Title string
+}
+
+// A Renderer embeds the links to one site. Embed returns false for a link
+// it has no player for, like a channel rather than a video.
+type Renderer interface {
+	Embed(u *url.URL) (Embed, bool)
+	// Origin is where the players are framed from, for the
+	// Content-Security-Policy.
+	Origin() string
+}
+
+// Renderers are the renderers of the sites that may be trusted, keyed by
+// domain. A domain covers its subdomains too.
+var Renderers = map[string]Renderer{
+	"youtube.com": YouTube{},
+	"youtu.be":    YouTube{},
+	"vimeo.com":   Vimeo{},
+}
+
+// ParseDomains reads a comma separated list of trusted domains, like
+// "youtube.com,youtu.be". Each needs a renderer. Blanks and repeats are
+// dropped.
+func ParseDomains(s string) ([]string, error) {
+	var domains []string
+	for _, d := range strings.Split(s, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" || slices.Contains(domains, d) {
+			continue
+		}
+		if _, ok := Renderers[d]; !ok {
+			return nil, fmt.Errorf("embed: no renderer for domain %q", d)
+		}
+		domains = append(domains, d)
+	}
+	return domains, nil
+}
+
+// Embedder embeds the links to its trusted domains.
+type Embedder struct {
+	renderers map[string]Renderer
+}
+
+// New returns an Embedder trusting domains. Domains without a renderer are
+// left out, and with no domains at all nothing is embedded.
+func New(domains []string) *Embedder {
+	e := &Embedder{renderers: map[string]Renderer{}}
+	for _, d := range domains {
+		if r, ok := Renderers[strings.ToLower(d)]; ok {
+			e.renderers[strings.ToLower(d)] = r
+		}
+	}
+	return e
+}
+
+// Embeds returns the players of the links in src, a post in Markdown, in
+// the order they are linked.
+func (e *Embedder) Embeds(src string) []Embed {
+	var embeds []Embed
+	for _, link := range markdown.Links(src) {
+		if len(embeds) == maxEmbeds {
+			break
+		}
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		r := e.renderer(u.Hostname())
+		if r == nil {
+			continue
+		}
+		if embed, ok := r.Embed(u); ok {
+			embed.URL = link
+			embeds = append(embeds, embed)
+		}
+	}
+	return embeds
+}
+
+// Origins are the origins the players are framed from, space separated for
+// the Content-Security-Policy, or "" when nothing is embedded.
+func (e *Embedder) Origins() string {
+	var origins []string
+	for _, r := range e.renderers {
+		if !slices.Contains(origins, r.Origin()) {
+			origins = append(origins, r.Origin())
+		}
+	}
+	slices.Sort(origins)
+	return strings.Join(origins, " ")
+}
+
+// renderer returns the renderer of host or of the closest of its parent
+// domains that is trusted, nil if none is.
+func (e *Embedder) renderer(host string) Renderer {
+	host = strings.ToLower(host)
+	for {
+		if r, ok := e.renderers[host]; ok {
+			return r
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			return nil
+		}
+		host = host[i+1:]
+	}
+}
+
+var (
+	youTubeIDRX = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	vimeoIDRX   = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// YouTube embeds videos, through the privacy-enhanced player that sets no
+// cookies until it is played.
+type YouTube struct{}
+
+func (YouTube) Embed(u *url.URL) (Embed, bool) {
+	var id string
+	path := strings.Trim(u.Path, "/")
+	switch {
+	case strings.EqualFold(u.Hostname(), "youtu.be"):
+		id = path
+	case path == "watch":
+		id = u.Query().Get("v")
+	case strings.HasPrefix(path, "shorts/"), strings.HasPrefix(path, "embed/"):
+		id = path[strings.IndexByte(path, '/')+1:]
+	}
+	if !youTubeIDRX.MatchString(id) {
+		return Embed{}, false
+	}
+	return Embed{Src: "https://www.youtube-nocookie.com/embed/" + id, Title: "YouTube video"}, true
+}
+
+func (YouTube) Origin() string {
+	return "https://www.youtube-nocookie.com"
+}
+
+// Vimeo embeds videos.
+type Vimeo struct{}
+
+func (Vimeo) Embed(u *url.URL) (Embed, bool) {
+	id := strings.Trim(u.Path, "/")
+	if !vimeoIDRX.MatchString(id) {
+		return Embed{}, false
+	}
+	return Embed{Src: "https://player.vimeo.com/video/" + id, Title: "Vimeo video"}, true
+}
+
+func (Vimeo) Origin() string {
+	return "https://player.vimeo.com"
+}
@@ -0,0 +1,53 @@
+package embed
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEmbeds(t *testing.T) {
+	e := New([]string{"youtube.com", "youtu.be"})
+
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"Watch link", "look https://www.youtube.com/watch?v=dQw4w9WgXcQ", []string{"https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ"}},
+		{"Short link", "[this](https://youtu.be/dQw4w9WgXcQ)", []string{"https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ"}},
+		{"Subdomain", "https://m.youtube.com/shorts/dQw4w9WgXcQ", []string{"https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ"}},
+		{"Arbitrary site", "https://example.com/watch?v=dQw4w9WgXcQ", nil},
+		{"Lookalike domain", "https://youtube.com.example.com/watch?v=dQw4w9WgXcQ https://notyoutube.com/watch?v=dQw4w9WgXcQ", nil},
+		{"Known but untrusted", "https://vimeo.com/76979871", nil},
+		{"No video", "https://www.youtube.com/@channel", nil},
+		{"Code", "`https://youtu.be/dQw4w9WgXcQ`", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, embed := range e.Embeds(tt.src) {
+				got = append(got, embed.Src)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q; expected %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := New(nil).Embeds("https://youtu.be/dQw4w9WgXcQ"); got != nil {
+		t.Errorf("got %v with no trusted domains; expected none", got)
+	}
+}
+
+func TestParseDomains(t *testing.T) {
+	got, err := ParseDomains(" YouTube.com, youtu.be,,youtube.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"youtube.com", "youtu.be"}; !slices.Equal(got, want) {
+		t.Errorf("got %q; expected %q", got, want)
+	}
+	if _, err := ParseDomains("youtube.com,example.com"); err == nil {
+		t.Error("got no error for a domain without a renderer")
+	}
+}
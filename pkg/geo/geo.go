@@ -0,0 +1,156 @@
+// Package geo tells which country a client address is in, so that traffic
+// from some countries can be turned away where the law asks for it. Lookups
+// go through Resolver, so that the forum doesn't depend on any one database
+// of addresses and tests can stub it.
+package geo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ErrUnknown is returned for addresses no country is known for.
+var ErrUnknown = errors.New("geo: unknown country")
+
+type Resolver interface {
+	// Country returns the ISO 3166-1 alpha-2 code of the country ip is
+	// in, in upper case, or an error when it can't tell.
+	Country(ip string) (string, error)
+}
+
+// Noop puts every address in no country, so nothing is ever blocked. It is
+// what tests use by default.
+type Noop struct{}
+
+func (Noop) Country(ip string) (string, error) {
+	return "", nil
+}
+
+// Ranges resolves addresses from a list of networks and their countries.
+// The networks are sorted by their first address, so that lookups are a
+// binary search.
+type Ranges struct {
+	nets []network
+}
+
+// network is a prefix as the range of addresses it holds, IPv4 ones mapped
+// into IPv6 so that both sort together.
+type network struct {
+	prefix      netip.Prefix
+	first, last [16]byte
+	country     string
+}
+
+func newNetwork(prefix netip.Prefix, country string) network {
+	prefix = prefix.Masked()
+	n := network{prefix: prefix, first: prefix.Addr().As16(), country: country}
+	bits := prefix.Bits()
+	if prefix.Addr().Is4() {
+		bits += 96
+	}
+	n.last = n.first
+	for i := bits; i < 128; i++ {
+		n.last[i/8] |= 1 << (7 - i%8)
+	}
+	return n
+}
+
+// LoadRanges reads the networks from path, one "CIDR,COUNTRY" pair per
+// line, like "203.0.113.0/24,NZ". Empty lines and lines starting with # are
+// skipped. The networks may come in any order but must not overlap. An
+// empty path gives an empty list, which knows no address.
+func LoadRanges(path string) (*Ranges, error) {
+	r := &Ranges{}
+	if path == "" {
+		return r, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cidr, country, ok := strings.Cut(line, ",")
+		if !ok {
+			return nil, fmt.Errorf("geo: %s:%d: expected CIDR,COUNTRY", path, n)
+		}
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("geo: %s:%d: %w", path, n, err)
+		}
+		if country, err = parseCountry(country); err != nil {
+			return nil, fmt.Errorf("geo: %s:%d: %w", path, n, err)
+		}
+		r.nets = append(r.nets, newNetwork(prefix, country))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(r.nets, func(a, b network) int { return bytes.Compare(a.first[:], b.first[:]) })
+	for i := 1; i < len(r.nets); i++ {
+		if bytes.Compare(r.nets[i].first[:], r.nets[i-1].last[:]) <= 0 {
+			return nil, fmt.Errorf("geo: %s: %s overlaps %s", path, r.nets[i].prefix, r.nets[i-1].prefix)
+		}
+	}
+	return r, nil
+}
+
+// Country returns the country of the network that has ip in it, and
+// ErrUnknown when none does.
+func (r *Ranges) Country(ip string) (string, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", fmt.Errorf("geo: invalid address %q", ip)
+	}
+	a := addr.As16()
+	// The last network starting at or before the address is the only one
+	// that may hold it.
+	i, found := slices.BinarySearchFunc(r.nets, a, func(n network, a [16]byte) int {
+		return bytes.Compare(n.first[:], a[:])
+	})
+	if !found {
+		i--
+	}
+	if i < 0 || bytes.Compare(a[:], r.nets[i].last[:]) > 0 {
+		return "", ErrUnknown
+	}
+	return r.nets[i].country, nil
+}
+
+// ParseCountries reads a comma separated list of country codes, like
+// "kp,IR". They are upper cased, and blanks are dropped.
+func ParseCountries(s string) ([]string, error) {
+	var countries []string
+	for _, field := range strings.Split(s, ",") {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		country, err := parseCountry(field)
+		if err != nil {
+			return nil, err
+		}
+		countries = append(countries, country)
+	}
+	return countries, nil
+}
+
+func parseCountry(s string) (string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) != 2 || s[0] < 'A' || s[0] > 'Z' || s[1] < 'A' || s[1] > 'Z' {
+		return "", fmt.Errorf("geo: invalid country code %q", s)
+	}
+	return s, nil
+}
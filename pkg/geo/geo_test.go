@@ -0,0 +1,76 @@
+package geo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ranges.csv")
+	ranges := "# network,country\n203.0.113.0/24,nz\n\n198.51.100.0/24, KP\n2001:db8::/32,IR\n198.51.101.0/25,CU\n10.0.0.1/8,IR\n"
+	if err := os.WriteFile(path, []byte(ranges), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := LoadRanges(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip      string
+		want    string
+		wantErr bool
+	}{
+		{ip: "203.0.113.7", want: "NZ"},
+		{ip: "198.51.100.1", want: "KP"},
+		{ip: "198.51.100.255", want: "KP"},
+		{ip: "198.51.101.0", want: "CU"},
+		{ip: "198.51.101.127", want: "CU"},
+		{ip: "198.51.101.128", wantErr: true},
+		{ip: "10.255.255.255", want: "IR"},
+		{ip: "2001:db8::1", want: "IR"},
+		{ip: "2001:db9::", wantErr: true},
+		{ip: "::", wantErr: true},
+		{ip: "192.0.2.1", wantErr: true},
+		{ip: "not an ip", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := r.Country(tt.ip)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: got %q, %v; expected %q", tt.ip, got, err, tt.want)
+		}
+	}
+	if _, err := r.Country("192.0.2.1"); !errors.Is(err, ErrUnknown) {
+		t.Errorf("got %v; expected %v", err, ErrUnknown)
+	}
+
+	for _, bad := range []string{"203.0.113.0/24,New Zealand\n", "198.51.100.0/24,KP\n198.51.100.128/25,CU\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadRanges(path); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+	if r, err := LoadRanges(""); err != nil || len(r.nets) != 0 {
+		t.Errorf("got %v, %v; expected an empty list", r, err)
+	}
+}
+
+func TestParseCountries(t *testing.T) {
+	got, err := ParseCountries(" kp, IR,,cu ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"KP", "IR", "CU"}; !slices.Equal(got, want) {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+	for _, s := range []string{"USA", "K1", "Ñ"} {
+		if _, err := ParseCountries(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
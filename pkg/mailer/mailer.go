@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"strings"
+)
+
+type Mailer interface {
+	Send(to, subject, body string) error
+	// SendHTML is Send for a body rendered from Templates.
+	SendHTML(to, subject, body string) error
+}
+
+// LogMailer writes mail to a logger instead of sending it. It is what the
+// server uses when no SMTP server is configured.
+type LogMailer struct {
+	log *log.Logger
+}
+
+func NewLogMailer(l *log.Logger) *LogMailer {
+	return &LogMailer{log: l}
+}
+
+func (m *LogMailer) Send(to, subject, body string) error {
+	m.log.Printf("mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+func (m *LogMailer) SendHTML(to, subject, body string) error {
+	return m.Send(to, subject, body)
+}
+
+// SMTPMailer sends mail through an SMTP server, authenticating only when a
+// username is set.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(addr, from, username, password string) *SMTPMailer {
+	m := &SMTPMailer{addr: addr, from: from}
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	return m.send(to, subject, "text/plain", body)
+}
+
+func (m *SMTPMailer) SendHTML(to, subject, body string) error {
+	return m.send(to, subject, "text/html", body)
+}
+
+func (m *SMTPMailer) send(to, subject, contentType, body string) error {
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: %s; charset=UTF-8\r\n\r\n%s", m.from, to, mime.QEncoding.Encode("utf-8", subject), contentType, body)
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	return nil
+}
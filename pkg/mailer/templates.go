@@ -0,0 +1,143 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// Names of the emails the forum sends. Every locale must have a template for
+// each of them.
+const (
+	Verification  = "verification"
+	PasswordReset = "reset"
+	Digest        = "digest"
+)
+
+var names = []string{Verification, PasswordReset, Digest}
+
+// DefaultLocale is the locale of the built-in templates.
+const DefaultLocale = "en"
+
+//go:embed templates
+var defaultTemplates embed.FS
+
+// VerificationData is rendered by the Verification template.
+type VerificationData struct {
+	Name string
+	Link string
+}
+
+// ResetData is rendered by the PasswordReset template.
+type ResetData struct {
+	Name    string
+	Link    string
+	Expires time.Time
+}
+
+// DigestData is rendered by the Digest template.
+type DigestData struct {
+	Name  string
+	Items []DigestItem
+}
+
+type DigestItem struct {
+	Summary string
+	Link    string
+}
+
+// Templates are the emails of every locale. A template file is
+// <locale>/<name>.html and defines a "subject" and a "body".
+type Templates struct {
+	locales  map[string]map[string]*template.Template
+	fallback string
+}
+
+// LoadTemplates parses the built-in templates, then those of dir, if not
+// nil, over them. A locale of dir replaces the built-in one of the same name
+// whole. Mail in a locale without templates uses fallback. A locale missing
+// a template, or a template missing its subject or body, is an error.
+func LoadTemplates(dir fs.FS, fallback string) (*Templates, error) {
+	t := &Templates{locales: map[string]map[string]*template.Template{}, fallback: fallback}
+	defaults, err := fs.Sub(defaultTemplates, "templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, fsys := range []fs.FS{defaults, dir} {
+		if fsys == nil {
+			continue
+		}
+		if err := t.load(fsys); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := t.locales[fallback]; !ok {
+		return nil, fmt.Errorf("mailer: no templates for the fallback locale %q", fallback)
+	}
+	return t, nil
+}
+
+func (t *Templates) load(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := entry.Name()
+		set := map[string]*template.Template{}
+		for _, name := range names {
+			file := path.Join(locale, name+".html")
+			if _, err := fs.Stat(fsys, file); errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("mailer: locale %q has no %q template", locale, name)
+			}
+			tmpl, err := template.ParseFS(fsys, file)
+			if err != nil {
+				return fmt.Errorf("mailer: %w", err)
+			}
+			for _, part := range []string{"subject", "body"} {
+				if tmpl.Lookup(part) == nil {
+					return fmt.Errorf("mailer: %s defines no %q", file, part)
+				}
+			}
+			set[name] = tmpl
+		}
+		t.locales[locale] = set
+	}
+	return nil
+}
+
+// Render renders the email name of locale, or of the fallback locale when
+// locale has no templates, with data.
+func (t *Templates) Render(locale, name string, data any) (subject, body string, err error) {
+	set, ok := t.locales[locale]
+	if !ok {
+		set = t.locales[t.fallback]
+	}
+	tmpl, ok := set[name]
+	if !ok {
+		return "", "", fmt.Errorf("mailer: unknown template %q", name)
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&b, "subject", data); err != nil {
+		return "", "", fmt.Errorf("mailer: %w", err)
+	}
+	// The subject goes in a header, not in HTML, so it is sent unescaped.
+	subject = html.UnescapeString(strings.TrimSpace(b.String()))
+
+	b.Reset()
+	if err := tmpl.ExecuteTemplate(&b, "body", data); err != nil {
+		return "", "", fmt.Errorf("mailer: %w", err)
+	}
+	return subject, b.String(), nil
+}
@@ -0,0 +1,161 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRenderTemplates(t *testing.T) {
+	emails, err := LoadTemplates(nil, DefaultLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		data        any
+		wantSubject string
+		wantBody    []string
+	}{
+		{
+			name:        Verification,
+			data:        VerificationData{Name: "alice", Link: "https://forum.example/verify?token=abc&x=1"},
+			wantSubject: "Confirm your email address",
+			wantBody:    []string{"Hi alice,", `<a href="https://forum.example/verify?token=abc&amp;x=1">`},
+		},
+		{
+			name:        PasswordReset,
+			data:        ResetData{Name: "bob", Link: "/password/reset?token=xyz", Expires: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)},
+			wantSubject: "Reset your password",
+			wantBody:    []string{"Hi bob,", `<a href="/password/reset?token=xyz">`, "until 01 May 2024 at 10:30 UTC"},
+		},
+		{
+			name:        Digest,
+			data:        DigestData{Name: "carol", Items: []DigestItem{{Summary: `dan replied to "Go & you"`, Link: "/post/4"}}},
+			wantSubject: "1 new notification",
+			wantBody:    []string{"Hi carol,", `<li><a href="/post/4">dan replied to &#34;Go &amp; you&#34;</a></li>`},
+		},
+		{
+			name:        Digest,
+			data:        DigestData{Name: "carol", Items: []DigestItem{{Summary: "a", Link: "/post/1"}, {Summary: "b", Link: "/post/2"}}},
+			wantSubject: "2 new notifications",
+			wantBody:    []string{`<a href="/post/1">a</a>`, `<a href="/post/2">b</a>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, body, err := emails.Render(DefaultLocale, tt.name, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("got subject %q; want %q", subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("expected %q to contain %q", body, want)
+				}
+			}
+		})
+	}
+
+	if _, _, err := emails.Render(DefaultLocale, "welcome", nil); err == nil {
+		t.Error("rendered an unknown template")
+	}
+}
+
+// locale returns a complete set of templates for a locale whose emails all
+// have subject.
+func locale(name, subject string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, email := range names {
+		fsys[name+"/"+email+".html"] = &fstest.MapFile{
+			Data: []byte(`{{define "subject"}}` + subject + `{{end}}{{define "body"}}<p>{{.Name}}</p>{{end}}`),
+		}
+	}
+	return fsys
+}
+
+func TestTemplateLocales(t *testing.T) {
+	dir := locale("fr", "Bonjour & bienvenue")
+	emails, err := LoadTemplates(dir, DefaultLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		locale      string
+		wantSubject string
+	}{
+		{name: "Own locale", locale: "fr", wantSubject: "Bonjour & bienvenue"},
+		{name: "Built-in locale", locale: DefaultLocale, wantSubject: "Confirm your email address"},
+		{name: "Unknown locale falls back", locale: "de", wantSubject: "Confirm your email address"},
+		{name: "No locale falls back", locale: "", wantSubject: "Confirm your email address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, _, err := emails.Render(tt.locale, Verification, VerificationData{Name: "alice"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("got subject %q; want %q", subject, tt.wantSubject)
+			}
+		})
+	}
+
+	// The fallback can be a locale of the directory too.
+	emails, err = LoadTemplates(dir, "fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _, err := emails.Render("de", Digest, DigestData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Bonjour & bienvenue" {
+		t.Errorf("got subject %q; want the fr one", subject)
+	}
+}
+
+func TestLoadTemplatesErrors(t *testing.T) {
+	missing := locale("fr", "Bonjour")
+	delete(missing, "fr/"+PasswordReset+".html")
+
+	noBody := locale("fr", "Bonjour")
+	noBody["fr/"+Digest+".html"] = &fstest.MapFile{Data: []byte(`{{define "subject"}}Bonjour{{end}}`)}
+
+	broken := locale("fr", "Bonjour")
+	broken["fr/"+Digest+".html"] = &fstest.MapFile{Data: []byte(`{{define "subject"}}{{.Name{{end}}`)}
+
+	tests := []struct {
+		name     string
+		dir      fstest.MapFS
+		fallback string
+		wantErr  string
+	}{
+		{name: "Missing template", dir: missing, fallback: DefaultLocale, wantErr: `locale "fr" has no "reset" template`},
+		{name: "Missing body", dir: noBody, fallback: DefaultLocale, wantErr: `defines no "body"`},
+		{name: "Parse error", dir: broken, fallback: DefaultLocale, wantErr: "digest.html"},
+		{name: "Unknown fallback", dir: nil, fallback: "de", wantErr: `no templates for the fallback locale "de"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.dir == nil {
+				_, err = LoadTemplates(nil, tt.fallback)
+			} else {
+				_, err = LoadTemplates(tt.dir, tt.fallback)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v; want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Package markdown renders the small Markdown subset posts are written in:
+// paragraphs, headings, quotes, lists, fenced code, inline code, emphasis,
+// links and ||spoilers||. The input is HTML-escaped before anything else, so raw HTML in
+// a post is shown as text and the output is safe to embed as is.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	headingRX  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletRX   = regexp.MustCompile(`^[-*]\s+(.*)$`)
+	orderedRX  = regexp.MustCompile(`^\d+\.\s+(.*)$`)
+	quoteRX    = regexp.MustCompile(`^&gt;\s?(.*)$`)
+	linkRX     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	bareURLRX  = regexp.MustCompile(`(?i)\b(https?://|www\.)\S`)
+	httpURLRX  = regexp.MustCompile(`(?i)\bhttps?://[^\s<>()\[\]"']+`)
+	strongRX   = regexp.MustCompile(`\*\*(\S[^*]*?)\*\*`)
+	emphasisRX = regexp.MustCompile(`\*(\S[^*]*?)\*|\b_(\S[^_]*?)_\b`)
+	spoilerRX  = regexp.MustCompile(`\|\|([^|\s](?:[^|]*[^|\s])?)\|\|`)
+	tagRX      = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	spaceRX    = regexp.MustCompile(`\s+`)
+)
+
+// Render turns src into HTML.
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	lines := strings.Split(html.EscapeString(src), "\n")
+
+	var b strings.Builder
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], " \t")
+		switch {
+		case line == "":
+			i++
+		case strings.HasPrefix(line, "```"):
+			i++
+			var code []string
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+				code = append(code, lines[i])
+				i++
+			}
+			i++ // the closing fence
+			b.WriteString("<pre><code>" + strings.Join(code, "\n") + "</code></pre>\n")
+		case headingRX.MatchString(line):
+			m := headingRX.FindStringSubmatch(line)
+			level := string(rune('0' + len(m[1])))
+			b.WriteString("<h" + level + ">" + inline(m[2]) + "</h" + level + ">\n")
+			i++
+		case bulletRX.MatchString(line):
+			i = list(&b, lines, i, bulletRX, "ul")
+		case orderedRX.MatchString(line):
+			i = list(&b, lines, i, orderedRX, "ol")
+		case quoteRX.MatchString(line):
+			var quote []string
+			for ; i < len(lines) && quoteRX.MatchString(lines[i]); i++ {
+				quote = append(quote, inline(quoteRX.FindStringSubmatch(lines[i])[1]))
+			}
+			b.WriteString("<blockquote>" + strings.Join(quote, "<br>\n") + "</blockquote>\n")
+		default:
+			var para []string
+			for ; i < len(lines) && startsParagraph(lines[i]); i++ {
+				para = append(para, inline(strings.TrimRight(lines[i], " \t")))
+			}
+			b.WriteString("<p>" + strings.Join(para, "<br>\n") + "</p>\n")
+		}
+	}
+	return b.String()
+}
+
+// Text is src as plain text, for where formatting isn't shown. The Markdown
+// is rendered and dropped along with any raw HTML, spoilers are left out
+// and runs of whitespace become single spaces.
+func Text(src string) string {
+	src = spoilerRX.ReplaceAllString(tagRX.ReplaceAllString(src, ""), "[spoiler]")
+	text := tagRX.ReplaceAllString(Render(src), "")
+	return strings.TrimSpace(spaceRX.ReplaceAllString(html.UnescapeString(text), " "))
+}
+
+// Snippet is the start of the Text of src, at most n characters cut at a
+// word boundary, with an ellipsis when anything was cut. A single word
+// longer than n is cut where it has to be. n of 0 keeps the whole text.
+func Snippet(src string, n int) string {
+	text := Text(src)
+	if n <= 0 || utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	cut := string(runes[:n])
+	if runes[n] != ' ' {
+		if i := strings.LastIndex(cut, " "); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRight(cut, " .,;:!?-") + "…"
+}
+
+// startsParagraph reports whether line continues a paragraph rather than
+// ending it or starting another kind of block.
+func startsParagraph(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	return line != "" && !strings.HasPrefix(line, "```") && !headingRX.MatchString(line) &&
+		!bulletRX.MatchString(line) && !orderedRX.MatchString(line) && !quoteRX.MatchString(line)
+}
+
+func list(b *strings.Builder, lines []string, i int, item *regexp.Regexp, tag string) int {
+	b.WriteString("<" + tag + ">\n")
+	for ; i < len(lines) && item.MatchString(lines[i]); i++ {
+		b.WriteString("<li>" + inline(item.FindStringSubmatch(lines[i])[1]) + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// inline renders code spans, links, emphasis and spoilers in already
+// escaped text. Nothing inside a code span is formatted.
+func inline(s string) string {
+	parts := strings.Split(s, "`")
+	for i := range parts {
+		if i%2 == 1 && i < len(parts)-1 {
+			parts[i] = "<code>" + parts[i] + "</code>"
+			continue
+		}
+		if i%2 == 1 {
+			// An unclosed backtick is just a backtick.
+			parts[i] = "`" + parts[i]
+		}
+		parts[i] = spoilers(emphasis(links(parts[i])))
+	}
+	return strings.Join(parts, "")
+}
+
+func links(s string) string {
+	return linkRX.ReplaceAllStringFunc(s, func(m string) string {
+		sub := linkRX.FindStringSubmatch(m)
+		if !safeURL(html.UnescapeString(sub[2])) {
+			return m
+		}
+		return `<a href="` + sub[2] + `" rel="nofollow noopener">` + sub[1] + `</a>`
+	})
+}
+
+// spoilers hides the text between double pipes until it is clicked, which
+// ui/static/js/spoiler.js takes care of.
+func spoilers(s string) string {
+	return spoilerRX.ReplaceAllString(s, `<span class="spoiler" tabindex="0">$1</span>`)
+}
+
+// HasLink reports whether src links anywhere, as a Markdown link or a bare
+// URL readers could copy.
+func HasLink(src string) bool {
+	return linkRX.MatchString(src) || bareURLRX.MatchString(src)
+}
+
+// Links returns the http(s) URLs src links to, Markdown links and bare URLs
+// alike, in order and without repeats. Links in code are left out, as they
+// are shown as text.
+func Links(src string) []string {
+	var links []string
+	add := func(link string) {
+		link = strings.TrimRight(link, ".,;:!?")
+		if !slices.Contains(links, link) {
+			links = append(links, link)
+		}
+	}
+	inFence := false
+	for _, line := range strings.Split(src, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		parts := strings.Split(line, "`")
+		for i, part := range parts {
+			if i%2 == 1 && i < len(parts)-1 {
+				continue
+			}
+			for _, m := range linkRX.FindAllStringSubmatch(part, -1) {
+				if u, err := url.Parse(m[2]); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+					add(m[2])
+				}
+			}
+			for _, link := range httpURLRX.FindAllString(linkRX.ReplaceAllString(part, ""), -1) {
+				add(link)
+			}
+		}
+	}
+	return links
+}
+
+// safeURL allows http(s) links and links within the forum only, which keeps
+// javascript: and data: URLs out.
+func safeURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		return u.Host == "" && strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//")
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+func emphasis(s string) string {
+	s = strongRX.ReplaceAllString(s, "<strong>$1</strong>")
+	return emphasisRX.ReplaceAllString(s, "<em>$1$2</em>")
+}
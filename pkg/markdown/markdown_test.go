@@ -0,0 +1,169 @@
+package markdown
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "Paragraphs and line breaks",
+			src:  "one\ntwo\n\nthree",
+			want: "<p>one<br>\ntwo</p>\n<p>three</p>\n",
+		},
+		{
+			name: "Heading",
+			src:  "## Hello *you*",
+			want: "<h2>Hello <em>you</em></h2>\n",
+		},
+		{
+			name: "Lists",
+			src:  "- a\n- **b**\n\n1. c",
+			want: "<ul>\n<li>a</li>\n<li><strong>b</strong></li>\n</ul>\n<ol>\n<li>c</li>\n</ol>\n",
+		},
+		{
+			name: "Quote",
+			src:  "> said",
+			want: "<blockquote>said</blockquote>\n",
+		},
+		{
+			name: "Code is not formatted",
+			src:  "`*x*` and\n```\n<b>*y*</b>\n```",
+			want: "<p><code>*x*</code> and</p>\n<pre><code>&lt;b&gt;*y*&lt;/b&gt;</code></pre>\n",
+		},
+		{
+			name: "Raw HTML is escaped",
+			src:  `<img src=x onerror="alert(1)">`,
+			want: "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>\n",
+		},
+		{
+			name: "Links",
+			src:  "[site](https://example.com/?a=1&b=2) [post](/post/1)",
+			want: `<p><a href="https://example.com/?a=1&amp;b=2" rel="nofollow noopener">site</a> <a href="/post/1" rel="nofollow noopener">post</a></p>` + "\n",
+		},
+		{
+			name: "Unsafe links stay text",
+			src:  "[x](javascript:alert(1)) [y](//evil.com)",
+			want: "<p>[x](javascript:alert(1)) [y](//evil.com)</p>\n",
+		},
+		{
+			name: "Lone asterisks",
+			src:  "2 * 3 * 4",
+			want: "<p>2 * 3 * 4</p>\n",
+		},
+		{
+			name: "Spoiler",
+			src:  "it was ||the **butler**|| all along",
+			want: `<p>it was <span class="spoiler" tabindex="0">the <strong>butler</strong></span> all along</p>` + "\n",
+		},
+		{
+			name: "Spoiler is escaped",
+			src:  `||<img src=x onerror="alert(1)">|| ||" onclick="alert(1)||`,
+			want: `<p><span class="spoiler" tabindex="0">&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</span> <span class="spoiler" tabindex="0">&#34; onclick=&#34;alert(1)</span></p>` + "\n",
+		},
+		{
+			name: "Unsafe link in a spoiler stays text",
+			src:  "||[x](javascript:alert(1))||",
+			want: `<p><span class="spoiler" tabindex="0">[x](javascript:alert(1))</span></p>` + "\n",
+		},
+		{
+			name: "Not a spoiler",
+			src:  "a || b, |||| and `||code||`",
+			want: "<p>a || b, |||| and <code>||code||</code></p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src); got != tt.want {
+				t.Errorf("got %q; expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasLink(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"no links here", false},
+		{"a [link](https://example.com)", true},
+		{"a [local link](/post/1)", true},
+		{"see https://example.com", true},
+		{"see HTTP://EXAMPLE.COM", true},
+		{"see www.example.com", true},
+		{"brackets [but] (no link)", false},
+		{"http and www alone", false},
+	}
+	for _, tt := range tests {
+		if got := HasLink(tt.src); got != tt.want {
+			t.Errorf("HasLink(%q) = %v; expected %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestLinks(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"no links here", nil},
+		{"a [link](https://example.com/a) and https://example.com/b.", []string{"https://example.com/a", "https://example.com/b"}},
+		{"twice https://example.com and [again](https://example.com)", []string{"https://example.com"}},
+		{"local [link](/post/1), www.example.com and [bad](javascript:alert(1))", nil},
+		{"`https://example.com/code`\n```\nhttps://example.com/fenced\n```\n(https://example.com/c)", []string{"https://example.com/c"}},
+	}
+	for _, tt := range tests {
+		if got := Links(tt.src); !slices.Equal(got, tt.want) {
+			t.Errorf("Links(%q) = %q; expected %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"## Hello *you*\n\n- **a**\n- [b](https://example.com)", "Hello you a b"},
+		{"`*x*` & <b>y</b><script>alert(1)</script>", "*x* & yalert(1)"},
+		{"  one\n\n\ttwo  ", "one two"},
+		{"it was ||the butler|| all along", "it was [spoiler] all along"},
+	}
+	for _, tt := range tests {
+		if got := Text(tt.src); got != tt.want {
+			t.Errorf("Text(%q) = %q; expected %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		n    int
+		want string
+	}{
+		{"Short enough", "one two", 7, "one two"},
+		{"Word boundary", "one two three", 9, "one two…"},
+		{"Cut at a space", "one two three", 7, "one two…"},
+		{"Trailing punctuation", "one, two", 6, "one…"},
+		{"Long word", "abcdefghij", 4, "abcd…"},
+		{"Markdown", "**one** [two](https://example.com) three", 8, "one two…"},
+		{"Characters not bytes", "héllo wörld ünïcode", 11, "héllo wörld…"},
+		{"No limit", "one two three", 0, "one two three"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Snippet(tt.src, tt.n); got != tt.want {
+				t.Errorf("got %q; expected %q", got, tt.want)
+			}
+		})
+	}
+}
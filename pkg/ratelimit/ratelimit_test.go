@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	for i, want := range []bool{true, true, false} {
+		if got := l.Allow("1.2.3.4"); got != want {
+			t.Errorf("event %d: got %v; expected %v", i, got, want)
+		}
+	}
+	if !l.Allow("5.6.7.8") {
+		t.Error("expected another key to have its own limit")
+	}
+
+	now = now.Add(time.Minute)
+	if !l.Allow("1.2.3.4") {
+		t.Error("expected the limit to reset after the window")
+	}
+	if len(l.windows) != 1 {
+		t.Errorf("got %d windows; expected the expired one to be swept", len(l.windows))
+	}
+}
+
+func TestTake(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := New(1, time.Minute)
+	l.now = func() time.Time { return now }
+
+	if ok, wait := l.Take("1"); !ok || wait != 0 {
+		t.Errorf("got %v, %v; expected the first event to pass", ok, wait)
+	}
+	now = now.Add(20 * time.Second)
+	if ok, wait := l.Take("1"); ok || wait != 40*time.Second {
+		t.Errorf("got %v, %v; expected to wait 40s", ok, wait)
+	}
+}
@@ -0,0 +1,87 @@
+package realip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseCIDRs("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		peer   string
+		header []string
+		want   string
+	}{
+		{
+			name: "Direct client",
+			peer: "203.0.113.7:5000",
+			want: "203.0.113.7",
+		},
+		{
+			name:   "Untrusted peer spoofing the header",
+			peer:   "203.0.113.7:5000",
+			header: []string{"198.51.100.1"},
+			want:   "203.0.113.7",
+		},
+		{
+			name:   "Through a trusted proxy",
+			peer:   "10.0.0.2:5000",
+			header: []string{"198.51.100.1"},
+			want:   "198.51.100.1",
+		},
+		{
+			name:   "Client prepends a fake hop",
+			peer:   "10.0.0.2:5000",
+			header: []string{"1.1.1.1, 198.51.100.1"},
+			want:   "198.51.100.1",
+		},
+		{
+			name:   "Chain of trusted proxies",
+			peer:   "192.168.1.1:5000",
+			header: []string{"198.51.100.1, 10.1.2.3", "10.0.0.9"},
+			want:   "198.51.100.1",
+		},
+		{
+			name:   "Garbage in the header",
+			peer:   "10.0.0.2:5000",
+			header: []string{"nonsense"},
+			want:   "10.0.0.2",
+		},
+		{
+			name: "Trusted proxy without the header",
+			peer: "10.0.0.2:5000",
+			want: "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.peer
+			for _, h := range tt.header {
+				r.Header.Add("X-Forwarded-For", h)
+			}
+			if got := ClientIP(r, trusted); got != tt.want {
+				t.Errorf("got %s; expected %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	if _, err := ParseCIDRs("10.0.0.0/33"); err == nil {
+		t.Error("expected an invalid mask to fail")
+	}
+	if _, err := ParseCIDRs("localhost"); err == nil {
+		t.Error("expected a host name to fail")
+	}
+	nets, err := ParseCIDRs("")
+	if err != nil || len(nets) != 0 {
+		t.Errorf("got %v, %v; expected no networks", nets, err)
+	}
+}
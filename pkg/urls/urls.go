@@ -0,0 +1,48 @@
+// Package urls builds the absolute links the forum hands out in feeds, the
+// API, notifications and emails, so they work outside of the site too.
+package urls
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Builder makes links under the canonical base URL of the site.
+type Builder struct {
+	base string
+}
+
+// New returns a Builder for base, an absolute http or https URL. A path in
+// base is kept, for forums served under a prefix.
+func New(base string) (*Builder, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("urls: invalid base URL %q: %w", base, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("urls: base URL %q must be an absolute http(s) URL without query or fragment", base)
+	}
+	return &Builder{base: strings.TrimSuffix(u.String(), "/")}, nil
+}
+
+// Path returns the absolute URL of path, which starts with a slash.
+func (b *Builder) Path(path string) string {
+	return b.base + path
+}
+
+// Post returns the canonical URL of a post.
+func (b *Builder) Post(postID int) string {
+	return b.Path(fmt.Sprintf("/post/%d", postID))
+}
+
+// Comment returns the canonical URL of a comment: its permalink, which leads
+// to its anchor on whichever page of its post it is on.
+func (b *Builder) Comment(postID, commentID int) string {
+	return b.Path(fmt.Sprintf("/posts/%d/comments/%d", postID, commentID))
+}
+
+// PasswordReset returns the link that resets a password with token.
+func (b *Builder) PasswordReset(token string) string {
+	return b.Path("/password/reset?token=" + url.QueryEscape(token))
+}
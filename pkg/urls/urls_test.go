@@ -0,0 +1,61 @@
+package urls
+
+import "testing"
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        string
+		wantPost    string
+		wantComment string
+		wantReset   string
+	}{
+		{
+			name:        "Host",
+			base:        "https://forum.example",
+			wantPost:    "https://forum.example/post/4",
+			wantComment: "https://forum.example/posts/4/comments/17",
+			wantReset:   "https://forum.example/password/reset?token=a%2Bb",
+		},
+		{
+			name:        "Trailing slash",
+			base:        "http://localhost:8080/",
+			wantPost:    "http://localhost:8080/post/4",
+			wantComment: "http://localhost:8080/posts/4/comments/17",
+			wantReset:   "http://localhost:8080/password/reset?token=a%2Bb",
+		},
+		{
+			name:        "Prefix",
+			base:        "https://example.com/forum",
+			wantPost:    "https://example.com/forum/post/4",
+			wantComment: "https://example.com/forum/posts/4/comments/17",
+			wantReset:   "https://example.com/forum/password/reset?token=a%2Bb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := New(tt.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := b.Post(4); got != tt.wantPost {
+				t.Errorf("got post URL %q; expected %q", got, tt.wantPost)
+			}
+			if got := b.Comment(4, 17); got != tt.wantComment {
+				t.Errorf("got comment URL %q; expected %q", got, tt.wantComment)
+			}
+			if got := b.PasswordReset("a+b"); got != tt.wantReset {
+				t.Errorf("got reset URL %q; expected %q", got, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, base := range []string{"", "/post", "forum.example", "ftp://forum.example", "https://forum.example/?x=1", "https://forum.example/#top", "http://%zz"} {
+		if _, err := New(base); err == nil {
+			t.Errorf("%q: got no error; expected one", base)
+		}
+	}
+}
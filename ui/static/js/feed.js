@@ -0,0 +1,39 @@
+// "Load more" on the home page: fetches the next page of the JSON feed and
+// appends the post cards the server rendered for it.
+(function () {
+  const button = document.getElementById("load-more");
+  const container = document.querySelector(".posts-container");
+  if (!button || !container) {
+    return;
+  }
+
+  button.addEventListener("click", async function () {
+    const params = new URLSearchParams({
+      after: button.dataset.next,
+      limit: button.dataset.limit,
+    });
+    if (button.dataset.category) {
+      params.set("category", button.dataset.category);
+    }
+
+    button.disabled = true;
+    try {
+      const res = await fetch("/feed.json?" + params.toString());
+      if (!res.ok) {
+        throw new Error(res.statusText);
+      }
+      const page = await res.json();
+      for (const post of page.posts) {
+        container.insertAdjacentHTML("beforeend", post.html);
+      }
+      if (page.next) {
+        button.dataset.next = page.next;
+        button.disabled = false;
+      } else {
+        button.remove();
+      }
+    } catch (err) {
+      button.disabled = false;
+    }
+  });
+})();
@@ -0,0 +1,30 @@
+// "Preview" on the post forms: renders the content through the same
+// Markdown pipeline as the post page and shows it under the textarea.
+(function () {
+  const button = document.getElementById("preview");
+  const output = document.getElementById("preview-output");
+  const content = document.querySelector("textarea[name=content]");
+  if (!button || !output || !content) {
+    return;
+  }
+
+  button.addEventListener("click", async function () {
+    button.disabled = true;
+    try {
+      const res = await fetch("/api/v1/preview", {
+        method: "POST",
+        headers: { "Content-Type": "text/markdown" },
+        body: content.value,
+      });
+      if (!res.ok) {
+        throw new Error(res.statusText);
+      }
+      const preview = await res.json();
+      output.innerHTML = preview.html;
+    } catch (err) {
+      output.textContent = "Preview is not available right now.";
+    } finally {
+      button.disabled = false;
+    }
+  });
+})();
@@ -0,0 +1,18 @@
+// Spoilers in rendered Markdown stay hidden until clicked, or until Enter is
+// pressed on one. Listening on the document covers previews shown later.
+(function () {
+  function reveal(event) {
+    const spoiler = event.target.closest && event.target.closest(".spoiler");
+    if (!spoiler || spoiler.classList.contains("revealed")) {
+      return;
+    }
+    if (event.type === "keydown" && event.key !== "Enter") {
+      return;
+    }
+    event.preventDefault();
+    spoiler.classList.add("revealed");
+  }
+
+  document.addEventListener("click", reveal);
+  document.addEventListener("keydown", reveal);
+})();